@@ -0,0 +1,95 @@
+package alert
+
+import (
+	"context"
+	"time"
+
+	"github.com/iceymoss/go-task/pkg/db/models"
+)
+
+// 告警类型，对应 AlertRule.AlertType
+const (
+	TypeFailure          = "failure"           // 执行失败
+	TypeTimeout          = "timeout"           // 执行超时
+	TypeRetryExceeded    = "retry_exceeded"    // 重试耗尽后仍失败
+	TypeSuccess          = "success"           // 执行成功
+	TypeDurationExceeded = "duration_exceeded" // 执行时长超过阈值
+)
+
+// 触发条件，对应 AlertRule.Condition
+const (
+	ConditionImmediate      = "immediate"       // 每次命中即触发
+	ConditionCountThreshold = "count_threshold" // 时间窗口内命中次数 >= ThresholdCount
+	ConditionRateThreshold  = "rate_threshold"  // 时间窗口内命中次数占执行次数的百分比 >= ThresholdCount
+)
+
+// 告警状态
+const (
+	StateFiring   = "firing"   // 告警中
+	StateResolved = "resolved" // 已恢复
+)
+
+// 发送状态，对应 AlertHistory.Status
+const (
+	StatusPending   = "pending"
+	StatusSending   = "sending"
+	StatusSent      = "sent"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled" // 被静默，不发送
+)
+
+// Alert 一次告警（触发或恢复），交给通知处理器发送
+type Alert struct {
+	AlertID     string
+	RuleID      uint
+	RuleName    string
+	JobID       uint // 任务在 sys_jobs 中的ID，系统任务与 YAML 任务为 0
+	JobName     string
+	ExecID      string
+	Type        string
+	Level       string
+	State       string
+	Title       string
+	Message     string
+	Details     map[string]any
+	Silenced    bool
+	TriggeredAt time.Time
+	ResolvedAt  *time.Time
+}
+
+// JobInfo 告警规则匹配所需的任务信息
+type JobInfo struct {
+	ID      uint   // 任务ID，没有数据库记录时为 0
+	GroupID *uint  // 所属分组
+	Groups  []uint // 所属分组及其所有上级分组，绑定到上级分组的规则同样适用
+}
+
+// Store 告警规则、静默与告警历史的持久化接口
+type Store interface {
+	// LoadRules 加载所有启用的告警规则
+	LoadRules() ([]models.AlertRule, error)
+	// LoadSilences 加载当前生效的静默规则
+	LoadSilences(now time.Time) ([]models.AlertSilence, error)
+	// JobInfo 按任务名查询任务信息
+	JobInfo(name string) (JobInfo, error)
+	// CreateAlert 写入告警历史
+	CreateAlert(alert *Alert, status string) error
+	// ResolveAlert 将告警标记为已恢复
+	ResolveAlert(alertID string, resolvedAt time.Time) error
+}
+
+// WindowStore 滑动窗口计数、告警限流与告警状态，集群部署时各节点共享
+type WindowStore interface {
+	// Record 在 key 的滑动窗口中记录一次事件，返回窗口内的事件数
+	Record(ctx context.Context, key, member string, at time.Time, window time.Duration) (int64, error)
+	// Count 返回 key 在 at 之前 window 时长内的事件数
+	Count(ctx context.Context, key string, at time.Time, window time.Duration) (int64, error)
+	// Allow 限流：interval 内同一个 key 只放行一次
+	Allow(ctx context.Context, key string, interval time.Duration) (bool, error)
+	// Firing 返回正在告警的告警ID，未告警时返回空字符串
+	Firing(ctx context.Context, key string) (string, error)
+	// Fire 标记为告警中，已处于告警中时返回 false
+	Fire(ctx context.Context, key, alertID string, ttl time.Duration) (bool, error)
+	// Resolve 清除告警状态，返回原告警ID，未告警时返回空字符串
+	Resolve(ctx context.Context, key string) (string, error)
+}
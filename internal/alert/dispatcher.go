@@ -0,0 +1,208 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/pkg/db/models"
+	"github.com/iceymoss/go-task/pkg/message/notify"
+)
+
+// defaultSendTimeout 一条告警在所有渠道上发送（含重试）的最长时间
+const defaultSendTimeout = time.Minute
+
+// ChannelStore 告警渠道与发送结果的持久化接口
+type ChannelStore interface {
+	// LoadChannels 加载所有启用的告警渠道
+	LoadChannels() ([]models.AlertChannel, error)
+	// UpdateDelivery 记录告警的发送结果
+	UpdateDelivery(alertID, status string, channels, failed []string, sentAt *time.Time) error
+}
+
+// DispatcherOption 告警分发器的配置选项
+type DispatcherOption func(*Dispatcher)
+
+// WithDispatcherLogger 配置日志
+func WithDispatcherLogger(log engine.Logger) DispatcherOption {
+	return func(d *Dispatcher) {
+		if log != nil {
+			d.logger = log
+		}
+	}
+}
+
+// WithRetryPolicy 配置单个渠道发送失败时的重试策略
+func WithRetryPolicy(policy notify.RetryPolicy) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.retry = policy
+	}
+}
+
+// Dispatcher 告警分发器：将告警发送到所有启用的渠道，并记录发送结果
+type Dispatcher struct {
+	store  ChannelStore
+	logger engine.Logger
+	retry  notify.RetryPolicy
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher 创建告警分发器
+func NewDispatcher(store ChannelStore, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		store: store,
+		retry: notify.DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.logger == nil {
+		d.logger = engine.NewDefaultLogger()
+	}
+	return d
+}
+
+// Handle 实现告警处理器，异步发送以免重试阻塞事件处理，用法：manager.OnAlert(dispatcher.Handle)
+func (d *Dispatcher) Handle(a *Alert) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), defaultSendTimeout)
+		defer cancel()
+		d.Dispatch(ctx, a)
+	}()
+}
+
+// Wait 等待发送中的告警完成
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Dispatch 同步发送告警到所有启用的渠道，任一渠道成功即视为已发送
+func (d *Dispatcher) Dispatch(ctx context.Context, a *Alert) {
+	channels, err := d.store.LoadChannels()
+	if err != nil {
+		d.logger.Error("❌ [Alert] Failed to load channels", err, "alert_id", a.AlertID)
+		return
+	}
+	if len(channels) == 0 {
+		return
+	}
+	sort.SliceStable(channels, func(i, j int) bool { return channels[i].Priority > channels[j].Priority })
+
+	// 恢复通知与触发时共用同一条告警历史，只记录触发时的发送结果
+	record := a.State == StateFiring
+	if record {
+		_ = d.store.UpdateDelivery(a.AlertID, StatusSending, nil, nil, nil)
+	}
+
+	msg := MessageOf(a)
+	var sent, failed []string
+	for _, ch := range channels {
+		if err := d.send(ctx, ch, msg); err != nil {
+			d.logger.Error("❌ [Alert] Failed to send alert",
+				err,
+				"alert_id", a.AlertID,
+				"channel", ch.Name,
+				"channel_type", ch.ChannelType,
+			)
+			failed = append(failed, ch.Name)
+			continue
+		}
+		sent = append(sent, ch.Name)
+	}
+
+	if record {
+		status := StatusFailed
+		var sentAt *time.Time
+		if len(sent) > 0 {
+			status = StatusSent
+			now := time.Now()
+			sentAt = &now
+		}
+		if err := d.store.UpdateDelivery(a.AlertID, status, sent, failed, sentAt); err != nil {
+			d.logger.Error("❌ [Alert] Failed to update delivery", err, "alert_id", a.AlertID)
+		}
+	}
+	d.logger.Info("📣 [Alert] Alert dispatched",
+		"alert_id", a.AlertID,
+		"state", a.State,
+		"sent", len(sent),
+		"failed", len(failed),
+	)
+}
+
+// send 按渠道配置创建发送器并带重试发送
+func (d *Dispatcher) send(ctx context.Context, ch models.AlertChannel, msg *notify.Message) error {
+	n, err := notify.New(ch.ChannelType, []byte(ch.Config))
+	if err != nil {
+		return err
+	}
+	return notify.Deliver(ctx, n, msg, d.retry)
+}
+
+// SendTest 向指定渠道发送一条测试消息，不重试
+func SendTest(ctx context.Context, channelType, config string) error {
+	n, err := notify.New(channelType, []byte(config))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return n.Notify(ctx, MessageOf(&Alert{
+		AlertID:     "test",
+		RuleName:    "test",
+		JobName:     "test",
+		Type:        TypeFailure,
+		Level:       "info",
+		State:       StateFiring,
+		Title:       "[go-task] Test notification",
+		Message:     "This is a test message from go-task.",
+		TriggeredAt: now,
+	}))
+}
+
+// MessageOf 将告警转换为通知消息，告警字段可在渠道模板中通过 {{.Fields.xxx}} 引用
+func MessageOf(a *Alert) *notify.Message {
+	fields := map[string]any{
+		"alert_id":     a.AlertID,
+		"rule_id":      a.RuleID,
+		"rule_name":    a.RuleName,
+		"job_id":       a.JobID,
+		"job_name":     a.JobName,
+		"exec_id":      a.ExecID,
+		"alert_type":   a.Type,
+		"level":        a.Level,
+		"state":        a.State,
+		"triggered_at": a.TriggeredAt.Format(time.DateTime),
+	}
+	for k, v := range a.Details {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
+
+	var b strings.Builder
+	if a.Message != "" {
+		b.WriteString(a.Message)
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Job: %s\nRule: %s\nLevel: %s\nState: %s\nTriggered: %s",
+		a.JobName, a.RuleName, a.Level, a.State, a.TriggeredAt.Format(time.DateTime))
+	if a.ResolvedAt != nil {
+		fmt.Fprintf(&b, "\nResolved: %s", a.ResolvedAt.Format(time.DateTime))
+	}
+	if a.ExecID != "" {
+		fmt.Fprintf(&b, "\nExecution: %s", a.ExecID)
+	}
+
+	return &notify.Message{
+		Title:  a.Title,
+		Text:   b.String(),
+		Level:  a.Level,
+		Fields: fields,
+	}
+}
@@ -0,0 +1,97 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/pkg/db/models"
+	"github.com/iceymoss/go-task/pkg/message/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// delivery 一次发送结果
+type delivery struct {
+	status   string
+	channels []string
+	failed   []string
+}
+
+// memoryChannelStore 测试用的渠道存储
+type memoryChannelStore struct {
+	mu         sync.Mutex
+	channels   []models.AlertChannel
+	deliveries []delivery
+}
+
+func (s *memoryChannelStore) LoadChannels() ([]models.AlertChannel, error) {
+	return append([]models.AlertChannel(nil), s.channels...), nil
+}
+
+func (s *memoryChannelStore) UpdateDelivery(alertID, status string, channels, failed []string, sentAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, delivery{status: status, channels: channels, failed: failed})
+	return nil
+}
+
+// 测试分发：成功与失败的渠道分别记录，恢复通知不覆盖发送状态
+func TestDispatcher(t *testing.T) {
+	var mu sync.Mutex
+	var received []notify.Message
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg notify.Message
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &msg)
+		mu.Lock()
+		received = append(received, msg)
+		mu.Unlock()
+	}))
+	t.Cleanup(ok.Close)
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(broken.Close)
+
+	store := &memoryChannelStore{channels: []models.AlertChannel{
+		{Name: "broken", ChannelType: notify.ChannelWebhook, Config: `{"url":"` + broken.URL + `"}`},
+		{Name: "ops", ChannelType: notify.ChannelWebhook, Config: `{"url":"` + ok.URL + `"}`, Priority: 10},
+	}}
+	d := NewDispatcher(store, WithRetryPolicy(notify.RetryPolicy{Attempts: 2, Delay: time.Millisecond}))
+
+	a := &Alert{
+		AlertID:     "a-1",
+		RuleName:    "on-failure",
+		JobName:     "backup",
+		Level:       "error",
+		State:       StateFiring,
+		Title:       "[ERROR] on-failure - backup",
+		Message:     "exit status 1",
+		Details:     map[string]any{"count": 1},
+		TriggeredAt: time.Now(),
+	}
+	d.Handle(a)
+	d.Wait()
+
+	require.Len(t, store.deliveries, 2)
+	assert.Equal(t, StatusSending, store.deliveries[0].status)
+	assert.Equal(t, delivery{status: StatusSent, channels: []string{"ops"}, failed: []string{"broken"}}, store.deliveries[1])
+
+	require.Len(t, received, 1)
+	assert.Equal(t, a.Title, received[0].Title)
+	assert.Contains(t, received[0].Text, "exit status 1")
+	assert.Equal(t, "backup", received[0].Fields["job_name"])
+
+	resolved := *a
+	resolved.State = StateResolved
+	d.Dispatch(context.Background(), &resolved)
+	assert.Len(t, store.deliveries, 2)
+	assert.Len(t, received, 2)
+}
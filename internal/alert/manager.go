@@ -0,0 +1,554 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/engine"
+	keys "github.com/iceymoss/go-task/pkg/db/key"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultRefreshInterval = 30 * time.Second                                    // 规则与静默的刷新间隔
+	defaultRateLimit       = time.Duration(keys.TTLAlertRateLimit) * time.Second // 同一规则在同一任务上两次触发的最小间隔
+	defaultWindow          = 300 * time.Second                                   // 规则未配置时间窗口时的默认值
+	firingTTL              = 7 * 24 * time.Hour                                  // 告警状态的最长保留时间，超过后视为已恢复
+)
+
+// Handler 告警处理器，告警触发与恢复时调用
+type Handler func(alert *Alert)
+
+// Option 告警管理器的配置选项
+type Option func(*Manager)
+
+// WithLogger 配置日志
+func WithLogger(log engine.Logger) Option {
+	return func(m *Manager) {
+		if log != nil {
+			m.logger = log
+		}
+	}
+}
+
+// WithRateLimit 配置同一规则在同一任务上两次触发的最小间隔
+func WithRateLimit(d time.Duration) Option {
+	return func(m *Manager) {
+		if d > 0 {
+			m.rateLimit = d
+		}
+	}
+}
+
+// WithRefreshInterval 配置规则与静默的刷新间隔
+func WithRefreshInterval(d time.Duration) Option {
+	return func(m *Manager) {
+		if d > 0 {
+			m.refresh = d
+		}
+	}
+}
+
+// Manager 告警管理器：订阅任务结束事件，按规则在滑动窗口上求值，应用静默与限流，
+// 在告警触发（firing）与恢复（resolved）时写入告警历史并通知处理器
+type Manager struct {
+	store     Store
+	windows   WindowStore
+	logger    engine.Logger
+	rateLimit time.Duration
+	refresh   time.Duration
+
+	mu       sync.RWMutex
+	rules    []models.AlertRule
+	silences []models.AlertSilence
+	jobs     map[string]JobInfo // 任务名 -> 任务信息，随规则一起刷新
+	loadedAt time.Time
+
+	handlersMu sync.RWMutex
+	handlers   []Handler
+}
+
+// NewManager 创建告警管理器，windows 为 nil 时使用内存实现
+func NewManager(store Store, windows WindowStore, opts ...Option) *Manager {
+	m := &Manager{
+		store:     store,
+		windows:   windows,
+		rateLimit: defaultRateLimit,
+		refresh:   defaultRefreshInterval,
+		jobs:      make(map[string]JobInfo),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.windows == nil {
+		m.windows = NewMemoryWindowStore()
+	}
+	if m.logger == nil {
+		m.logger = engine.NewDefaultLogger()
+	}
+	return m
+}
+
+// Register 订阅任务结束事件
+func (m *Manager) Register(em *engine.EventManager) {
+	em.OnFunc(engine.EventTypeAfterJob, m.HandleEvent)
+	em.OnFunc(engine.EventTypeJobError, m.HandleEvent)
+	em.OnFunc(engine.EventTypeJobPanic, m.HandleEvent)
+}
+
+// OnAlert 注册告警处理器，被静默的告警不会通知
+func (m *Manager) OnAlert(h Handler) {
+	m.handlersMu.Lock()
+	defer m.handlersMu.Unlock()
+	m.handlers = append(m.handlers, h)
+}
+
+// Reload 立即重新加载告警规则与静默，规则变更后调用
+func (m *Manager) Reload() error {
+	rules, err := m.store.LoadRules()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	silences, err := m.store.LoadSilences(now)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = rules
+	m.silences = silences
+	m.jobs = make(map[string]JobInfo)
+	m.loadedAt = now
+	return nil
+}
+
+// ensureLoaded 超过刷新间隔时重新加载规则与静默
+func (m *Manager) ensureLoaded() {
+	m.mu.RLock()
+	stale := time.Since(m.loadedAt) > m.refresh
+	m.mu.RUnlock()
+	if !stale {
+		return
+	}
+	if err := m.Reload(); err != nil {
+		m.logger.Error("❌ [Alert] Load alert rules failed", err)
+	}
+}
+
+// jobInfo 查询任务信息，结果缓存到下一次刷新
+func (m *Manager) jobInfo(name string) JobInfo {
+	m.mu.RLock()
+	info, ok := m.jobs[name]
+	m.mu.RUnlock()
+	if ok {
+		return info
+	}
+
+	info, err := m.store.JobInfo(name)
+	if err != nil {
+		m.logger.Warn("⚠️ [Alert] Lookup job failed", "name", name, err)
+		return JobInfo{}
+	}
+	m.mu.Lock()
+	m.jobs[name] = info
+	m.mu.Unlock()
+	return info
+}
+
+// matchingRules 适用于该任务的规则：全局规则、绑定该任务的规则以及绑定其分组或上级分组的规则
+func (m *Manager) matchingRules(job JobInfo) []models.AlertRule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matched []models.AlertRule
+	for _, rule := range m.rules {
+		if !rule.Enable {
+			continue
+		}
+		if rule.JobID != nil && (job.ID == 0 || *rule.JobID != job.ID) {
+			continue
+		}
+		if rule.GroupID != nil && !job.inGroup(*rule.GroupID) {
+			continue
+		}
+		matched = append(matched, rule)
+	}
+	return matched
+}
+
+// inGroup 任务是否属于该分组或其子分组
+func (j JobInfo) inGroup(groupID uint) bool {
+	if j.GroupID != nil && *j.GroupID == groupID {
+		return true
+	}
+	return slices.Contains(j.Groups, groupID)
+}
+
+// observation 一次执行结束时观察到的结果
+type observation struct {
+	jobName    string
+	execID     string
+	at         time.Time
+	failed     bool
+	status     string
+	retries    int
+	durationMs int64
+	err        error
+}
+
+func observe(event *engine.Event) observation {
+	ob := observation{
+		jobName: event.TaskName,
+		execID:  event.ExecID,
+		at:      event.TimeStamp,
+		failed:  event.Type != engine.EventTypeAfterJob,
+		err:     event.Error,
+	}
+	if ob.at.IsZero() {
+		ob.at = time.Now()
+	}
+	if ob.execID == "" {
+		ob.execID = strconv.FormatInt(ob.at.UnixNano(), 10)
+	}
+	if event.Data != nil {
+		ob.status, _ = event.Data["status"].(string)
+		ob.retries, _ = event.Data["retry_count"].(int)
+		ob.durationMs, _ = event.Data["duration_ms"].(int64)
+	}
+	return ob
+}
+
+// hit 本次执行是否命中规则的告警类型
+func (ob observation) hit(rule models.AlertRule) bool {
+	switch rule.AlertType {
+	case TypeFailure:
+		return ob.failed
+	case TypeTimeout:
+		return ob.failed && ob.status == string(engine.ExecutionStatusTimeout)
+	case TypeRetryExceeded:
+		return ob.failed && ob.retries > 0
+	case TypeSuccess:
+		return !ob.failed
+	case TypeDurationExceeded:
+		return rule.ThresholdDuration != nil && ob.durationMs > int64(*rule.ThresholdDuration)
+	}
+	return false
+}
+
+// HandleEvent 处理任务结束事件
+func (m *Manager) HandleEvent(event *engine.Event) {
+	if event == nil || event.TaskName == "" {
+		return
+	}
+	m.ensureLoaded()
+
+	ob := observe(event)
+	job := m.jobInfo(event.TaskName)
+	for _, rule := range m.matchingRules(job) {
+		m.evaluate(context.Background(), rule, job, ob)
+	}
+}
+
+// evaluate 按规则的触发条件求值，并完成 firing/resolved 状态转换
+func (m *Manager) evaluate(ctx context.Context, rule models.AlertRule, job JobInfo, ob observation) {
+	hit := ob.hit(rule)
+	window := time.Duration(rule.ThresholdTime) * time.Second
+	if window <= 0 {
+		window = defaultWindow
+	}
+	details := map[string]any{"condition": rule.Condition}
+
+	var met bool
+	switch rule.Condition {
+	case ConditionCountThreshold:
+		hits, err := m.countHits(ctx, rule, ob, hit, window)
+		if err != nil {
+			m.logger.Error("❌ [Alert] Aggregate alert window failed", "rule", rule.Name, "name", ob.jobName, err)
+			return
+		}
+		met = hits >= int64(max(rule.ThresholdCount, 1))
+		details["count"] = hits
+		details["window_seconds"] = int(window.Seconds())
+	case ConditionRateThreshold:
+		total, err := m.windows.Record(ctx, keys.KeyAlertAggregate(rule.ID, ob.jobName, "total"), ob.execID, ob.at, window)
+		if err != nil {
+			m.logger.Error("❌ [Alert] Aggregate alert window failed", "rule", rule.Name, "name", ob.jobName, err)
+			return
+		}
+		hits, err := m.countHits(ctx, rule, ob, hit, window)
+		if err != nil {
+			m.logger.Error("❌ [Alert] Aggregate alert window failed", "rule", rule.Name, "name", ob.jobName, err)
+			return
+		}
+		met = total > 0 && hits*100 >= int64(rule.ThresholdCount)*total
+		details["count"] = hits
+		details["total"] = total
+		details["rate"] = float64(hits) * 100 / float64(max(total, 1))
+		details["window_seconds"] = int(window.Seconds())
+	default:
+		met = hit
+	}
+
+	firingKey := keys.KeyAlertFiring(rule.ID, ob.jobName)
+	if met {
+		m.fire(ctx, firingKey, rule, job, ob, details)
+	} else {
+		m.resolve(ctx, firingKey, rule, job, ob)
+	}
+}
+
+// countHits 命中时记录到滑动窗口，返回窗口内的命中次数
+func (m *Manager) countHits(ctx context.Context, rule models.AlertRule, ob observation, hit bool, window time.Duration) (int64, error) {
+	k := keys.KeyAlertAggregate(rule.ID, ob.jobName, "hit")
+	if hit {
+		return m.windows.Record(ctx, k, ob.execID, ob.at, window)
+	}
+	return m.windows.Count(ctx, k, ob.at, window)
+}
+
+// fire 触发告警：已处于告警中或被限流时忽略
+func (m *Manager) fire(ctx context.Context, firingKey string, rule models.AlertRule, job JobInfo, ob observation, details map[string]any) {
+	if id, err := m.windows.Firing(ctx, firingKey); err != nil || id != "" {
+		if err != nil {
+			m.logger.Error("❌ [Alert] Read alert state failed", "rule", rule.Name, "name", ob.jobName, err)
+		}
+		return
+	}
+
+	allowed, err := m.windows.Allow(ctx, keys.KeyAlertRateLimit(rule.ID, ob.jobName), m.rateLimit)
+	if err != nil {
+		m.logger.Error("❌ [Alert] Check alert rate limit failed", "rule", rule.Name, "name", ob.jobName, err)
+		return
+	}
+	if !allowed {
+		m.logger.Info("⏳ [Alert] Alert rate limited", "rule", rule.Name, "name", ob.jobName)
+		return
+	}
+
+	a := newAlert(rule, job, ob, details)
+	ok, err := m.windows.Fire(ctx, firingKey, a.AlertID, firingTTL)
+	if err != nil || !ok {
+		if err != nil {
+			m.logger.Error("❌ [Alert] Save alert state failed", "rule", rule.Name, "name", ob.jobName, err)
+		}
+		return
+	}
+
+	a.Silenced = m.silenced(rule, a)
+	status := StatusPending
+	if a.Silenced {
+		status = StatusCancelled
+	}
+	if err := m.store.CreateAlert(a, status); err != nil {
+		m.logger.Error("❌ [Alert] Save alert history failed", "alert_id", a.AlertID, err)
+	}
+
+	m.logger.Warn("🚨 [Alert] Alert firing",
+		"alert_id", a.AlertID,
+		"rule", rule.Name,
+		"name", ob.jobName,
+		"exec_id", ob.execID,
+		"type", a.Type,
+		"level", a.Level,
+		"silenced", a.Silenced,
+	)
+	if !a.Silenced {
+		m.notify(a)
+	}
+}
+
+// resolve 恢复告警：未处于告警中时忽略
+func (m *Manager) resolve(ctx context.Context, firingKey string, rule models.AlertRule, job JobInfo, ob observation) {
+	id, err := m.windows.Resolve(ctx, firingKey)
+	if err != nil {
+		m.logger.Error("❌ [Alert] Clear alert state failed", "rule", rule.Name, "name", ob.jobName, err)
+		return
+	}
+	if id == "" {
+		return
+	}
+
+	resolvedAt := ob.at
+	if err := m.store.ResolveAlert(id, resolvedAt); err != nil {
+		m.logger.Error("❌ [Alert] Resolve alert history failed", "alert_id", id, err)
+	}
+
+	a := newAlert(rule, job, ob, nil)
+	a.AlertID = id
+	a.State = StateResolved
+	a.ResolvedAt = &resolvedAt
+	a.Title = "[RESOLVED] " + a.Title
+	a.Message = fmt.Sprintf("Job %s recovered from %s", ob.jobName, rule.AlertType)
+	a.Silenced = m.silenced(rule, a)
+
+	m.logger.Info("✅ [Alert] Alert resolved", "alert_id", id, "rule", rule.Name, "name", ob.jobName)
+	if !a.Silenced {
+		m.notify(a)
+	}
+}
+
+// notify 通知所有处理器
+func (m *Manager) notify(a *Alert) {
+	m.handlersMu.RLock()
+	handlers := append([]Handler(nil), m.handlers...)
+	m.handlersMu.RUnlock()
+
+	for _, h := range handlers {
+		h(a)
+	}
+}
+
+// newAlert 根据规则与执行结果构造告警
+func newAlert(rule models.AlertRule, job JobInfo, ob observation, details map[string]any) *Alert {
+	level := rule.AlertLevel
+	if level == "" {
+		level = "warning"
+	}
+	if details == nil {
+		details = make(map[string]any)
+	}
+	details["job_name"] = ob.jobName
+	details["exec_id"] = ob.execID
+	details["duration_ms"] = ob.durationMs
+	details["retry_count"] = ob.retries
+	if ob.status != "" {
+		details["status"] = ob.status
+	}
+	if ob.err != nil {
+		details["error"] = ob.err.Error()
+	}
+
+	return &Alert{
+		AlertID:     uuid.New().String(),
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		JobID:       job.ID,
+		JobName:     ob.jobName,
+		ExecID:      ob.execID,
+		Type:        rule.AlertType,
+		Level:       level,
+		State:       StateFiring,
+		Title:       fmt.Sprintf("[%s] %s - %s", strings.ToUpper(level), rule.Name, ob.jobName),
+		Message:     alertMessage(rule, ob, details),
+		Details:     details,
+		TriggeredAt: ob.at,
+	}
+}
+
+// alertMessage 告警正文
+func alertMessage(rule models.AlertRule, ob observation, details map[string]any) string {
+	var msg string
+	switch rule.AlertType {
+	case TypeTimeout:
+		msg = fmt.Sprintf("Job %s timed out", ob.jobName)
+	case TypeRetryExceeded:
+		msg = fmt.Sprintf("Job %s still failed after %d retries", ob.jobName, ob.retries)
+	case TypeSuccess:
+		msg = fmt.Sprintf("Job %s succeeded", ob.jobName)
+	case TypeDurationExceeded:
+		msg = fmt.Sprintf("Job %s ran for %dms", ob.jobName, ob.durationMs)
+		if rule.ThresholdDuration != nil {
+			msg += fmt.Sprintf(", exceeding the %dms threshold", *rule.ThresholdDuration)
+		}
+	default:
+		msg = fmt.Sprintf("Job %s failed", ob.jobName)
+	}
+	if ob.err != nil && rule.AlertType != TypeSuccess {
+		msg += ": " + ob.err.Error()
+	}
+
+	switch rule.Condition {
+	case ConditionCountThreshold:
+		msg += fmt.Sprintf(" (%d times in the last %ds)", details["count"], details["window_seconds"])
+	case ConditionRateThreshold:
+		msg += fmt.Sprintf(" (%.1f%% of %d runs in the last %ds)", details["rate"], details["total"], details["window_seconds"])
+	}
+	return msg
+}
+
+// silenceMatcher 静默规则中的附加匹配器
+type silenceMatcher struct {
+	Type  string `json:"type"`  // job, alert_type, level, rule
+	Value string `json:"value"` // 匹配值
+}
+
+// silenced 告警是否被规则自带的静默时段或静默规则屏蔽
+func (m *Manager) silenced(rule models.AlertRule, a *Alert) bool {
+	now := time.Now()
+	if rule.SilenceEnabled &&
+		(rule.SilenceStart == nil || !now.Before(*rule.SilenceStart)) &&
+		(rule.SilenceEnd == nil || !now.After(*rule.SilenceEnd)) {
+		return true
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, s := range m.silences {
+		if matchSilence(s, a, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSilence 静默规则是否生效并匹配该告警，所有配置了的条件都需满足
+func matchSilence(s models.AlertSilence, a *Alert, now time.Time) bool {
+	if s.Status != "" && s.Status != "active" {
+		return false
+	}
+	if now.Before(s.StartTime) || now.After(s.EndTime) {
+		return false
+	}
+	if s.JobID != nil && *s.JobID != a.JobID {
+		return false
+	}
+	if s.JobName != "" && s.JobName != a.JobName {
+		return false
+	}
+	if s.AlertType != "" && s.AlertType != a.Type {
+		return false
+	}
+	if s.AlertLevel != "" && s.AlertLevel != a.Level {
+		return false
+	}
+	if s.Matchers == "" {
+		return true
+	}
+
+	var matchers []silenceMatcher
+	if err := json.Unmarshal([]byte(s.Matchers), &matchers); err != nil {
+		return false
+	}
+	for _, matcher := range matchers {
+		var actual string
+		switch matcher.Type {
+		case "job":
+			actual = a.JobName
+		case "alert_type":
+			actual = a.Type
+		case "level":
+			actual = a.Level
+		case "rule":
+			if matcher.Value == strconv.FormatUint(uint64(a.RuleID), 10) {
+				continue
+			}
+			actual = a.RuleName
+		default:
+			return false
+		}
+		if actual != matcher.Value {
+			return false
+		}
+	}
+	return true
+}
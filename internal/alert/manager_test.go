@@ -0,0 +1,244 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore 测试用的告警存储
+type memoryStore struct {
+	mu       sync.Mutex
+	rules    []models.AlertRule
+	silences []models.AlertSilence
+	jobs     map[string]JobInfo
+	history  map[string]string // 告警ID -> 发送状态
+	resolved map[string]bool
+}
+
+func newMemoryStore(rules ...models.AlertRule) *memoryStore {
+	return &memoryStore{
+		rules:    rules,
+		jobs:     make(map[string]JobInfo),
+		history:  make(map[string]string),
+		resolved: make(map[string]bool),
+	}
+}
+
+func (s *memoryStore) LoadRules() ([]models.AlertRule, error) { return s.rules, nil }
+func (s *memoryStore) LoadSilences(now time.Time) ([]models.AlertSilence, error) {
+	return s.silences, nil
+}
+func (s *memoryStore) JobInfo(name string) (JobInfo, error) { return s.jobs[name], nil }
+
+func (s *memoryStore) CreateAlert(a *Alert, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history[a.AlertID] = status
+	return nil
+}
+
+func (s *memoryStore) ResolveAlert(alertID string, resolvedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resolved[alertID] = true
+	return nil
+}
+
+func newTestManager(t *testing.T, store *memoryStore, opts ...Option) (*Manager, *[]*Alert) {
+	m := NewManager(store, nil, opts...)
+	require.NoError(t, m.Reload())
+	var alerts []*Alert
+	m.OnAlert(func(a *Alert) { alerts = append(alerts, a) })
+	return m, &alerts
+}
+
+func failed(name string, at time.Time) *engine.Event {
+	return &engine.Event{
+		Type:      engine.EventTypeJobError,
+		TaskName:  name,
+		ExecID:    at.Format(time.RFC3339Nano),
+		TimeStamp: at,
+		Error:     errors.New("exit status 1"),
+		Data:      map[string]any{"status": "failed", "retry_count": 0, "duration_ms": int64(10)},
+	}
+}
+
+func succeeded(name string, at time.Time) *engine.Event {
+	return &engine.Event{
+		Type:      engine.EventTypeAfterJob,
+		TaskName:  name,
+		ExecID:    at.Format(time.RFC3339Nano),
+		TimeStamp: at,
+		Data:      map[string]any{"status": "success", "retry_count": 0, "duration_ms": int64(10)},
+	}
+}
+
+// 测试 immediate 规则：失败时触发且不重复告警，成功后恢复
+func TestManagerImmediateFiringAndResolved(t *testing.T) {
+	store := newMemoryStore(models.AlertRule{ID: 1, Name: "on-failure", AlertType: TypeFailure, Condition: ConditionImmediate, Enable: true})
+	m, alerts := newTestManager(t, store)
+	now := time.Now()
+
+	m.HandleEvent(failed("backup", now))
+	m.HandleEvent(failed("backup", now.Add(time.Second)))
+	require.Len(t, *alerts, 1, "告警中不重复触发")
+	fired := (*alerts)[0]
+	assert.Equal(t, StateFiring, fired.State)
+	assert.Equal(t, "backup", fired.JobName)
+	assert.Contains(t, fired.Message, "exit status 1")
+	assert.Equal(t, StatusPending, store.history[fired.AlertID])
+
+	m.HandleEvent(succeeded("backup", now.Add(2*time.Second)))
+	require.Len(t, *alerts, 2)
+	assert.Equal(t, StateResolved, (*alerts)[1].State)
+	assert.Equal(t, fired.AlertID, (*alerts)[1].AlertID)
+	assert.True(t, store.resolved[fired.AlertID])
+
+	// 恢复后限流时间内再次失败不会立即告警
+	m.HandleEvent(failed("backup", now.Add(3*time.Second)))
+	assert.Len(t, *alerts, 2)
+}
+
+// 测试 count_threshold 与 rate_threshold 规则在滑动窗口上求值
+func TestManagerThresholdConditions(t *testing.T) {
+	store := newMemoryStore(
+		models.AlertRule{ID: 1, Name: "3-in-5m", AlertType: TypeFailure, Condition: ConditionCountThreshold, ThresholdCount: 3, ThresholdTime: 300, Enable: true},
+		models.AlertRule{ID: 2, Name: "half-failed", AlertType: TypeFailure, Condition: ConditionRateThreshold, ThresholdCount: 50, ThresholdTime: 300, Enable: true},
+	)
+	m, alerts := newTestManager(t, store)
+	now := time.Now()
+
+	m.HandleEvent(succeeded("report", now))
+	m.HandleEvent(succeeded("report", now.Add(time.Second)))
+	m.HandleEvent(failed("report", now.Add(2*time.Second)))
+	assert.Empty(t, *alerts, "1/3 未达到 50%")
+
+	m.HandleEvent(failed("report", now.Add(3*time.Second)))
+	require.Len(t, *alerts, 1)
+	assert.Equal(t, "half-failed", (*alerts)[0].RuleName)
+	assert.Equal(t, int64(4), (*alerts)[0].Details["total"])
+
+	m.HandleEvent(failed("report", now.Add(4*time.Second)))
+	require.Len(t, *alerts, 2)
+	assert.Equal(t, "3-in-5m", (*alerts)[1].RuleName)
+	assert.Equal(t, int64(3), (*alerts)[1].Details["count"])
+
+	// 窗口滑过之后命中次数回落，告警恢复
+	m.HandleEvent(succeeded("report", now.Add(10*time.Minute)))
+	require.Len(t, *alerts, 4)
+	assert.Equal(t, StateResolved, (*alerts)[2].State)
+	assert.Equal(t, StateResolved, (*alerts)[3].State)
+}
+
+// 测试规则匹配与静默：只绑定到指定任务的规则不影响其他任务，被静默的告警记录为 cancelled 且不通知
+func TestManagerRuleScopeAndSilence(t *testing.T) {
+	jobID := uint(7)
+	store := newMemoryStore(
+		models.AlertRule{ID: 1, Name: "job-7", JobID: &jobID, AlertType: TypeFailure, Condition: ConditionImmediate, AlertLevel: "critical", Enable: true},
+		models.AlertRule{ID: 2, Name: "disabled", AlertType: TypeFailure, Condition: ConditionImmediate, Enable: false},
+	)
+	store.jobs["sync"] = JobInfo{ID: 7}
+	store.silences = []models.AlertSilence{{
+		Name:      "maintenance",
+		Matchers:  `[{"type":"level","value":"critical"}]`,
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(time.Hour),
+		Status:    "active",
+	}}
+	m, alerts := newTestManager(t, store)
+
+	m.HandleEvent(failed("other", time.Now()))
+	m.HandleEvent(failed("sync", time.Now()))
+	assert.Empty(t, *alerts)
+	require.Len(t, store.history, 1)
+	for _, status := range store.history {
+		assert.Equal(t, StatusCancelled, status)
+	}
+}
+
+// 测试分组规则：绑定到上级分组的规则同样适用于子分组中的任务
+func TestManagerGroupRule(t *testing.T) {
+	reporting, daily := uint(1), uint(3)
+	store := newMemoryStore(
+		models.AlertRule{ID: 1, Name: "reporting", GroupID: &reporting, AlertType: TypeFailure, Condition: ConditionImmediate, Enable: true},
+	)
+	store.jobs["sales"] = JobInfo{ID: 7, GroupID: &daily, Groups: []uint{reporting, daily}}
+	store.jobs["backup"] = JobInfo{ID: 8}
+	m, alerts := newTestManager(t, store)
+
+	m.HandleEvent(failed("backup", time.Now()))
+	assert.Empty(t, *alerts)
+	m.HandleEvent(failed("sales", time.Now()))
+	require.Len(t, *alerts, 1)
+	assert.Equal(t, "sales", (*alerts)[0].JobName)
+}
+
+// 测试时长与重试耗尽类型的命中判断
+func TestObservationHit(t *testing.T) {
+	threshold := 100
+	ob := observe(&engine.Event{
+		Type:     engine.EventTypeJobError,
+		TaskName: "slow",
+		Data:     map[string]any{"status": "timeout", "retry_count": 2, "duration_ms": int64(150)},
+	})
+	assert.True(t, ob.hit(models.AlertRule{AlertType: TypeFailure}))
+	assert.True(t, ob.hit(models.AlertRule{AlertType: TypeTimeout}))
+	assert.True(t, ob.hit(models.AlertRule{AlertType: TypeRetryExceeded}))
+	assert.True(t, ob.hit(models.AlertRule{AlertType: TypeDurationExceeded, ThresholdDuration: &threshold}))
+	assert.False(t, ob.hit(models.AlertRule{AlertType: TypeSuccess}))
+	assert.NotEmpty(t, ob.execID)
+}
+
+// 测试 Redis 窗口存储：滑动窗口计数、限流与告警状态
+func TestRedisWindowStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := NewRedisWindowStore(client)
+	ctx := context.Background()
+	now := time.Now()
+
+	n, err := store.Record(ctx, "w", "a", now.Add(-10*time.Minute), 5*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = store.Record(ctx, "w", "b", now, 5*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "窗口之外的事件被清理")
+	n, err = store.Count(ctx, "w", now.Add(6*time.Minute), 5*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	ok, err := store.Allow(ctx, "limit", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.Allow(ctx, "limit", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.Fire(ctx, "firing", "alert-1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.Fire(ctx, "firing", "alert-2", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	id, err := store.Firing(ctx, "firing")
+	require.NoError(t, err)
+	assert.Equal(t, "alert-1", id)
+	id, err = store.Resolve(ctx, "firing")
+	require.NoError(t, err)
+	assert.Equal(t, "alert-1", id)
+	id, err = store.Resolve(ctx, "firing")
+	require.NoError(t, err)
+	assert.Empty(t, id)
+}
@@ -0,0 +1,185 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisWindowStore 基于 Redis 的实现：滑动窗口使用有序集合（分数为事件时间），限流与告警状态使用带过期时间的字符串
+type RedisWindowStore struct {
+	client *redis.Client
+}
+
+// 确保 RedisWindowStore 实现了 WindowStore 接口
+var _ WindowStore = (*RedisWindowStore)(nil)
+
+// NewRedisWindowStore 创建 Redis 窗口存储
+func NewRedisWindowStore(client *redis.Client) *RedisWindowStore {
+	return &RedisWindowStore{client: client}
+}
+
+func (r *RedisWindowStore) Record(ctx context.Context, key, member string, at time.Time, window time.Duration) (int64, error) {
+	var card *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, &redis.Z{Score: float64(at.UnixMilli()), Member: member})
+		pipe.ZRemRangeByScore(ctx, key, "-inf", windowStart(at, window))
+		card = pipe.ZCard(ctx, key)
+		pipe.PExpire(ctx, key, window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return card.Val(), nil
+}
+
+func (r *RedisWindowStore) Count(ctx context.Context, key string, at time.Time, window time.Duration) (int64, error) {
+	return r.client.ZCount(ctx, key, windowStart(at, window), "+inf").Result()
+}
+
+func (r *RedisWindowStore) Allow(ctx context.Context, key string, interval time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, 1, interval).Result()
+}
+
+func (r *RedisWindowStore) Firing(ctx context.Context, key string) (string, error) {
+	id, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return id, err
+}
+
+func (r *RedisWindowStore) Fire(ctx context.Context, key, alertID string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, alertID, ttl).Result()
+}
+
+func (r *RedisWindowStore) Resolve(ctx context.Context, key string) (string, error) {
+	id, err := r.client.GetDel(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return id, err
+}
+
+// windowStart 窗口的起始分数（不含）
+func windowStart(at time.Time, window time.Duration) string {
+	return "(" + strconv.FormatInt(at.Add(-window).UnixMilli(), 10)
+}
+
+// memoryWindowStore 单机部署时使用的内存实现
+type memoryWindowStore struct {
+	mu      sync.Mutex
+	windows map[string]map[string]time.Time // key -> 成员 -> 事件时间
+	expires map[string]time.Time            // 限流与告警状态的过期时间
+	values  map[string]string               // 告警状态
+}
+
+// 确保 memoryWindowStore 实现了 WindowStore 接口
+var _ WindowStore = (*memoryWindowStore)(nil)
+
+// NewMemoryWindowStore 创建内存窗口存储，仅在单个节点内生效
+func NewMemoryWindowStore() WindowStore {
+	return &memoryWindowStore{
+		windows: make(map[string]map[string]time.Time),
+		expires: make(map[string]time.Time),
+		values:  make(map[string]string),
+	}
+}
+
+func (m *memoryWindowStore) Record(_ context.Context, key, member string, at time.Time, window time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events, ok := m.windows[key]
+	if !ok {
+		events = make(map[string]time.Time)
+		m.windows[key] = events
+	}
+	events[member] = at
+	start := at.Add(-window)
+	for id, t := range events {
+		if !t.After(start) {
+			delete(events, id)
+		}
+	}
+	return int64(len(events)), nil
+}
+
+func (m *memoryWindowStore) Count(_ context.Context, key string, at time.Time, window time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	start := at.Add(-window)
+	var n int64
+	for _, t := range m.windows[key] {
+		if t.After(start) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// live 判断 key 是否存在且未过期，调用方需持有锁
+func (m *memoryWindowStore) live(key string) bool {
+	exp, ok := m.expires[key]
+	if !ok {
+		return false
+	}
+	if time.Now().After(exp) {
+		delete(m.expires, key)
+		delete(m.values, key)
+		return false
+	}
+	return true
+}
+
+func (m *memoryWindowStore) Allow(_ context.Context, key string, interval time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.live(key) {
+		return false, nil
+	}
+	m.expires[key] = time.Now().Add(interval)
+	return true, nil
+}
+
+func (m *memoryWindowStore) Firing(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.live(key) {
+		return "", nil
+	}
+	return m.values[key], nil
+}
+
+func (m *memoryWindowStore) Fire(_ context.Context, key, alertID string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.live(key) {
+		return false, nil
+	}
+	m.expires[key] = time.Now().Add(ttl)
+	m.values[key] = alertID
+	return true, nil
+}
+
+func (m *memoryWindowStore) Resolve(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.live(key) {
+		return "", nil
+	}
+	id := m.values[key]
+	delete(m.expires, key)
+	delete(m.values, key)
+	return id, nil
+}
@@ -0,0 +1,261 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// 审计动作
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionEnable   = "enable"
+	ActionDisable  = "disable"
+	ActionRun      = "run"
+	ActionKill     = "kill"
+	ActionCancel   = "cancel"
+	ActionLogin    = "login"
+	ActionRollback = "rollback"
+)
+
+// 审计资源
+const (
+	ResourceJob       = "job"
+	ResourceExecution = "execution"
+	ResourceUser      = "user"
+	ResourceGroup     = "group"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+
+	// RequestIDHeader 请求ID，客户端未携带时由中间件生成并写回响应
+	RequestIDHeader = "X-Request-ID"
+
+	recordKey = "audit_record"
+)
+
+// ignoredFields 计算变更时忽略的字段
+var ignoredFields = map[string]bool{"UpdatedAt": true, "updated_at": true}
+
+// Store 审计日志的持久化接口
+type Store interface {
+	CreateAuditLog(log *models.AuditLog) error
+}
+
+// Change 一个字段的变更
+type Change struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// record 处理器对本次请求的审计标注
+type record struct {
+	action     string
+	resource   string
+	resourceID string
+	oldValue   any
+	newValue   any
+	userID     *uint
+	username   string
+}
+
+// Option 审计中间件的配置选项
+type Option func(*middleware)
+
+// WithLogger 配置日志
+func WithLogger(log engine.Logger) Option {
+	return func(m *middleware) {
+		if log != nil {
+			m.logger = log
+		}
+	}
+}
+
+type middleware struct {
+	store  Store
+	logger engine.Logger
+}
+
+// Middleware 记录所有写操作（POST、PUT、PATCH、DELETE）的审计日志；处理器通过 Record、SetValues 标注动作与变更，未标注时按请求方法与路由记录
+func Middleware(store Store, opts ...Option) gin.HandlerFunc {
+	m := &middleware{store: store}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.logger == nil {
+		m.logger = engine.NewDefaultLogger()
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		rec := &record{}
+		c.Set(recordKey, rec)
+		c.Next()
+
+		entry := rec.entry(c)
+		entry.RequestID = requestID
+		if err := m.store.CreateAuditLog(entry); err != nil {
+			m.logger.Error("❌ [Audit] Write audit log failed", err, "action", entry.Action, "resource", entry.Resource)
+		}
+	}
+}
+
+// entry 由请求与处理器的标注生成审计日志
+func (r *record) entry(c *gin.Context) *models.AuditLog {
+	entry := &models.AuditLog{
+		Action:     r.action,
+		Resource:   r.resource,
+		ResourceID: r.resourceID,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		IP:         c.ClientIP(),
+		UserAgent:  truncate(c.Request.UserAgent(), 500),
+		Status:     StatusSuccess,
+	}
+	if entry.Action == "" {
+		entry.Action = strings.ToLower(c.Request.Method)
+	}
+	if entry.Resource == "" {
+		entry.Resource = truncate(c.FullPath(), 100)
+		if entry.Resource == "" {
+			entry.Resource = truncate(c.Request.URL.Path, 100)
+		}
+	}
+	status := c.Writer.Status()
+	if status >= http.StatusBadRequest {
+		entry.Status = StatusFailed
+		entry.ErrorCode = strconv.Itoa(status)
+	}
+	details := map[string]any{"status_code": status}
+
+	entry.UserID, entry.Username = r.userID, r.username
+	if entry.UserID == nil {
+		if v, ok := c.Get("user_id"); ok {
+			if id, ok := v.(uint); ok {
+				entry.UserID = &id
+			}
+		}
+	}
+	if entry.Username == "" {
+		entry.Username = c.GetString("username")
+	}
+
+	if r.oldValue != nil || r.newValue != nil {
+		entry.OldValue = toJSON(r.oldValue)
+		entry.NewValue = toJSON(r.newValue)
+		if changes := Diff(r.oldValue, r.newValue); len(changes) > 0 {
+			details["changes"] = changes
+		}
+	}
+	entry.Details = toJSON(details)
+	return entry
+}
+
+// Record 标注本次请求的动作与资源，重复调用时覆盖之前的标注
+func Record(c *gin.Context, action, resource, resourceID string) {
+	if rec := recordFrom(c); rec != nil {
+		rec.action, rec.resource, rec.resourceID = action, resource, resourceID
+	}
+}
+
+// SetValues 设置资源变更前后的值，创建时 old 为 nil，删除时 new 为 nil
+func SetValues(c *gin.Context, old, new any) {
+	if rec := recordFrom(c); rec != nil {
+		rec.oldValue, rec.newValue = old, new
+	}
+}
+
+// SetUser 设置操作用户，用于登录等认证之前的请求；userID 为 0 表示用户不存在
+func SetUser(c *gin.Context, userID uint, username string) {
+	if rec := recordFrom(c); rec != nil {
+		if userID > 0 {
+			rec.userID = &userID
+		}
+		rec.username = username
+	}
+}
+
+func recordFrom(c *gin.Context) *record {
+	if v, ok := c.Get(recordKey); ok {
+		rec, _ := v.(*record)
+		return rec
+	}
+	return nil
+}
+
+// Diff 按 JSON 字段比较两个值，返回发生变化的字段
+func Diff(old, new any) map[string]Change {
+	oldFields, newFields := toFields(old), toFields(new)
+	changes := make(map[string]Change)
+	for key, oldValue := range oldFields {
+		if ignoredFields[key] {
+			continue
+		}
+		newValue, ok := newFields[key]
+		if !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changes[key] = Change{Old: oldValue, New: newValue}
+		}
+	}
+	for key, newValue := range newFields {
+		if _, ok := oldFields[key]; !ok && !ignoredFields[key] {
+			changes[key] = Change{New: newValue}
+		}
+	}
+	return changes
+}
+
+// toFields 将结构体转换为字段 map，nil 返回空 map
+func toFields(v any) map[string]any {
+	fields := make(map[string]any)
+	if v == nil {
+		return fields
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+func toJSON(v any) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
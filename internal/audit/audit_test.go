@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	mu   sync.Mutex
+	logs []*models.AuditLog
+	err  error
+}
+
+func (m *memoryStore) CreateAuditLog(log *models.AuditLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logs = append(m.logs, log)
+	return m.err
+}
+
+type job struct {
+	Name      string
+	CronExpr  string
+	Enable    bool
+	UpdatedAt string
+}
+
+func newAuditRouter(store Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+		c.Set("username", "alice")
+		c.Next()
+	}, Middleware(store))
+
+	r.GET("/jobs", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.PUT("/jobs/:id", func(c *gin.Context) {
+		Record(c, ActionUpdate, ResourceJob, c.Param("id"))
+		before := job{Name: "sync", CronExpr: "* * * * *", Enable: true, UpdatedAt: "t1"}
+		after := job{Name: "sync", CronExpr: "0 * * * *", Enable: true, UpdatedAt: "t2"}
+		SetValues(c, before, after)
+		c.Status(http.StatusOK)
+	})
+	r.DELETE("/jobs/:id", func(c *gin.Context) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+	})
+	r.POST("/login", func(c *gin.Context) {
+		Record(c, ActionLogin, ResourceUser, "")
+		SetUser(c, 0, "mallory")
+		c.Status(http.StatusUnauthorized)
+	})
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	store := &memoryStore{}
+	r := newAuditRouter(store)
+
+	// 读请求不记录
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+	assert.Empty(t, store.logs)
+	assert.Empty(t, w.Header().Get(RequestIDHeader))
+
+	// 处理器标注的更新记录变更前后的值与字段差异
+	req := httptest.NewRequest(http.MethodPut, "/jobs/3", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set("User-Agent", "curl/8")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Len(t, store.logs, 1)
+	log := store.logs[0]
+	assert.Equal(t, ActionUpdate, log.Action)
+	assert.Equal(t, ResourceJob, log.Resource)
+	assert.Equal(t, "3", log.ResourceID)
+	assert.Equal(t, uint(7), *log.UserID)
+	assert.Equal(t, "alice", log.Username)
+	assert.Equal(t, StatusSuccess, log.Status)
+	assert.Equal(t, "req-1", log.RequestID)
+	assert.Equal(t, "req-1", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "curl/8", log.UserAgent)
+	assert.Contains(t, log.OldValue, `"CronExpr":"* * * * *"`)
+	assert.Contains(t, log.NewValue, `"CronExpr":"0 * * * *"`)
+
+	var details struct {
+		StatusCode int               `json:"status_code"`
+		Changes    map[string]Change `json:"changes"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(log.Details), &details))
+	assert.Equal(t, http.StatusOK, details.StatusCode)
+	assert.Equal(t, map[string]Change{"CronExpr": {Old: "* * * * *", New: "0 * * * *"}}, details.Changes)
+
+	// 未标注的请求按方法与路由记录，失败时记录状态码
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/jobs/3", nil))
+	require.Len(t, store.logs, 2)
+	log = store.logs[1]
+	assert.Equal(t, "delete", log.Action)
+	assert.Equal(t, "/jobs/:id", log.Resource)
+	assert.Equal(t, StatusFailed, log.Status)
+	assert.Equal(t, "403", log.ErrorCode)
+	assert.NotEmpty(t, log.RequestID)
+
+	// 登录失败时记录请求中的用户名
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", nil))
+	require.Len(t, store.logs, 3)
+	log = store.logs[2]
+	assert.Equal(t, ActionLogin, log.Action)
+	assert.Equal(t, "mallory", log.Username)
+	assert.Equal(t, StatusFailed, log.Status)
+
+	// 写入失败不影响请求
+	store.err = errors.New("db down")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/jobs/3", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestDiff(t *testing.T) {
+	// 创建时所有字段都是新增
+	changes := Diff(nil, job{Name: "a"})
+	assert.Equal(t, Change{New: "a"}, changes["Name"])
+	assert.NotContains(t, changes, "UpdatedAt")
+
+	// 删除时所有字段都被移除
+	changes = Diff(job{Name: "a"}, nil)
+	assert.Equal(t, Change{Old: "a"}, changes["Name"])
+
+	assert.Empty(t, Diff(job{Name: "a", UpdatedAt: "t1"}, job{Name: "a", UpdatedAt: "t2"}))
+	assert.Equal(t, map[string]Change{"Enable": {Old: false, New: true}}, Diff(job{Name: "a"}, job{Name: "a", Enable: true}))
+}
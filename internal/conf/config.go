@@ -11,11 +11,17 @@ import (
 )
 
 type Config struct {
-	Server ServerConfig   `mapstructure:"server"`
-	Jobs   []JobConfig    `mapstructure:"jobs"`
-	Mysql  MysqlConfig    `mapstructure:"mysql"`
-	Redis  RedisConfig    `mapstructure:"redis"`
-	Auth   AuthConfig     `mapstructure:"auth"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Jobs      []JobConfig     `mapstructure:"jobs"`
+	Mysql     MysqlConfig     `mapstructure:"mysql"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Mongo     MongoConfig     `mapstructure:"mongo"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	Scheduler SchedulerConfig `mapstructure:"scheduler"`
+	Shell     ShellConfig     `mapstructure:"shell"`
+
+	DataSources map[string]DataSourceConfig `mapstructure:"datasources"`
+	Storage     StorageConfig               `mapstructure:"storage"`
 }
 
 type ServerConfig struct {
@@ -39,6 +45,12 @@ type RedisConfig struct {
 	PoolSize int    `mapstructure:"pool_size"`
 }
 
+// MongoConfig MongoDB 配置，执行日志存储为 mongo 时使用
+type MongoConfig struct {
+	URI      string `mapstructure:"uri"`
+	Database string `mapstructure:"database"`
+}
+
 type AuthConfig struct {
 	JWTSecret      string `mapstructure:"jwt_secret"`
 	TokenExpireHrs int    `mapstructure:"token_expire_hrs"`
@@ -49,11 +61,55 @@ type AuthConfig struct {
 	} `mapstructure:"default_admin"`
 }
 
+// SchedulerConfig 调度内核配置
+type SchedulerConfig struct {
+	Queue             string `mapstructure:"queue"`              // 任务队列实现: memory(默认) 或 redis，redis 模式下所有节点共享执行
+	WorkerNum         int    `mapstructure:"worker_num"`         // 每个节点的 worker 数量
+	VisibilityTimeout int    `mapstructure:"visibility_timeout"` // redis 队列的可见性超时（秒）
+	LogSink           string `mapstructure:"log_sink"`           // 执行日志存储: mysql(默认), mongo, none
+}
+
+// ShellConfig Worker 节点对 shell:shell 任务的限制
+type ShellConfig struct {
+	AllowedCommands []string `mapstructure:"allowed_commands"` // 允许执行的程序，为空时不限制；sh/bash 模式下校验的是 shell 本身
+	AllowedRunAs    []string `mapstructure:"allowed_run_as"`   // 允许的 run_as，为空时不限制
+	DefaultRunAs    string   `mapstructure:"default_run_as"`   // 任务未指定 run_as 时使用的用户
+	WorkspaceRoot   string   `mapstructure:"workspace_root"`   // 执行工作区的根目录，默认系统临时目录
+}
+
+// DataSourceConfig sql:sql 任务可使用的命名数据源
+type DataSourceConfig struct {
+	Driver       string `mapstructure:"driver"` // mysql、postgres、sqlite
+	DSN          string `mapstructure:"dsn"`
+	MaxOpenConns int    `mapstructure:"max_open_conns"`
+	MaxIdleConns int    `mapstructure:"max_idle_conns"`
+}
+
+// StorageConfig 任务产出文件（如 SQL 导出结果）的本地存储
+type StorageConfig struct {
+	BasePath string `mapstructure:"base_path"` // 存储目录，为空时不启用
+}
+
 type JobConfig struct {
-	Name   string                 `mapstructure:"name"`
-	Cron   string                 `mapstructure:"cron"`
-	Enable bool                   `mapstructure:"enable"`
-	Params map[string]interface{} `mapstructure:"params"`
+	Name    string                 `mapstructure:"name"`
+	Cron    string                 `mapstructure:"cron"`
+	Enable  bool                   `mapstructure:"enable"`
+	Misfire string                 `mapstructure:"misfire"` // 错过触发的补偿策略: skip(默认), run_once, run_all
+	Params  map[string]interface{} `mapstructure:"params"`
+
+	Timeout      int      `mapstructure:"timeout"`       // 单次执行超时(秒)，0 使用默认值
+	MaxRetries   *int     `mapstructure:"max_retries"`   // 最大重试次数，不配置时使用默认重试策略
+	RetryBackoff string   `mapstructure:"retry_backoff"` // 重试退避方式: exponential(默认), linear, fixed
+	RetryDelay   int      `mapstructure:"retry_delay"`   // 首次重试前的等待时间(秒)
+	RetryOn      []string `mapstructure:"retry_on"`      // 只重试指定类别的错误: timeout, network；不配置时所有错误都重试
+	Priority     int      `mapstructure:"priority"`      // 优先级，数值越大越先执行
+	Concurrency  string   `mapstructure:"concurrency"`   // 并发策略: allow(默认), forbid, replace, queue
+	MaxPending   int      `mapstructure:"max_pending"`   // queue 策略下最多排队等待的执行数，默认 1
+	Tags         []string `mapstructure:"tags"`          // 标签
+
+	DependsOn         []string `mapstructure:"depends_on"`         // 上游任务名，上游本轮执行完成后才执行
+	DependencyType    string   `mapstructure:"dependency_type"`    // 依赖类型: all_success(默认), any_success, all_complete
+	DependencyTimeout int      `mapstructure:"dependency_timeout"` // 等待上游的超时时间(秒)，超时后放弃本次触发，0 表示一直等待
 }
 
 // LoadConfig 加载配置
@@ -105,6 +161,9 @@ func LoadConfig(path string) (*Config, error) {
 			Port:     rdbPort,
 			PassWord: c.Redis.Password,
 		},
+		Mongo: config.MongoDB{
+			Link: c.Mongo.URI,
+		},
 	}
 
 	return &c, nil
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"github.com/iceymoss/go-task/pkg/constants"
+)
+
+// TaskerCreator 定义 Tasker 构造函数签名
+type TaskerCreator func() Tasker
+
+// TaskDefaults 任务内置的调度默认值（可选实现），core.Task 与内置任务都实现了该接口
+type TaskDefaults interface {
+	GetDefaultCron() string
+	GetDefaultParams() map[string]any
+	GetTaskType() constants.TaskType
+}
+
+// MetadataProvider 声明了元数据的旧任务可以实现该接口，适配后对外暴露
+type MetadataProvider interface {
+	Metadata() TaskMetadata
+}
+
+// AdaptTask 将旧的 core.Task 适配为 Tasker
+func AdaptTask(task Task) Tasker {
+	return &legacyTask{task: task}
+}
+
+// AdaptCreator 将旧的任务构造函数适配为 TaskerCreator
+func AdaptCreator(creator TaskCreator) TaskerCreator {
+	return func() Tasker {
+		return AdaptTask(creator())
+	}
+}
+
+// DefaultParams 返回任务的默认参数，未实现 TaskDefaults 时返回 nil
+func DefaultParams(task any) map[string]any {
+	if d, ok := task.(TaskDefaults); ok {
+		return d.GetDefaultParams()
+	}
+	return nil
+}
+
+// legacyTask core.Task 到 Tasker 的适配器，钩子为空实现
+type legacyTask struct {
+	task Task
+}
+
+func (l *legacyTask) Run(ctx *TaskContext, params map[string]any) error {
+	return l.task.Run(ctx, params)
+}
+
+func (l *legacyTask) Identifier() string { return l.task.Identifier() }
+
+func (l *legacyTask) Metadata() TaskMetadata {
+	if p, ok := l.task.(MetadataProvider); ok {
+		return p.Metadata()
+	}
+	return TaskMetadata{Name: l.task.Identifier()}
+}
+
+func (l *legacyTask) ValidateParams(params map[string]any) error {
+	return ValidateTaskParams(l.task, params)
+}
+
+func (l *legacyTask) BeforeRun(*TaskContext, map[string]any) error { return nil }
+
+func (l *legacyTask) AfterRun(*TaskContext, map[string]any, error) error { return nil }
+
+func (l *legacyTask) GetDefaultCron() string           { return l.task.GetDefaultCron() }
+func (l *legacyTask) GetDefaultParams() map[string]any { return l.task.GetDefaultParams() }
+func (l *legacyTask) GetTaskType() constants.TaskType  { return l.task.GetTaskType() }
+
+// Unwrap 返回被适配的旧任务
+func (l *legacyTask) Unwrap() Task { return l.task }
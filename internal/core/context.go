@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"io"
+
+	"github.com/iceymoss/go-task/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// taskContextKey TaskContext 在 context 中的 key
+type taskContextKey struct{}
+
+// BindTaskContext 将任务上下文绑定到其内部的 context 上，
+// 任务派生出的子 context（如 WithTimeout）仍可以通过 FromContext 取回
+func BindTaskContext(tc *TaskContext) *TaskContext {
+	if tc.Context == nil {
+		tc.Context = context.Background()
+	}
+	tc.Context = context.WithValue(tc.Context, taskContextKey{}, tc)
+	return tc
+}
+
+// FromContext 获取 context 中的任务上下文，旧接口 core.Task 通过它拿到执行ID、日志与进度回调
+func FromContext(ctx context.Context) (*TaskContext, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	if tc, ok := ctx.(*TaskContext); ok {
+		return tc, true
+	}
+	tc, ok := ctx.Value(taskContextKey{}).(*TaskContext)
+	return tc, ok
+}
+
+// Log 返回任务的日志记录器，未注入时使用全局日志
+func (c *TaskContext) Log() *zap.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return logger.Logger
+}
+
+// StdoutWriter 返回任务的标准输出，未注入时丢弃
+func (c *TaskContext) StdoutWriter() io.Writer {
+	if c.Stdout != nil {
+		return c.Stdout
+	}
+	return io.Discard
+}
+
+// StderrWriter 返回任务的标准错误，未注入时丢弃
+func (c *TaskContext) StderrWriter() io.Writer {
+	if c.Stderr != nil {
+		return c.Stderr
+	}
+	return io.Discard
+}
+
+// Progress 上报任务进度，未注入进度回调时忽略
+func (c *TaskContext) Progress(current, total int, message string) {
+	if c.OnProgress != nil {
+		c.OnProgress(TaskProgress{Current: current, Total: total, Message: message})
+	}
+}
+
+// ReportProgress 上报任务进度，context 中没有任务上下文时直接忽略
+func ReportProgress(ctx context.Context, current, total int, message string) {
+	if tc, ok := FromContext(ctx); ok {
+		tc.Progress(current, total, message)
+	}
+}
+
+// LoggerFromContext 返回任务上下文中的日志记录器，不存在时使用全局日志
+func LoggerFromContext(ctx context.Context) *zap.Logger {
+	if tc, ok := FromContext(ctx); ok {
+		return tc.Log()
+	}
+	return logger.Logger
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// outputKey TaskOutput 在 context 中的 key
+type outputKey struct{}
+
+// TaskOutput 任务输出收集器，任务通过 SetOutput 发布结构化结果，供工作流下游节点引用
+type TaskOutput struct {
+	mu   sync.Mutex
+	data map[string]any
+}
+
+// NewTaskOutput 创建输出收集器
+func NewTaskOutput() *TaskOutput {
+	return &TaskOutput{data: make(map[string]any)}
+}
+
+// Set 写入一个输出字段
+func (o *TaskOutput) Set(key string, value any) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.data[key] = value
+}
+
+// Get 读取一个输出字段
+func (o *TaskOutput) Get(key string) (any, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	v, ok := o.data[key]
+	return v, ok
+}
+
+// Data 返回全部输出的副本，没有任何输出时返回 nil
+func (o *TaskOutput) Data() map[string]any {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.data) == 0 {
+		return nil
+	}
+	data := make(map[string]any, len(o.data))
+	for k, v := range o.data {
+		data[k] = v
+	}
+	return data
+}
+
+// WithOutput 返回携带输出收集器的 context，调用方在任务结束后通过收集器读取输出
+func WithOutput(ctx context.Context) (context.Context, *TaskOutput) {
+	out := NewTaskOutput()
+	return context.WithValue(ctx, outputKey{}, out), out
+}
+
+// OutputFromContext 获取 context 中的输出收集器
+func OutputFromContext(ctx context.Context) (*TaskOutput, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	out, ok := ctx.Value(outputKey{}).(*TaskOutput)
+	return out, ok
+}
+
+// SetOutput 发布任务输出，context 中没有收集器时（如普通 cron 调度）直接忽略
+func SetOutput(ctx context.Context, key string, value any) {
+	if out, ok := OutputFromContext(ctx); ok {
+		out.Set(key, value)
+	}
+}
+
+// SetOutput 发布任务输出，等价于 core.SetOutput(ctx, key, value)
+func (c *TaskContext) SetOutput(key string, value any) {
+	SetOutput(c, key, value)
+}
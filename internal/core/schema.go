@@ -0,0 +1,309 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ParamValidator 可以校验参数的任务，Tasker 与声明了 ParamSchema 的任务都实现了该接口
+type ParamValidator interface {
+	ValidateParams(params map[string]any) error
+}
+
+// ValidateTaskParams 任务实现了 ParamValidator 时校验参数，否则不做校验
+func ValidateTaskParams(task any, params map[string]any) error {
+	if v, ok := task.(ParamValidator); ok {
+		return v.ValidateParams(params)
+	}
+	return nil
+}
+
+// ValidationError 参数验证错误，Field 为字段路径，如 headers.Authorization、targets[0].host
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors 一次校验发现的所有错误
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "invalid params: " + strings.Join(msgs, "; ")
+}
+
+// Fields 字段路径 -> 错误信息，供前端在表单中就地展示
+func (e ValidationErrors) Fields() map[string]string {
+	fields := make(map[string]string, len(e))
+	for _, err := range e {
+		if _, ok := fields[err.Field]; !ok {
+			fields[err.Field] = err.Message
+		}
+	}
+	return fields
+}
+
+// Validate 按 Schema 校验参数；根节点视为对象，params 的每个键按 Properties 校验，未声明的键不做限制
+func (s ParamSchema) Validate(params map[string]any) error {
+	v := &schemaValidator{}
+	if s.Required && len(params) == 0 {
+		v.fail("", "required")
+	}
+	if s.Type == "" || s.Type == "object" {
+		v.properties("", s.Properties, params)
+	} else {
+		v.fail("", "root schema must be an object")
+	}
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// schemaValidator 收集校验过程中的错误
+type schemaValidator struct {
+	errs ValidationErrors
+}
+
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	if path == "" {
+		path = "root"
+	}
+	v.errs = append(v.errs, &ValidationError{Field: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// properties 按属性名的字典序校验，保证错误顺序稳定
+func (v *schemaValidator) properties(path string, props map[string]ParamSchema, values map[string]any) {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop := props[name]
+		sub := joinPath(path, name)
+		value, ok := values[name]
+		if !ok || value == nil {
+			if prop.Required && prop.Default == nil {
+				v.fail(sub, "required")
+			}
+			continue
+		}
+		v.value(sub, prop, value)
+	}
+}
+
+// value 校验单个值
+func (v *schemaValidator) value(path string, s ParamSchema, value any) {
+	switch s.Type {
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			v.fail(path, "must be a string")
+			return
+		}
+		v.stringRules(path, s, str)
+	case "integer":
+		n, ok := toNumber(value)
+		if !ok || n != math.Trunc(n) {
+			v.fail(path, "must be an integer")
+			return
+		}
+		v.numberRules(path, s, n)
+	case "number":
+		n, ok := toNumber(value)
+		if !ok {
+			v.fail(path, "must be a number")
+			return
+		}
+		v.numberRules(path, s, n)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.fail(path, "must be a boolean")
+			return
+		}
+	case "object":
+		obj, ok := toObject(value)
+		if !ok {
+			v.fail(path, "must be an object")
+			return
+		}
+		v.properties(path, s.Properties, obj)
+	case "array":
+		items, ok := toArray(value)
+		if !ok {
+			v.fail(path, "must be an array")
+			return
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				sub := fmt.Sprintf("%s[%d]", path, i)
+				if item == nil {
+					if s.Items.Required {
+						v.fail(sub, "required")
+					}
+					continue
+				}
+				v.value(sub, *s.Items, item)
+			}
+		}
+	case "":
+		// 未声明类型，只校验枚举
+	default:
+		v.fail(path, "unknown schema type %q", s.Type)
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		v.fail(path, "must be one of %s", formatEnum(s.Enum))
+	}
+}
+
+func (v *schemaValidator) stringRules(path string, s ParamSchema, str string) {
+	length := utf8.RuneCountInString(str)
+	if s.MinLength != nil && length < *s.MinLength {
+		v.fail(path, "length must be >= %d", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.fail(path, "length must be <= %d", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		re, err := compilePattern(s.Pattern)
+		if err != nil {
+			v.fail(path, "invalid pattern %q", s.Pattern)
+		} else if !re.MatchString(str) {
+			v.fail(path, "must match pattern %q", s.Pattern)
+		}
+	}
+}
+
+func (v *schemaValidator) numberRules(path string, s ParamSchema, n float64) {
+	if s.Minimum != nil && n < *s.Minimum {
+		v.fail(path, "must be >= %s", formatNumber(*s.Minimum))
+	}
+	if s.Maximum != nil && n > *s.Maximum {
+		v.fail(path, "must be <= %s", formatNumber(*s.Maximum))
+	}
+}
+
+// patterns 已编译的正则缓存，Schema 在每次执行前都会校验
+var patterns sync.Map
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// toNumber 参数可能来自 JSON（float64、json.Number）或 YAML（int 等），统一转换为 float64
+func toNumber(value any) (float64, bool) {
+	switch n := value.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case bool, string:
+		return 0, false
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// toObject 接受键为字符串的任意 map
+func toObject(value any) (map[string]any, bool) {
+	if m, ok := value.(map[string]any); ok {
+		return m, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	m := make(map[string]any, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+// toArray 接受任意切片与数组
+func toArray(value any) ([]any, bool) {
+	if a, ok := value.([]any); ok {
+		return a, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	a := make([]any, rv.Len())
+	for i := range a {
+		a[i] = rv.Index(i).Interface()
+	}
+	return a, true
+}
+
+// inEnum 数字按数值比较（JSON 中的 1 与 YAML 中的 1 相等），其余按深度相等比较
+func inEnum(enum []any, value any) bool {
+	n, isNum := toNumber(value)
+	for _, candidate := range enum {
+		if isNum {
+			if c, ok := toNumber(candidate); ok && c == n {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []any) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		parts[i] = fmt.Sprint(e)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+var httpSchema = ParamSchema{
+	Type: "object",
+	Properties: map[string]ParamSchema{
+		"url":     {Type: "string", Required: true, Pattern: `^https?://`},
+		"method":  {Type: "string", Enum: []any{"GET", "POST"}, Default: "GET"},
+		"timeout": {Type: "integer", Minimum: ptr(1.0), Maximum: ptr(300.0)},
+		"retry":   {Type: "boolean"},
+		"headers": {
+			Type: "object",
+			Properties: map[string]ParamSchema{
+				"Authorization": {Type: "string", Required: true, MinLength: ptr(8)},
+			},
+		},
+		"expect_codes": {Type: "array", Items: &ParamSchema{Type: "integer", Enum: []any{200, 201, 204}}},
+	},
+}
+
+// 测试合法参数，兼容 JSON（float64）与 YAML（int）解码出的数字
+func TestParamSchemaValid(t *testing.T) {
+	assert.NoError(t, httpSchema.Validate(map[string]any{
+		"url":          "https://example.com",
+		"timeout":      float64(30),
+		"headers":      map[string]any{"Authorization": "Bearer abcdef"},
+		"expect_codes": []any{float64(200), 204},
+		"extra":        "未声明的参数不做限制",
+	}))
+	assert.NoError(t, httpSchema.Validate(map[string]any{
+		"url":          "http://example.com",
+		"timeout":      5,
+		"expect_codes": []int{201},
+	}))
+}
+
+// 测试所有规则的字段级错误路径
+func TestParamSchemaErrors(t *testing.T) {
+	err := httpSchema.Validate(map[string]any{
+		"method":       "DELETE",
+		"timeout":      1.5,
+		"retry":        "yes",
+		"headers":      map[string]any{},
+		"expect_codes": []any{200, float64(500), "x"},
+	})
+	require.Error(t, err)
+
+	var verrs ValidationErrors
+	require.True(t, errors.As(err, &verrs))
+	assert.Equal(t, map[string]string{
+		"expect_codes[1]":       "must be one of [200, 201, 204]",
+		"expect_codes[2]":       "must be an integer",
+		"headers.Authorization": "required",
+		"method":                "must be one of [GET, POST]",
+		"retry":                 "must be a boolean",
+		"timeout":               "must be an integer",
+		"url":                   "required",
+	}, verrs.Fields())
+
+	err = httpSchema.Validate(map[string]any{
+		"url":     "ftp://example.com",
+		"timeout": 301,
+		"headers": map[string]any{"Authorization": "short"},
+	})
+	assert.EqualError(t, err, `invalid params: headers.Authorization: length must be >= 8; timeout: must be <= 300; url: must match pattern "^https?://"`)
+
+	err = httpSchema.Validate(map[string]any{"url": "https://example.com", "headers": "Bearer x"})
+	assert.EqualError(t, err, "invalid params: headers: must be an object")
+}
+
+// 测试根节点必填与 BaseTask 默认校验
+func TestBaseTaskValidateParams(t *testing.T) {
+	task := &BaseTask{metadata: TaskMetadata{ParamSchema: ParamSchema{Type: "object", Required: true}}}
+	assert.EqualError(t, task.ValidateParams(nil), "invalid params: root: required")
+	assert.NoError(t, task.ValidateParams(map[string]any{"any": 1}))
+
+	assert.NoError(t, ValidateTaskParams(struct{}{}, nil), "未实现 ParamValidator 的任务不校验")
+	assert.Error(t, ValidateTaskParams(task, nil))
+}
@@ -2,6 +2,8 @@ package core
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	"go.uber.org/zap"
 )
@@ -66,6 +68,10 @@ type TaskContext struct {
 	// 日志记录器
 	Logger *zap.Logger // 结构化日志记录器
 
+	// 输出捕获（按行写入执行日志，未注入时丢弃）
+	Stdout io.Writer // 标准输出
+	Stderr io.Writer // 标准错误
+
 	// Worker信息
 	WorkerID string // 执行Worker ID
 	Hostname string // 主机名
@@ -99,6 +105,18 @@ type Tasker interface {
 	AfterRun(ctx *TaskContext, params map[string]any, err error) error
 }
 
+// Execute 依次调用 BeforeRun、Run、AfterRun；BeforeRun 失败时不执行，AfterRun 的错误仅在 Run 成功时返回
+func Execute(ctx *TaskContext, task Tasker, params map[string]any) error {
+	if err := task.BeforeRun(ctx, params); err != nil {
+		return err
+	}
+	err := task.Run(ctx, params)
+	if afterErr := task.AfterRun(ctx, params, err); afterErr != nil && err == nil {
+		err = afterErr
+	}
+	return err
+}
+
 // BaseTask 基础任务实现（提供默认方法）
 type BaseTask struct {
 	metadata TaskMetadata
@@ -110,9 +128,9 @@ func (b *BaseTask) Metadata() TaskMetadata {
 }
 
 // ValidateParams 默认参数验证实现
-// 使用 ParamSchema 进行验证
+// 使用 ParamSchema 进行验证，返回 ValidationErrors
 func (b *BaseTask) ValidateParams(params map[string]any) error {
-	return validateParamsWithSchema(b.metadata.ParamSchema, params)
+	return b.metadata.ParamSchema.Validate(params)
 }
 
 // BeforeRun 默认前置钩子（空实现）
@@ -125,88 +143,22 @@ func (b *BaseTask) AfterRun(ctx *TaskContext, params map[string]any, err error)
 	return nil
 }
 
-// validateParamsWithSchema 使用Schema验证参数
-func validateParamsWithSchema(schema ParamSchema, params map[string]any) error {
-	// TODO: 实现完整的JSON Schema验证逻辑
-	// 这里先做简单实现
-
-	// 检查必填参数
-	if schema.Required {
-		_, exists := params[schema.Type]
-		if !exists && len(params) == 0 {
-			return &ValidationError{
-				Field:   "root",
-				Message: "参数不能为空",
-			}
-		}
-	}
-
-	// 类型检查
-	for key, value := range params {
-		switch schema.Type {
-		case "string":
-			if _, ok := value.(string); !ok {
-				return &ValidationError{
-					Field:   key,
-					Message: "参数必须是字符串类型",
-				}
-			}
-		case "integer":
-			if _, ok := value.(int); !ok {
-				if _, ok := value.(float64); !ok {
-					return &ValidationError{
-						Field:   key,
-						Message: "参数必须是整数类型",
-					}
-				}
-			}
-		case "boolean":
-			if _, ok := value.(bool); !ok {
-				return &ValidationError{
-					Field:   key,
-					Message: "参数必须是布尔类型",
-				}
-			}
-		case "object":
-			if _, ok := value.(map[string]interface{}); !ok {
-				return &ValidationError{
-					Field:   key,
-					Message: "参数必须是对象类型",
-				}
-			}
-		case "array":
-			if _, ok := value.([]interface{}); !ok {
-				return &ValidationError{
-					Field:   key,
-					Message: "参数必须是数组类型",
-				}
-			}
-		}
-	}
-
-	return nil
-}
-
-// ValidationError 参数验证错误
-type ValidationError struct {
-	Field   string
-	Message string
-}
-
-func (e *ValidationError) Error() string {
-	return "参数验证失败 [" + e.Field + "]: " + e.Message
+// WorkflowExecutor 工作流执行器，由调度引擎实现并注入 CompositeTask（避免 core 反向依赖 engine）
+type WorkflowExecutor interface {
+	ExecuteWorkflow(ctx *TaskContext, workflowID string, dag interface{}, params map[string]any) error
 }
 
 // CompositeTask 组合任务（工作流任务的包装器）
 type CompositeTask struct {
 	BaseTask
-	workflowID   string         // 工作流ID
-	dag          interface{}    // DAG定义
-	globalParams map[string]any // 全局参数
+	workflowID   string           // 工作流ID
+	dag          interface{}      // DAG定义
+	globalParams map[string]any   // 全局参数
+	executor     WorkflowExecutor // 工作流执行引擎
 }
 
 // NewCompositeTask 创建组合任务
-func NewCompositeTask(workflowID string, dag interface{}, globalParams map[string]any) *CompositeTask {
+func NewCompositeTask(workflowID string, dag interface{}, globalParams map[string]any, executor WorkflowExecutor) *CompositeTask {
 	return &CompositeTask{
 		BaseTask: BaseTask{
 			metadata: TaskMetadata{
@@ -222,6 +174,7 @@ func NewCompositeTask(workflowID string, dag interface{}, globalParams map[strin
 		workflowID:   workflowID,
 		dag:          dag,
 		globalParams: globalParams,
+		executor:     executor,
 	}
 }
 
@@ -232,10 +185,24 @@ func (c *CompositeTask) Identifier() string {
 
 // Run 执行工作流
 func (c *CompositeTask) Run(ctx *TaskContext, params map[string]any) error {
-	// TODO: 调用工作流执行引擎
-	ctx.Logger.Info("执行组合任务",
-		zap.String("workflow_id", c.workflowID),
-		zap.Any("params", params),
-	)
-	return nil
+	if c.executor == nil {
+		return errors.New("workflow executor not configured")
+	}
+
+	// 运行时参数覆盖全局参数
+	merged := make(map[string]any, len(c.globalParams)+len(params))
+	for k, v := range c.globalParams {
+		merged[k] = v
+	}
+	for k, v := range params {
+		merged[k] = v
+	}
+
+	if ctx.Logger != nil {
+		ctx.Logger.Info("执行组合任务",
+			zap.String("workflow_id", c.workflowID),
+			zap.Any("params", merged),
+		)
+	}
+	return c.executor.ExecuteWorkflow(ctx, c.workflowID, c.dag, merged)
 }
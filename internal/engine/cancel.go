@@ -0,0 +1,251 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	keys "github.com/iceymoss/go-task/pkg/db/key"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrExecutionCancelled 执行被手动取消
+var ErrExecutionCancelled = errors.New("execution cancelled")
+
+// cancelTombstoneTTL 已取消但尚未出队的执行ID保留时长，超过后清理
+const cancelTombstoneTTL = 24 * time.Hour
+
+// CancelRequest 取消请求：按执行ID取消单次执行，或按任务名取消该任务在 At 之前分发的所有执行
+type CancelRequest struct {
+	ExecID  string    `json:"exec_id,omitempty"`
+	JobName string    `json:"job_name,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// CancelBus 在集群内广播取消请求，持有执行的节点收到后在本地取消
+type CancelBus interface {
+	Publish(ctx context.Context, req CancelRequest) error
+	// Subscribe 订阅取消请求（非阻塞），ctx 结束时停止订阅
+	Subscribe(ctx context.Context, handler func(req CancelRequest)) error
+}
+
+// runningExec 本节点正在执行的任务
+type runningExec struct {
+	name   string
+	cancel context.CancelCauseFunc
+}
+
+// cancelRegistry 本节点的取消登记：正在执行的任务的 cancel 函数，以及已取消但尚未出队的执行
+type cancelRegistry struct {
+	mu         sync.Mutex
+	running    map[string]runningExec // 执行ID -> 正在执行的任务
+	tombstones map[string]time.Time   // 已取消但尚未执行的执行ID -> 取消时间
+	killed     map[string]time.Time   // 任务名 -> 在此时间之前分发的执行全部取消
+}
+
+func newCancelRegistry() *cancelRegistry {
+	return &cancelRegistry{
+		running:    make(map[string]runningExec),
+		tombstones: make(map[string]time.Time),
+		killed:     make(map[string]time.Time),
+	}
+}
+
+// register 登记一次开始执行的任务
+func (r *cancelRegistry) register(execID, name string, cancel context.CancelCauseFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running[execID] = runningExec{name: name, cancel: cancel}
+}
+
+// unregister 执行结束后移除登记
+func (r *cancelRegistry) unregister(execID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.running, execID)
+}
+
+// cancel 取消一次执行：正在执行则取消其 context，否则记录下来，出队时跳过。返回是否命中正在执行的任务
+func (r *cancelRegistry) cancel(execID string, at time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if run, ok := r.running[execID]; ok {
+		run.cancel(ErrExecutionCancelled)
+		return true
+	}
+
+	for id, t := range r.tombstones {
+		if at.Sub(t) > cancelTombstoneTTL {
+			delete(r.tombstones, id)
+		}
+	}
+	r.tombstones[execID] = at
+	return false
+}
+
+// kill 取消任务在 at 之前分发的所有执行，返回被取消的正在执行的执行ID
+func (r *cancelRegistry) kill(name string, at time.Time) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if at.After(r.killed[name]) {
+		r.killed[name] = at
+	}
+	var cancelled []string
+	for id, run := range r.running {
+		if run.name == name {
+			run.cancel(ErrExecutionCancelled)
+			cancelled = append(cancelled, id)
+		}
+	}
+	return cancelled
+}
+
+// isCancelled 队列项出队时检查是否已被取消
+func (r *cancelRegistry) isCancelled(item TaskItem) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tombstones[item.ID]; ok {
+		delete(r.tombstones, item.ID)
+		return true
+	}
+	killedAt, ok := r.killed[item.Name]
+	return ok && !item.EnqueuedAt.After(killedAt)
+}
+
+// runningIDs 任务在本节点正在执行的执行ID
+func (r *cancelRegistry) runningIDs(name string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []string
+	for id, run := range r.running {
+		if run.name == name {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// CancelExecution 取消一次执行：正在执行的任务会取消其 context，仍在队列中的任务出队时被跳过。
+// 配置了 CancelBus 时同时广播给集群内的其他节点。
+func (s *Scheduler) CancelExecution(execID string) error {
+	req := CancelRequest{ExecID: execID, At: time.Now()}
+	s.applyCancel(req)
+	return s.publishCancel(req)
+}
+
+// KillJob 取消任务当前所有正在执行和排队中的执行，返回本节点被取消的执行ID
+func (s *Scheduler) KillJob(name string) ([]string, error) {
+	if !s.HasJob(name) {
+		return nil, ErrJobNotFound
+	}
+	req := CancelRequest{JobName: name, At: time.Now()}
+	cancelled := s.applyCancel(req)
+	return cancelled, s.publishCancel(req)
+}
+
+// RunningExecutions 任务在本节点正在执行的执行ID
+func (s *Scheduler) RunningExecutions(name string) []string {
+	return s.cancels.runningIDs(name)
+}
+
+// applyCancel 在本节点执行取消请求
+func (s *Scheduler) applyCancel(req CancelRequest) []string {
+	if req.ExecID != "" {
+		if s.cancels.cancel(req.ExecID, req.At) {
+			s.logger.Info("🛑 [Cancel] Cancelling running execution", "exec_id", req.ExecID)
+			return []string{req.ExecID}
+		}
+		return nil
+	}
+	if req.JobName != "" {
+		cancelled := s.cancels.kill(req.JobName, req.At)
+		s.logger.Info("🛑 [Cancel] Killing job", "name", req.JobName, "running", len(cancelled))
+		return cancelled
+	}
+	return nil
+}
+
+// publishCancel 将取消请求广播给集群
+func (s *Scheduler) publishCancel(req CancelRequest) error {
+	if s.cancelBus == nil {
+		return nil
+	}
+	return s.cancelBus.Publish(context.Background(), req)
+}
+
+// startCancelSubscriber 订阅集群内的取消请求
+func (s *Scheduler) startCancelSubscriber() {
+	if s.cancelBus == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancelBusStop = cancel
+	if err := s.cancelBus.Subscribe(ctx, func(req CancelRequest) {
+		s.applyCancel(req)
+	}); err != nil {
+		s.logger.Error("❌ [Cancel] Subscribe cancel requests failed", err)
+	}
+}
+
+// RedisCancelBus 基于 Redis Pub/Sub 的取消广播
+type RedisCancelBus struct {
+	client *redis.Client
+	logger Logger
+}
+
+// 确保 RedisCancelBus 实现了 CancelBus 接口
+var _ CancelBus = (*RedisCancelBus)(nil)
+
+// NewRedisCancelBus 创建 Redis 取消广播
+func NewRedisCancelBus(client *redis.Client, log Logger) *RedisCancelBus {
+	if log == nil {
+		log = NewDefaultLogger()
+	}
+	return &RedisCancelBus{client: client, logger: log}
+}
+
+func (b *RedisCancelBus) Publish(ctx context.Context, req CancelRequest) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, keys.KeyTaskCancelChannel(), payload).Err()
+}
+
+func (b *RedisCancelBus) Subscribe(ctx context.Context, handler func(req CancelRequest)) error {
+	pubsub := b.client.Subscribe(ctx, keys.KeyTaskCancelChannel())
+	// 等待订阅确认，保证返回后发布的消息都能收到
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				var req CancelRequest
+				if err := json.Unmarshal([]byte(msg.Payload), &req); err != nil {
+					b.logger.Warn("⚠️ [Cancel] Invalid cancel request", "payload", msg.Payload, err)
+					continue
+				}
+				handler(req)
+			}
+		}
+	}()
+	return nil
+}
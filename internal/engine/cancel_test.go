@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
+	"github.com/iceymoss/go-task/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingTask 一直阻塞到 context 结束或被放行
+type blockingTask struct {
+	runs    *int32
+	release chan struct{}
+}
+
+func (t *blockingTask) Run(ctx context.Context, params map[string]any) error {
+	atomic.AddInt32(t.runs, 1)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.release:
+		return nil
+	}
+}
+func (t *blockingTask) Identifier() string               { return "test:blocking" }
+func (t *blockingTask) GetDefaultCron() string           { return "" }
+func (t *blockingTask) GetDefaultParams() map[string]any { return nil }
+func (t *blockingTask) GetTaskType() constants.TaskType  { return constants.TaskTypeAPI }
+
+func newBlockingScheduler(t *testing.T, opts ...Option) (*Scheduler, *memoryExecutionStore, *int32, chan struct{}) {
+	var runs int32
+	release := make(chan struct{})
+	registry := NewTaskRegistry()
+	registry.Register("block", func() core.Task { return &blockingTask{runs: &runs, release: release} })
+
+	store := newMemoryExecutionStore()
+	opts = append([]Option{WithExecutionStore(store), WithWorkerNum(1)}, opts...)
+	s := NewScheduler(registry, opts...)
+	t.Cleanup(s.Stop)
+	require.NoError(t, s.AddJob("@every 1h", "block", "block", nil, "TEST", nil))
+	return s, store, &runs, release
+}
+
+func lastStatus(store *memoryExecutionStore, id string) ExecutionStatus {
+	statuses, _ := store.get(id)
+	if len(statuses) == 0 {
+		return ""
+	}
+	return statuses[len(statuses)-1]
+}
+
+// 测试取消正在执行的任务：context 被取消，执行记录为 cancelled，发出取消事件且不会重试
+func TestCancelRunningExecution(t *testing.T) {
+	s, store, runs, _ := newBlockingScheduler(t)
+	events := make(chan *Event, 1)
+	s.EventManager.OnFunc(EventTypeJobCancelled, func(event *Event) { events <- event })
+
+	execID, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(s.RunningExecutions("block")) == 1 }, 2*time.Second, 5*time.Millisecond)
+
+	require.NoError(t, s.CancelExecution(execID))
+
+	select {
+	case event := <-events:
+		assert.Equal(t, execID, event.ExecID)
+		assert.ErrorIs(t, event.Error, ErrExecutionCancelled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("未收到取消事件")
+	}
+	assert.Equal(t, ExecutionStatusCancelled, lastStatus(store, execID))
+	assert.Equal(t, int32(1), atomic.LoadInt32(runs), "取消后不应重试")
+
+	stat, _ := s.Stats.Get("block")
+	assert.Equal(t, LastResultCancelled, stat.LastResult)
+}
+
+// 测试取消排队中的任务以及 KillJob：队列项出队时被跳过
+func TestCancelQueuedExecutionAndKillJob(t *testing.T) {
+	s, store, runs, release := newBlockingScheduler(t)
+
+	first, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(s.RunningExecutions("block")) == 1 }, 2*time.Second, 5*time.Millisecond)
+
+	// 唯一的 worker 被占用，第二次触发停留在队列中
+	queued, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.NoError(t, s.CancelExecution(queued))
+	close(release)
+
+	require.Eventually(t, func() bool { return lastStatus(store, queued) == ExecutionStatusCancelled }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, ExecutionStatusSuccess, lastStatus(store, first))
+	assert.Equal(t, int32(1), atomic.LoadInt32(runs))
+
+	// KillJob 之前分发的执行全部取消，之后的分发不受影响
+	killed, err := s.ManualRun("block")
+	require.NoError(t, err)
+	_, err = s.KillJob("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, killed) == ExecutionStatusCancelled }, 2*time.Second, 5*time.Millisecond)
+
+	after, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, after) == ExecutionStatusSuccess }, 2*time.Second, 5*time.Millisecond)
+
+	_, err = s.KillJob("missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+// 测试通过 Redis Pub/Sub 把取消请求送到持有执行的节点
+func TestRedisCancelBusPropagation(t *testing.T) {
+	client := newTestRedis(t)
+	worker, store, _, _ := newBlockingScheduler(t, WithCancelBus(NewRedisCancelBus(client, nil)))
+	api, _, _, _ := newBlockingScheduler(t, WithCancelBus(NewRedisCancelBus(client, nil)))
+	worker.startCancelSubscriber()
+	api.startCancelSubscriber()
+
+	execID, err := worker.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(worker.RunningExecutions("block")) == 1 }, 2*time.Second, 5*time.Millisecond)
+
+	// 从另一个节点发起取消
+	require.NoError(t, api.CancelExecution(execID))
+	require.Eventually(t, func() bool { return lastStatus(store, execID) == ExecutionStatusCancelled }, 2*time.Second, 5*time.Millisecond)
+}
@@ -2,12 +2,16 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime/debug"
 	"sync/atomic"
 	"time"
 )
 
+// ErrJobTimeout 任务执行超时
+var ErrJobTimeout = errors.New("job timed out")
+
 // JobFunc 任务函数类型
 type JobFunc func(ctx context.Context) error
 
@@ -49,16 +53,22 @@ func Recover(logger Logger) JobWrapper {
 	}
 }
 
-// DelayIfStillRunning 如果任务正在运行，则延迟执行
+// DelayIfStillRunning 如果任务正在运行，则等待上一次执行结束后再执行，等待期间 ctx 结束时放弃执行
 func DelayIfStillRunning(logger Logger) JobWrapper {
-	var running int32
+	running := make(chan struct{}, 1)
 	return func(next JobFunc) JobFunc {
 		return func(ctx context.Context) error {
-			if !atomic.CompareAndSwapInt32(&running, 0, 1) {
+			select {
+			case running <- struct{}{}:
+			default:
 				logger.Info("⏳ [JobWrapper] Job is still running, delaying...")
-				return fmt.Errorf("job is still running")
+				select {
+				case running <- struct{}{}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
 			}
-			defer atomic.StoreInt32(&running, 0)
+			defer func() { <-running }()
 			return next(ctx)
 		}
 	}
@@ -111,8 +121,12 @@ func Timeout(timeout time.Duration) JobWrapper {
 				// 任务在超时时间内顺利（或报错）跑完了
 				return err
 			case <-timeoutCtx.Done():
+				// 上游 context 先结束（如手动取消），返回上游的原因而不是超时
+				if ctx.Err() != nil {
+					return context.Cause(ctx)
+				}
 				// 时间到了，任务还没跑完。timeoutCtx.Done() 的通道会收到关闭信号
-				return fmt.Errorf("job timed out after %v", timeout)
+				return fmt.Errorf("%w after %v", ErrJobTimeout, timeout)
 			}
 		}
 	}
@@ -123,15 +137,16 @@ func Logging(taskName string, logger Logger) JobWrapper {
 	return func(next JobFunc) JobFunc {
 		return func(ctx context.Context) error {
 			startTime := time.Now()
-			logger.Info("🚀 [JobWrapper] Job started", "task", taskName, "start_time", startTime)
+			execID := ExecIDFromContext(ctx)
+			logger.Info("🚀 [JobWrapper] Job started", "task", taskName, "exec_id", execID, "start_time", startTime)
 
 			err := next(ctx)
 
 			duration := time.Since(startTime)
 			if err != nil {
-				logger.Error("❌ [JobWrapper] Job failed", "task", taskName, "duration", duration, err)
+				logger.Error("❌ [JobWrapper] Job failed", "task", taskName, "exec_id", execID, "duration", duration, err)
 			} else {
-				logger.Info("✅ [JobWrapper] Job completed successfully", "task", taskName, "duration", duration)
+				logger.Info("✅ [JobWrapper] Job completed successfully", "task", taskName, "exec_id", execID, "duration", duration)
 			}
 
 			return err
@@ -0,0 +1,297 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	keys "github.com/iceymoss/go-task/pkg/db/key"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrConcurrencyLimited 任务的并发策略拒绝了本次触发（forbid 下仍在执行，或 queue 下排队已满）
+var ErrConcurrencyLimited = errors.New("job concurrency limit reached")
+
+const (
+	defaultMaxPending      = 1               // queue 策略下默认最多排队的执行数
+	concurrencyStaleMargin = time.Hour       // 登记的过期余量：节点异常退出时遗留的登记超过该时长后清理
+	concurrencyReleaseWait = 5 * time.Second // 释放登记的超时时间
+)
+
+// ConcurrencyStore 记录任务活跃（排队中与执行中）的执行，按分发时间排序；集群部署时各节点共享
+type ConcurrencyStore interface {
+	// Acquire 登记一次执行；limit>0 且活跃执行数已达到 limit 时不登记并返回 false。
+	// 登记时间早于 now-staleAfter 的执行视为异常退出遗留，登记前清理
+	Acquire(ctx context.Context, name, execID string, at time.Time, limit int, staleAfter time.Duration) (bool, error)
+	// Ahead 返回排在该执行之前的活跃执行数，未登记时返回 0
+	Ahead(ctx context.Context, name, execID string, staleAfter time.Duration) (int, error)
+	// Release 执行结束、被取消或跳过后移除登记
+	Release(ctx context.Context, name, execID string) error
+	// Clear 任务移除后清理其所有登记
+	Clear(ctx context.Context, name string) error
+}
+
+// concurrencyEntry 一次活跃执行的登记
+type concurrencyEntry struct {
+	execID string
+	at     time.Time
+}
+
+// memoryConcurrencyStore 单机部署时使用的内存实现
+type memoryConcurrencyStore struct {
+	mu      sync.Mutex
+	entries map[string][]concurrencyEntry // 任务名 -> 按登记时间排序的活跃执行
+}
+
+// 确保 memoryConcurrencyStore 实现了 ConcurrencyStore 接口
+var _ ConcurrencyStore = (*memoryConcurrencyStore)(nil)
+
+func newMemoryConcurrencyStore() *memoryConcurrencyStore {
+	return &memoryConcurrencyStore{entries: make(map[string][]concurrencyEntry)}
+}
+
+// purge 清理过期的登记，调用方需持有锁
+func (m *memoryConcurrencyStore) purge(name string, staleAfter time.Duration) []concurrencyEntry {
+	entries := m.entries[name]
+	if staleAfter > 0 {
+		staleBefore := time.Now().Add(-staleAfter)
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.at.After(staleBefore) {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+		m.entries[name] = entries
+	}
+	return entries
+}
+
+func (m *memoryConcurrencyStore) Acquire(_ context.Context, name, execID string, at time.Time, limit int, staleAfter time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := m.purge(name, staleAfter)
+	if limit > 0 && len(entries) >= limit {
+		return false, nil
+	}
+	entries = append(entries, concurrencyEntry{execID: execID, at: at})
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].at.Before(entries[j].at) })
+	m.entries[name] = entries
+	return true, nil
+}
+
+func (m *memoryConcurrencyStore) Ahead(_ context.Context, name, execID string, staleAfter time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, e := range m.purge(name, staleAfter) {
+		if e.execID == execID {
+			return i, nil
+		}
+	}
+	return 0, nil
+}
+
+func (m *memoryConcurrencyStore) Release(_ context.Context, name, execID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := m.entries[name]
+	for i, e := range entries {
+		if e.execID == execID {
+			m.entries[name] = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	if len(m.entries[name]) == 0 {
+		delete(m.entries, name)
+	}
+	return nil
+}
+
+func (m *memoryConcurrencyStore) Clear(_ context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, name)
+	return nil
+}
+
+// RedisConcurrencyStore 基于 Redis 有序集合的实现，集群内所有节点共享同一份活跃执行，
+// 成员为执行ID，分数为分发时间（毫秒）
+type RedisConcurrencyStore struct {
+	client *redis.Client
+}
+
+// 确保 RedisConcurrencyStore 实现了 ConcurrencyStore 接口
+var _ ConcurrencyStore = (*RedisConcurrencyStore)(nil)
+
+// NewRedisConcurrencyStore 创建 Redis 并发登记
+func NewRedisConcurrencyStore(client *redis.Client) *RedisConcurrencyStore {
+	return &RedisConcurrencyStore{client: client}
+}
+
+// concurrencyAcquireScript 原子地清理过期登记、检查数量并登记
+// KEYS[1]: 活跃执行集合; ARGV: 执行ID, 分发时间, 上限, 过期分数, key 过期时间(ms)
+var concurrencyAcquireScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[4])
+local limit = tonumber(ARGV[3])
+if limit > 0 and redis.call('ZCARD', KEYS[1]) >= limit then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+// concurrencyAheadScript 清理过期登记后返回执行的排名
+// KEYS[1]: 活跃执行集合; ARGV: 执行ID, 过期分数
+var concurrencyAheadScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[2])
+local rank = redis.call('ZRANK', KEYS[1], ARGV[1])
+if not rank then
+	return 0
+end
+return rank
+`)
+
+func (r *RedisConcurrencyStore) Acquire(ctx context.Context, name, execID string, at time.Time, limit int, staleAfter time.Duration) (bool, error) {
+	n, err := concurrencyAcquireScript.Run(ctx, r.client, []string{keys.KeyTaskConcurrent(name)},
+		execID, at.UnixMilli(), limit, staleScore(staleAfter), staleAfter.Milliseconds(),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *RedisConcurrencyStore) Ahead(ctx context.Context, name, execID string, staleAfter time.Duration) (int, error) {
+	return concurrencyAheadScript.Run(ctx, r.client, []string{keys.KeyTaskConcurrent(name)}, execID, staleScore(staleAfter)).Int()
+}
+
+func (r *RedisConcurrencyStore) Release(ctx context.Context, name, execID string) error {
+	return r.client.ZRem(ctx, keys.KeyTaskConcurrent(name), execID).Err()
+}
+
+func (r *RedisConcurrencyStore) Clear(ctx context.Context, name string) error {
+	return r.client.Del(ctx, keys.KeyTaskConcurrent(name)).Err()
+}
+
+// staleScore 过期登记的分数上限（不含）
+func staleScore(staleAfter time.Duration) string {
+	return "(" + strconv.FormatInt(time.Now().Add(-staleAfter).UnixMilli(), 10)
+}
+
+// concurrencyLimit 并发策略对应的活跃执行上限，0 表示不限制
+func concurrencyLimit(reg JobDefinition) int {
+	switch reg.concurrency {
+	case ConcurrencyForbid:
+		return 1
+	case ConcurrencyQueue:
+		maxPending := reg.maxPending
+		if maxPending <= 0 {
+			maxPending = defaultMaxPending
+		}
+		return 1 + maxPending
+	default:
+		return 0
+	}
+}
+
+// concurrencyTTL 登记的最长保留时间：每次尝试的超时 × 最大尝试次数，再加上余量
+func (s *Scheduler) concurrencyTTL(name string) time.Duration {
+	s.mu.RLock()
+	timeout := s.jobDefinition[name].timeout
+	s.mu.RUnlock()
+	if timeout <= 0 {
+		timeout = defaultTaskTimeout
+	}
+	return timeout*time.Duration(s.RetryManager.getMaxAttempts(name)) + concurrencyStaleMargin
+}
+
+// admitConcurrency 按任务的并发策略登记本次分发，返回 ErrConcurrencyLimited 时应跳过本次触发。
+// replace 策略先取消之前分发的所有执行，本次执行在它们退出后开始
+func (s *Scheduler) admitConcurrency(item *TaskItem, reg JobDefinition) error {
+	if reg.concurrency == "" || reg.concurrency == ConcurrencyAllow {
+		return nil
+	}
+
+	if reg.concurrency == ConcurrencyReplace {
+		req := CancelRequest{JobName: item.Name, At: item.EnqueuedAt.Add(-time.Nanosecond)}
+		s.applyCancel(req)
+		if err := s.publishCancel(req); err != nil {
+			s.logger.Warn("⚠️ [Concurrency] Publish replace cancel failed", "name", item.Name, err)
+		}
+	}
+
+	ok, err := s.concurrencyStore.Acquire(context.Background(), item.Name, item.ID, item.EnqueuedAt, concurrencyLimit(reg), s.concurrencyTTL(item.Name))
+	if err != nil {
+		// 登记失败时不阻塞任务，退化为 allow
+		s.logger.Warn("⚠️ [Concurrency] Acquire failed, run without concurrency control", "name", item.Name, "exec_id", item.ID, err)
+		return nil
+	}
+	if !ok {
+		return ErrConcurrencyLimited
+	}
+	item.Concurrency = reg.concurrency
+	return nil
+}
+
+// skipByConcurrency 记录被并发策略跳过的触发
+func (s *Scheduler) skipByConcurrency(item TaskItem, policy ConcurrencyPolicy) {
+	s.logger.Info("⏭️ [Dispatcher] Job is still running, skip by concurrency policy", "name", item.Name, "exec_id", item.ID, "policy", string(policy))
+	s.createExecution(item)
+	s.finishExecution(item, ExecutionStatusSkipped, ErrConcurrencyLimited)
+	s.EventManager.Emit(&Event{
+		Type:      EventTypeJobSkipped,
+		TaskName:  item.Name,
+		ExecID:    item.ID,
+		TimeStamp: time.Now(),
+		Error:     ErrConcurrencyLimited,
+		Data:      map[string]any{"reason": "concurrency", "policy": string(policy), "trigger": item.Trigger},
+	})
+}
+
+// concurrencyAhead 返回排在该执行之前、尚未结束的执行数
+func (s *Scheduler) concurrencyAhead(item TaskItem) int {
+	if item.Concurrency == "" || item.Concurrency == ConcurrencyAllow {
+		return 0
+	}
+	ahead, err := s.concurrencyStore.Ahead(context.Background(), item.Name, item.ID, s.concurrencyTTL(item.Name))
+	if err != nil {
+		s.logger.Warn("⚠️ [Concurrency] Check turn failed, run without waiting", "name", item.Name, "exec_id", item.ID, err)
+		return 0
+	}
+	return ahead
+}
+
+// waitConcurrencyTurn 挂起排在其它执行之后的执行，之前的执行结束时唤醒并重新入队，等待期间不占用 worker
+func (s *Scheduler) waitConcurrencyTurn(item TaskItem, ahead int) {
+	s.logger.Debug("⏳ [Schedule] Waiting for previous executions to finish", "name", item.Name, "exec_id", item.ID, "ahead", ahead)
+	s.parkWaiting(concurrencyWaitKey(item.Name), item)
+}
+
+// concurrencyWaitKey 等待同一任务之前的执行结束的挂起键，与分组的挂起键区分
+func concurrencyWaitKey(name string) string {
+	return "job:" + name
+}
+
+// releaseConcurrency 执行结束、被取消或未能入队后移除登记
+func (s *Scheduler) releaseConcurrency(item TaskItem) {
+	if item.Concurrency == "" || item.Concurrency == ConcurrencyAllow {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), concurrencyReleaseWait)
+	defer cancel()
+	if err := s.concurrencyStore.Release(ctx, item.Name, item.ID); err != nil {
+		s.logger.Error("❌ [Concurrency] Release failed", "name", item.Name, "exec_id", item.ID, err)
+		return
+	}
+	// 唤醒同一任务挂起的执行，各自重新检查是否轮到自己
+	s.wakeWaiting(concurrencyWaitKey(item.Name))
+}
@@ -0,0 +1,268 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试 forbid 策略：上一次未结束时跳过本次触发，记录 skipped 执行与跳过事件
+func TestConcurrencyForbid(t *testing.T) {
+	s, store, runs, release := newBlockingScheduler(t)
+	require.NoError(t, s.AddJob("@every 1h", "block", "block", nil, "TEST", &JobOptions{Concurrency: ConcurrencyForbid}))
+
+	skipped := make(chan *Event, 1)
+	s.EventManager.OnFunc(EventTypeJobSkipped, func(e *Event) {
+		skipped <- e
+	})
+
+	first, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, first) == ExecutionStatusRunning }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(runs) == 1 }, time.Second, 5*time.Millisecond)
+
+	second, err := s.ManualRun("block")
+	assert.ErrorIs(t, err, ErrConcurrencyLimited)
+	assert.Equal(t, ExecutionStatusSkipped, lastStatus(store, second))
+	select {
+	case e := <-skipped:
+		assert.Equal(t, second, e.ExecID)
+		assert.Equal(t, "forbid", e.Data["policy"])
+	case <-time.After(time.Second):
+		t.Fatal("未收到跳过事件")
+	}
+
+	// cron 触发同样被跳过
+	s.Dispatch("block")
+	assert.Equal(t, int32(1), atomic.LoadInt32(runs))
+
+	// 上一次结束后可以再次执行
+	release <- struct{}{}
+	require.Eventually(t, func() bool { return lastStatus(store, first) == ExecutionStatusSuccess }, time.Second, 5*time.Millisecond)
+	third, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, third) == ExecutionStatusRunning }, time.Second, 5*time.Millisecond)
+	release <- struct{}{}
+}
+
+// historyRecorder 记录写入历史的事件类型
+type historyRecorder chan EventType
+
+func (h historyRecorder) SaveEvent(event *Event) error {
+	h <- event.Type
+	return nil
+}
+
+// 测试被并发策略跳过的触发同样写入任务历史
+func TestConcurrencyForbidRecordsHistory(t *testing.T) {
+	history := make(historyRecorder, 4)
+	s, store, _, release := newBlockingScheduler(t, WithHistoryStorage(history))
+	require.NoError(t, s.AddJob("@every 1h", "block", "block", nil, "TEST", &JobOptions{Concurrency: ConcurrencyForbid}))
+
+	first, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, first) == ExecutionStatusRunning }, time.Second, 5*time.Millisecond)
+	_, err = s.ManualRun("block")
+	require.ErrorIs(t, err, ErrConcurrencyLimited)
+
+	select {
+	case typ := <-history:
+		assert.Equal(t, EventTypeJobSkipped, typ)
+	case <-time.After(time.Second):
+		t.Fatal("跳过事件未写入历史")
+	}
+	release <- struct{}{}
+}
+
+// 测试 queue 策略：排队等待上一次结束后依次执行，超过排队上限的触发被跳过
+func TestConcurrencyQueue(t *testing.T) {
+	s, store, runs, release := newBlockingScheduler(t, WithWorkerNum(2))
+	require.NoError(t, s.AddJob("@every 1h", "block", "block", nil, "TEST", &JobOptions{Concurrency: ConcurrencyQueue, MaxPending: 1}))
+	require.NoError(t, s.AddJob("@every 1h", "block", "other", nil, "TEST", nil))
+
+	first, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, first) == ExecutionStatusRunning }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(runs) == 1 }, time.Second, 5*time.Millisecond)
+
+	second, err := s.ManualRun("block")
+	require.NoError(t, err)
+	_, err = s.ManualRun("block")
+	assert.ErrorIs(t, err, ErrConcurrencyLimited, "排队已满")
+
+	// 第二次执行挂起等待，不会与第一次重叠，也不占用 worker
+	require.Eventually(t, func() bool { return s.waiters.len(concurrencyWaitKey("block")) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(runs))
+	assert.Equal(t, ExecutionStatusPending, lastStatus(store, second))
+	other, err := s.ManualRun("other")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, other) == ExecutionStatusRunning }, time.Second, 5*time.Millisecond)
+
+	release <- struct{}{}
+	release <- struct{}{}
+	// 上一次结束后立即唤醒，不必等到兜底重试
+	require.Eventually(t, func() bool { return lastStatus(store, second) == ExecutionStatusRunning }, waitRetryInterval/2, 5*time.Millisecond)
+	assert.Equal(t, ExecutionStatusSuccess, lastStatus(store, first))
+	release <- struct{}{}
+	require.Eventually(t, func() bool { return lastStatus(store, second) == ExecutionStatusSuccess }, time.Second, 5*time.Millisecond)
+}
+
+// 测试 replace 策略：取消正在执行的实例后执行本次触发
+func TestConcurrencyReplace(t *testing.T) {
+	s, store, runs, release := newBlockingScheduler(t, WithWorkerNum(2))
+	require.NoError(t, s.AddJob("@every 1h", "block", "block", nil, "TEST", &JobOptions{
+		Concurrency: ConcurrencyReplace,
+		Retry:       NoRetryPolicy(),
+	}))
+
+	first, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, first) == ExecutionStatusRunning }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(runs) == 1 }, time.Second, 5*time.Millisecond)
+
+	second, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, second) == ExecutionStatusRunning }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, ExecutionStatusCancelled, lastStatus(store, first))
+	assert.Equal(t, []string{second}, s.RunningExecutions("block"))
+	release <- struct{}{}
+}
+
+// 测试分组并发上限：组内任务同时执行的数量不超过上限，等待的执行不占用 worker
+func TestGroupConcurrency(t *testing.T) {
+	s, store, runs, release := newBlockingScheduler(t, WithWorkerNum(2))
+	s.SetGroupConcurrency("reporting", 1)
+	assert.Equal(t, 1, s.GroupConcurrency("reporting"))
+	for _, name := range []string{"daily", "weekly"} {
+		require.NoError(t, s.AddJob("@every 1h", "block", name, nil, "TEST", &JobOptions{Groups: []string{"reporting"}}))
+	}
+
+	first, err := s.ManualRun("daily")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, first) == ExecutionStatusRunning }, time.Second, 5*time.Millisecond)
+	second, err := s.ManualRun("weekly")
+	require.NoError(t, err)
+
+	// 组外任务仍可以使用空闲的 worker
+	other, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, other) == ExecutionStatusRunning }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, ExecutionStatusPending, lastStatus(store, second))
+	require.Eventually(t, func() bool { return atomic.LoadInt32(runs) == 2 }, time.Second, 5*time.Millisecond)
+
+	release <- struct{}{}
+	release <- struct{}{}
+	// 名额释放后立即唤醒挂起的执行，不必等到兜底重试
+	require.Eventually(t, func() bool { return lastStatus(store, second) == ExecutionStatusRunning }, waitRetryInterval/2, 5*time.Millisecond)
+	release <- struct{}{}
+	require.Eventually(t, func() bool { return lastStatus(store, second) == ExecutionStatusSuccess }, time.Second, 5*time.Millisecond)
+
+	// 取消上限后不再限制
+	s.SetGroupConcurrency("reporting", 0)
+	assert.Zero(t, s.GroupConcurrency("reporting"))
+}
+
+// 测试 Redis 并发登记：上限、排队顺序、释放与过期清理
+func TestRedisConcurrencyStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewRedisConcurrencyStore(newTestRedis(t))
+	now := time.Now()
+
+	ok, err := store.Acquire(ctx, "job", "a", now, 2, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.Acquire(ctx, "job", "b", now.Add(time.Millisecond), 2, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.Acquire(ctx, "job", "c", now.Add(2*time.Millisecond), 2, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, ok, "达到上限后拒绝登记")
+
+	ahead, err := store.Ahead(ctx, "job", "b", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ahead)
+	ahead, err = store.Ahead(ctx, "job", "unknown", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 0, ahead, "未登记的执行不需要等待")
+
+	require.NoError(t, store.Release(ctx, "job", "a"))
+	ahead, err = store.Ahead(ctx, "job", "b", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 0, ahead)
+
+	// 节点异常退出遗留的登记过期后被清理，不再占用名额
+	ok, err = store.Acquire(ctx, "stale", "old", now.Add(-2*time.Hour), 1, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.Acquire(ctx, "stale", "new", now, 1, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// 测试 DelayIfStillRunning 等待上一次执行结束而不是直接返回错误
+func TestDelayIfStillRunning(t *testing.T) {
+	release := make(chan struct{})
+	var running, overlapped int32
+	job := Chain{DelayIfStillRunning(NewDefaultLogger())}.Apply(func(ctx context.Context) error {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.StoreInt32(&overlapped, 1)
+		}
+		defer atomic.AddInt32(&running, -1)
+		<-release
+		return nil
+	})
+
+	errs := make(chan error, 2)
+	go func() { errs <- job(context.Background()) }()
+	go func() { errs <- job(context.Background()) }()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+	assert.Zero(t, atomic.LoadInt32(&overlapped))
+
+	// 等待期间 ctx 结束时放弃执行
+	hold := make(chan struct{})
+	blocked := Chain{DelayIfStillRunning(NewDefaultLogger())}.Apply(func(ctx context.Context) error {
+		<-hold
+		return nil
+	})
+	go func() { _ = blocked(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(blocked(ctx), context.DeadlineExceeded))
+	close(hold)
+}
+
+// 测试内存队列中挂起的执行保留 pending 记录，节点宕机后可以由 Leader 接管
+func TestParkedItemKeepsPendingRecord(t *testing.T) {
+	stateStore := NewRedisStateStore(newTestRedis(t))
+	s, store, _, release := newBlockingScheduler(t, WithWorkerNum(2), WithStateStore(stateStore))
+	require.NoError(t, s.AddJob("@every 1h", "block", "block", nil, "TEST", &JobOptions{Concurrency: ConcurrencyQueue, MaxPending: 1}))
+
+	first, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, first) == ExecutionStatusRunning }, time.Second, 5*time.Millisecond)
+	second, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return s.waiters.len(concurrencyWaitKey("block")) == 1 }, time.Second, 5*time.Millisecond)
+
+	pending, err := stateStore.LoadPendingItems()
+	require.NoError(t, err)
+	require.Len(t, pending, 1, "执行中的任务不再保留 pending 记录")
+	assert.Equal(t, second, pending[0].ID)
+	assert.Equal(t, s.workerID, pending[0].Owner)
+
+	release <- struct{}{}
+	require.Eventually(t, func() bool { return lastStatus(store, second) == ExecutionStatusRunning }, time.Second, 5*time.Millisecond)
+	pending, err = stateStore.LoadPendingItems()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	release <- struct{}{}
+}
@@ -3,6 +3,7 @@ package engine
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -10,6 +11,7 @@ import (
 var (
 	ErrCircularDependency = errors.New("circular dependency detected")
 	ErrDependencyNotFound = errors.New("dependency not found")
+	ErrDependencyTimeout  = errors.New("timed out waiting for upstream dependencies")
 )
 
 // DependencyType 依赖类型
@@ -21,12 +23,40 @@ const (
 	DependencyTypeAllComplete                       // 所有依赖完成后执行（无论成功失败）
 )
 
+// ParseDependencyType 解析配置中的依赖类型：all_success(默认), any_success, all_complete
+func ParseDependencyType(s string) (DependencyType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "all_success":
+		return DependencyTypeAllSuccess, nil
+	case "any_success":
+		return DependencyTypeAnySuccess, nil
+	case "all_complete":
+		return DependencyTypeAllComplete, nil
+	default:
+		return 0, fmt.Errorf("unknown dependency type: %s", s)
+	}
+}
+
+// String 返回依赖类型在配置中的名称
+func (dt DependencyType) String() string {
+	switch dt {
+	case DependencyTypeAllSuccess:
+		return "all_success"
+	case DependencyTypeAnySuccess:
+		return "any_success"
+	case DependencyTypeAllComplete:
+		return "all_complete"
+	default:
+		return "unknown"
+	}
+}
+
 // DependencyRule 任务依赖规则
 type DependencyRule struct {
 	TaskName       string         // 当前任务名称
 	DependsOn      []string       // 依赖的任务名称列表
 	DependencyType DependencyType // 依赖类型
-	Timeout        time.Duration  // 等待依赖完成的超时时间
+	Timeout        time.Duration  // 等待依赖完成的超时时间，超时后放弃本次触发，<=0 时一直等待
 	CheckInterval  time.Duration  // 检查依赖状态的间隔
 }
 
@@ -35,6 +65,8 @@ type DependencyManager struct {
 	dependencies map[string]*DependencyRule // 任务名 -> 依赖规则
 	taskStatus   map[string]TaskStatus      // 任务名 -> 任务状态
 	graph        map[string][]string        // 任务依赖图（用于检测循环依赖）
+	waitingSince map[string]time.Time       // 任务名 -> 开始等待上游的时间
+	onChange     func(string, TaskStatus)   // 任务状态变化回调（用于持久化）
 	logger       Logger
 	mu           sync.RWMutex
 }
@@ -65,11 +97,12 @@ func NewDependencyManager(logger Logger) *DependencyManager {
 		dependencies: make(map[string]*DependencyRule),
 		taskStatus:   make(map[string]TaskStatus),
 		graph:        make(map[string][]string),
+		waitingSince: make(map[string]time.Time),
 		logger:       logger,
 	}
 }
 
-// AddDependency 添加依赖关系
+// AddDependency 添加依赖关系，已有规则时整体替换并保留任务的完成状态
 func (dm *DependencyManager) AddDependency(rule *DependencyRule) error {
 	dm.mu.Lock()
 	defer dm.mu.Unlock()
@@ -86,9 +119,11 @@ func (dm *DependencyManager) AddDependency(rule *DependencyRule) error {
 	dm.graph[rule.TaskName] = rule.DependsOn
 
 	// 初始化任务状态
-	dm.taskStatus[rule.TaskName] = TaskStatus{
-		Completed: false,
-		Success:   false,
+	if _, ok := dm.taskStatus[rule.TaskName]; !ok {
+		dm.taskStatus[rule.TaskName] = TaskStatus{
+			Completed: false,
+			Success:   false,
+		}
 	}
 
 	dm.logger.Info("✅ [Dependency] Added dependency",
@@ -100,14 +135,52 @@ func (dm *DependencyManager) AddDependency(rule *DependencyRule) error {
 	return nil
 }
 
+// RemoveDependency 移除任务的依赖规则与完成状态
+func (dm *DependencyManager) RemoveDependency(taskName string) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	delete(dm.dependencies, taskName)
+	delete(dm.graph, taskName)
+	delete(dm.taskStatus, taskName)
+	delete(dm.waitingSince, taskName)
+}
+
+// ClearDependency 移除任务的依赖规则，保留任务自身的完成状态供下游判断
+func (dm *DependencyManager) ClearDependency(taskName string) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	delete(dm.dependencies, taskName)
+	delete(dm.graph, taskName)
+	delete(dm.waitingSince, taskName)
+}
+
+// Graph 返回当前依赖图的副本：任务名 -> 上游任务名
+func (dm *DependencyManager) Graph() map[string][]string {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	graph := make(map[string][]string, len(dm.graph))
+	for k, v := range dm.graph {
+		graph[k] = append([]string(nil), v...)
+	}
+	return graph
+}
+
 // checkCircularDependency 检查循环依赖
 func (dm *DependencyManager) checkCircularDependency(task string, dependencies []string) error {
+	return CheckCircularDependency(dm.graph, task, dependencies)
+}
+
+// CheckCircularDependency 检查在依赖图 graph 中将 task 的上游设置为 dependencies 后是否形成环，形成环时返回 ErrCircularDependency
+func CheckCircularDependency(graph map[string][]string, task string, dependencies []string) error {
 	visited := make(map[string]bool)
 	recursionStack := make(map[string]bool)
 
 	// 构建临时图用于检测
 	tempGraph := make(map[string][]string)
-	for k, v := range dm.graph {
+	for k, v := range graph {
 		tempGraph[k] = make([]string, len(v))
 		copy(tempGraph[k], v)
 	}
@@ -116,7 +189,7 @@ func (dm *DependencyManager) checkCircularDependency(task string, dependencies [
 
 	// 对每个依赖任务进行DFS检测
 	for _, dep := range dependencies {
-		if dm.hasCycle(tempGraph, dep, visited, recursionStack) {
+		if hasCycle(tempGraph, dep, visited, recursionStack) {
 			return ErrCircularDependency
 		}
 	}
@@ -125,13 +198,13 @@ func (dm *DependencyManager) checkCircularDependency(task string, dependencies [
 }
 
 // hasCycle 检测图中是否存在环
-func (dm *DependencyManager) hasCycle(graph map[string][]string, node string, visited, recursionStack map[string]bool) bool {
+func hasCycle(graph map[string][]string, node string, visited, recursionStack map[string]bool) bool {
 	visited[node] = true
 	recursionStack[node] = true
 
 	for _, neighbor := range graph[node] {
 		if !visited[neighbor] {
-			if dm.hasCycle(graph, neighbor, visited, recursionStack) {
+			if hasCycle(graph, neighbor, visited, recursionStack) {
 				return true
 			}
 		} else if recursionStack[neighbor] {
@@ -201,21 +274,43 @@ func (dm *DependencyManager) checkAllComplete(dependencies []string) bool {
 
 // UpdateTaskStatus 更新任务状态
 func (dm *DependencyManager) UpdateTaskStatus(taskName string, success bool, err error) {
-	dm.mu.Lock()
-	defer dm.mu.Unlock()
-
-	dm.taskStatus[taskName] = TaskStatus{
+	status := TaskStatus{
 		Completed:  true,
 		Success:    success,
 		FinishedAt: time.Now(),
 		Error:      err,
 	}
 
+	dm.mu.Lock()
+	dm.taskStatus[taskName] = status
+	onChange := dm.onChange
+	dm.mu.Unlock()
+
 	dm.logger.Info("📊 [Dependency] Updated task status",
 		"task", taskName,
 		"success", success,
-		"finished_at", time.Now(),
+		"finished_at", status.FinishedAt,
 	)
+
+	if onChange != nil {
+		onChange(taskName, status)
+	}
+}
+
+// OnStatusChange 注册任务状态变化回调
+func (dm *DependencyManager) OnStatusChange(fn func(taskName string, status TaskStatus)) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.onChange = fn
+}
+
+// RestoreTaskStatus 恢复持久化的任务状态（重启或换主后调用）
+func (dm *DependencyManager) RestoreTaskStatus(statuses map[string]TaskStatus) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	for name, status := range statuses {
+		dm.taskStatus[name] = status
+	}
 }
 
 // GetDependencyRule 获取任务的依赖规则
@@ -277,24 +372,49 @@ func (dm *DependencyManager) GetDependentTasks(taskName string) []string {
 // ClearTaskStatus 清除任务状态（用于重试场景）
 func (dm *DependencyManager) ClearTaskStatus(taskName string) {
 	dm.mu.Lock()
-	defer dm.mu.Unlock()
+	dm.taskStatus[taskName] = TaskStatus{}
+	onChange := dm.onChange
+	dm.mu.Unlock()
 
-	dm.taskStatus[taskName] = TaskStatus{
-		Completed: false,
-		Success:   false,
+	if onChange != nil {
+		onChange(taskName, TaskStatus{})
 	}
 }
 
 // dependencyTypeToString 将依赖类型转换为字符串
 func (dm *DependencyManager) dependencyTypeToString(dt DependencyType) string {
-	switch dt {
-	case DependencyTypeAllSuccess:
-		return "all_success"
-	case DependencyTypeAnySuccess:
-		return "any_success"
-	case DependencyTypeAllComplete:
-		return "all_complete"
-	default:
-		return "unknown"
+	return dt.String()
+}
+
+// startWaiting 记录任务开始等待上游，返回规则的等待超时；已在等待时保持原有的开始时间并返回 0
+func (dm *DependencyManager) startWaiting(taskName string) time.Duration {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	if _, ok := dm.waitingSince[taskName]; ok {
+		return 0
+	}
+	dm.waitingSince[taskName] = time.Now()
+	if rule, ok := dm.dependencies[taskName]; ok {
+		return rule.Timeout
+	}
+	return 0
+}
+
+// stopWaiting 任务不再等待上游（已分发或放弃等待）
+func (dm *DependencyManager) stopWaiting(taskName string) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	delete(dm.waitingSince, taskName)
+}
+
+// waitExpired 任务等待上游的时间是否超过了规则的 Timeout
+func (dm *DependencyManager) waitExpired(taskName string) bool {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+	rule, ok := dm.dependencies[taskName]
+	if !ok || rule.Timeout <= 0 {
+		return false
 	}
+	since, waiting := dm.waitingSince[taskName]
+	return waiting && time.Since(since) >= rule.Timeout
 }
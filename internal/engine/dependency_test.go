@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDependencyType(t *testing.T) {
+	for s, want := range map[string]DependencyType{
+		"":             DependencyTypeAllSuccess,
+		"all_success":  DependencyTypeAllSuccess,
+		"ANY_SUCCESS":  DependencyTypeAnySuccess,
+		"all_complete": DependencyTypeAllComplete,
+	} {
+		got, err := ParseDependencyType(s)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, s)
+	}
+	_, err := ParseDependencyType("sometimes")
+	assert.Error(t, err)
+	assert.Equal(t, "any_success", DependencyTypeAnySuccess.String())
+}
+
+// 测试依赖规则的注册、循环检测、等待超时以及上游完成后唤醒下游
+func TestAddJobWithDependency(t *testing.T) {
+	s, counters := newCountingScheduler(t, nil)
+
+	require.NoError(t, s.AddJob("@every 1h", "run_once", "up", nil, "TEST", nil))
+	rule := &DependencyRule{DependsOn: []string{"up"}, Timeout: 100 * time.Millisecond}
+	require.NoError(t, s.AddJobWithDependency("@every 1h", "skip", "down", nil, "TEST", nil, rule))
+	assert.Equal(t, map[string][]string{"down": {"up"}}, s.DependencyManager.Graph())
+
+	// 形成环的规则被拒绝，原有规则不变
+	err := s.AddJobWithDependency("@every 1h", "run_once", "up", nil, "TEST", nil, &DependencyRule{DependsOn: []string{"down"}})
+	assert.ErrorIs(t, err, ErrCircularDependency)
+	assert.ErrorIs(t, CheckCircularDependency(s.DependencyManager.Graph(), "up", []string{"down"}), ErrCircularDependency)
+
+	// 上游未完成时挂起，超时后放弃本次触发
+	s.Dispatch("down")
+	stat, _ := s.Stats.Get("down")
+	assert.Equal(t, Waiting, stat.Status)
+	require.Eventually(t, func() bool {
+		stat, _ := s.Stats.Get("down")
+		return stat.Status == Idle && stat.LastResult == LastResultDependencyWait
+	}, time.Second, 5*time.Millisecond)
+
+	// 上游执行成功后唤醒等待中的下游
+	s.Dispatch("down")
+	s.Dispatch("up")
+	require.Eventually(t, func() bool { return atomic.LoadInt64(counters["skip"]) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(1), atomic.LoadInt64(counters["run_once"]))
+
+	// 重新注册时不声明上游即清除依赖规则
+	require.NoError(t, s.AddJobWithDependency("@every 1h", "skip", "down", nil, "TEST", nil, nil))
+	assert.Empty(t, s.DependencyManager.Graph())
+}
@@ -5,28 +5,93 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	keys "github.com/iceymoss/go-task/pkg/db/key"
+
 	"github.com/go-redis/redis/v8"
 )
 
 const DefaultLeaderKeyTTL = 15
 
+var (
+	ErrNotLeader  = errors.New("instance is not the leader")
+	ErrStaleEpoch = errors.New("stale leader epoch")
+)
+
+// epochHistory 保留接管时间的任期数
+const epochHistory = 16
+
+// acquireScript 抢占锁成功后原子地递增任期，保证每次换主任期单调递增，并记录新任期的接管时间
+// KEYS: 锁, 任期, 接管时间  ARGV: 实例ID, TTL(ms), 当前时间(ms), 保留的任期数
+var acquireScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	local epoch = redis.call('INCR', KEYS[2])
+	redis.call('HSET', KEYS[3], epoch, ARGV[3])
+	redis.call('HDEL', KEYS[3], epoch - tonumber(ARGV[4]))
+	return epoch
+end
+return 0
+`)
+
+// renewScript 仅当锁仍由自己持有时续约
+// KEYS: 锁  ARGV: 实例ID, TTL(ms)
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript 仅当锁仍由自己持有时删除
+// KEYS: 锁  ARGV: 实例ID
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// verifyScript 校验锁仍由自己持有且任期未被更新
+// KEYS: 锁, 任期  ARGV: 实例ID, 任期
+var verifyScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return -1
+end
+if tonumber(redis.call('GET', KEYS[2]) or '0') ~= tonumber(ARGV[2]) then
+	return -2
+end
+return 1
+`)
+
 // LeaderElector 抽象的选主接口
 type LeaderElector interface {
 	// Start 启动选主。在这里接收 Scheduler 传来的回调函数
 	Start(ctx context.Context, onStartedLeading func(), onStoppedLeading func()) error
 	Stop(ctx context.Context) error
 	IsLeader() bool
+
+	// Epoch 返回当前实例持有的 Leader 任期（fencing token），非 Leader 时返回 0
+	Epoch() int64
+	// VerifyEpoch 原子地校验本实例仍是 Leader 且任期未变，分发任务前调用
+	VerifyEpoch(ctx context.Context, epoch int64) error
+	// LatestEpoch 返回集群中最新的 Leader 任期，执行任务前用于识别旧任期分发的任务
+	LatestEpoch(ctx context.Context) (int64, error)
+	// EpochStartedAt 返回任期 epoch 的 Leader 接管的时间，未知时返回零值
+	EpochStartedAt(ctx context.Context, epoch int64) (time.Time, error)
 }
 
 // RedisLeaderElector 基于 Redis 的简单选主实现
-// 使用一个带 TTL 的 key 做 Leader 锁，value 为实例 ID。
+// 使用一个带 TTL 的 key 做 Leader 锁，value 为实例 ID；
+// 每次抢到锁时递增 KeyLeaderVersion 作为任期，续约与释放都通过 Lua 做 compare-and-set。
 type RedisLeaderElector struct {
 	client        *redis.Client
 	key           string
+	versionKey    string
+	epochsKey     string
 	id            string
 	ttl           time.Duration
 	renewInterval time.Duration
@@ -34,6 +99,7 @@ type RedisLeaderElector struct {
 	logger Logger
 
 	isLeader int32
+	epoch    int64
 	mu       sync.RWMutex
 	started  bool
 }
@@ -49,6 +115,8 @@ func NewRedisLeaderElector(client *redis.Client, key string, ttl, renewInterval
 	return &RedisLeaderElector{
 		client:        client,
 		key:           key,
+		versionKey:    keys.KeyLeaderVersion(),
+		epochsKey:     keys.KeyLeaderEpochs(),
 		id:            defaultInstanceID(),
 		ttl:           ttl,
 		renewInterval: renewInterval,
@@ -107,13 +175,14 @@ func (r *RedisLeaderElector) loop(ctx context.Context, onStarted func(), onStopp
 					}
 				}
 			} else {
-				ok, err := r.acquireLock(ctx)
+				epoch, err := r.acquireLock(ctx)
 				if err != nil {
 					continue
 				}
-				if ok {
+				if epoch > 0 {
+					atomic.StoreInt64(&r.epoch, epoch)
 					r.setLeader(true)
-					r.logger.Info("👑 [LeaderElector] became leader", "id", r.id)
+					r.logger.Info("👑 [LeaderElector] became leader", "id", r.id, "epoch", epoch)
 					if onStarted != nil {
 						onStarted() // 抢到锁，触发启动回调
 					}
@@ -123,46 +192,27 @@ func (r *RedisLeaderElector) loop(ctx context.Context, onStarted func(), onStopp
 	}
 }
 
-// acquireLock 使用 SETNX + TTL 抢占锁
-func (r *RedisLeaderElector) acquireLock(ctx context.Context) (bool, error) {
-	ok, err := r.client.SetNX(ctx, r.key, r.id, r.ttl).Result()
-	return ok, err
+// acquireLock 使用 SET NX PX 抢占锁，成功时返回新的任期，未抢到返回 0
+func (r *RedisLeaderElector) acquireLock(ctx context.Context) (int64, error) {
+	return acquireScript.Run(ctx, r.client, []string{r.key, r.versionKey, r.epochsKey}, r.id, r.ttl.Milliseconds(), time.Now().UnixMilli(), epochHistory).Int64()
 }
 
 // renewLock 续约锁（仅在自己仍然是锁持有者时）
 func (r *RedisLeaderElector) renewLock(ctx context.Context) error {
-	val, err := r.client.Get(ctx, r.key).Result()
-	if errors.Is(err, redis.Nil) {
-		// key 不存在，锁已丢失
-		return fmt.Errorf("lock key missing")
-	}
+	ok, err := renewScript.Run(ctx, r.client, []string{r.key}, r.id, r.ttl.Milliseconds()).Int64()
 	if err != nil {
 		return err
 	}
-	if val != r.id {
-		// 已经不是自己持有
-		return fmt.Errorf("lock owned by another instance")
+	if ok == 0 {
+		// 锁已过期或已被其他实例持有
+		return fmt.Errorf("lock lost or owned by another instance")
 	}
-
-	// 续约 TTL
-	_, err = r.client.Expire(ctx, r.key, r.ttl).Result()
-	return err
+	return nil
 }
 
 // releaseLock 释放锁（仅在自己仍是持有者时）
 func (r *RedisLeaderElector) releaseLock(ctx context.Context) error {
-	val, err := r.client.Get(ctx, r.key).Result()
-	if errors.Is(err, redis.Nil) {
-		return nil
-	}
-	if err != nil {
-		return err
-	}
-	if val != r.id {
-		return nil
-	}
-	_, err = r.client.Del(ctx, r.key).Result()
-	return err
+	return releaseScript.Run(ctx, r.client, []string{r.key}, r.id).Err()
 }
 
 // Stop 停止选主（通过取消 ctx 实现）
@@ -176,10 +226,58 @@ func (r *RedisLeaderElector) IsLeader() bool {
 	return atomic.LoadInt32(&r.isLeader) == 1
 }
 
+// Epoch 返回当前持有的任期，非 Leader 时返回 0
+func (r *RedisLeaderElector) Epoch() int64 {
+	if !r.IsLeader() {
+		return 0
+	}
+	return atomic.LoadInt64(&r.epoch)
+}
+
+// VerifyEpoch 校验锁仍由本实例持有且任期未被更新
+func (r *RedisLeaderElector) VerifyEpoch(ctx context.Context, epoch int64) error {
+	if epoch <= 0 {
+		return ErrNotLeader
+	}
+	res, err := verifyScript.Run(ctx, r.client, []string{r.key, r.versionKey}, r.id, epoch).Int64()
+	if err != nil {
+		return err
+	}
+	switch res {
+	case -1:
+		return ErrNotLeader
+	case -2:
+		return ErrStaleEpoch
+	}
+	return nil
+}
+
+// LatestEpoch 返回集群最新的任期
+func (r *RedisLeaderElector) LatestEpoch(ctx context.Context) (int64, error) {
+	epoch, err := r.client.Get(ctx, r.versionKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return epoch, err
+}
+
+// EpochStartedAt 返回任期 epoch 的 Leader 接管的时间
+func (r *RedisLeaderElector) EpochStartedAt(ctx context.Context, epoch int64) (time.Time, error) {
+	ms, err := r.client.HGet(ctx, r.epochsKey, strconv.FormatInt(epoch, 10)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(ms), nil
+}
+
 func (r *RedisLeaderElector) setLeader(v bool) {
 	if v {
 		atomic.StoreInt32(&r.isLeader, 1)
 	} else {
 		atomic.StoreInt32(&r.isLeader, 0)
+		atomic.StoreInt64(&r.epoch, 0)
 	}
 }
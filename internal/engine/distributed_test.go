@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试换主后旧 Leader 无法续约、释放新 Leader 的锁，且旧任期会被拒绝
+func TestRedisLeaderElectorFencing(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	a := NewRedisLeaderElector(client, "leader", 2*time.Second, time.Second, NewDefaultLogger())
+	b := NewRedisLeaderElector(client, "leader", 2*time.Second, time.Second, NewDefaultLogger())
+
+	epochA, err := a.acquireLock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), epochA)
+
+	epochB, err := b.acquireLock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), epochB, "锁被持有时不能抢占")
+	assert.Error(t, b.renewLock(ctx), "非持有者不能续约")
+
+	// A 停顿导致锁过期，B 接管并获得更大的任期
+	mr.FastForward(3 * time.Second)
+	epochB, err = b.acquireLock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), epochB)
+
+	assert.Error(t, a.renewLock(ctx), "旧 Leader 不能续约新 Leader 的锁")
+	require.NoError(t, a.releaseLock(ctx))
+	owner, err := client.Get(ctx, "leader").Result()
+	require.NoError(t, err)
+	assert.Equal(t, b.id, owner, "旧 Leader 不能删除新 Leader 的锁")
+
+	assert.ErrorIs(t, a.VerifyEpoch(ctx, epochA), ErrNotLeader)
+	assert.NoError(t, b.VerifyEpoch(ctx, epochB))
+
+	latest, err := a.LatestEpoch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, epochB, latest)
+}
+
+// 测试正常换主后，旧 Leader 在失去锁之前入队的任务仍会执行，接管之后才入队的任务被拒绝
+func TestCheckItemEpochAfterFailover(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	a := NewRedisLeaderElector(client, "leader", 2*time.Second, time.Second, NewDefaultLogger())
+	b := NewRedisLeaderElector(client, "leader", 2*time.Second, time.Second, NewDefaultLogger())
+	epochA, err := a.acquireLock(ctx)
+	require.NoError(t, err)
+
+	// A 分发的任务还在 Redis 队列中时发生换主
+	queued := TaskItem{ID: "queued", Name: "job", Epoch: epochA, EnqueuedAt: time.Now()}
+	time.Sleep(5 * time.Millisecond)
+	mr.FastForward(3 * time.Second)
+	epochB, err := b.acquireLock(ctx)
+	require.NoError(t, err)
+	require.Greater(t, epochB, epochA)
+	started, err := b.EpochStartedAt(ctx, epochB)
+	require.NoError(t, err)
+	assert.False(t, started.IsZero())
+
+	time.Sleep(5 * time.Millisecond)
+	late := TaskItem{ID: "late", Name: "job", Epoch: epochA, EnqueuedAt: time.Now()}
+	current := TaskItem{ID: "current", Name: "job", Epoch: epochB, EnqueuedAt: time.Now()}
+
+	s := NewScheduler(NewTaskRegistry(), WithLeaderElector(b))
+	t.Cleanup(s.Stop)
+	assert.NoError(t, s.checkItemEpoch(queued), "换主前入队的任务不应被丢弃")
+	assert.ErrorIs(t, s.checkItemEpoch(late), ErrStaleEpoch)
+	assert.NoError(t, s.checkItemEpoch(current))
+
+	// 接管时间未知（如记录已被清理）时保守拒绝
+	require.NoError(t, client.Del(ctx, b.epochsKey).Err())
+	assert.ErrorIs(t, s.checkItemEpoch(queued), ErrStaleEpoch)
+}
@@ -2,9 +2,12 @@ package engine
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/iceymoss/go-task/pkg/message/notify"
 )
 
 // EventType 事件类型
@@ -17,6 +20,7 @@ const (
 	EventTypeJobPanic      EventType = "job_panic"      // 任务panic
 	EventTypeJobSkipped    EventType = "job_skipped"    // 任务被跳过
 	EventTypeJobRetry      EventType = "job_retry"      // 任务重试
+	EventTypeJobCancelled  EventType = "job_cancelled"  // 任务被取消
 	EventTypeDependencyMet EventType = "dependency_met" // 依赖满足
 )
 
@@ -197,6 +201,7 @@ func LoggingEventHandler(log Logger) EventHandlerFunc {
 		fields := []any{
 			"event_type", string(event.Type),
 			"task_name", event.TaskName,
+			"exec_id", event.ExecID,
 			"timestamp", event.TimeStamp,
 		}
 
@@ -217,6 +222,8 @@ func LoggingEventHandler(log Logger) EventHandlerFunc {
 			log.Info("⏭️ [Event] Job skipped", fields...)
 		case EventTypeJobRetry:
 			log.Warn("🔄 [Event] Job retrying", fields...)
+		case EventTypeJobCancelled:
+			log.Warn("🛑 [Event] Job cancelled", fields...)
 		case EventTypeDependencyMet:
 			log.Info("✅ [Event] Dependencies met", fields...)
 		}
@@ -325,22 +332,61 @@ type WebhookConfig struct {
 }
 
 func NewWebhookEventHandler(config WebhookConfig, log Logger) EventHandlerFunc {
+	var notifiers []notify.Notifier
+	if config.Enabled {
+		for _, url := range config.URLs {
+			n, err := notify.NewWebhook(notify.WebhookConfig{URL: url, Secret: config.Secret})
+			if err != nil {
+				log.Error("❌ [Event] Invalid webhook url", err, "url", url)
+				continue
+			}
+			notifiers = append(notifiers, n)
+		}
+	}
+
 	return func(event *Event) {
-		if !config.Enabled || len(config.URLs) == 0 {
+		if len(notifiers) == 0 {
 			return
 		}
 
-		// TODO: 实现Webhook发送逻辑
-		// - 准备payload
-		// - 计算签名（如果有secret）
-		// - 发送HTTP POST请求到所有URL
-		// - 处理重试
+		msg := webhookMessage(event)
+		for i, n := range notifiers {
+			if err := notify.Deliver(context.Background(), n, msg, notify.DefaultRetryPolicy); err != nil {
+				log.Error("❌ [Event] Failed to send webhook",
+					err,
+					"event_type", string(event.Type),
+					"task_name", event.TaskName,
+					"url", config.URLs[i],
+				)
+			}
+		}
+	}
+}
 
-		log.Debug("🌐 [Event] Webhook would be sent",
-			"event_type", string(event.Type),
-			"task_name", event.TaskName,
-			"url_count", len(config.URLs),
-		)
+// webhookMessage 将事件转换为 Webhook 消息，事件详情放在 fields 中
+func webhookMessage(event *Event) *notify.Message {
+	fields := map[string]any{
+		"event_type": string(event.Type),
+		"task_name":  event.TaskName,
+		"exec_id":    event.ExecID,
+		"timestamp":  event.TimeStamp,
+	}
+	for k, v := range event.Data {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
+
+	level, text := "info", ""
+	if event.Error != nil {
+		level, text = "error", event.Error.Error()
+		fields["error"] = text
+	}
+	return &notify.Message{
+		Title:  fmt.Sprintf("[%s] %s", event.Type, event.TaskName),
+		Text:   text,
+		Level:  level,
+		Fields: fields,
 	}
 }
 
@@ -357,7 +403,7 @@ func DependencyMetEventHandler(scheduler *Scheduler, log Logger) EventHandlerFun
 		if stat.Status == Waiting || stat.Status == Idle {
 			log.Debugf("🔔 [EventPush] Upstream task %s finished! Pushing downstream task to queue: %s", depTaskName, depTaskName)
 			// 直接推给 Dispatcher 唤醒执行
-			scheduler.Dispatch(depTaskName)
+			scheduler.dispatch(depTaskName, 0, TriggerDependency)
 		}
 	}
 }
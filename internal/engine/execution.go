@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExecutionStatus 单次执行的状态
+type ExecutionStatus string
+
+const (
+	ExecutionStatusPending   ExecutionStatus = "pending"
+	ExecutionStatusRunning   ExecutionStatus = "running"
+	ExecutionStatusSuccess   ExecutionStatus = "success"
+	ExecutionStatusFailed    ExecutionStatus = "failed"
+	ExecutionStatusTimeout   ExecutionStatus = "timeout"
+	ExecutionStatusCancelled ExecutionStatus = "cancelled"
+	ExecutionStatusSkipped   ExecutionStatus = "skipped" // 被并发策略跳过，未执行
+)
+
+// Finished 是否为结束状态
+func (s ExecutionStatus) Finished() bool {
+	return s != ExecutionStatusPending && s != ExecutionStatusRunning
+}
+
+// 触发来源
+const (
+	TriggerCron       = "cron"       // cron 定时触发
+	TriggerManual     = "manual"     // 手动触发
+	TriggerDependency = "dependency" // 上游依赖满足后触发
+	TriggerMisfire    = "misfire"    // 补跑错过的周期
+	TriggerAPI        = "api"        // 通过 Dispatch 等接口触发
+)
+
+// Execution 一次任务执行的记录，从分发开始贯穿队列、执行直至结束
+type Execution struct {
+	ExecID        string
+	JobName       string
+	Status        ExecutionStatus
+	TriggerSource string
+	WorkerID      string
+	ScheduledAt   time.Time
+	StartedAt     *time.Time
+	FinishedAt    *time.Time
+	DurationMs    *int64
+	RetryCount    int
+	MaxRetries    int
+	Result        map[string]any
+	ErrorMessage  string
+}
+
+// ExecutionStore 执行记录的持久化接口
+type ExecutionStore interface {
+	// CreateExecution 分发时写入 pending 状态的执行记录
+	CreateExecution(exec *Execution) error
+	// UpdateExecution 按 ExecID 更新执行记录
+	UpdateExecution(exec *Execution) error
+}
+
+// IDGenerator 执行ID生成器
+type IDGenerator interface {
+	NextID() string
+}
+
+// IDGeneratorFunc 函数类型的执行ID生成器
+type IDGeneratorFunc func() string
+
+func (f IDGeneratorFunc) NextID() string {
+	return f()
+}
+
+// defaultIDGenerator 默认使用 UUID 作为执行ID
+var defaultIDGenerator = IDGeneratorFunc(func() string {
+	return uuid.New().String()
+})
+
+// execKey 执行信息在 context 中的 key
+type execKey struct{}
+
+// execInfo 随 context 传递的执行信息，重试装饰器据此累计重试次数
+type execInfo struct {
+	id      string
+	retries int32
+}
+
+// withExecInfo 返回携带执行信息的 context
+func withExecInfo(ctx context.Context, id string) (context.Context, *execInfo) {
+	info := &execInfo{id: id}
+	return context.WithValue(ctx, execKey{}, info), info
+}
+
+func execInfoFromContext(ctx context.Context) *execInfo {
+	if ctx == nil {
+		return nil
+	}
+	info, _ := ctx.Value(execKey{}).(*execInfo)
+	return info
+}
+
+// ExecIDFromContext 获取当前执行的 ID，不在调度器执行上下文中时返回空字符串
+func ExecIDFromContext(ctx context.Context) string {
+	if info := execInfoFromContext(ctx); info != nil {
+		return info.id
+	}
+	return ""
+}
+
+// recordRetry 累计当前执行的重试次数
+func recordRetry(ctx context.Context) {
+	if info := execInfoFromContext(ctx); info != nil {
+		atomic.AddInt32(&info.retries, 1)
+	}
+}
+
+// executionStatusOf 根据执行结果推导最终状态
+func executionStatusOf(err error) ExecutionStatus {
+	switch {
+	case err == nil:
+		return ExecutionStatusSuccess
+	case errors.Is(err, ErrExecutionCancelled):
+		return ExecutionStatusCancelled
+	case errors.Is(err, ErrJobTimeout), errors.Is(err, context.DeadlineExceeded):
+		return ExecutionStatusTimeout
+	case errors.Is(err, context.Canceled):
+		return ExecutionStatusCancelled
+	default:
+		return ExecutionStatusFailed
+	}
+}
+
+// createExecution 写入 pending 状态的执行记录
+func (s *Scheduler) createExecution(item TaskItem) {
+	if s.executionStore == nil {
+		return
+	}
+	exec := &Execution{
+		ExecID:        item.ID,
+		JobName:       item.Name,
+		Status:        ExecutionStatusPending,
+		TriggerSource: item.Trigger,
+		ScheduledAt:   item.EnqueuedAt,
+		MaxRetries:    s.RetryManager.getMaxAttempts(item.Name) - 1,
+	}
+	if err := s.executionStore.CreateExecution(exec); err != nil {
+		s.logger.Error("❌ [Execution] Create execution failed", "name", item.Name, "exec_id", item.ID, err)
+	}
+}
+
+// updateExecution 更新执行记录
+func (s *Scheduler) updateExecution(exec *Execution) {
+	if s.executionStore == nil {
+		return
+	}
+	if err := s.executionStore.UpdateExecution(exec); err != nil {
+		s.logger.Error("❌ [Execution] Update execution failed", "name", exec.JobName, "exec_id", exec.ExecID, err)
+	}
+}
@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
+	"github.com/iceymoss/go-task/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryExecutionStore 记录每个执行ID经历的状态
+type memoryExecutionStore struct {
+	mu       sync.Mutex
+	statuses map[string][]ExecutionStatus
+	last     map[string]Execution
+}
+
+func newMemoryExecutionStore() *memoryExecutionStore {
+	return &memoryExecutionStore{
+		statuses: make(map[string][]ExecutionStatus),
+		last:     make(map[string]Execution),
+	}
+}
+
+func (m *memoryExecutionStore) CreateExecution(exec *Execution) error {
+	return m.UpdateExecution(exec)
+}
+
+func (m *memoryExecutionStore) UpdateExecution(exec *Execution) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statuses[exec.ExecID] = append(m.statuses[exec.ExecID], exec.Status)
+	m.last[exec.ExecID] = *exec
+	return nil
+}
+
+func (m *memoryExecutionStore) get(id string) ([]ExecutionStatus, Execution) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ExecutionStatus(nil), m.statuses[id]...), m.last[id]
+}
+
+// flakyTask 前 failures 次执行失败，并发布执行ID作为输出
+type flakyTask struct {
+	calls    *int32
+	failures int32
+}
+
+func (t *flakyTask) Run(ctx context.Context, params map[string]any) error {
+	core.SetOutput(ctx, "exec_id", ExecIDFromContext(ctx))
+	if atomic.AddInt32(t.calls, 1) <= t.failures {
+		return errors.New("boom")
+	}
+	return nil
+}
+func (t *flakyTask) Identifier() string               { return "test:flaky" }
+func (t *flakyTask) GetDefaultCron() string           { return "" }
+func (t *flakyTask) GetDefaultParams() map[string]any { return nil }
+func (t *flakyTask) GetTaskType() constants.TaskType  { return constants.TaskTypeAPI }
+
+// 测试手动触发分配执行ID，执行记录依次经历 pending、running、success 并记录重试次数，事件携带同一个ID
+func TestSchedulerExecutionLifecycle(t *testing.T) {
+	var seq int64
+	gen := IDGeneratorFunc(func() string {
+		return fmt.Sprintf("exec-%d", atomic.AddInt64(&seq, 1))
+	})
+	store := newMemoryExecutionStore()
+
+	var calls int32
+	registry := NewTaskRegistry()
+	registry.Register("flaky", func() core.Task { return &flakyTask{calls: &calls, failures: 1} })
+
+	s := NewScheduler(registry, WithExecutionStore(store), WithIDGenerator(gen), WithWorkerNum(1))
+	t.Cleanup(s.Stop)
+	require.NoError(t, s.AddJob("@every 1h", "flaky", "flaky", nil, "TEST", nil))
+	s.SetRetryPolicy("flaky", FixedDelayPolicy(2, time.Millisecond))
+
+	events := make(chan *Event, 1)
+	s.EventManager.OnFunc(EventTypeAfterJob, func(event *Event) { events <- event })
+
+	execID, err := s.ManualRun("flaky")
+	require.NoError(t, err)
+	assert.Equal(t, "exec-1", execID)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, execID, event.ExecID)
+	case <-time.After(3 * time.Second):
+		t.Fatal("任务未在预期时间内完成")
+	}
+
+	statuses, exec := store.get(execID)
+	assert.Equal(t, []ExecutionStatus{ExecutionStatusPending, ExecutionStatusRunning, ExecutionStatusSuccess}, statuses)
+	assert.Equal(t, TriggerManual, exec.TriggerSource)
+	assert.Equal(t, 1, exec.RetryCount)
+	assert.Equal(t, s.workerID, exec.WorkerID)
+	assert.Equal(t, execID, exec.Result["exec_id"], "任务内部可以通过 context 读取执行ID")
+
+	stat, _ := s.Stats.Get("flaky")
+	assert.Equal(t, execID, stat.LastExecID)
+
+	_, err = s.ManualRun("missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+// 测试执行结果到执行状态的映射
+func TestExecutionStatusOf(t *testing.T) {
+	assert.Equal(t, ExecutionStatusSuccess, executionStatusOf(nil))
+	assert.Equal(t, ExecutionStatusTimeout, executionStatusOf(fmt.Errorf("%w after 1s", ErrJobTimeout)))
+	assert.Equal(t, ExecutionStatusCancelled, executionStatusOf(context.Canceled))
+	assert.Equal(t, ExecutionStatusFailed, executionStatusOf(errors.New("boom")))
+}
+
+// schemaTask 声明了 ParamSchema 的任务
+type schemaTask struct {
+	flakyTask
+}
+
+func (t *schemaTask) ValidateParams(params map[string]any) error {
+	return core.ParamSchema{Type: "object", Properties: map[string]core.ParamSchema{
+		"url": {Type: "string", Required: true},
+	}}.Validate(params)
+}
+
+// 测试执行前按 ParamSchema 校验参数：不合法时不执行、不重试，执行记录为失败
+func TestSchedulerValidatesParamsBeforeRun(t *testing.T) {
+	store := newMemoryExecutionStore()
+	var calls int32
+	registry := NewTaskRegistry()
+	registry.Register("schema", func() core.Task { return &schemaTask{flakyTask{calls: &calls}} })
+
+	s := NewScheduler(registry, WithExecutionStore(store), WithWorkerNum(1))
+	t.Cleanup(s.Stop)
+	require.NoError(t, s.AddJob("@every 1h", "schema", "schema", map[string]any{"method": "GET"}, "TEST", nil))
+	s.SetRetryPolicy("schema", FixedDelayPolicy(2, time.Millisecond))
+
+	events := make(chan *Event, 1)
+	s.EventManager.OnFunc(EventTypeJobError, func(event *Event) { events <- event })
+
+	execID, err := s.ManualRun("schema")
+	require.NoError(t, err)
+
+	select {
+	case event := <-events:
+		assert.EqualError(t, event.Error, "invalid params: url: required")
+	case <-time.After(3 * time.Second):
+		t.Fatal("任务未在预期时间内完成")
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	_, exec := store.get(execID)
+	assert.Equal(t, ExecutionStatusFailed, exec.Status)
+	assert.Equal(t, 0, exec.RetryCount)
+}
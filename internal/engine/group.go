@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"time"
+)
+
+// groupConcurrencyKey 分组的活跃执行在 ConcurrencyStore 中登记的名称
+func groupConcurrencyKey(group string) string {
+	return "group:" + group
+}
+
+// SetGroupConcurrency 设置分组内同时执行的任务数上限，limit<=0 时取消限制。
+// 任务通过 JobOptions.Groups 声明所属分组，各级分组的上限同时生效
+func (s *Scheduler) SetGroupConcurrency(group string, limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if limit <= 0 {
+		delete(s.groupLimits, group)
+		return
+	}
+	s.groupLimits[group] = limit
+}
+
+// GroupConcurrency 返回分组的并发上限，0 表示不限制
+func (s *Scheduler) GroupConcurrency(group string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.groupLimits[group]
+}
+
+// admitGroups 为本次执行登记所属分组的并发名额，返回已登记的分组；
+// 任一分组已满时撤销本次已登记的名额，返回已满的分组与 false
+func (s *Scheduler) admitGroups(item TaskItem) ([]string, string, bool) {
+	s.mu.RLock()
+	var limited []string
+	limits := make(map[string]int)
+	for _, group := range s.jobDefinition[item.Name].groups {
+		if limit := s.groupLimits[group]; limit > 0 {
+			limited = append(limited, group)
+			limits[group] = limit
+		}
+	}
+	s.mu.RUnlock()
+	if len(limited) == 0 {
+		return nil, "", true
+	}
+
+	ttl := s.concurrencyTTL(item.Name)
+	acquired := make([]string, 0, len(limited))
+	for _, group := range limited {
+		ok, err := s.concurrencyStore.Acquire(context.Background(), groupConcurrencyKey(group), item.ID, time.Now(), limits[group], ttl)
+		if err != nil {
+			// 登记失败时不阻塞任务，忽略该分组的上限
+			s.logger.Warn("⚠️ [Concurrency] Acquire group slot failed, run without group limit", "name", item.Name, "group", group, err)
+			continue
+		}
+		if !ok {
+			s.releaseGroups(item, acquired)
+			return nil, group, false
+		}
+		acquired = append(acquired, group)
+	}
+	return acquired, "", true
+}
+
+// releaseGroups 执行结束后释放分组名额，并唤醒等待该分组的下一个执行
+func (s *Scheduler) releaseGroups(item TaskItem, groups []string) {
+	if len(groups) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), concurrencyReleaseWait)
+	defer cancel()
+	for _, group := range groups {
+		if err := s.concurrencyStore.Release(ctx, groupConcurrencyKey(group), item.ID); err != nil {
+			s.logger.Error("❌ [Concurrency] Release group slot failed", "name", item.Name, "group", group, err)
+			continue
+		}
+		s.wakeWaiting(groupConcurrencyKey(group))
+	}
+}
+
+// waitGroupSlot 分组并发已满时挂起执行，名额释放后重新入队，等待期间不占用 worker
+func (s *Scheduler) waitGroupSlot(item TaskItem, group string) {
+	s.logger.Info("⏳ [Schedule] Group concurrency limit reached, wait for a free slot", "name", item.Name, "group", group, "exec_id", item.ID)
+	s.parkWaiting(groupConcurrencyKey(group), item)
+}
@@ -1,9 +1,18 @@
 package engine
 
+import "time"
+
 // TaskItem 表示队列中的一个任务
 type TaskItem struct {
-	Name     string // 任务名称
-	Priority int    // 优先级，值越大优先级越高
+	ID         string    `json:"id"`          // 执行ID，每次分发唯一，贯穿队列、执行记录、事件与日志
+	Name       string    `json:"name"`        // 任务名称
+	Priority   int       `json:"priority"`    // 优先级，值越大优先级越高
+	EnqueuedAt time.Time `json:"enqueued_at"` // 入队时间
+	Epoch      int64     `json:"epoch"`       // 分发时 Leader 的任期，0 表示非 cron 分发（手动触发、依赖触发）
+	Trigger    string    `json:"trigger"`     // 触发来源：cron, manual, dependency, misfire, api
+
+	Concurrency ConcurrencyPolicy `json:"concurrency,omitempty"` // 分发时登记的并发策略，为空表示未登记（allow）
+	Deferred    int               `json:"deferred,omitempty"`    // 在持久化队列中挂起的次数，使每次挂起后的队列成员与处理中的成员不同
 }
 
 // 定义一个基于 TaskItem 切片的类型，用于实现堆接口
@@ -13,9 +22,13 @@ type priorityQueue []TaskItem
 func (pq *priorityQueue) Len() int { return len(*pq) }
 
 // Less 决定了堆的排序方式。
-// 我们希望优先级高的排在前面（最大堆），所以这里用大于号 (>)
+// 我们希望优先级高的排在前面（最大堆），所以这里用大于号 (>)；
+// 优先级相同时先入队的先执行，保证同一任务的多次执行按分发顺序出队
 func (pq *priorityQueue) Less(i, j int) bool {
-	return (*pq)[i].Priority > (*pq)[j].Priority
+	if (*pq)[i].Priority != (*pq)[j].Priority {
+		return (*pq)[i].Priority > (*pq)[j].Priority
+	}
+	return (*pq)[i].EnqueuedAt.Before((*pq)[j].EnqueuedAt)
 }
 
 // Swap 交换两个元素
@@ -0,0 +1,202 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RemoveJob 从调度器中彻底移除任务：删除 cron 条目、任务定义、运行状态、依赖规则、重试策略、
+// 并发登记以及状态存储中的调度记录。
+// 已在执行中的实例会继续跑完，但不会再被触发。
+func (s *Scheduler) RemoveJob(name string) error {
+	s.mu.Lock()
+	reg, ok := s.jobDefinition[name]
+	if !ok {
+		s.mu.Unlock()
+		return ErrJobNotFound
+	}
+	s.removeCronEntry(reg)
+	delete(s.jobDefinition, name)
+	s.mu.Unlock()
+
+	s.Stats.Delete(name)
+	s.DependencyManager.RemoveDependency(name)
+	s.RetryManager.RemovePolicy(name)
+	s.clearJobState(name, reg)
+
+	s.logger.Info("🗑️ [Schedule] Job removed", "name", name)
+	return nil
+}
+
+// clearJobState 清理已移除任务的并发登记与持久化的调度状态，并唤醒挂起等待的执行，
+// 唤醒后因任务已不存在而结束，不会一直占用 pending 记录
+func (s *Scheduler) clearJobState(name string, reg JobDefinition) {
+	if err := s.concurrencyStore.Clear(context.Background(), name); err != nil {
+		s.logger.Error("❌ [Schedule] Clear concurrency entries failed", "name", name, err)
+	}
+	s.wakeWaiting(concurrencyWaitKey(name))
+	for _, group := range reg.groups {
+		s.wakeWaiting(groupConcurrencyKey(group))
+	}
+
+	if s.stateStore == nil {
+		return
+	}
+	if err := s.stateStore.RemoveJobState(name); err != nil {
+		s.logger.Error("❌ [State] Remove job state failed", "name", name, err)
+	}
+}
+
+// removeCronEntry 移除任务当前挂载的 cron 条目，调用方需持有 s.mu
+func (s *Scheduler) removeCronEntry(reg JobDefinition) {
+	if reg.entryID != 0 {
+		s.cron.Remove(reg.entryID)
+	}
+}
+
+// RescheduleJob 修改任务的 cron 表达式。新表达式解析失败时保持原有调度不变；
+// 暂停中的任务只更新表达式，恢复时按新表达式调度。
+func (s *Scheduler) RescheduleJob(name, cronExpr string) error {
+	s.mu.Lock()
+	reg, ok := s.jobDefinition[name]
+	if !ok {
+		s.mu.Unlock()
+		return ErrJobNotFound
+	}
+
+	if reg.paused {
+		if err := s.validateCronExpr(cronExpr); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+	} else {
+		entryID, err := s.cron.AddFunc(cronExpr, s.cronWrapper(name))
+		if err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		s.removeCronEntry(reg)
+		reg.entryID = entryID
+	}
+	reg.cronExpr = cronExpr
+	s.jobDefinition[name] = reg
+	s.mu.Unlock()
+
+	s.Stats.Update(name, func(stat *JobStats) {
+		stat.CronExpr = cronExpr
+	})
+	if !reg.paused {
+		s.refreshNextRun(name, reg.entryID)
+	}
+
+	s.logger.Info("🔁 [Schedule] Job rescheduled", "name", name, "cron", cronExpr)
+	return nil
+}
+
+// PauseJob 暂停任务：移除 cron 条目但保留任务定义与运行统计，暂停期间依赖触发也不会分发。
+// 手动触发（ManualRun）不受影响。
+func (s *Scheduler) PauseJob(name string) error {
+	s.mu.Lock()
+	reg, ok := s.jobDefinition[name]
+	if !ok {
+		s.mu.Unlock()
+		return ErrJobNotFound
+	}
+	if reg.paused {
+		s.mu.Unlock()
+		return nil
+	}
+	s.removeCronEntry(reg)
+	reg.entryID = 0
+	reg.paused = true
+	s.jobDefinition[name] = reg
+	s.mu.Unlock()
+
+	s.Stats.Update(name, func(stat *JobStats) {
+		if stat.Status != Running {
+			stat.Status = Paused
+		}
+		stat.RawNext = time.Time{}
+		stat.NextRunTime = ""
+	})
+
+	s.logger.Info("⏸️ [Schedule] Job paused", "name", name)
+	return nil
+}
+
+// ResumeJob 恢复已暂停的任务，按当前 cron 表达式重新挂载
+func (s *Scheduler) ResumeJob(name string) error {
+	s.mu.Lock()
+	reg, ok := s.jobDefinition[name]
+	if !ok {
+		s.mu.Unlock()
+		return ErrJobNotFound
+	}
+	if !reg.paused {
+		s.mu.Unlock()
+		return nil
+	}
+	if reg.cronExpr != "" {
+		entryID, err := s.cron.AddFunc(reg.cronExpr, s.cronWrapper(name))
+		if err != nil {
+			s.mu.Unlock()
+			return fmt.Errorf("resume job %s: %w", name, err)
+		}
+		reg.entryID = entryID
+	}
+	reg.paused = false
+	s.jobDefinition[name] = reg
+	s.mu.Unlock()
+
+	s.Stats.Update(name, func(stat *JobStats) {
+		if stat.Status == Paused {
+			stat.Status = Idle
+		}
+	})
+	if reg.entryID != 0 {
+		s.refreshNextRun(name, reg.entryID)
+	}
+
+	s.logger.Info("▶️ [Schedule] Job resumed", "name", name)
+	return nil
+}
+
+// ReplaceParams 原子地替换任务参数，下一次执行起生效，正在执行的实例仍使用旧参数
+func (s *Scheduler) ReplaceParams(name string, params map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reg, ok := s.jobDefinition[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	reg.params = params
+	s.jobDefinition[name] = reg
+	return nil
+}
+
+// HasJob 任务是否已注册到调度器
+func (s *Scheduler) HasJob(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.jobDefinition[name]
+	return ok
+}
+
+// isPaused 任务当前是否处于暂停状态
+func (s *Scheduler) isPaused(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.jobDefinition[name].paused
+}
+
+// validateCronExpr 使用调度器自身的解析器校验 cron 表达式（暂停中的任务不占用 cron 条目）
+func (s *Scheduler) validateCronExpr(expr string) error {
+	entryID, err := s.cron.AddFunc(expr, func() {})
+	if err != nil {
+		return err
+	}
+	s.cron.Remove(entryID)
+	return nil
+}
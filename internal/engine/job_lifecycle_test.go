@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试同名任务重复注册、改期、暂停恢复与移除都不会留下多余的 cron 条目
+func TestSchedulerJobLifecycle(t *testing.T) {
+	s, _ := newCountingScheduler(t, nil)
+
+	require.NoError(t, s.AddJob("@every 1h", "run_once", "job", map[string]any{"v": 1}, "TEST", nil))
+	s.SetPriority("job", 5)
+	require.NoError(t, s.AddJob("@every 2h", "run_once", "job", map[string]any{"v": 2}, "TEST", nil))
+	assert.Len(t, s.cron.Entries(), 1, "重复注册应替换原有条目")
+	assert.Equal(t, 5, s.jobDefinition["job"].priority, "替换时保留优先级等附加配置")
+
+	// 表达式非法时保持原有调度
+	assert.Error(t, s.RescheduleJob("job", "not a cron"))
+	assert.Equal(t, "@every 2h", s.jobDefinition["job"].cronExpr)
+	require.NoError(t, s.RescheduleJob("job", "@every 3h"))
+	assert.Len(t, s.cron.Entries(), 1)
+
+	require.NoError(t, s.PauseJob("job"))
+	assert.Empty(t, s.cron.Entries())
+	stat, _ := s.Stats.Get("job")
+	assert.Equal(t, Paused, stat.Status)
+
+	// 暂停期间改期只更新表达式
+	require.NoError(t, s.RescheduleJob("job", "@every 4h"))
+	assert.Empty(t, s.cron.Entries())
+
+	// 暂停期间重新注册仍保持暂停，不会挂载 cron 条目
+	require.NoError(t, s.AddJob("@every 4h", "run_once", "job", map[string]any{"v": 2}, "TEST", nil))
+	assert.Empty(t, s.cron.Entries())
+	assert.True(t, s.jobDefinition["job"].paused)
+	stat, _ = s.Stats.Get("job")
+	assert.Equal(t, Paused, stat.Status)
+	assert.Empty(t, stat.NextRunTime)
+
+	require.NoError(t, s.ResumeJob("job"))
+	require.Len(t, s.cron.Entries(), 1)
+	assert.Equal(t, s.jobDefinition["job"].entryID, s.cron.Entries()[0].ID)
+	stat, _ = s.Stats.Get("job")
+	assert.Equal(t, Idle, stat.Status)
+	assert.Equal(t, "@every 4h", stat.CronExpr)
+
+	require.NoError(t, s.ReplaceParams("job", map[string]any{"v": 3}))
+	assert.Equal(t, 3, s.jobDefinition["job"].params["v"])
+
+	require.NoError(t, s.RemoveJob("job"))
+	assert.Empty(t, s.cron.Entries())
+	assert.False(t, s.HasJob("job"))
+	_, ok := s.Stats.Get("job")
+	assert.False(t, ok)
+	assert.ErrorIs(t, s.RemoveJob("job"), ErrJobNotFound)
+	assert.ErrorIs(t, s.PauseJob("job"), ErrJobNotFound)
+}
+
+// 测试移除任务时一并清理重试策略、并发登记、挂起的执行与状态存储中的记录
+func TestRemoveJobClearsState(t *testing.T) {
+	store := NewRedisStateStore(newTestRedis(t))
+	s, counters := newCountingScheduler(t, store)
+	require.NoError(t, s.AddJob("@every 1h", "run_once", "job", nil, "TEST", nil))
+	s.RetryManager.SetPolicy("job", NewRetryPolicy(2, "", 0, nil))
+	require.NoError(t, store.SaveJobState(&JobState{Name: "job", LastScheduledAt: time.Now(), RunCount: 3}))
+	require.NoError(t, store.SaveDependencyStatus("job", TaskStatus{Completed: true, Success: true}))
+
+	ctx := context.Background()
+	ok, err := s.concurrencyStore.Acquire(ctx, "job", "e1", time.Now(), 0, time.Hour)
+	require.NoError(t, err)
+	require.True(t, ok)
+	s.waiters.park(concurrencyWaitKey("job"), TaskItem{ID: "e2", Name: "job"})
+
+	require.NoError(t, s.RemoveJob("job"))
+
+	_, ok = s.RetryManager.GetPolicy("job")
+	assert.False(t, ok)
+	ok, err = s.concurrencyStore.Acquire(ctx, "job", "e3", time.Now(), 1, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, ok, "之前的并发登记应被清理")
+	assert.Zero(t, s.waiters.len(concurrencyWaitKey("job")), "挂起的执行应被唤醒")
+
+	states, err := store.LoadJobStates()
+	require.NoError(t, err)
+	assert.NotContains(t, states, "job")
+	statuses, err := store.LoadDependencyStatus()
+	require.NoError(t, err)
+	assert.NotContains(t, statuses, "job")
+
+	// 唤醒的执行因任务已不存在而结束
+	time.Sleep(50 * time.Millisecond)
+	assert.Zero(t, atomic.LoadInt64(counters["run_once"]))
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+// ConcurrencyPolicy 同一任务的多次触发发生重叠时的处理策略（参考 Kubernetes CronJob）
+type ConcurrencyPolicy string
+
+const (
+	ConcurrencyAllow   ConcurrencyPolicy = "allow"   // 允许并发执行（默认）
+	ConcurrencyForbid  ConcurrencyPolicy = "forbid"  // 上一次未结束时跳过本次触发
+	ConcurrencyReplace ConcurrencyPolicy = "replace" // 取消正在执行的实例，执行本次触发
+	ConcurrencyQueue   ConcurrencyPolicy = "queue"   // 排队等待上一次结束
+)
+
+// ParseConcurrencyPolicy 解析配置中的并发策略，无法识别时返回 allow；
+// 兼容 sys_jobs.concurrent_policy 旧取值：skip、deny 视为 forbid，delay 视为 queue
+func ParseConcurrencyPolicy(s string) ConcurrencyPolicy {
+	switch ConcurrencyPolicy(strings.ToLower(strings.TrimSpace(s))) {
+	case ConcurrencyForbid, "skip", "deny":
+		return ConcurrencyForbid
+	case ConcurrencyReplace:
+		return ConcurrencyReplace
+	case ConcurrencyQueue, "delay":
+		return ConcurrencyQueue
+	default:
+		return ConcurrencyAllow
+	}
+}
+
+// JobOptions 任务实例的执行配置，零值表示使用默认值
+type JobOptions struct {
+	Timeout     time.Duration     // 单次执行的超时时间，<=0 时使用默认的 2 小时
+	Retry       *RetryPolicy      // 重试策略，nil 时使用默认策略
+	Priority    int               // 优先级，数值越大越先执行
+	Concurrency ConcurrencyPolicy // 并发策略，空值等同于 allow
+	MaxPending  int               // queue 策略下最多排队等待的执行数，<=0 时为 1
+	Tags        []string          // 标签，仅用于展示与筛选
+	TaskID      string            // 任务ID（来自 sys_jobs.id），注入 TaskContext 与任务日志
+	Groups      []string          // 所属分组及其上级分组，受 SetGroupConcurrency 设置的并发上限约束
+}
+
+// ErrorClass 可重试的错误类别
+type ErrorClass string
+
+const (
+	ErrorClassTimeout ErrorClass = "timeout" // 执行超时
+	ErrorClassNetwork ErrorClass = "network" // 网络错误（连接失败、DNS 解析失败等）
+)
+
+// ParseErrorClasses 解析配置中的错误类别，忽略无法识别的值
+func ParseErrorClasses(names []string) []ErrorClass {
+	classes := make([]ErrorClass, 0, len(names))
+	for _, name := range names {
+		switch class := ErrorClass(strings.ToLower(strings.TrimSpace(name))); class {
+		case ErrorClassTimeout, ErrorClassNetwork:
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
+
+// matchErrorClass 判断错误是否属于指定类别
+func matchErrorClass(err error, class ErrorClass) bool {
+	switch class {
+	case ErrorClassTimeout:
+		if errors.Is(err, ErrJobTimeout) || errors.Is(err, context.DeadlineExceeded) {
+			return true
+		}
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	case ErrorClassNetwork:
+		var netErr net.Error
+		return errors.As(err, &netErr)
+	}
+	return false
+}
+
+// NewRetryPolicy 根据最大重试次数与退避方式构造重试策略。
+// backoff 支持 exponential（默认）、linear、fixed；delay 为首次重试的等待时间，<=0 时为 1 秒
+func NewRetryPolicy(maxRetries int, backoff string, delay time.Duration, retryOn []ErrorClass) *RetryPolicy {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	var policy *RetryPolicy
+	switch strings.ToLower(strings.TrimSpace(backoff)) {
+	case "linear":
+		policy = LinearBackoffPolicy(maxRetries+1, delay)
+	case "fixed":
+		policy = FixedDelayPolicy(maxRetries+1, delay)
+	default:
+		policy = ExponentialBackoffPolicy(maxRetries+1, delay)
+	}
+	policy.RetryableClasses = retryOn
+	return policy
+}
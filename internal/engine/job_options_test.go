@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试可重试错误列表按 errors.Is(err, target) 匹配被包装的错误，并支持按错误类别重试
+func TestRetryManagerShouldRetryMatching(t *testing.T) {
+	rm := NewRetryManager(nil, NewDefaultLogger())
+	errRateLimited := errors.New("rate limited")
+
+	rm.SetPolicy("errors", &RetryPolicy{MaxAttempts: 3, RetryableErrors: []error{errRateLimited}})
+	assert.True(t, rm.ShouldRetry("errors", 1, fmt.Errorf("call api: %w", errRateLimited)))
+	assert.False(t, rm.ShouldRetry("errors", 1, errors.New("bad request")))
+	assert.False(t, rm.ShouldRetry("errors", 3, errRateLimited), "达到最大次数后不再重试")
+
+	rm.SetPolicy("classes", NewRetryPolicy(2, "fixed", time.Millisecond, ParseErrorClasses([]string{"timeout", "network", "unknown"})))
+	netErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	assert.True(t, rm.ShouldRetry("classes", 1, fmt.Errorf("request: %w", netErr)))
+	assert.True(t, rm.ShouldRetry("classes", 1, fmt.Errorf("%w after 1s", ErrJobTimeout)))
+	assert.False(t, rm.ShouldRetry("classes", 1, errors.New("exit status 1")))
+	assert.Equal(t, 3, rm.getMaxAttempts("classes"), "最大重试 2 次即最多执行 3 次")
+
+	// 任务标记为不可重试的错误，即使命中可重试类别也不再重试
+	assert.False(t, rm.ShouldRetry("classes", 1, core.Permanent(fmt.Errorf("request: %w", netErr))))
+	assert.False(t, rm.ShouldRetry("default", 1, core.Permanent(errors.New("status code 404"))))
+	assert.True(t, rm.ShouldRetry("default", 1, errors.New("status code 503")))
+}
+
+// 测试各退避方式的重试间隔：linear 按次数线性增长，区别于 fixed
+func TestRetryPolicyBackoff(t *testing.T) {
+	rm := NewRetryManager(nil, NewDefaultLogger())
+	for name, backoff := range map[string]string{"linear": "linear", "fixed": "fixed", "exponential": "exponential"} {
+		policy := NewRetryPolicy(3, backoff, time.Second, nil)
+		policy.JitterEnabled = false
+		rm.SetPolicy(name, policy)
+	}
+
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 3 * time.Second} {
+		assert.Equal(t, want, rm.CalculateDelay("linear", attempt))
+		assert.Equal(t, time.Second, rm.CalculateDelay("fixed", attempt))
+	}
+	assert.Equal(t, 4*time.Second, rm.CalculateDelay("exponential", 3))
+}
+
+// 测试 AddJob 的执行配置：每次尝试的超时、重试策略、优先级与标签
+func TestAddJobWithOptions(t *testing.T) {
+	s, store, runs, _ := newBlockingScheduler(t)
+	require.NoError(t, s.AddJob("@every 1h", "block", "block", nil, "TEST", &JobOptions{
+		Timeout:  50 * time.Millisecond,
+		Retry:    NewRetryPolicy(1, "fixed", time.Millisecond, nil),
+		Priority: 7,
+		Tags:     []string{"nightly"},
+	}))
+
+	assert.Equal(t, 7, s.jobDefinition["block"].priority)
+	stat, _ := s.Stats.Get("block")
+	assert.Equal(t, []string{"nightly"}, stat.Tags)
+
+	execID, err := s.ManualRun("block")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lastStatus(store, execID) == ExecutionStatusTimeout }, 2*time.Second, 5*time.Millisecond)
+
+	_, exec := store.get(execID)
+	assert.Equal(t, 1, exec.RetryCount, "第一次超时后重试一次")
+	assert.Equal(t, int32(2), atomic.LoadInt32(runs))
+
+	// 不带配置重新注册时沿用原有配置
+	require.NoError(t, s.AddJob("@every 2h", "block", "block", nil, "TEST", nil))
+	assert.Equal(t, 7, s.jobDefinition["block"].priority)
+	assert.Equal(t, 50*time.Millisecond, s.jobDefinition["block"].timeout)
+}
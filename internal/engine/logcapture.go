@@ -0,0 +1,297 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	LogStreamStdout = "stdout" // 任务标准输出
+	LogStreamStderr = "stderr" // 任务标准错误
+
+	logFlushInterval        = 500 * time.Millisecond // 执行日志批量写入的间隔
+	logFlushBatch           = 200                    // 缓冲达到该行数时立即写入
+	maxLogLinesPerExecution = 10000                  // 单次执行最多保留的日志行数，超出部分丢弃
+	maxLogLineBytes         = 16 * 1024              // 单行日志的最大长度，超出时截断
+)
+
+// LogEntry 执行期间捕获的一行日志：任务日志或 stdout/stderr 输出
+type LogEntry struct {
+	ExecID    string         `json:"exec_id"`
+	JobName   string         `json:"job_name"`
+	TaskID    string         `json:"task_id,omitempty"`
+	Seq       int64          `json:"seq"`   // 执行内从 1 开始递增的序号，用于增量读取
+	Level     string         `json:"level"` // 日志级别 debug/info/warn/error，输出行为 stdout/stderr
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// LogSink 执行日志的存储接口（MySQL、MongoDB 等）
+type LogSink interface {
+	// WriteLogs 批量写入同一次执行的日志
+	WriteLogs(entries []LogEntry) error
+	// ReadLogs 按序号升序读取 Seq > afterSeq 的日志，最多 limit 条
+	ReadLogs(ctx context.Context, execID string, afterSeq int64, limit int) ([]LogEntry, error)
+}
+
+// WithLogSink 注入执行日志存储，未注入时不捕获任务输出
+func WithLogSink(sink LogSink) Option {
+	return func(s *Scheduler) {
+		s.logSink = sink
+	}
+}
+
+// ExecutionLogs 增量读取一次执行捕获的日志，未配置日志存储时返回空
+func (s *Scheduler) ExecutionLogs(ctx context.Context, execID string, afterSeq int64, limit int) ([]LogEntry, error) {
+	if s.logSink == nil {
+		return nil, nil
+	}
+	return s.logSink.ReadLogs(ctx, execID, afterSeq, limit)
+}
+
+// logCollector 收集一次执行的日志，按间隔或行数批量写入日志存储；nil 表示不捕获
+type logCollector struct {
+	sink    LogSink
+	logger  Logger
+	execID  string
+	jobName string
+	taskID  string
+
+	stdout *lineWriter
+	stderr *lineWriter
+
+	mu      sync.Mutex
+	seq     int64
+	pending []LogEntry
+	dropped int
+
+	flushCh chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func (s *Scheduler) newLogCollector(item TaskItem, reg JobDefinition) *logCollector {
+	if s.logSink == nil {
+		return nil
+	}
+	c := &logCollector{
+		sink:    s.logSink,
+		logger:  s.logger,
+		execID:  item.ID,
+		jobName: item.Name,
+		taskID:  reg.taskID,
+		flushCh: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	c.stdout = &lineWriter{c: c, stream: LogStreamStdout}
+	c.stderr = &lineWriter{c: c, stream: LogStreamStderr}
+	go c.run()
+	return c
+}
+
+// add 追加一行日志
+func (c *logCollector) add(level, message string, fields map[string]any) {
+	if c == nil {
+		return
+	}
+	if len(message) > maxLogLineBytes {
+		message = message[:maxLogLineBytes] + "...(truncated)"
+	}
+
+	c.mu.Lock()
+	if c.seq >= maxLogLinesPerExecution {
+		c.dropped++
+		c.mu.Unlock()
+		return
+	}
+	c.seq++
+	c.pending = append(c.pending, LogEntry{
+		ExecID:    c.execID,
+		JobName:   c.jobName,
+		TaskID:    c.taskID,
+		Seq:       c.seq,
+		Level:     level,
+		Message:   message,
+		Fields:    fields,
+		Timestamp: time.Now(),
+	})
+	full := len(c.pending) >= logFlushBatch
+	c.mu.Unlock()
+
+	if full {
+		select {
+		case c.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (c *logCollector) run() {
+	defer close(c.done)
+	ticker := time.NewTicker(logFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.flush()
+		case <-c.flushCh:
+			c.flush()
+		}
+	}
+}
+
+// flush 写入缓冲中的日志，只由 run 协程与 close 调用，保证写入顺序
+func (c *logCollector) flush() {
+	c.mu.Lock()
+	entries := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	if len(entries) == 0 {
+		return
+	}
+	if err := c.sink.WriteLogs(entries); err != nil {
+		c.logger.Error("❌ [Logs] Write execution logs failed", err, "exec_id", c.execID, "lines", len(entries))
+	}
+}
+
+// finish 写出未换行的输出，执行失败时追加错误信息，然后结束收集；返回前所有日志均已写入
+func (c *logCollector) finish(err error) {
+	if c == nil {
+		return
+	}
+	c.stdout.Flush()
+	c.stderr.Flush()
+	if err != nil {
+		c.add("error", "execution failed: "+err.Error(), nil)
+	}
+	close(c.stop)
+	<-c.done
+
+	c.mu.Lock()
+	if c.dropped > 0 {
+		c.seq++
+		c.pending = append(c.pending, LogEntry{
+			ExecID:    c.execID,
+			JobName:   c.jobName,
+			TaskID:    c.taskID,
+			Seq:       c.seq,
+			Level:     "warn",
+			Message:   fmt.Sprintf("%d log lines dropped, limit is %d lines per execution", c.dropped, maxLogLinesPerExecution),
+			Timestamp: time.Now(),
+		})
+	}
+	c.mu.Unlock()
+	c.flush()
+}
+
+// writers 返回捕获 stdout/stderr 的 Writer，不捕获时返回 nil
+func (c *logCollector) writers() (stdout, stderr io.Writer) {
+	if c == nil {
+		return nil, nil
+	}
+	return c.stdout, c.stderr
+}
+
+// wrapLogger 让任务日志在原有输出之外同时写入执行日志
+func (c *logCollector) wrapLogger(log *zap.Logger) *zap.Logger {
+	if c == nil {
+		return log
+	}
+	return log.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, &captureCore{LevelEnabler: zapcore.InfoLevel, c: c})
+	}))
+}
+
+// captureCore 将 zap 日志写入执行日志的 zapcore.Core，不受全局日志级别影响，始终捕获 Info 及以上
+type captureCore struct {
+	zapcore.LevelEnabler
+	c      *logCollector
+	fields []zapcore.Field
+}
+
+func (cc *captureCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *cc
+	clone.fields = append(append([]zapcore.Field(nil), cc.fields...), fields...)
+	return &clone
+}
+
+func (cc *captureCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if cc.Enabled(ent.Level) {
+		return ce.AddCore(ent, cc)
+	}
+	return ce
+}
+
+func (cc *captureCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var values map[string]any
+	if len(cc.fields)+len(fields) > 0 {
+		enc := zapcore.NewMapObjectEncoder()
+		for _, f := range cc.fields {
+			f.AddTo(enc)
+		}
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+		values = enc.Fields
+	}
+	cc.c.add(ent.Level.String(), ent.Message, values)
+	return nil
+}
+
+func (cc *captureCore) Sync() error { return nil }
+
+// lineWriter 将任务的 stdout/stderr 按行写入执行日志，未换行的部分在 Flush 时写出
+type lineWriter struct {
+	c      *logCollector
+	stream string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	// 超长且没有换行的输出（如进度条）直接按最大长度切分
+	for len(w.buf) >= maxLogLineBytes {
+		w.emit(w.buf[:maxLogLineBytes])
+		w.buf = w.buf[maxLogLineBytes:]
+	}
+	return len(p), nil
+}
+
+// Flush 写出未换行的剩余输出
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *lineWriter) emit(line []byte) {
+	w.c.add(w.stream, string(bytes.TrimRight(line, "\r")), nil)
+}
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memoryLogSink 测试用的内存日志存储
+type memoryLogSink struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	writes  int
+}
+
+func (m *memoryLogSink) WriteLogs(entries []LogEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, entries...)
+	m.writes++
+	return nil
+}
+
+func (m *memoryLogSink) ReadLogs(_ context.Context, execID string, afterSeq int64, limit int) ([]LogEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []LogEntry
+	for _, e := range m.entries {
+		if e.ExecID == execID && e.Seq > afterSeq && len(list) < limit {
+			list = append(list, e)
+		}
+	}
+	return list, nil
+}
+
+// outputTask 写 stdout/stderr 并通过任务日志记录一行
+type outputTask struct {
+	hookTask
+	fail bool
+}
+
+func (t *outputTask) Run(ctx *core.TaskContext, params map[string]any) error {
+	fmt.Fprint(ctx.StdoutWriter(), "line 1\nline")
+	fmt.Fprint(ctx.StdoutWriter(), " 2\r\npartial")
+	fmt.Fprintln(ctx.StderrWriter(), "warning: disk almost full")
+	ctx.Log().Info("step done", zap.Int("step", 1))
+	if t.fail {
+		return fmt.Errorf("boom")
+	}
+	return nil
+}
+
+// 测试执行期间的任务日志与 stdout/stderr 按行写入日志存储，执行结束前全部写完
+func TestSchedulerCapturesExecutionLogs(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	sink := &memoryLogSink{}
+	registry := NewTaskRegistry()
+	registry.RegisterTasker("output", func() core.Tasker {
+		return &outputTask{hookTask: hookTask{mu: &mu, calls: &calls}, fail: true}
+	})
+
+	s := NewScheduler(registry, WithWorkerNum(1), WithLogSink(sink))
+	t.Cleanup(s.Stop)
+	require.NoError(t, s.AddJob("@every 1h", "output", "output-job", nil, "TEST", &JobOptions{TaskID: "7", Retry: NewRetryPolicy(0, "", 0, nil)}))
+
+	done := make(chan struct{})
+	s.EventManager.OnFunc(EventTypeJobError, func(*Event) { close(done) })
+	execID, err := s.ManualRun("output-job")
+	require.NoError(t, err)
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("任务未在预期时间内完成")
+	}
+
+	entries, err := s.ExecutionLogs(context.Background(), execID, 0, 100)
+	require.NoError(t, err)
+	var lines []string
+	for i, e := range entries {
+		assert.Equal(t, int64(i+1), e.Seq)
+		assert.Equal(t, "output-job", e.JobName)
+		assert.Equal(t, "7", e.TaskID)
+		lines = append(lines, e.Level+":"+e.Message)
+	}
+	assert.Equal(t, []string{
+		"stdout:line 1",
+		"stdout:line 2",
+		"stderr:warning: disk almost full",
+		"info:step done",
+		"stdout:partial",
+		"error:execution failed: task failed after 1 attempts, last error: boom",
+	}, lines)
+	// 任务日志的字段写入 Fields，预置的 job/exec_id 标签不重复记录
+	assert.Equal(t, map[string]any{"step": int64(1)}, entries[3].Fields)
+
+	// 增量读取
+	entries, err = s.ExecutionLogs(context.Background(), execID, 4, 100)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+// 测试单次执行的日志行数上限
+func TestLogCollectorLimit(t *testing.T) {
+	sink := &memoryLogSink{}
+	s := &Scheduler{logSink: sink, logger: NewDefaultLogger()}
+	c := s.newLogCollector(TaskItem{ID: "exec-1", Name: "job"}, JobDefinition{})
+
+	stdout, _ := c.writers()
+	fmt.Fprint(stdout, strings.Repeat("x\n", maxLogLinesPerExecution+5))
+	// 缓冲达到批量大小后由后台协程写入，不必等到执行结束
+	assert.Eventually(t, func() bool {
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		return sink.writes > 0
+	}, time.Second, 5*time.Millisecond)
+	c.finish(nil)
+
+	require.Len(t, sink.entries, maxLogLinesPerExecution+1)
+	last := sink.entries[len(sink.entries)-1]
+	assert.Equal(t, "warn", last.Level)
+	assert.Contains(t, last.Message, "5 log lines dropped")
+	assert.Greater(t, sink.writes, 1)
+}
+
+// 测试未配置日志存储时不捕获
+func TestLogCollectorDisabled(t *testing.T) {
+	s := &Scheduler{logger: NewDefaultLogger()}
+	c := s.newLogCollector(TaskItem{ID: "exec-1", Name: "job"}, JobDefinition{})
+	assert.Nil(t, c)
+
+	stdout, stderr := c.writers()
+	assert.Nil(t, stdout)
+	assert.Nil(t, stderr)
+	log := zap.NewNop()
+	assert.Same(t, log, c.wrapLogger(log))
+	c.add("info", "ignored", nil)
+	c.finish(nil)
+}
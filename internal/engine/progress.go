@@ -0,0 +1,257 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
+	keys "github.com/iceymoss/go-task/pkg/db/key"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// progressTTL 进度记录的保留时间，与执行记录缓存一致
+const progressTTL = keys.TTLExecution * time.Second
+
+// ExecutionProgress 一次执行的实时进度，执行开始、任务上报进度与执行结束时更新
+type ExecutionProgress struct {
+	ExecID    string          `json:"exec_id"`
+	JobName   string          `json:"job_name"`
+	Status    ExecutionStatus `json:"status"`
+	Current   int             `json:"current"`
+	Total     int             `json:"total"`
+	Percent   float64         `json:"percent"` // 0-100，Total<=0 时为 0
+	Message   string          `json:"message"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Finished 执行是否已经结束
+func (p *ExecutionProgress) Finished() bool {
+	return p.Status.Finished()
+}
+
+// ProgressStore 执行进度的存储接口
+type ProgressStore interface {
+	SaveProgress(ctx context.Context, p *ExecutionProgress) error
+	// GetProgress 不存在时返回 nil, nil
+	GetProgress(ctx context.Context, execID string) (*ExecutionProgress, error)
+}
+
+// memoryProgressStore 默认的内存实现，仅本节点可见
+type memoryProgressStore struct {
+	mu    sync.Mutex
+	items map[string]*ExecutionProgress
+}
+
+func newMemoryProgressStore() *memoryProgressStore {
+	return &memoryProgressStore{items: make(map[string]*ExecutionProgress)}
+}
+
+func (m *memoryProgressStore) SaveProgress(_ context.Context, p *ExecutionProgress) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 顺带清理过期记录，避免长期运行后无限增长
+	expired := time.Now().Add(-progressTTL)
+	for id, item := range m.items {
+		if item.UpdatedAt.Before(expired) {
+			delete(m.items, id)
+		}
+	}
+	cp := *p
+	m.items[p.ExecID] = &cp
+	return nil
+}
+
+func (m *memoryProgressStore) GetProgress(_ context.Context, execID string) (*ExecutionProgress, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.items[execID]
+	if !ok {
+		return nil, nil
+	}
+	cp := *p
+	return &cp, nil
+}
+
+// RedisProgressStore 基于 Redis 的实现，进度以 JSON 写入 KeyExecution，集群内任意节点都可以读取
+type RedisProgressStore struct {
+	client *redis.Client
+}
+
+// 确保 RedisProgressStore 实现了 ProgressStore 接口
+var _ ProgressStore = (*RedisProgressStore)(nil)
+
+// NewRedisProgressStore 创建 Redis 进度存储
+func NewRedisProgressStore(client *redis.Client) *RedisProgressStore {
+	return &RedisProgressStore{client: client}
+}
+
+func (r *RedisProgressStore) SaveProgress(ctx context.Context, p *ExecutionProgress) error {
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, keys.KeyExecution(p.ExecID), raw, progressTTL).Err()
+}
+
+func (r *RedisProgressStore) GetProgress(ctx context.Context, execID string) (*ExecutionProgress, error) {
+	raw, err := r.client.Get(ctx, keys.KeyExecution(execID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var p ExecutionProgress
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// WithProgressStore 注入执行进度存储，集群部署时使用 Redis 实现使任意节点都能查询进度
+func WithProgressStore(store ProgressStore) Option {
+	return func(s *Scheduler) {
+		if store != nil {
+			s.progressStore = store
+		}
+	}
+}
+
+// WithTaskLogger 配置任务使用的 zap 日志，每次执行会在其上附加任务与执行ID，默认使用全局日志
+func WithTaskLogger(log *zap.Logger) Option {
+	return func(s *Scheduler) {
+		if log != nil {
+			s.taskLogger = log
+		}
+	}
+}
+
+// Progress 查询一次执行的实时进度，不存在时返回 nil, nil
+func (s *Scheduler) Progress(ctx context.Context, execID string) (*ExecutionProgress, error) {
+	return s.progressStore.GetProgress(ctx, execID)
+}
+
+// progressTracker 维护一次执行的进度，任务上报与状态变化都写入进度存储
+type progressTracker struct {
+	store  ProgressStore
+	logger Logger
+
+	mu       sync.Mutex
+	progress ExecutionProgress
+}
+
+func (s *Scheduler) newProgressTracker(name, execID string) *progressTracker {
+	return &progressTracker{
+		store:  s.progressStore,
+		logger: s.logger,
+		progress: ExecutionProgress{
+			ExecID:  execID,
+			JobName: name,
+			Status:  ExecutionStatusRunning,
+		},
+	}
+}
+
+// start 执行开始时写入 running 状态
+func (t *progressTracker) start() {
+	t.update(func(*ExecutionProgress) {})
+}
+
+// report 任务上报进度，作为 TaskContext.OnProgress
+func (t *progressTracker) report(p core.TaskProgress) {
+	t.update(func(ep *ExecutionProgress) {
+		ep.Current = p.Current
+		ep.Total = p.Total
+		ep.Message = p.Message
+		ep.Percent = progressPercent(p.Current, p.Total)
+	})
+}
+
+// finish 执行结束时写入最终状态，成功时进度补满
+func (t *progressTracker) finish(status ExecutionStatus) {
+	t.update(func(ep *ExecutionProgress) {
+		ep.Status = status
+		if status == ExecutionStatusSuccess {
+			ep.Percent = 100
+			if ep.Total > 0 {
+				ep.Current = ep.Total
+			}
+		}
+	})
+}
+
+func (t *progressTracker) update(fn func(*ExecutionProgress)) {
+	// 持锁写入，保证结束状态不会被之前的进度覆盖
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(&t.progress)
+	t.progress.UpdatedAt = time.Now()
+	p := t.progress
+
+	if err := t.store.SaveProgress(context.Background(), &p); err != nil {
+		t.logger.Warn("⚠️ [Progress] Save progress failed", "exec_id", p.ExecID, err)
+	}
+}
+
+func progressPercent(current, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	percent := float64(current) / float64(total) * 100
+	return math.Round(math.Min(math.Max(percent, 0), 100)*10) / 10
+}
+
+// taskContext 构造每一次尝试的任务上下文：日志附加任务与执行ID，进度写入进度存储，输出写入执行日志
+func (s *Scheduler) taskContext(ctx context.Context, item TaskItem, reg JobDefinition, tracker *progressTracker, logs *logCollector) *core.TaskContext {
+	stdout, stderr := logs.writers()
+	log := s.taskLogger.With(
+		zap.String("job", item.Name),
+		zap.String("task", reg.taskName),
+		zap.String("task_id", reg.taskID),
+		zap.String("exec_id", item.ID),
+		zap.String("worker_id", s.workerID),
+	)
+	return core.BindTaskContext(&core.TaskContext{
+		Context:     ctx,
+		TaskID:      reg.taskID,
+		TaskName:    item.Name,
+		ExecutionID: item.ID,
+		OnProgress:  tracker.report,
+		Logger:      logs.wrapLogger(log),
+		Stdout:      stdout,
+		Stderr:      stderr,
+		WorkerID:    s.workerID,
+		Hostname:    s.hostname,
+		Metadata: map[string]interface{}{
+			"trigger": item.Trigger,
+			"source":  reg.source,
+			"attempt": attemptOf(ctx),
+		},
+	})
+}
+
+// attemptOf 当前是第几次尝试（从 1 开始）
+func attemptOf(ctx context.Context) int {
+	if info := execInfoFromContext(ctx); info != nil {
+		return int(atomic.LoadInt32(&info.retries)) + 1
+	}
+	return 1
+}
+
+// hostname 当前主机名
+func hostname() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown-host"
+	}
+	return host
+}
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// progressTask 上报进度并记录收到的任务上下文
+type progressTask struct {
+	hookTask
+	seen chan *core.TaskContext
+}
+
+func (t *progressTask) Run(ctx *core.TaskContext, params map[string]any) error {
+	core.ReportProgress(ctx, 1, 4, "step 1")
+	// 任务派生出的子 context 同样可以上报进度
+	sub, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	core.ReportProgress(sub, 2, 4, "step 2")
+	t.seen <- ctx
+	return nil
+}
+
+// 测试调度器为每次执行构造完整的任务上下文，并记录进度直到执行结束
+func TestSchedulerTaskContextAndProgress(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	seen := make(chan *core.TaskContext, 1)
+	registry := NewTaskRegistry()
+	registry.RegisterTasker("progress", func() core.Tasker {
+		return &progressTask{hookTask: hookTask{mu: &mu, calls: &calls}, seen: seen}
+	})
+
+	s := NewScheduler(registry, WithWorkerNum(1))
+	t.Cleanup(s.Stop)
+	require.NoError(t, s.AddJob("@every 1h", "progress", "progress-job", nil, "TEST", &JobOptions{TaskID: "42"}))
+
+	done := make(chan struct{})
+	s.EventManager.OnFunc(EventTypeAfterJob, func(*Event) { close(done) })
+	execID, err := s.ManualRun("progress-job")
+	require.NoError(t, err)
+
+	var tc *core.TaskContext
+	select {
+	case tc = <-seen:
+	case <-time.After(3 * time.Second):
+		t.Fatal("任务未在预期时间内执行")
+	}
+	assert.Equal(t, "42", tc.TaskID)
+	assert.Equal(t, "progress-job", tc.TaskName)
+	assert.Equal(t, execID, tc.ExecutionID)
+	assert.NotNil(t, tc.Logger)
+	assert.NotEmpty(t, tc.WorkerID)
+	assert.NotEmpty(t, tc.Hostname)
+	assert.Equal(t, TriggerManual, tc.Metadata["trigger"])
+	assert.Equal(t, 1, tc.Metadata["attempt"])
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("任务未在预期时间内完成")
+	}
+	p, err := s.Progress(context.Background(), execID)
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, ExecutionStatusSuccess, p.Status)
+	assert.Equal(t, "progress-job", p.JobName)
+	assert.Equal(t, 4, p.Current)
+	assert.Equal(t, 4, p.Total)
+	assert.Equal(t, float64(100), p.Percent)
+	assert.Equal(t, "step 2", p.Message)
+	assert.True(t, p.Finished())
+}
+
+// 测试进度上报与失败状态
+func TestProgressTracker(t *testing.T) {
+	store := newMemoryProgressStore()
+	s := &Scheduler{progressStore: store, logger: NewDefaultLogger()}
+	tracker := s.newProgressTracker("job", "exec-1")
+
+	tracker.start()
+	p, err := store.GetProgress(context.Background(), "exec-1")
+	require.NoError(t, err)
+	assert.Equal(t, ExecutionStatusRunning, p.Status)
+	assert.False(t, p.Finished())
+
+	tracker.report(core.TaskProgress{Current: 1, Total: 3, Message: "cloning"})
+	p, _ = store.GetProgress(context.Background(), "exec-1")
+	assert.Equal(t, 33.3, p.Percent)
+	assert.Equal(t, "cloning", p.Message)
+
+	// 失败时保留最后一次上报的进度
+	tracker.finish(ExecutionStatusFailed)
+	p, _ = store.GetProgress(context.Background(), "exec-1")
+	assert.Equal(t, ExecutionStatusFailed, p.Status)
+	assert.Equal(t, 1, p.Current)
+	assert.True(t, p.Finished())
+
+	p, err = store.GetProgress(context.Background(), "missing")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+// 测试 Redis 进度存储写入 KeyExecution 并设置过期时间
+func TestRedisProgressStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := NewRedisProgressStore(client)
+	ctx := context.Background()
+
+	p, err := store.GetProgress(ctx, "exec-1")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	now := time.Now().Truncate(time.Millisecond)
+	require.NoError(t, store.SaveProgress(ctx, &ExecutionProgress{
+		ExecID:    "exec-1",
+		JobName:   "job",
+		Status:    ExecutionStatusRunning,
+		Current:   2,
+		Total:     5,
+		Percent:   40,
+		Message:   "pushing",
+		UpdatedAt: now,
+	}))
+
+	p, err = store.GetProgress(ctx, "exec-1")
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, "pushing", p.Message)
+	assert.Equal(t, 40.0, p.Percent)
+	assert.True(t, p.UpdatedAt.Equal(now))
+
+	stored := mr.Keys()
+	require.Len(t, stored, 1)
+	assert.Contains(t, stored[0], "execution:exec-1")
+	assert.Equal(t, progressTTL, mr.TTL(stored[0]))
+}
@@ -9,20 +9,37 @@ import (
 // DefaultWorkerNum 默认工作协程数量
 const defaultWorkerNum = 10
 
+// QueueHandler 队列的任务处理函数
+type QueueHandler func(item TaskItem)
+
+// Queue 任务队列接口：调度器只负责入队，由队列的 worker 取出并调用 QueueHandler 执行
+type Queue interface {
+	// Enqueue 入队一个任务
+	Enqueue(item TaskItem) error
+	// Stop 停止取任务，并等待正在执行的任务结束
+	Stop()
+}
+
+// QueueFactory 队列构造函数，调度器在初始化时传入自己的处理函数
+type QueueFactory func(handler QueueHandler, workerNum int, log Logger) Queue
+
+// 确保 TaskQueue 实现了 Queue 接口
+var _ Queue = (*TaskQueue)(nil)
+
 // TaskQueue 简单优先级任务队列，使用内存优先队列 + 固定工作协程
 type TaskQueue struct {
-	handler   func(name string) // 任务处理函数
-	mu        sync.Mutex        // 保护 items 和 closed 的并发访问
-	cond      *sync.Cond        // 条件变量，用于通知 worker 有新任务
-	items     *priorityQueue    // 任务列表
-	workerNum int               // worker 数量
-	wg        sync.WaitGroup    // 等待 worker 退出
-	closed    bool              // 是否已关闭队列
+	handler   QueueHandler   // 任务处理函数
+	mu        sync.Mutex     // 保护 items 和 closed 的并发访问
+	cond      *sync.Cond     // 条件变量，用于通知 worker 有新任务
+	items     *priorityQueue // 任务列表
+	workerNum int            // worker 数量
+	wg        sync.WaitGroup // 等待 worker 退出
+	closed    bool           // 是否已关闭队列
 	logger    Logger
 }
 
 // NewTaskQueue 创建任务队列, 会启动固定数量的 worker
-func NewTaskQueue(handler func(name string), workerNum int, log Logger) *TaskQueue {
+func NewTaskQueue(handler QueueHandler, workerNum int, log Logger) *TaskQueue {
 	if workerNum <= 0 {
 		workerNum = defaultWorkerNum
 	}
@@ -79,7 +96,7 @@ func (q *TaskQueue) workerLoop(id int) {
 		}
 		q.logger.Info(fmt.Sprintf("🧵 [TaskQueue] Worker-%d handling job: %s (priority=%d)", id, item.Name, item.Priority))
 		if q.handler != nil {
-			q.handler(item.Name)
+			q.handler(item)
 		}
 	}
 }
@@ -105,7 +122,7 @@ func (q *TaskQueue) pop() (TaskItem, bool) {
 }
 
 // Enqueue 入队一个任务
-func (q *TaskQueue) Enqueue(name string, priority int) error {
+func (q *TaskQueue) Enqueue(item TaskItem) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
@@ -114,10 +131,7 @@ func (q *TaskQueue) Enqueue(name string, priority int) error {
 	}
 
 	// 使用 heap.Push 插入元素，内部会自动调整树结构保持最大堆形态
-	heap.Push(q.items, TaskItem{
-		Name:     name,
-		Priority: priority,
-	})
+	heap.Push(q.items, item)
 
 	// 唤醒一个等待的 worker
 	q.cond.Signal()
@@ -0,0 +1,238 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	keys "github.com/iceymoss/go-task/pkg/db/key"
+
+	"github.com/robfig/cron/v3"
+)
+
+// pendingHeartbeatInterval 存活标记的刷新与 pending 项巡检间隔
+const pendingHeartbeatInterval = keys.TTLHeartbeat * time.Second / 3
+
+// saveJobState 将任务的运行状态写入状态存储
+func (s *Scheduler) saveJobState(name string) {
+	if s.stateStore == nil {
+		return
+	}
+	stat, ok := s.Stats.Get(name)
+	if !ok {
+		return
+	}
+	err := s.stateStore.SaveJobState(&JobState{
+		Name:            name,
+		LastScheduledAt: stat.RawLastScheduled,
+		LastRunAt:       stat.RawLastRun,
+		LastResult:      stat.LastResult,
+		RunCount:        stat.RunCount,
+	})
+	if err != nil {
+		s.logger.Error("❌ [State] Save job state failed", "name", name, err)
+	}
+}
+
+// recoverState 从状态存储恢复任务状态、依赖状态与未执行的队列项，并补跑错过的周期
+// 在 cron 启动前调用：单机模式在 Start 中，分布式模式在成为 Leader 时
+func (s *Scheduler) recoverState() {
+	if s.stateStore == nil {
+		return
+	}
+
+	states, err := s.stateStore.LoadJobStates()
+	if err != nil {
+		s.logger.Error("❌ [State] Load job states failed", err)
+		states = nil
+	}
+	for name, st := range states {
+		s.Stats.Update(name, func(stat *JobStats) {
+			stat.RunCount = st.RunCount
+			stat.RawLastScheduled = st.LastScheduledAt
+			stat.RawLastRun = st.LastRunAt
+			if !st.LastRunAt.IsZero() {
+				stat.LastRunTime = st.LastRunAt.Format("2006-01-02 15:04:05")
+			}
+			if st.LastResult != "" {
+				stat.LastResult = st.LastResult
+			}
+		})
+	}
+
+	if statuses, err := s.stateStore.LoadDependencyStatus(); err != nil {
+		s.logger.Error("❌ [State] Load dependency status failed", err)
+	} else {
+		s.DependencyManager.RestoreTaskStatus(statuses)
+	}
+
+	s.recoverPendingItems()
+	s.catchUpMisfires(states, time.Now())
+}
+
+// recoverPendingItems 重新入队失联节点留下的、尚未执行的任务（仅非持久化队列需要）
+// 队列项仍在存活节点的内存队列中时由该节点自己执行，这里只接管存活标记已经消失的节点的队列项；
+// 接管后保留原任期与入队时间，执行前照常经过 checkItemEpoch 的任期校验
+func (s *Scheduler) recoverPendingItems() {
+	if !s.trackPending() {
+		return
+	}
+	s.recoverMu.Lock()
+	defer s.recoverMu.Unlock()
+
+	items, err := s.stateStore.LoadPendingItems()
+	if err != nil {
+		s.logger.Error("❌ [State] Load pending items failed", err)
+		return
+	}
+
+	alive := make(map[string]bool)
+	for _, pending := range items {
+		if pending.Owner == s.workerID {
+			continue
+		}
+		if pending.Owner != "" {
+			ok, seen := alive[pending.Owner]
+			if !seen {
+				ok, err = s.stateStore.IsAlive(pending.Owner)
+				if err != nil {
+					// 无法确认节点状态时不接管，留到下一次巡检
+					s.logger.Error("❌ [State] Check pending item owner failed", "owner", pending.Owner, err)
+					continue
+				}
+				alive[pending.Owner] = ok
+			}
+			if ok {
+				continue
+			}
+		}
+
+		item := pending.TaskItem
+		s.mu.RLock()
+		_, ok := s.jobDefinition[item.Name]
+		s.mu.RUnlock()
+		if !ok {
+			_ = s.stateStore.RemovePendingItem(item.ID)
+			s.logger.Warn("⚠️ [State] Drop pending item of unknown job", "name", item.Name, "id", item.ID)
+			continue
+		}
+
+		s.Stats.Update(item.Name, func(stat *JobStats) {
+			stat.Status = Queued
+		})
+		// enqueue 会把队列项的所属节点改写为本节点
+		if err := s.enqueue(item); err != nil {
+			s.logger.Error("❌ [State] Requeue pending item failed", "name", item.Name, err)
+			continue
+		}
+		s.logger.Info("♻️ [State] Recovered pending job", "name", item.Name, "id", item.ID, "owner", pending.Owner, "epoch", item.Epoch)
+	}
+}
+
+// startPendingHeartbeat 定期刷新本节点的存活标记，Leader 同时巡检失联节点留下的 pending 项
+func (s *Scheduler) startPendingHeartbeat() {
+	if !s.trackPending() {
+		return
+	}
+	s.heartbeat()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.pendingStop = cancel
+	go func() {
+		ticker := time.NewTicker(pendingHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.heartbeat()
+				if s.leaderElector == nil || s.leaderElector.IsLeader() {
+					s.recoverPendingItems()
+				}
+			}
+		}
+	}()
+}
+
+// stopPendingHeartbeat 停止心跳并清除存活标记，本节点未执行的队列项可以立即被 Leader 接管
+func (s *Scheduler) stopPendingHeartbeat() {
+	if s.pendingStop == nil {
+		return
+	}
+	s.pendingStop()
+	if err := s.stateStore.ClearHeartbeat(s.workerID); err != nil {
+		s.logger.Error("❌ [State] Clear heartbeat failed", "worker_id", s.workerID, err)
+	}
+}
+
+func (s *Scheduler) heartbeat() {
+	if err := s.stateStore.Heartbeat(s.workerID, keys.TTLHeartbeat*time.Second); err != nil {
+		s.logger.Error("❌ [State] Heartbeat failed", "worker_id", s.workerID, err)
+	}
+}
+
+// catchUpMisfires 根据每个任务的补偿策略补跑停机期间错过的 cron 周期
+func (s *Scheduler) catchUpMisfires(states map[string]*JobState, now time.Time) {
+	type candidate struct {
+		name    string
+		entryID cron.EntryID
+		policy  MisfirePolicy
+	}
+
+	s.mu.RLock()
+	candidates := make([]candidate, 0)
+	for name, reg := range s.jobDefinition {
+		if reg.entryID == 0 || reg.misfire == "" || reg.misfire == MisfirePolicySkip {
+			continue
+		}
+		candidates = append(candidates, candidate{name: name, entryID: reg.entryID, policy: reg.misfire})
+	}
+	s.mu.RUnlock()
+
+	var epoch int64
+	if s.leaderElector != nil {
+		epoch = s.leaderElector.Epoch()
+	}
+
+	for _, c := range candidates {
+		st, ok := states[c.name]
+		if !ok || st.LastScheduledAt.IsZero() {
+			// 从未被调度过，没有可补跑的周期
+			continue
+		}
+
+		schedule := s.cron.Entry(c.entryID).Schedule
+		if schedule == nil {
+			continue
+		}
+
+		missed := 0
+		for t := schedule.Next(st.LastScheduledAt); !t.IsZero() && t.Before(now) && missed < maxMisfireCatchUp; t = schedule.Next(t) {
+			missed++
+		}
+		if missed == 0 {
+			continue
+		}
+
+		runs := 1
+		if c.policy == MisfirePolicyRunAll {
+			runs = missed
+		}
+		s.logger.Warn("⏰ [Schedule] Catching up missed runs",
+			"name", c.name,
+			"policy", string(c.policy),
+			"missed", missed,
+			"runs", runs,
+		)
+
+		// 先记录本次补偿，避免补跑过程中再次重启导致重复补跑
+		s.Stats.Update(c.name, func(stat *JobStats) {
+			stat.RawLastScheduled = now
+		})
+		s.saveJobState(c.name)
+
+		for i := 0; i < runs; i++ {
+			s.dispatch(c.name, epoch, TriggerMisfire)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	keys "github.com/iceymoss/go-task/pkg/db/key"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultVisibilityTimeout = 60 * time.Second       // 任务被取出后多久未心跳即视为 Worker 失联
+	defaultQueuePollInterval = 500 * time.Millisecond // 队列为空时的轮询间隔
+	workerHeartbeatInterval  = keys.TTLHeartbeat * time.Second / 3
+)
+
+// 队列按优先级分为三个桶，从高到低依次出队
+var queueBuckets = []string{keys.PriorityHigh, keys.PriorityNormal, keys.PriorityLow}
+
+// popScript 原子地从最高优先级的非空桶中取出一个任务，并移入 processing 集合
+// KEYS: processing, high, normal, low  ARGV: 可见性超时截止时间(ms), 桶名...
+// processing 中的成员格式为 "<桶名>|<任务JSON>"，便于超时后放回原来的桶
+var popScript = redis.NewScript(`
+for i = 2, #KEYS do
+	local items = redis.call('ZRANGE', KEYS[i], 0, 0)
+	if #items > 0 then
+		redis.call('ZREM', KEYS[i], items[1])
+		local member = ARGV[i] .. '|' .. items[1]
+		redis.call('ZADD', KEYS[1], ARGV[1], member)
+		return member
+	end
+end
+return false
+`)
+
+// requeueScript 将可见性超时（或挂起到期）的任务放回原来的桶，score 置 0 以便优先被重新取出
+// KEYS: processing 或 delayed, high, normal, low  ARGV: 当前时间(ms), 桶名...
+var requeueScript = redis.NewScript(`
+local buckets = {}
+for i = 2, #KEYS do
+	buckets[ARGV[i]] = KEYS[i]
+end
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, member in ipairs(expired) do
+	local sep = string.find(member, '|', 1, true)
+	redis.call('ZREM', KEYS[1], member)
+	if sep then
+		local key = buckets[string.sub(member, 1, sep - 1)]
+		if key then
+			redis.call('ZADD', key, 0, string.sub(member, sep + 1))
+		end
+	end
+end
+return #expired
+`)
+
+// deferScript 将任务挂起到 delayed 集合，并记录到等待对象的 Hash，成员格式与 processing 相同
+// KEYS: delayed, 等待 Hash  ARGV: "<桶名>|<任务JSON>", 恢复时间(ms), 执行ID, Hash 过期时间(ms)
+var deferScript = redis.NewScript(`
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[3], ARGV[1])
+redis.call('PEXPIRE', KEYS[2], ARGV[4])
+return 1
+`)
+
+// wakeScript 将等待 Hash 中仍处于挂起状态的任务放回原来的桶，score 置 0 以便优先被取出
+// KEYS: delayed, high, normal, low, 等待 Hash  ARGV: 占位, 桶名...
+var wakeScript = redis.NewScript(`
+local buckets = {}
+for i = 2, #KEYS - 1 do
+	buckets[ARGV[i]] = KEYS[i]
+end
+local entries = redis.call('HGETALL', KEYS[#KEYS])
+redis.call('DEL', KEYS[#KEYS])
+local woken = 0
+for i = 2, #entries, 2 do
+	local member = entries[i]
+	local sep = string.find(member, '|', 1, true)
+	if sep and redis.call('ZREM', KEYS[1], member) == 1 then
+		local key = buckets[string.sub(member, 1, sep - 1)]
+		if key then
+			redis.call('ZADD', key, 0, string.sub(member, sep + 1))
+			woken = woken + 1
+		end
+	end
+end
+return woken
+`)
+
+// 确保 RedisTaskQueue 实现了 Queue 接口
+var _ Queue = (*RedisTaskQueue)(nil)
+
+// RedisTaskQueue 基于 Redis 的分布式任务队列
+// 所有节点共享同一组优先级 ZSET：Leader 负责入队，每个节点的 worker 都会拉取任务执行。
+// 任务被取出后进入 processing 集合并带有可见性超时，执行期间由心跳续期；
+// 节点宕机导致心跳中断时，任务会被任意节点的巡检协程放回队列重新执行。
+type RedisTaskQueue struct {
+	client            *redis.Client
+	handler           QueueHandler
+	workerNum         int
+	workerID          string
+	visibilityTimeout time.Duration
+	pollInterval      time.Duration
+	logger            Logger
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// RedisQueueOption Redis 队列的配置项
+type RedisQueueOption func(*RedisTaskQueue)
+
+// WithVisibilityTimeout 配置任务的可见性超时时间
+func WithVisibilityTimeout(timeout time.Duration) RedisQueueOption {
+	return func(q *RedisTaskQueue) {
+		if timeout > 0 {
+			q.visibilityTimeout = timeout
+		}
+	}
+}
+
+// WithQueuePollInterval 配置队列为空时的轮询间隔
+func WithQueuePollInterval(interval time.Duration) RedisQueueOption {
+	return func(q *RedisTaskQueue) {
+		if interval > 0 {
+			q.pollInterval = interval
+		}
+	}
+}
+
+// WithWorkerID 配置当前节点的 Worker ID（默认使用主机名 + 时间戳）
+func WithWorkerID(id string) RedisQueueOption {
+	return func(q *RedisTaskQueue) {
+		if id != "" {
+			q.workerID = id
+		}
+	}
+}
+
+// NewRedisTaskQueue 创建 Redis 任务队列，会注册当前 Worker 并启动固定数量的 worker 协程
+func NewRedisTaskQueue(client *redis.Client, handler QueueHandler, workerNum int, log Logger, opts ...RedisQueueOption) *RedisTaskQueue {
+	if workerNum <= 0 {
+		workerNum = defaultWorkerNum
+	}
+	if log == nil {
+		log = NewDefaultLogger()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &RedisTaskQueue{
+		client:            client,
+		handler:           handler,
+		workerNum:         workerNum,
+		workerID:          defaultInstanceID(),
+		visibilityTimeout: defaultVisibilityTimeout,
+		pollInterval:      defaultQueuePollInterval,
+		logger:            log,
+		ctx:               ctx,
+		cancel:            cancel,
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	q.register()
+
+	q.wg.Add(2)
+	go q.heartbeatLoop()
+	go q.reaperLoop()
+
+	for i := 0; i < q.workerNum; i++ {
+		q.wg.Add(1)
+		go q.workerLoop(i)
+	}
+	return q
+}
+
+// RedisQueueFactory 返回创建 Redis 队列的工厂函数，配合 WithQueue 注入调度器
+func RedisQueueFactory(client *redis.Client, opts ...RedisQueueOption) QueueFactory {
+	return func(handler QueueHandler, workerNum int, log Logger) Queue {
+		return NewRedisTaskQueue(client, handler, workerNum, log, opts...)
+	}
+}
+
+// Durable 队列内容保存在 Redis 中，重启后不会丢失
+func (q *RedisTaskQueue) Durable() bool {
+	return true
+}
+
+// WorkerID 返回当前节点的 Worker ID
+func (q *RedisTaskQueue) WorkerID() string {
+	return q.workerID
+}
+
+// Enqueue 按优先级写入对应的 ZSET，同一桶内按入队时间先进先出
+func (q *RedisTaskQueue) Enqueue(item TaskItem) error {
+	if item.EnqueuedAt.IsZero() {
+		item.EnqueuedAt = time.Now()
+	}
+	payload, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return q.client.ZAdd(context.Background(), keys.KeyTaskQueue(priorityBucket(item.Priority)), &redis.Z{
+		Score:  float64(item.EnqueuedAt.UnixMilli()),
+		Member: string(payload),
+	}).Err()
+}
+
+// Defer 将任务挂起在 Redis 中直到 until，期间不会被取出；到期后由巡检协程放回队列，Wake(key) 时提前恢复。
+// 挂起的任务与普通任务一样保存在 Redis 中，节点宕机也不会丢失
+func (q *RedisTaskQueue) Defer(key string, item TaskItem, until time.Time) error {
+	// 当前成员仍在 processing 中，挂起后的成员需要不同，避免本次确认时误删被唤醒后再次取出的任务
+	item.Deferred++
+	payload, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	member := priorityBucket(item.Priority) + "|" + string(payload)
+	return deferScript.Run(context.Background(), q.client,
+		[]string{keys.KeyTaskQueueDelayed(), keys.KeyTaskQueueWaiting(key)},
+		member, until.UnixMilli(), item.ID, (2 * time.Until(until)).Milliseconds(),
+	).Err()
+}
+
+// Wake 恢复所有等待 key 的挂起任务
+func (q *RedisTaskQueue) Wake(key string) error {
+	keyList, args := q.scriptArgs(keys.KeyTaskQueueDelayed(), 0)
+	keyList = append(keyList, keys.KeyTaskQueueWaiting(key))
+	return wakeScript.Run(context.Background(), q.client, keyList, args...).Err()
+}
+
+// Stop 停止拉取任务，等待正在执行的任务完成后注销 Worker
+func (q *RedisTaskQueue) Stop() {
+	q.stopOnce.Do(func() {
+		q.cancel()
+		q.wg.Wait()
+		q.deregister()
+	})
+}
+
+// workerLoop 循环拉取任务并执行
+func (q *RedisTaskQueue) workerLoop(id int) {
+	defer q.wg.Done()
+
+	for {
+		if q.ctx.Err() != nil {
+			return
+		}
+
+		member, item, err := q.pop()
+		if err != nil {
+			q.logger.Error("❌ [RedisQueue] Pop task failed", err)
+		}
+		if err != nil || member == "" {
+			select {
+			case <-q.ctx.Done():
+				return
+			case <-time.After(q.pollInterval):
+			}
+			continue
+		}
+
+		q.logger.Info(fmt.Sprintf("🧵 [RedisQueue] Worker %s-%d handling job: %s (priority=%d)", q.workerID, id, item.Name, item.Priority))
+		q.process(member, item)
+	}
+}
+
+// process 执行任务，期间定期续期可见性超时，完成后从 processing 集合中确认移除
+func (q *RedisTaskQueue) process(member string, item TaskItem) {
+	done := make(chan struct{})
+	go q.extendLoop(member, item, done)
+
+	if q.handler != nil {
+		q.handler(item)
+	}
+	close(done)
+
+	if err := q.client.ZRem(context.Background(), keys.KeyTaskQueueProcessing(), member).Err(); err != nil {
+		q.logger.Error("❌ [RedisQueue] Ack task failed", "name", item.Name, "id", item.ID, err)
+	}
+}
+
+// extendLoop 任务执行期间续期可见性超时
+func (q *RedisTaskQueue) extendLoop(member string, item TaskItem, done <-chan struct{}) {
+	ticker := time.NewTicker(q.visibilityTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			changed, err := q.client.ZAddArgs(context.Background(), keys.KeyTaskQueueProcessing(), redis.ZAddArgs{
+				XX:      true,
+				Ch:      true,
+				Members: []redis.Z{{Score: float64(q.deadline().UnixMilli()), Member: member}},
+			}).Result()
+			if err != nil {
+				q.logger.Error("❌ [RedisQueue] Extend visibility timeout failed", "name", item.Name, "id", item.ID, err)
+			} else if changed == 0 {
+				q.logger.Warn("⚠️ [RedisQueue] Task is no longer owned by this worker, it may be executed again", "name", item.Name, "id", item.ID)
+			}
+		}
+	}
+}
+
+// pop 取出一个任务，队列为空时返回空字符串
+func (q *RedisTaskQueue) pop() (string, TaskItem, error) {
+	keyList, args := q.scriptArgs(keys.KeyTaskQueueProcessing(), q.deadline().UnixMilli())
+	res, err := popScript.Run(q.ctx, q.client, keyList, args...).Result()
+	if err == redis.Nil {
+		return "", TaskItem{}, nil
+	}
+	if err != nil {
+		if q.ctx.Err() != nil {
+			return "", TaskItem{}, nil
+		}
+		return "", TaskItem{}, err
+	}
+
+	member, _ := res.(string)
+	sep := strings.IndexByte(member, '|')
+	if sep < 0 {
+		return "", TaskItem{}, fmt.Errorf("malformed queue member: %s", member)
+	}
+
+	var item TaskItem
+	if err := json.Unmarshal([]byte(member[sep+1:]), &item); err != nil {
+		// 无法解析的任务直接丢弃，避免反复投递
+		_ = q.client.ZRem(context.Background(), keys.KeyTaskQueueProcessing(), member).Err()
+		return "", TaskItem{}, fmt.Errorf("decode queue item: %w", err)
+	}
+	return member, item, nil
+}
+
+// Requeue 将可见性超时的任务放回队列，返回放回的数量
+func (q *RedisTaskQueue) Requeue(ctx context.Context) (int64, error) {
+	keyList, args := q.scriptArgs(keys.KeyTaskQueueProcessing(), time.Now().UnixMilli())
+	return requeueScript.Run(ctx, q.client, keyList, args...).Int64()
+}
+
+// PromoteDeferred 将挂起到期的任务放回队列，返回放回的数量
+func (q *RedisTaskQueue) PromoteDeferred(ctx context.Context) (int64, error) {
+	keyList, args := q.scriptArgs(keys.KeyTaskQueueDelayed(), time.Now().UnixMilli())
+	return requeueScript.Run(ctx, q.client, keyList, args...).Int64()
+}
+
+// reaperLoop 定期回收失联 Worker 持有的任务，并恢复挂起到期的任务
+func (q *RedisTaskQueue) reaperLoop() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.visibilityTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := q.Requeue(q.ctx)
+			if err != nil && q.ctx.Err() == nil {
+				q.logger.Error("❌ [RedisQueue] Requeue expired tasks failed", err)
+			} else if n > 0 {
+				q.logger.Warn("♻️ [RedisQueue] Requeued expired tasks", "count", n)
+			}
+			if _, err := q.PromoteDeferred(q.ctx); err != nil && q.ctx.Err() == nil {
+				q.logger.Error("❌ [RedisQueue] Promote deferred tasks failed", err)
+			}
+		}
+	}
+}
+
+// heartbeatLoop 定期上报 Worker 心跳
+func (q *RedisTaskQueue) heartbeatLoop() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(workerHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-ticker.C:
+			q.register()
+		}
+	}
+}
+
+// register 注册 Worker 并刷新心跳
+func (q *RedisTaskQueue) register() {
+	ctx := context.Background()
+	host, _ := os.Hostname()
+
+	pipe := q.client.TxPipeline()
+	pipe.SAdd(ctx, keys.KeyWorkersRegistered(), q.workerID)
+	pipe.Set(ctx, keys.KeyWorkerHeartbeat(q.workerID), time.Now().Unix(), keys.TTLHeartbeat*time.Second)
+	pipe.HSet(ctx, keys.KeyWorkerInfo(q.workerID), map[string]any{
+		"hostname":    host,
+		"concurrency": q.workerNum,
+	})
+	pipe.Expire(ctx, keys.KeyWorkerInfo(q.workerID), keys.TTLHeartbeat*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		q.logger.Error("❌ [RedisQueue] Worker heartbeat failed", "worker_id", q.workerID, err)
+	}
+}
+
+// deregister 注销 Worker
+func (q *RedisTaskQueue) deregister() {
+	ctx := context.Background()
+	pipe := q.client.TxPipeline()
+	pipe.SRem(ctx, keys.KeyWorkersRegistered(), q.workerID)
+	pipe.Del(ctx, keys.KeyWorkerHeartbeat(q.workerID), keys.KeyWorkerInfo(q.workerID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		q.logger.Error("❌ [RedisQueue] Worker deregister failed", "worker_id", q.workerID, err)
+	}
+}
+
+// scriptArgs 构造 Lua 脚本的 KEYS 与 ARGV：KEYS[1] 为 set（processing 或 delayed），其后为各个桶
+func (q *RedisTaskQueue) scriptArgs(set string, first int64) ([]string, []any) {
+	keyList := []string{set}
+	args := []any{first}
+	for _, bucket := range queueBuckets {
+		keyList = append(keyList, keys.KeyTaskQueue(bucket))
+		args = append(args, bucket)
+	}
+	return keyList, args
+}
+
+func (q *RedisTaskQueue) deadline() time.Time {
+	return time.Now().Add(q.visibilityTimeout)
+}
+
+// priorityBucket 将数值优先级映射到队列桶：大于 0 为 high，小于 0 为 low，其余为 normal
+func priorityBucket(priority int) string {
+	switch {
+	case priority > 0:
+		return keys.PriorityHigh
+	case priority < 0:
+		return keys.PriorityLow
+	default:
+		return keys.PriorityNormal
+	}
+}
@@ -0,0 +1,185 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	keys "github.com/iceymoss/go-task/pkg/db/key"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedis(t *testing.T) *redis.Client {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// newIdleRedisQueue 创建不启动 worker 的队列，用于单独测试入队/出队
+func newIdleRedisQueue(client *redis.Client, visibility time.Duration) *RedisTaskQueue {
+	return &RedisTaskQueue{
+		client:            client,
+		workerID:          "idle",
+		visibilityTimeout: visibility,
+		pollInterval:      10 * time.Millisecond,
+		logger:            NewDefaultLogger(),
+		ctx:               context.Background(),
+	}
+}
+
+// 测试挂起的任务保存在 Redis 中：到期或被唤醒前不会被取出，已被取出的任务不会因唤醒重复入队
+func TestRedisTaskQueueDeferAndWake(t *testing.T) {
+	client := newTestRedis(t)
+	q := newIdleRedisQueue(client, time.Minute)
+	ctx := context.Background()
+
+	waiting := TaskItem{ID: "w", Name: "waiting", EnqueuedAt: time.Now()}
+	require.NoError(t, q.Defer("job:waiting", waiting, time.Now().Add(time.Hour)))
+	require.NoError(t, q.Enqueue(TaskItem{ID: "n", Name: "next"}))
+
+	member, item, err := q.pop()
+	require.NoError(t, err)
+	assert.Equal(t, "next", item.Name, "挂起的任务不会被取出")
+	require.NoError(t, client.ZRem(ctx, keys.KeyTaskQueueProcessing(), member).Err())
+	member, _, err = q.pop()
+	require.NoError(t, err)
+	assert.Empty(t, member)
+
+	require.NoError(t, q.Wake("job:waiting"))
+	member, item, err = q.pop()
+	require.NoError(t, err)
+	require.NotEmpty(t, member)
+	assert.Equal(t, "w", item.ID)
+	assert.Equal(t, 1, item.Deferred)
+
+	// 再次挂起后的成员与处理中的成员不同，确认处理中的成员不影响挂起的任务
+	require.NoError(t, q.Defer("job:waiting", item, time.Now().Add(-time.Millisecond)))
+	require.NoError(t, client.ZRem(ctx, keys.KeyTaskQueueProcessing(), member).Err())
+	n, err := q.PromoteDeferred(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "挂起到期后放回队列")
+	_, item, err = q.pop()
+	require.NoError(t, err)
+	assert.Equal(t, 2, item.Deferred)
+
+	// 已被取出的任务不会因唤醒重复入队
+	require.NoError(t, q.Wake("job:waiting"))
+	member, _, err = q.pop()
+	require.NoError(t, err)
+	assert.Empty(t, member)
+}
+
+// 测试按优先级出队，同一优先级先进先出
+func TestRedisTaskQueuePriority(t *testing.T) {
+	client := newTestRedis(t)
+	q := newIdleRedisQueue(client, time.Minute)
+
+	base := time.Now()
+	require.NoError(t, q.Enqueue(TaskItem{ID: "1", Name: "low", Priority: -1, EnqueuedAt: base}))
+	require.NoError(t, q.Enqueue(TaskItem{ID: "2", Name: "normal-1", Priority: 0, EnqueuedAt: base.Add(time.Millisecond)}))
+	require.NoError(t, q.Enqueue(TaskItem{ID: "3", Name: "high", Priority: 5, EnqueuedAt: base.Add(2 * time.Millisecond)}))
+	require.NoError(t, q.Enqueue(TaskItem{ID: "4", Name: "normal-2", Priority: 0, EnqueuedAt: base.Add(3 * time.Millisecond)}))
+
+	var names []string
+	for {
+		member, item, err := q.pop()
+		require.NoError(t, err)
+		if member == "" {
+			break
+		}
+		names = append(names, item.Name)
+	}
+	assert.Equal(t, []string{"high", "normal-1", "normal-2", "low"}, names)
+
+	// 取出但未确认的任务都在 processing 集合中
+	n, err := client.ZCard(context.Background(), keys.KeyTaskQueueProcessing()).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), n)
+}
+
+// 测试 Worker 失联后任务超过可见性超时会被重新投递
+func TestRedisTaskQueueRequeueExpired(t *testing.T) {
+	client := newTestRedis(t)
+	q := newIdleRedisQueue(client, 50*time.Millisecond)
+
+	require.NoError(t, q.Enqueue(TaskItem{ID: "1", Name: "job", Priority: 5}))
+	member, item, err := q.pop()
+	require.NoError(t, err)
+	require.NotEmpty(t, member)
+
+	// 未超时前不会被回收
+	n, err := q.Requeue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	time.Sleep(100 * time.Millisecond)
+	n, err = q.Requeue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, again, err := q.pop()
+	require.NoError(t, err)
+	assert.Equal(t, item.ID, again.ID, "超时任务应回到原来的优先级队列")
+	assert.Equal(t, "job", again.Name)
+}
+
+// 测试多个节点共享同一个队列，每个任务只被执行一次
+func TestRedisTaskQueueSharedWorkers(t *testing.T) {
+	client := newTestRedis(t)
+
+	var mu sync.Mutex
+	handled := make(map[string]string)
+	var wg sync.WaitGroup
+	const total = 20
+	wg.Add(total)
+
+	newNode := func(id string) *RedisTaskQueue {
+		return NewRedisTaskQueue(client, func(item TaskItem) {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, dup := handled[item.ID]; dup {
+				t.Errorf("task %s handled twice", item.ID)
+				return
+			}
+			handled[item.ID] = id
+			wg.Done()
+		}, 2, NewDefaultLogger(), WithWorkerID(id), WithQueuePollInterval(5*time.Millisecond))
+	}
+	nodeA := newNode("node-a")
+	nodeB := newNode("node-b")
+
+	workers, err := client.SMembers(context.Background(), keys.KeyWorkersRegistered()).Result()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"node-a", "node-b"}, workers)
+
+	for i := 0; i < total; i++ {
+		require.NoError(t, nodeA.Enqueue(TaskItem{ID: fmt.Sprint(i), Name: "job"}))
+	}
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tasks were not handled in time")
+	}
+
+	nodeA.Stop()
+	nodeB.Stop()
+
+	assert.Len(t, handled, total)
+	n, err := client.ZCard(context.Background(), keys.KeyTaskQueueProcessing()).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "执行完成的任务应被确认移除")
+
+	workers, err = client.SMembers(context.Background(), keys.KeyWorkersRegistered()).Result()
+	require.NoError(t, err)
+	assert.Empty(t, workers, "停止后 Worker 应被注销")
+}
@@ -2,6 +2,8 @@ package engine
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/iceymoss/go-task/internal/core"
@@ -9,25 +11,30 @@ import (
 
 // TaskRegistry 任务模板注册表 (只管名字和构造函数的映射)
 type TaskRegistry struct {
-	creators map[string]core.TaskCreator
+	creators map[string]core.TaskerCreator
 	mu       sync.RWMutex
 }
 
 func NewTaskRegistry() *TaskRegistry {
 	return &TaskRegistry{
-		creators: make(map[string]core.TaskCreator),
+		creators: make(map[string]core.TaskerCreator),
 	}
 }
 
-// Register 注册一个任务模板
+// Register 注册一个旧接口 core.Task 的任务模板，执行时通过适配器按 Tasker 调用
 func (r *TaskRegistry) Register(name string, creator core.TaskCreator) {
+	r.RegisterTasker(name, core.AdaptCreator(creator))
+}
+
+// RegisterTasker 注册一个 Tasker 任务模板
+func (r *TaskRegistry) RegisterTasker(name string, creator core.TaskerCreator) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.creators[name] = creator
 }
 
 // Get 获取任务模板的构造函数
-func (r *TaskRegistry) Get(name string) (core.TaskCreator, error) {
+func (r *TaskRegistry) Get(name string) (core.TaskerCreator, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	creator, ok := r.creators[name]
@@ -36,3 +43,25 @@ func (r *TaskRegistry) Get(name string) (core.TaskCreator, error) {
 	}
 	return creator, nil
 }
+
+// Metadata 返回所有任务模板的元数据（不含工作流），按注册名排序，供前端生成参数表单
+func (r *TaskRegistry) Metadata() []core.TaskMetadata {
+	r.mu.RLock()
+	creators := make(map[string]core.TaskerCreator, len(r.creators))
+	for name, creator := range r.creators {
+		if !strings.HasPrefix(name, workflowTaskPrefix) {
+			creators[name] = creator
+		}
+	}
+	r.mu.RUnlock()
+
+	list := make([]core.TaskMetadata, 0, len(creators))
+	for name, creator := range creators {
+		// 以注册名为准，AddJob 与 YAML 配置都按注册名引用任务模板
+		meta := creator().Metadata()
+		meta.Name = name
+		list = append(list, meta)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hookTask 记录钩子调用顺序的 Tasker
+type hookTask struct {
+	mu    *sync.Mutex
+	calls *[]string
+	fail  bool
+}
+
+func (t *hookTask) record(s string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*t.calls = append(*t.calls, s)
+}
+
+func (t *hookTask) Run(ctx *core.TaskContext, params map[string]any) error {
+	t.record("run:" + ctx.TaskName + ":" + ctx.ExecutionID)
+	if t.fail {
+		return errors.New("boom")
+	}
+	return nil
+}
+func (t *hookTask) Identifier() string { return "test:hook" }
+func (t *hookTask) Metadata() core.TaskMetadata {
+	return core.TaskMetadata{Name: "test:hook", Category: "ops", ParamSchema: core.ParamSchema{Type: "object"}}
+}
+func (t *hookTask) ValidateParams(map[string]any) error { return nil }
+func (t *hookTask) BeforeRun(*core.TaskContext, map[string]any) error {
+	t.record("before")
+	return nil
+}
+func (t *hookTask) AfterRun(_ *core.TaskContext, _ map[string]any, err error) error {
+	t.record("after:" + errString(err))
+	return nil
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	return err.Error()
+}
+
+// 测试调度器原生执行 Tasker：按 BeforeRun、Run、AfterRun 顺序调用，任务上下文携带任务名与执行ID
+func TestSchedulerRunsTasker(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	registry := NewTaskRegistry()
+	registry.RegisterTasker("hook", func() core.Tasker { return &hookTask{mu: &mu, calls: &calls} })
+
+	s := NewScheduler(registry, WithWorkerNum(1))
+	t.Cleanup(s.Stop)
+	require.NoError(t, s.AddJob("@every 1h", "hook", "hook-job", nil, "TEST", nil))
+
+	done := make(chan struct{})
+	s.EventManager.OnFunc(EventTypeAfterJob, func(*Event) { close(done) })
+	execID, err := s.ManualRun("hook-job")
+	require.NoError(t, err)
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("任务未在预期时间内完成")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"before", "run:hook-job:" + execID, "after:ok"}, calls)
+}
+
+// 测试注册表元数据：Tasker 使用自身元数据，旧任务经适配器只暴露名称，工作流不列出
+func TestRegistryMetadata(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	registry := NewTaskRegistry()
+	registry.RegisterTasker("test:hook", func() core.Tasker { return &hookTask{mu: &mu, calls: &calls} })
+	registry.Register("legacy", func() core.Task { return &countingTask{runs: new(int64)} })
+	registry.Register(WorkflowTaskName("wf"), func() core.Task { return &countingTask{runs: new(int64)} })
+
+	list := registry.Metadata()
+	require.Len(t, list, 2)
+	assert.Equal(t, "legacy", list[0].Name)
+	assert.Equal(t, "test:hook", list[1].Name)
+	assert.Equal(t, "ops", list[1].Category)
+
+	creator, err := registry.Get("legacy")
+	require.NoError(t, err)
+	task := creator()
+	assert.NoError(t, task.ValidateParams(nil))
+	_, ok := task.(core.TaskDefaults)
+	assert.True(t, ok, "适配器保留旧任务的默认调度配置")
+}
+
+// 测试 AfterRun 的错误只在 Run 成功时返回，BeforeRun 失败时不执行
+func TestExecuteHooks(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	task := &hookTask{mu: &mu, calls: &calls, fail: true}
+	err := core.Execute(&core.TaskContext{TaskName: "a", ExecutionID: "1"}, task, nil)
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, []string{"before", "run:a:1", "after:boom"}, calls)
+}
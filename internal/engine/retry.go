@@ -8,6 +8,8 @@ import (
 	"math/rand"
 	"sync"
 	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
 )
 
 // RetryPolicy 重试策略
@@ -16,8 +18,10 @@ type RetryPolicy struct {
 	InitialDelay      time.Duration // 初始延迟
 	MaxDelay          time.Duration // 最大延迟
 	BackoffMultiplier float64       // 退避乘数
+	LinearBackoff     bool          // 线性退避：第 n 次重试等待 InitialDelay * n，忽略退避乘数
 	JitterEnabled     bool          // 是否启用随机抖动
 	RetryableErrors   []error       // 可重试的错误列表
+	RetryableClasses  []ErrorClass  // 可重试的错误类别（超时、网络错误等）
 }
 
 // DefaultRetryPolicy 默认重试策略
@@ -76,6 +80,14 @@ func (rm *RetryManager) SetPolicy(taskName string, policy *RetryPolicy) {
 	)
 }
 
+// RemovePolicy 移除任务的重试策略
+func (rm *RetryManager) RemovePolicy(taskName string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	delete(rm.policies, taskName)
+}
+
 // GetPolicy 获取重试策略
 func (rm *RetryManager) GetPolicy(taskName string) (*RetryPolicy, bool) {
 	rm.mu.RLock()
@@ -97,10 +109,20 @@ func (rm *RetryManager) ShouldRetry(taskName string, attempt int, err error) boo
 		return false
 	}
 
-	// 如果配置了可重试的错误列表，检查错误是否在列表中
-	if len(policy.RetryableErrors) > 0 {
+	// 任务标记为不可重试的错误
+	if core.IsPermanent(err) {
+		return false
+	}
+
+	// 如果配置了可重试的错误列表或错误类别，只重试命中的错误
+	if len(policy.RetryableErrors) > 0 || len(policy.RetryableClasses) > 0 {
 		for _, retryableErr := range policy.RetryableErrors {
-			if errors.Is(retryableErr, err) {
+			if errors.Is(err, retryableErr) {
+				return true
+			}
+		}
+		for _, class := range policy.RetryableClasses {
+			if matchErrorClass(err, class) {
 				return true
 			}
 		}
@@ -119,6 +141,9 @@ func (rm *RetryManager) CalculateDelay(taskName string, attempt int) time.Durati
 
 	// 指数退避
 	delay := time.Duration(float64(policy.InitialDelay) * math.Pow(policy.BackoffMultiplier, float64(attempt-1)))
+	if policy.LinearBackoff {
+		delay = policy.InitialDelay * time.Duration(attempt)
+	}
 
 	// 限制最大延迟
 	if delay > policy.MaxDelay {
@@ -149,6 +174,11 @@ func (rm *RetryManager) ExecuteWithRetry(taskName string, ctx context.Context, e
 
 		lastErr = err
 
+		// 已被取消或超时的执行不再重试
+		if ctx.Err() != nil {
+			return err
+		}
+
 		// 检查是否应该重试
 		if !rm.ShouldRetry(taskName, attempt, err) {
 			break
@@ -157,8 +187,10 @@ func (rm *RetryManager) ExecuteWithRetry(taskName string, ctx context.Context, e
 		// 计算延迟
 		delay := rm.CalculateDelay(taskName, attempt)
 
+		recordRetry(ctx)
 		rm.logger.Warn("🔄 [Retry] Task failed, will retry",
 			"task", taskName,
+			"exec_id", ExecIDFromContext(ctx),
 			"attempt", attempt,
 			"delay", delay,
 			err,
@@ -169,6 +201,7 @@ func (rm *RetryManager) ExecuteWithRetry(taskName string, ctx context.Context, e
 			rm.em.Emit(&Event{
 				Type:      EventTypeJobRetry,
 				TaskName:  taskName,
+				ExecID:    ExecIDFromContext(ctx),
 				TimeStamp: time.Now(),
 				Context:   ctx,
 				Error:     err,
@@ -221,6 +254,7 @@ func LinearBackoffPolicy(maxAttempts int, delay time.Duration) *RetryPolicy {
 		InitialDelay:      delay,
 		MaxDelay:          time.Duration(maxAttempts) * delay,
 		BackoffMultiplier: 1.0,
+		LinearBackoff:     true,
 		JitterEnabled:     true,
 	}
 }
@@ -2,25 +2,44 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/iceymoss/go-task/internal/core"
+	"github.com/iceymoss/go-task/pkg/logger"
 
 	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
 )
 
 const (
 	defaultTaskTimeout = 2 * time.Hour
 )
 
+// ErrJobNotFound 调度器中不存在该任务
+var ErrJobNotFound = errors.New("job not found")
+
 type JobDefinition struct {
-	creator  core.TaskCreator // 任务实现
-	params   map[string]any   // 任务参数
-	chain    Chain            // 任务链, 可以加入日志，重试，限流，日志，指标，历史记录等操作
-	priority int              // 任务优先级
-	timeout  time.Duration    // 任务超时时间
+	creator  core.TaskerCreator // 任务实现
+	params   map[string]any     // 任务参数
+	chain    Chain              // 任务链, 可以加入日志，重试，限流，日志，指标，历史记录等操作
+	priority int                // 任务优先级
+	timeout  time.Duration      // 任务超时时间
+	entryID  cron.EntryID       // cron 条目ID，为 0 表示没有定时触发
+	misfire  MisfirePolicy      // 错过触发的补偿策略
+	taskName string             // 任务模板名
+	taskID   string             // 任务ID（来自 sys_jobs.id），写入 TaskContext
+	cronExpr string             // 当前生效的 cron 表达式
+	source   string             // 任务来源
+	paused   bool               // 是否已暂停（暂停期间不占用 cron 条目）
+
+	concurrency ConcurrencyPolicy // 并发策略
+	maxPending  int               // queue 策略下最多排队等待的执行数
+	tags        []string          // 标签
+	groups      []string          // 所属分组及其上级分组
 }
 
 type Scheduler struct {
@@ -29,12 +48,32 @@ type Scheduler struct {
 	DependencyManager *DependencyManager       // 任务依赖管理器
 	EventManager      *EventManager            // 事件管理器
 	RetryManager      *RetryManager            // 重试管理器
-	TaskQueue         *TaskQueue               // 任务队列（可选，支持优先级和限流）
+	TaskQueue         Queue                    // 任务队列（可选，支持优先级和限流）
+	queueFactory      QueueFactory             // 任务队列构造函数，默认使用内存队列
+	workerNum         int                      // 队列 worker 数量
+	Workflows         *WorkflowEngine          // 工作流执行引擎
+	workflowStore     WorkflowStore            // 工作流执行记录存储（可选）
+	stateStore        StateStore               // 调度状态存储（可选，用于重启、换主后恢复）
+	executionStore    ExecutionStore           // 执行记录存储（可选）
+	idGenerator       IDGenerator              // 执行ID生成器
+	workerID          string                   // 当前节点标识，写入执行记录
+	cancels           *cancelRegistry          // 本节点执行的取消登记
+	cancelBus         CancelBus                // 取消请求的集群广播（可选）
+	cancelBusStop     context.CancelFunc       // 停止订阅取消请求
+	pendingStop       context.CancelFunc       // 停止 pending 项的心跳与接管巡检
+	recoverMu         sync.Mutex               // 串行化 pending 项的接管，避免换主回调与巡检重复入队
+	concurrencyStore  ConcurrencyStore         // 并发策略的活跃执行登记，默认仅在本节点内生效
 	logger            Logger                   // 日志管理器
+	taskLogger        *zap.Logger              // 任务日志，每次执行附加任务与执行ID后注入 TaskContext
+	progressStore     ProgressStore            // 执行进度存储
+	logSink           LogSink                  // 执行日志存储（可选，捕获任务日志与 stdout/stderr）
+	hostname          string                   // 当前主机名
 	leaderElector     LeaderElector            // 选主器（可选，支持分布式部署）
 	leaderCancel      context.CancelFunc       // 选主停止函数
 	registry          *TaskRegistry            // 调度器持有一个菜单(注册表)
 	jobDefinition     map[string]JobDefinition // 存放具体的任务订单
+	groupLimits       map[string]int           // 分组 -> 同时执行的任务数上限
+	waiters           *waitList                // 挂起等待分组名额或之前的执行结束的执行（队列不支持挂起时使用）
 	mu                sync.RWMutex             // 保护 registered 和任务状态的并发访问
 }
 
@@ -45,18 +84,56 @@ func NewScheduler(registry *TaskRegistry, opts ...Option) *Scheduler {
 		EventManager:      NewEventManager(NewDefaultLogger()),
 		DependencyManager: NewDependencyManager(NewDefaultLogger()),
 		jobDefinition:     make(map[string]JobDefinition),
+		groupLimits:       make(map[string]int),
+		waiters:           newWaitList(),
 		registry:          registry,
+		workerNum:         defaultWorkerNum,
+		idGenerator:       defaultIDGenerator,
+		cancels:           newCancelRegistry(),
+		concurrencyStore:  newMemoryConcurrencyStore(),
+		progressStore:     newMemoryProgressStore(),
+		hostname:          hostname(),
 	}
 
 	// 应用外部传入的 Option (可以覆盖上面的默认值)
 	for _, opt := range opts {
 		opt(scheduler)
 	}
+	if scheduler.logger == nil {
+		scheduler.logger = NewDefaultLogger()
+	}
 
 	scheduler.RetryManager = NewRetryManager(scheduler.EventManager, scheduler.logger)
+	// pkg/logger 的全局日志为包装函数跳过了一层调用栈，直接使用时需要还原
+	if scheduler.taskLogger == nil {
+		scheduler.taskLogger = logger.Logger.WithOptions(zap.AddCallerSkip(-1))
+	}
+
+	scheduler.Workflows = NewWorkflowEngine(registry, scheduler.workflowStore, scheduler.logger)
 
-	// 初始化任务队列（默认 10 个 worker）
-	scheduler.TaskQueue = NewTaskQueue(scheduler.runTaskWithStats, defaultWorkerNum, scheduler.logger)
+	// 初始化任务队列（默认使用 10 个 worker 的内存队列）
+	if scheduler.queueFactory == nil {
+		scheduler.queueFactory = func(handler QueueHandler, workerNum int, log Logger) Queue {
+			return NewTaskQueue(handler, workerNum, log)
+		}
+	}
+	scheduler.TaskQueue = scheduler.queueFactory(scheduler.handleQueueItem, scheduler.workerNum, scheduler.logger)
+
+	// 执行记录中的 Worker ID：Redis 队列沿用队列的 Worker ID，否则使用主机名
+	if w, ok := scheduler.TaskQueue.(interface{ WorkerID() string }); ok {
+		scheduler.workerID = w.WorkerID()
+	} else {
+		scheduler.workerID = defaultInstanceID()
+	}
+
+	// 依赖完成状态变化时同步写入状态存储
+	if scheduler.stateStore != nil {
+		scheduler.DependencyManager.OnStatusChange(func(taskName string, status TaskStatus) {
+			if err := scheduler.stateStore.SaveDependencyStatus(taskName, status); err != nil {
+				scheduler.logger.Error("❌ [State] Save dependency status failed", "task", taskName, err)
+			}
+		})
+	}
 
 	WithDependencyLogger(scheduler.logger)
 
@@ -75,6 +152,7 @@ func NewScheduler(registry *TaskRegistry, opts ...Option) *Scheduler {
 
 	scheduler.EventManager.OnFunc(EventTypeJobSkipped, LoggingEventHandler(scheduler.logger))
 	scheduler.EventManager.OnFunc(EventTypeJobRetry, LoggingEventHandler(scheduler.logger))
+	scheduler.EventManager.OnFunc(EventTypeJobCancelled, LoggingEventHandler(scheduler.logger))
 
 	return scheduler
 }
@@ -85,7 +163,16 @@ type Option func(*Scheduler)
 // WithWorkerNum 配置任务队列的并发 worker 数量
 func WithWorkerNum(num int) Option {
 	return func(s *Scheduler) {
-		s.TaskQueue = NewTaskQueue(s.runTaskWithStats, num, s.logger)
+		if num > 0 {
+			s.workerNum = num
+		}
+	}
+}
+
+// WithQueue 替换任务队列的实现，例如使用 RedisQueueFactory 让多个节点共享执行
+func WithQueue(factory QueueFactory) Option {
+	return func(s *Scheduler) {
+		s.queueFactory = factory
 	}
 }
 
@@ -108,6 +195,54 @@ func WithHistoryStorage(storage HistoryStorage) Option {
 	return func(s *Scheduler) {
 		s.EventManager.OnFunc(EventTypeAfterJob, NewHistoryEventHandler(storage, s.logger))
 		s.EventManager.OnFunc(EventTypeJobError, NewHistoryEventHandler(storage, s.logger))
+		s.EventManager.OnFunc(EventTypeJobCancelled, NewHistoryEventHandler(storage, s.logger))
+		s.EventManager.OnFunc(EventTypeJobSkipped, NewHistoryEventHandler(storage, s.logger))
+	}
+}
+
+// WithWorkflowStore 注入工作流执行记录存储器
+func WithWorkflowStore(store WorkflowStore) Option {
+	return func(s *Scheduler) {
+		s.workflowStore = store
+	}
+}
+
+// WithStateStore 注入调度状态存储，用于在重启或换主后恢复队列、依赖状态并补跑错过的周期
+func WithStateStore(store StateStore) Option {
+	return func(s *Scheduler) {
+		s.stateStore = store
+	}
+}
+
+// WithExecutionStore 注入执行记录存储，每次分发都会写入一条执行记录并随执行推进更新状态
+func WithExecutionStore(store ExecutionStore) Option {
+	return func(s *Scheduler) {
+		s.executionStore = store
+	}
+}
+
+// WithIDGenerator 替换执行ID生成器（默认 UUID），例如使用 WUID
+func WithIDGenerator(gen IDGenerator) Option {
+	return func(s *Scheduler) {
+		if gen != nil {
+			s.idGenerator = gen
+		}
+	}
+}
+
+// WithCancelBus 注入取消请求的集群广播，使取消可以到达实际持有执行的节点
+func WithCancelBus(bus CancelBus) Option {
+	return func(s *Scheduler) {
+		s.cancelBus = bus
+	}
+}
+
+// WithConcurrencyStore 注入并发策略的活跃执行登记，集群部署时使用 Redis 实现使策略在所有节点间生效
+func WithConcurrencyStore(store ConcurrencyStore) Option {
+	return func(s *Scheduler) {
+		if store != nil {
+			s.concurrencyStore = store
+		}
 	}
 }
 
@@ -128,14 +263,17 @@ func WithLogger(log Logger) Option {
 }
 
 // buildDefaultChain 为任务构建默认的执行链：
-// Logging + Metrics + RetryWithPolicy + Timeout
-func (s *Scheduler) buildDefaultChain(taskName string) Chain {
+// Logging + Metrics + RetryWithPolicy + Timeout，超时作用于每一次尝试，<=0 时使用默认超时
+func (s *Scheduler) buildDefaultChain(taskName string, timeout time.Duration) Chain {
+	if timeout <= 0 {
+		timeout = defaultTaskTimeout
+	}
 	return Chain{}.
 		Then(
 			Logging(taskName, s.logger),
 			Metrics(taskName, s.logger),
 			RetryWithPolicy(s.RetryManager, taskName),
-			Timeout(defaultTaskTimeout),
+			Timeout(timeout),
 		)
 }
 
@@ -149,102 +287,394 @@ func (s *Scheduler) buildDefaultChain(taskName string) Chain {
 //   - uniqueJobName: 任务实例的全系统唯一标识 (Instance ID)，如 "job_ping_baidu"。内核依据此 ID 进行并发隔离、依赖拓扑构建、状态追踪及手动触发。
 //   - params:        该实例的专属运行时参数。执行前会与任务模板自带的 DefaultParams 发生合并与覆盖。
 //   - source:        任务来源标记 (如 "SYSTEM", "YAML", "API")，仅用于控制台展示、日志追踪与运维审计。
+//   - opts:          超时、重试、优先级、并发策略与标签等执行配置，为 nil 时使用默认值；
+//     同名任务重新注册且 opts 为 nil 时沿用原有配置。
 //
 // 返回值:
 //   - error: 当传入的 taskName 在注册表中不存在，或 cronExpr 语法解析失败时，将拒绝挂载并返回错误。
-func (s *Scheduler) AddJob(cronExpr, taskName string, uniqueJobName string, params map[string]any, source string) error {
+func (s *Scheduler) AddJob(cronExpr, taskName string, uniqueJobName string, params map[string]any, source string, opts *JobOptions) error {
 	// 获取任务实现
 	creator, err := s.registry.Get(taskName)
 	if err != nil {
 		return err
 	}
 
-	// 初始化状态
-	s.Stats.Set(uniqueJobName, &JobStats{
-		Name:       uniqueJobName,
-		CronExpr:   cronExpr,
-		Status:     Idle,
-		LastResult: LastResultPending,
-		Source:     source,
-	})
-
-	// 保存引用以便手动触发
 	s.mu.Lock()
-	s.jobDefinition[uniqueJobName] = JobDefinition{
+	old, exists := s.jobDefinition[uniqueJobName]
+	// 暂停中的任务重新注册后保持暂停，只校验表达式，恢复时再挂载
+	paused := exists && old.paused
+	var entryID cron.EntryID
+	if paused {
+		err = s.validateCronExpr(cronExpr)
+	} else {
+		// 先挂载新的 cron 条目，失败时保持原有任务不变
+		entryID, err = s.cron.AddFunc(cronExpr, s.cronWrapper(uniqueJobName))
+	}
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	def := JobDefinition{
 		creator:  creator,
 		params:   params,
-		chain:    s.buildDefaultChain(uniqueJobName),
-		priority: 0,
+		entryID:  entryID,
+		taskName: taskName,
+		cronExpr: cronExpr,
+		source:   source,
+		paused:   paused,
+	}
+	if exists {
+		// 同名任务重复注册时替换原有条目，避免叠加出多个 cron 触发
+		s.removeCronEntry(old)
+		def.misfire = old.misfire
+		if opts == nil {
+			def.priority = old.priority
+			def.timeout = old.timeout
+			def.concurrency = old.concurrency
+			def.maxPending = old.maxPending
+			def.tags = old.tags
+			def.taskID = old.taskID
+			def.groups = old.groups
+		}
 	}
+	if opts != nil {
+		def.priority = opts.Priority
+		def.timeout = opts.Timeout
+		def.concurrency = opts.Concurrency
+		def.maxPending = opts.MaxPending
+		def.tags = opts.Tags
+		def.taskID = opts.TaskID
+		def.groups = opts.Groups
+	}
+	def.chain = s.buildDefaultChain(uniqueJobName, def.timeout)
+	s.jobDefinition[uniqueJobName] = def
 	s.mu.Unlock()
 
-	// 包装执行逻辑
-	wrapper := func() {
-		s.Dispatch(uniqueJobName)
+	if opts != nil && opts.Retry != nil {
+		s.SetRetryPolicy(uniqueJobName, opts.Retry)
 	}
 
-	// 加入 Cron 底层任务调度器中，负责任务调度
-	entryID, err := s.cron.AddFunc(cronExpr, wrapper)
-	if err == nil {
-		stat, ok := s.Stats.Get(uniqueJobName)
-		if !ok {
-			return fmt.Errorf("⚠️ [Schedule] Job not jobDefinition: %s", uniqueJobName)
+	// 初始化状态，已存在的任务保留运行统计
+	if exists {
+		s.Stats.Update(uniqueJobName, func(stat *JobStats) {
+			stat.CronExpr = cronExpr
+			stat.Source = source
+			stat.Tags = def.tags
+		})
+	} else {
+		s.Stats.Set(uniqueJobName, &JobStats{
+			Name:       uniqueJobName,
+			CronExpr:   cronExpr,
+			Status:     Idle,
+			LastResult: LastResultPending,
+			Source:     source,
+			Tags:       def.tags,
+		})
+	}
+
+	if !paused {
+		s.refreshNextRun(uniqueJobName, entryID)
+	}
+	return nil
+}
+
+// cronWrapper 包装 cron 触发时的执行逻辑
+func (s *Scheduler) cronWrapper(name string) func() {
+	return func() {
+		s.dispatchScheduled(name)
+	}
+}
+
+// refreshNextRun 从 cron 条目同步下次执行时间
+func (s *Scheduler) refreshNextRun(name string, entryID cron.EntryID) {
+	next := s.cron.Entry(entryID).Next
+	s.Stats.Update(name, func(stat *JobStats) {
+		stat.RawNext = next
+		if !next.IsZero() {
+			stat.NextRunTime = next.Format("2006-01-02 15:04:05")
+		}
+	})
+}
+
+// AddWorkflow 将工作流注册为一个可调度的任务：
+// 工作流会以 "workflow:<id>" 的模板名注册到 TaskRegistry，之后与普通任务一样由 cron 触发、
+// 经过队列、重试与超时链执行；cronExpr 为空时仅注册，可通过 ManualRun 手动触发。
+func (s *Scheduler) AddWorkflow(cronExpr string, def *WorkflowDefinition, source string) error {
+	if def == nil || def.DAG == nil {
+		return ErrInvalidWorkflow
+	}
+	local := *def
+	local.DAG = def.DAG.clone()
+	if err := local.Validate(); err != nil {
+		return err
+	}
+
+	name := WorkflowTaskName(def.WorkflowID)
+	s.registry.RegisterTasker(name, func() core.Tasker {
+		return core.NewCompositeTask(local.WorkflowID, &local, local.GlobalParams, s.Workflows)
+	})
+	// 工作流的重试由 FailureStrategy 控制，外层执行链不再重复重试
+	s.SetRetryPolicy(name, NoRetryPolicy())
+
+	if cronExpr == "" {
+		creator, _ := s.registry.Get(name)
+		s.Stats.Set(name, &JobStats{
+			Name:       name,
+			Status:     Idle,
+			LastResult: LastResultPending,
+			Source:     source,
+		})
+		s.mu.Lock()
+		// 之前带 cron 注册过的同名工作流不再定时触发
+		if old, exists := s.jobDefinition[name]; exists {
+			s.removeCronEntry(old)
+		}
+		s.jobDefinition[name] = JobDefinition{
+			creator:  creator,
+			params:   local.GlobalParams,
+			chain:    s.buildDefaultChain(name, 0),
+			taskName: name,
+			source:   source,
+		}
+		s.mu.Unlock()
+		return nil
+	}
+	return s.AddJob(cronExpr, name, name, local.GlobalParams, source, nil)
+}
+
+// newQueueItem 构造一个待入队的任务，并分配执行ID
+func (s *Scheduler) newQueueItem(name string, priority int, trigger string) TaskItem {
+	return TaskItem{
+		ID:         s.idGenerator.NextID(),
+		Name:       name,
+		Priority:   priority,
+		EnqueuedAt: time.Now(),
+		Trigger:    trigger,
+	}
+}
+
+// enqueue 入队任务；使用非持久化队列时先记录到状态存储，重启后可以恢复
+func (s *Scheduler) enqueue(item TaskItem) error {
+	track := s.trackPending()
+	if track {
+		if err := s.stateStore.SavePendingItem(s.workerID, item); err != nil {
+			s.logger.Error("❌ [State] Save pending item failed", "name", item.Name, err)
+		}
+	}
+	if err := s.TaskQueue.Enqueue(item); err != nil {
+		if track {
+			_ = s.stateStore.RemovePendingItem(item.ID)
 		}
-		stat.RawNext = s.cron.Entry(entryID).Next
-		stat.NextRunTime = stat.RawNext.Format("2006-01-02 15:04:05")
+		s.finishExecution(item, ExecutionStatusCancelled, err)
+		s.releaseConcurrency(item)
+		return err
 	}
-	return err
+	return nil
+}
+
+// trackPending 是否需要在状态存储中记录待执行的队列项
+func (s *Scheduler) trackPending() bool {
+	if s.stateStore == nil {
+		return false
+	}
+	if dq, ok := s.TaskQueue.(durableQueue); ok && dq.Durable() {
+		return false
+	}
+	return true
+}
+
+// handleQueueItem 队列 worker 取出任务后的处理函数
+func (s *Scheduler) handleQueueItem(item TaskItem) {
+	if s.cancels.isCancelled(item) {
+		s.removePendingItem(item)
+		s.skipCancelled(item)
+		s.releaseConcurrency(item)
+		return
+	}
+	// queue、replace 策略下之前的执行尚未结束、或分组并发已满时挂起，等待期间保留 pending 记录；
+	// 任务自身的并发登记保留到真正执行结束
+	if ahead := s.concurrencyAhead(item); ahead > 0 {
+		s.waitConcurrencyTurn(item, ahead)
+		return
+	}
+	groups, full, ok := s.admitGroups(item)
+	if !ok {
+		s.waitGroupSlot(item, full)
+		return
+	}
+	s.removePendingItem(item)
+	defer s.releaseGroups(item, groups)
+	defer s.releaseConcurrency(item)
+
+	if err := s.checkItemEpoch(item); err != nil {
+		s.logger.Warn("🚫 [Schedule] Reject job dispatched by stale leader", "name", item.Name, "exec_id", item.ID, "epoch", item.Epoch, err)
+		s.finishExecution(item, ExecutionStatusCancelled, err)
+		s.EventManager.Emit(&Event{
+			Type:      EventTypeJobSkipped,
+			TaskName:  item.Name,
+			ExecID:    item.ID,
+			TimeStamp: time.Now(),
+			Error:     err,
+			Data:      map[string]any{"reason": "stale_epoch", "epoch": item.Epoch},
+		})
+		return
+	}
+	s.runTaskWithStats(item)
+}
+
+// removePendingItem 队列项开始执行或被跳过后移除状态存储中的 pending 记录
+func (s *Scheduler) removePendingItem(item TaskItem) {
+	if !s.trackPending() {
+		return
+	}
+	if err := s.stateStore.RemovePendingItem(item.ID); err != nil {
+		s.logger.Error("❌ [State] Remove pending item failed", "name", item.Name, err)
+	}
+}
+
+// skipCancelled 跳过在队列中已被取消的任务
+func (s *Scheduler) skipCancelled(item TaskItem) {
+	s.logger.Info("🛑 [Schedule] Skip cancelled job", "name", item.Name, "exec_id", item.ID)
+	s.finishExecution(item, ExecutionStatusCancelled, ErrExecutionCancelled)
+	s.Stats.Update(item.Name, func(stat *JobStats) {
+		if stat.Status == Queued {
+			stat.Status = Idle
+		}
+		stat.LastResult = LastResultCancelled
+	})
+	s.EventManager.Emit(&Event{
+		Type:      EventTypeJobCancelled,
+		TaskName:  item.Name,
+		ExecID:    item.ID,
+		TimeStamp: time.Now(),
+		Error:     ErrExecutionCancelled,
+		Data:      map[string]any{"reason": "cancelled_in_queue"},
+	})
+}
+
+// finishExecution 将未能执行的队列项标记为结束
+func (s *Scheduler) finishExecution(item TaskItem, status ExecutionStatus, err error) {
+	now := time.Now()
+	exec := &Execution{
+		ExecID:        item.ID,
+		JobName:       item.Name,
+		Status:        status,
+		TriggerSource: item.Trigger,
+		WorkerID:      s.workerID,
+		ScheduledAt:   item.EnqueuedAt,
+		FinishedAt:    &now,
+	}
+	if err != nil {
+		exec.ErrorMessage = err.Error()
+	}
+	s.updateExecution(exec)
+}
+
+// checkItemEpoch 拒绝旧 Leader 失去锁之后才分发的任务：
+// 旧任期的任务只要在下一任 Leader 接管之前入队就是合法分发，换主后照常执行，
+// 接管之后才入队的说明旧 Leader 已失去锁，由新 Leader 负责本周期的分发
+func (s *Scheduler) checkItemEpoch(item TaskItem) error {
+	if item.Epoch == 0 || s.leaderElector == nil {
+		return nil
+	}
+	ctx := context.Background()
+	latest, err := s.leaderElector.LatestEpoch(ctx)
+	if err != nil {
+		// 无法确认任期时保守拒绝，由新 Leader 在下个周期重新分发
+		return err
+	}
+	if item.Epoch >= latest {
+		return nil
+	}
+	takeover, err := s.leaderElector.EpochStartedAt(ctx, item.Epoch+1)
+	if err != nil {
+		return err
+	}
+	if !takeover.IsZero() && item.EnqueuedAt.Before(takeover) {
+		return nil
+	}
+	return fmt.Errorf("%w: item epoch %d, latest %d", ErrStaleEpoch, item.Epoch, latest)
 }
 
 // runTaskWithStats 执行并记录状态
-func (s *Scheduler) runTaskWithStats(name string) {
+func (s *Scheduler) runTaskWithStats(item TaskItem) {
+	name := item.Name
+	execID := item.ID
+
 	// 读取注册信息
 	s.mu.RLock()
 	reg, ok := s.jobDefinition[name]
 	s.mu.RUnlock()
 	if !ok {
-		s.logger.Info("⚠️ [Schedule] Job not jobDefinition", "name", name)
+		s.logger.Info("⚠️ [Schedule] Job not jobDefinition", "name", name, "exec_id", execID)
+		s.finishExecution(item, ExecutionStatusCancelled, ErrJobNotFound)
 		return
 	}
 
 	task := reg.creator()
 	params := reg.params
 	chain := reg.chain
-	timeout := reg.timeout
-	if timeout <= 0 {
-		timeout = defaultTaskTimeout
-	}
 
-	stat, ok := s.Stats.Get(name)
-	if !ok {
-		s.logger.Info("⚠️ [Schedule] Job not jobDefinition", "name", name)
+	if _, ok := s.Stats.Get(name); !ok {
+		s.logger.Info("⚠️ [Schedule] Job not jobDefinition", "name", name, "exec_id", execID)
+		s.finishExecution(item, ExecutionStatusCancelled, ErrJobNotFound)
 		return
 	}
-	ctx := context.Background()
+	ctx, info := withExecInfo(context.Background(), execID)
 
 	// 发射任务开始事件
 	s.EventManager.Emit(&Event{
 		Type:      EventTypeBeforeJob,
 		TaskName:  name,
+		ExecID:    execID,
 		TimeStamp: time.Now(),
 		Context:   ctx,
 	})
 
 	// 更新为运行状态
-	stat.Status = Running
-	stat.RunCount++
+	s.Stats.Update(name, func(stat *JobStats) {
+		stat.Status = Running
+		stat.RunCount++
+		stat.LastExecID = execID
+	})
 
-	s.logger.Info("🚀 [Schedule] Starting job", "name", name)
+	s.logger.Info("🚀 [Schedule] Starting job", "name", name, "exec_id", execID)
 
-	// 执行 (带超时控制)
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	// 登记取消函数，CancelExecution/KillJob 可以中止本次执行
+	ctx, cancelRun := context.WithCancelCause(ctx)
+	s.cancels.register(execID, name, cancelRun)
+	defer func() {
+		s.cancels.unregister(execID)
+		cancelRun(nil)
+	}()
+	runCtx := ctx
+
+	// 超时由执行链中的 Timeout 控制，作用于每一次尝试
+	ctx, output := core.WithOutput(ctx)
 
 	startTime := time.Now()
+	exec := &Execution{
+		ExecID:        execID,
+		JobName:       name,
+		Status:        ExecutionStatusRunning,
+		TriggerSource: item.Trigger,
+		WorkerID:      s.workerID,
+		ScheduledAt:   item.EnqueuedAt,
+		StartedAt:     &startTime,
+		MaxRetries:    s.RetryManager.getMaxAttempts(name) - 1,
+	}
+	if exec.ScheduledAt.IsZero() {
+		exec.ScheduledAt = startTime
+	}
+	s.updateExecution(exec)
+	tracker := s.newProgressTracker(name, execID)
+	tracker.start()
+	logs := s.newLogCollector(item, reg)
 
-	// 包装为 JobFunc
+	// 包装为 JobFunc，每次尝试都构造新的任务上下文
 	jobFunc := func(c context.Context) error {
-		return task.Run(c, params)
+		return core.Execute(s.taskContext(c, item, reg, tracker, logs), task, params)
 	}
 
 	// 应用任务链（含重试、日志、指标等）
@@ -252,73 +682,152 @@ func (s *Scheduler) runTaskWithStats(name string) {
 		jobFunc = chain.Apply(jobFunc)
 	}
 
-	err := jobFunc(ctx)
+	// 参数不符合任务的 ParamSchema 时直接失败，不执行也不重试
+	err := core.ValidateTaskParams(task, params)
+	if err == nil {
+		err = jobFunc(ctx)
+	}
 	durationMs := time.Since(startTime).Milliseconds()
+	cancelled := err != nil && errors.Is(context.Cause(runCtx), ErrExecutionCancelled)
+	if cancelled {
+		err = ErrExecutionCancelled
+	}
+	// 执行结束前写完所有日志，日志流读到结束状态时不会遗漏最后的输出
+	logs.finish(err)
 
 	// 更新结束状态
+	finishedAt := time.Now()
+	paused := s.isPaused(name)
+	s.Stats.Update(name, func(stat *JobStats) {
+		stat.RawLastRun = finishedAt
+		stat.LastRunTime = finishedAt.Format("2006-01-02 15:04:05")
+		if cancelled {
+			stat.LastResult = LastResultCancelled
+			stat.Status = Idle
+		} else if err != nil {
+			stat.LastResult = fmt.Sprintf(LastResultError, err)
+			stat.Status = Error
+		} else {
+			stat.LastResult = LastResultSuccess
+			stat.Status = Idle
+		}
+		// 执行期间被暂停的任务，结束后保持暂停状态
+		if paused {
+			stat.Status = Paused
+		}
+	})
+	s.saveJobState(name)
+
+	exec.Status = executionStatusOf(err)
+	tracker.finish(exec.Status)
+	exec.FinishedAt = &finishedAt
+	exec.DurationMs = &durationMs
+	exec.RetryCount = int(atomic.LoadInt32(&info.retries))
+	exec.Result = output.Data()
 	if err != nil {
-		stat.LastResult = fmt.Sprintf(LastResultError, err)
-		stat.Status = Error
+		exec.ErrorMessage = err.Error()
+	}
+	s.updateExecution(exec)
+
+	if cancelled {
+		s.DependencyManager.UpdateTaskStatus(name, false, err)
+		s.logger.Info("🛑 [Schedule] Job cancelled", "name", name, "exec_id", execID)
+
+		s.EventManager.Emit(&Event{
+			Type:      EventTypeJobCancelled,
+			TaskName:  name,
+			ExecID:    execID,
+			TimeStamp: time.Now(),
+			Context:   ctx,
+			Error:     err,
+			Data: map[string]any{
+				"duration_ms": durationMs,
+				"start_time":  startTime,
+				"retry_count": exec.RetryCount,
+				"status":      string(exec.Status),
+			},
+		})
+	} else if err != nil {
 		s.DependencyManager.UpdateTaskStatus(name, false, err)
-		s.logger.Info(fmt.Sprintf("❌ [Schedule] Job failed: %s, err: %v", name, err))
+		s.logger.Info(fmt.Sprintf("❌ [Schedule] Job failed: %s, exec_id: %s, err: %v", name, execID, err))
 
 		s.EventManager.Emit(&Event{
 			Type:      EventTypeJobError,
 			TaskName:  name,
+			ExecID:    execID,
 			TimeStamp: time.Now(),
 			Context:   ctx,
 			Error:     err,
 			Data: map[string]any{
 				"duration_ms": durationMs,
 				"start_time":  startTime,
+				"retry_count": exec.RetryCount,
+				"status":      string(exec.Status),
 			},
 		})
 	} else {
-		stat.LastResult = LastResultSuccess
-		stat.Status = Idle
 		s.DependencyManager.UpdateTaskStatus(name, true, nil)
-		s.logger.Info("✅ [Schedule] Job finished", "name", name)
+		s.logger.Info("✅ [Schedule] Job finished", "name", name, "exec_id", execID)
 
 		s.EventManager.Emit(&Event{
 			Type:      EventTypeAfterJob,
 			TaskName:  name,
+			ExecID:    execID,
 			TimeStamp: time.Now(),
 			Context:   ctx,
 			Data: map[string]any{
 				"duration_ms": durationMs,
 				"start_time":  startTime,
+				"retry_count": exec.RetryCount,
+				"status":      string(exec.Status),
 			},
 		})
 	}
 }
 
-// ManualRun 手动触发
-func (s *Scheduler) ManualRun(uniqueJobName string) error {
+// TaskTypes 返回注册表中所有任务模板的元数据（含参数 Schema）
+func (s *Scheduler) TaskTypes() []core.TaskMetadata {
+	return s.registry.Metadata()
+}
+
+// ManualRun 手动触发，返回本次执行的ID；任务的并发策略拒绝本次触发时返回 ErrConcurrencyLimited
+func (s *Scheduler) ManualRun(uniqueJobName string) (string, error) {
+	s.mu.RLock()
 	reg, ok := s.jobDefinition[uniqueJobName]
+	s.mu.RUnlock()
 	if !ok {
-		return fmt.Errorf("job not found")
+		return "", ErrJobNotFound
 	}
+	item := s.newQueueItem(uniqueJobName, reg.priority, TriggerManual)
+	if err := s.admitConcurrency(&item, reg); err != nil {
+		s.skipByConcurrency(item, reg.concurrency)
+		return item.ID, err
+	}
+	s.createExecution(item)
 	if s.TaskQueue != nil {
-		if err := s.TaskQueue.Enqueue(uniqueJobName, reg.priority); err != nil {
-			return err
+		if err := s.enqueue(item); err != nil {
+			return "", err
 		}
-		return nil
+		return item.ID, nil
 	}
-	go s.runTaskWithStats(uniqueJobName)
-	return nil
+	go s.handleQueueItem(item)
+	return item.ID, nil
 }
 
 // AddJobWithDependency 添加带依赖的任务
-func (s *Scheduler) AddJobWithDependency(cronExpr, taskName string, uniqueJobName string, params map[string]any, source string, dependencyRule *DependencyRule) error {
-	// 先添加依赖规则
-	if dependencyRule != nil {
+func (s *Scheduler) AddJobWithDependency(cronExpr, taskName string, uniqueJobName string, params map[string]any, source string, opts *JobOptions, dependencyRule *DependencyRule) error {
+	// 先添加依赖规则，未声明上游时清除之前注册的规则
+	if dependencyRule != nil && len(dependencyRule.DependsOn) > 0 {
+		dependencyRule.TaskName = uniqueJobName
 		if err := s.DependencyManager.AddDependency(dependencyRule); err != nil {
 			return fmt.Errorf("failed to add dependency: %w", err)
 		}
+	} else {
+		s.DependencyManager.ClearDependency(uniqueJobName)
 	}
 
 	// 添加任务
-	return s.AddJob(cronExpr, taskName, uniqueJobName, params, source)
+	return s.AddJob(cronExpr, taskName, uniqueJobName, params, source, opts)
 }
 
 // GetDependencyChain 获取任务的依赖链
@@ -331,8 +840,38 @@ func (s *Scheduler) GetDependentTasks(taskName string) []string {
 	return s.DependencyManager.GetDependentTasks(taskName)
 }
 
+// dispatchScheduled cron 触发的分发：分布式部署时先校验本实例仍是 Leader，并为任务打上任期
+func (s *Scheduler) dispatchScheduled(name string) {
+	var epoch int64
+	if s.leaderElector != nil {
+		epoch = s.leaderElector.Epoch()
+		if err := s.leaderElector.VerifyEpoch(context.Background(), epoch); err != nil {
+			s.logger.Warn("🚫 [Dispatcher] Not the current leader, skip dispatch", "name", name, "epoch", epoch, err)
+			return
+		}
+	}
+
+	// 记录触发时间，用于重启后计算错过的周期
+	s.mu.RLock()
+	entryID := s.jobDefinition[name].entryID
+	s.mu.RUnlock()
+	now := time.Now()
+	s.Stats.Update(name, func(stat *JobStats) {
+		stat.RawLastScheduled = now
+	})
+	s.refreshNextRun(name, entryID)
+	s.saveJobState(name)
+
+	s.dispatch(name, epoch, TriggerCron)
+}
+
 // Dispatch 尝试分发任务：如果依赖未满足则挂起(标记为Waiting)，否则真正入队
 func (s *Scheduler) Dispatch(name string) {
+	s.dispatch(name, 0, TriggerAPI)
+}
+
+// dispatch 分发任务，epoch 为分发时 Leader 的任期，trigger 为触发来源
+func (s *Scheduler) dispatch(name string, epoch int64, trigger string) {
 	// 统一通过队列执行，便于限流和优先级控制
 	// 加入任务队列后，TaskQueue初始化时开启的worker会自动从队列中获取任务进行处理
 
@@ -342,9 +881,12 @@ func (s *Scheduler) Dispatch(name string) {
 	if !ok {
 		return
 	}
+	if reg.paused {
+		s.logger.Info("⏸️ [Dispatcher] Job is paused, skip dispatch", "name", name)
+		return
+	}
 
-	stat, ok := s.Stats.Get(name)
-	if !ok {
+	if _, ok := s.Stats.Get(name); !ok {
 		s.logger.Info("⚠️ [Schedule] Job not jobDefinition", "name", name)
 		return
 	}
@@ -352,30 +894,71 @@ func (s *Scheduler) Dispatch(name string) {
 	// 无阻塞检查依赖状态
 	satisfied, err := s.DependencyManager.CheckDependencies(name)
 	if err != nil {
-		stat.Status = Error
-		stat.LastResult = fmt.Sprintf(LastResultDependencyCheck, err)
+		s.Stats.Update(name, func(stat *JobStats) {
+			stat.Status = Error
+			stat.LastResult = fmt.Sprintf(LastResultDependencyCheck, err)
+		})
 		s.logger.Info("❌ [Dispatcher] Job dependency check failed", "name", name, err)
 		return
 	}
 
 	// 依赖未满足，仅仅标记为挂起等待,避免不占用 Worker 协程
 	if !satisfied {
-		stat.Status = Waiting
+		s.Stats.Update(name, func(stat *JobStats) {
+			stat.Status = Waiting
+		})
+		if timeout := s.DependencyManager.startWaiting(name); timeout > 0 {
+			time.AfterFunc(timeout, func() { s.expireDependencyWait(name) })
+		}
 		s.logger.Info("⏳ [Dispatcher] Job triggered but waiting for upstream dependencies...", "name", name)
 		return
 	}
+	s.DependencyManager.stopWaiting(name)
+
+	// 按并发策略登记，forbid 仍在执行或 queue 排队已满时跳过本次触发
+	item := s.newQueueItem(name, reg.priority, trigger)
+	item.Epoch = epoch
+	if err := s.admitConcurrency(&item, reg); err != nil {
+		s.skipByConcurrency(item, reg.concurrency)
+		return
+	}
 
 	// 依赖已完全满足，推入真实执行队列
-	stat.Status = Queued
+	s.Stats.Update(name, func(stat *JobStats) {
+		stat.Status = Queued
+	})
+	s.createExecution(item)
 	if s.TaskQueue != nil {
-		if err := s.TaskQueue.Enqueue(name, reg.priority); err != nil {
-			s.logger.Info("⚠️ [Dispatcher] Enqueue job failed", "name", name, err)
+		if err := s.enqueue(item); err != nil {
+			s.logger.Info("⚠️ [Dispatcher] Enqueue job failed", "name", name, "exec_id", item.ID, err)
 		}
 	} else {
-		go s.runTaskWithStats(name)
+		go s.handleQueueItem(item)
 	}
 }
 
+// expireDependencyWait 等待上游超过规则的 Timeout 时放弃本次触发，任务回到 Idle 等待下一次触发
+func (s *Scheduler) expireDependencyWait(name string) {
+	if !s.DependencyManager.waitExpired(name) {
+		return
+	}
+	s.DependencyManager.stopWaiting(name)
+	s.Stats.Update(name, func(stat *JobStats) {
+		if stat.Status == Waiting {
+			stat.Status = Idle
+			stat.LastResult = LastResultDependencyWait
+		}
+	})
+	s.logger.Warn("⌛ [Dispatcher] Upstream dependencies not met in time, skip this trigger", "name", name)
+	s.EventManager.Emit(&Event{
+		Type:      EventTypeJobSkipped,
+		TaskName:  name,
+		TimeStamp: time.Now(),
+		Error:     ErrDependencyTimeout,
+		Data:      map[string]any{"reason": "dependency_timeout"},
+	})
+}
+
 // RegisterEventHandler 注册事件处理器
 func (s *Scheduler) RegisterEventHandler(eventType EventType, handler EventHandler) {
 	s.EventManager.On(eventType, handler)
@@ -392,8 +975,14 @@ func (s *Scheduler) SetRetryPolicy(taskName string, policy *RetryPolicy) {
 }
 
 func (s *Scheduler) Start() {
-	// 如果没有配置 Leader 选举，则保持单机行为：直接启动 cron
+	// 所有节点都要订阅取消请求，执行可能落在任意节点上
+	s.startCancelSubscriber()
+	// 先上报存活标记，避免 Leader 把本节点刚入队的任务当作失联节点的任务接管
+	s.startPendingHeartbeat()
+
+	// 如果没有配置 Leader 选举，则保持单机行为：恢复状态后直接启动 cron
 	if s.leaderElector == nil {
+		s.recoverState()
 		s.cron.Start()
 		return
 	}
@@ -401,6 +990,8 @@ func (s *Scheduler) Start() {
 	// 定义抢到 Leader 和失去 Leader 时的动作
 	onStarted := func() {
 		s.logger.Info("👑 [Scheduler] This instance became leader, starting cron")
+		// 新 Leader 接管前一任留下的状态，并按补偿策略补跑换主期间错过的周期
+		s.recoverState()
 		s.cron.Start()
 	}
 	onStopped := func() {
@@ -423,16 +1014,34 @@ func (s *Scheduler) Stop() {
 	if s.leaderCancel != nil {
 		s.leaderCancel()
 	}
+	if s.cancelBusStop != nil {
+		s.cancelBusStop()
+	}
 	if s.leaderElector != nil {
 		_ = s.leaderElector.Stop(context.Background())
 	}
 
-	s.EventManager.Stop()
-
+	// 先停止触发和执行，等待正在执行的任务发出最后的事件后再关闭事件管理器
 	s.cron.Stop()
 	if s.TaskQueue != nil {
 		s.TaskQueue.Stop()
 	}
+	s.stopPendingHeartbeat()
+
+	s.EventManager.Stop()
+}
+
+// SetMisfirePolicy 为任务设置错过触发的补偿策略
+func (s *Scheduler) SetMisfirePolicy(taskName string, policy MisfirePolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reg, ok := s.jobDefinition[taskName]
+	if !ok {
+		return
+	}
+	reg.misfire = policy
+	s.jobDefinition[taskName] = reg
 }
 
 // SetPriority 为任务设置优先级（数值越大优先级越高）
@@ -0,0 +1,218 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	keys "github.com/iceymoss/go-task/pkg/db/key"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// MisfirePolicy 错过触发（停机、换主期间落空的 cron 周期）的补偿策略
+type MisfirePolicy string
+
+const (
+	MisfirePolicySkip    MisfirePolicy = "skip"     // 忽略错过的周期（默认）
+	MisfirePolicyRunOnce MisfirePolicy = "run_once" // 无论错过多少次，只补跑一次
+	MisfirePolicyRunAll  MisfirePolicy = "run_all"  // 补跑每一个错过的周期
+)
+
+// maxMisfireCatchUp run_all 策略下单个任务最多补跑的次数，避免长时间停机后瞬间堆积
+const maxMisfireCatchUp = 100
+
+// ParseMisfirePolicy 解析配置中的补偿策略，无法识别时返回 skip
+func ParseMisfirePolicy(s string) MisfirePolicy {
+	switch MisfirePolicy(strings.ToLower(strings.TrimSpace(s))) {
+	case MisfirePolicyRunOnce:
+		return MisfirePolicyRunOnce
+	case MisfirePolicyRunAll:
+		return MisfirePolicyRunAll
+	default:
+		return MisfirePolicySkip
+	}
+}
+
+// JobState 需要跨重启保留的任务状态
+type JobState struct {
+	Name            string    `json:"name"`
+	LastScheduledAt time.Time `json:"last_scheduled_at"` // 最近一次 cron 触发时间，用于计算错过的周期
+	LastRunAt       time.Time `json:"last_run_at"`       // 最近一次执行结束时间
+	LastResult      string    `json:"last_result"`
+	RunCount        int64     `json:"run_count"`
+}
+
+// StateStore 调度器状态的持久化接口：任务状态、依赖完成状态以及尚未执行的队列项
+type StateStore interface {
+	SaveJobState(state *JobState) error
+	LoadJobStates() (map[string]*JobState, error)
+
+	SaveDependencyStatus(taskName string, status TaskStatus) error
+	LoadDependencyStatus() (map[string]TaskStatus, error)
+
+	// RemoveJobState 任务移除后清理其调度状态与依赖状态，同名任务重新注册时不会补跑或满足下游依赖
+	RemoveJobState(name string) error
+
+	// SavePendingItem 记录已入队但尚未开始执行的任务及其所在节点，非持久化队列（内存队列）重启后据此恢复
+	SavePendingItem(owner string, item TaskItem) error
+	RemovePendingItem(id string) error
+	LoadPendingItems() ([]PendingItem, error)
+
+	// Heartbeat 刷新节点的存活标记，ttl 内未刷新即视为节点失联
+	Heartbeat(owner string, ttl time.Duration) error
+	// ClearHeartbeat 节点正常退出时清除存活标记，剩余的 pending 项可以立即被接管
+	ClearHeartbeat(owner string) error
+	// IsAlive 节点的存活标记是否仍然有效
+	IsAlive(owner string) (bool, error)
+}
+
+// PendingItem 状态存储中的待执行队列项
+// 队列项只存在于 Owner 节点的内存队列中，Owner 仍然存活时由它自己执行，失联后才由 Leader 接管
+type PendingItem struct {
+	TaskItem
+	Owner string `json:"owner"` // 入队节点的 Worker ID，为空表示旧版本写入的记录
+}
+
+// durableQueue 队列内容本身可以跨重启保留（如 Redis 队列），此时无需额外记录 pending 项
+type durableQueue interface {
+	Durable() bool
+}
+
+// RedisStateStore 基于 Redis Hash 的状态存储，多个节点共享，换主后新 Leader 可以继续使用
+type RedisStateStore struct {
+	client *redis.Client
+}
+
+// 确保 RedisStateStore 实现了 StateStore 接口
+var _ StateStore = (*RedisStateStore)(nil)
+
+// NewRedisStateStore 创建 Redis 状态存储
+func NewRedisStateStore(client *redis.Client) *RedisStateStore {
+	return &RedisStateStore{client: client}
+}
+
+// dependencyStatusRecord TaskStatus 的可序列化形式
+type dependencyStatusRecord struct {
+	Completed  bool      `json:"completed"`
+	Success    bool      `json:"success"`
+	FinishedAt time.Time `json:"finished_at"`
+	Error      string    `json:"error,omitempty"`
+}
+
+func (r *RedisStateStore) SaveJobState(state *JobState) error {
+	return r.hset(keys.KeySchedulerJobState(), state.Name, state)
+}
+
+func (r *RedisStateStore) LoadJobStates() (map[string]*JobState, error) {
+	raw, err := r.client.HGetAll(context.Background(), keys.KeySchedulerJobState()).Result()
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]*JobState, len(raw))
+	for name, v := range raw {
+		var st JobState
+		if err := json.Unmarshal([]byte(v), &st); err != nil {
+			continue
+		}
+		states[name] = &st
+	}
+	return states, nil
+}
+
+func (r *RedisStateStore) SaveDependencyStatus(taskName string, status TaskStatus) error {
+	record := dependencyStatusRecord{
+		Completed:  status.Completed,
+		Success:    status.Success,
+		FinishedAt: status.FinishedAt,
+	}
+	if status.Error != nil {
+		record.Error = status.Error.Error()
+	}
+	return r.hset(keys.KeySchedulerDependencyState(), taskName, record)
+}
+
+func (r *RedisStateStore) LoadDependencyStatus() (map[string]TaskStatus, error) {
+	raw, err := r.client.HGetAll(context.Background(), keys.KeySchedulerDependencyState()).Result()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make(map[string]TaskStatus, len(raw))
+	for name, v := range raw {
+		var record dependencyStatusRecord
+		if err := json.Unmarshal([]byte(v), &record); err != nil {
+			continue
+		}
+		status := TaskStatus{
+			Completed:  record.Completed,
+			Success:    record.Success,
+			FinishedAt: record.FinishedAt,
+		}
+		if record.Error != "" {
+			status.Error = errors.New(record.Error)
+		}
+		statuses[name] = status
+	}
+	return statuses, nil
+}
+
+func (r *RedisStateStore) RemoveJobState(name string) error {
+	ctx := context.Background()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, keys.KeySchedulerJobState(), name)
+		pipe.HDel(ctx, keys.KeySchedulerDependencyState(), name)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisStateStore) SavePendingItem(owner string, item TaskItem) error {
+	return r.hset(keys.KeySchedulerPendingItems(), item.ID, PendingItem{TaskItem: item, Owner: owner})
+}
+
+func (r *RedisStateStore) RemovePendingItem(id string) error {
+	return r.client.HDel(context.Background(), keys.KeySchedulerPendingItems(), id).Err()
+}
+
+func (r *RedisStateStore) LoadPendingItems() ([]PendingItem, error) {
+	raw, err := r.client.HGetAll(context.Background(), keys.KeySchedulerPendingItems()).Result()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]PendingItem, 0, len(raw))
+	for _, v := range raw {
+		var item PendingItem
+		if err := json.Unmarshal([]byte(v), &item); err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Heartbeat 与 Redis 队列的 Worker 心跳共用同一个 key
+func (r *RedisStateStore) Heartbeat(owner string, ttl time.Duration) error {
+	return r.client.Set(context.Background(), keys.KeyWorkerHeartbeat(owner), time.Now().Unix(), ttl).Err()
+}
+
+func (r *RedisStateStore) ClearHeartbeat(owner string) error {
+	return r.client.Del(context.Background(), keys.KeyWorkerHeartbeat(owner)).Err()
+}
+
+func (r *RedisStateStore) IsAlive(owner string) (bool, error) {
+	n, err := r.client.Exists(context.Background(), keys.KeyWorkerHeartbeat(owner)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *RedisStateStore) hset(key, field string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return r.client.HSet(context.Background(), key, field, raw).Err()
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
+	"github.com/iceymoss/go-task/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTask 记录执行次数的测试任务
+type countingTask struct {
+	runs *int64
+}
+
+func (t *countingTask) Run(ctx context.Context, params map[string]any) error {
+	atomic.AddInt64(t.runs, 1)
+	return nil
+}
+func (t *countingTask) Identifier() string               { return "test:counting" }
+func (t *countingTask) GetDefaultCron() string           { return "" }
+func (t *countingTask) GetDefaultParams() map[string]any { return nil }
+func (t *countingTask) GetTaskType() constants.TaskType  { return constants.TaskTypeAPI }
+
+func newCountingScheduler(t *testing.T, store StateStore) (*Scheduler, map[string]*int64) {
+	registry := NewTaskRegistry()
+	counters := make(map[string]*int64)
+	for _, name := range []string{"run_all", "run_once", "skip", "pending"} {
+		var n int64
+		counters[name] = &n
+		registry.Register(name, func() core.Task { return &countingTask{runs: &n} })
+	}
+	s := NewScheduler(registry, WithStateStore(store), WithWorkerNum(2))
+	t.Cleanup(s.Stop)
+	return s, counters
+}
+
+// 测试重启后按补偿策略补跑错过的周期，并恢复未执行的队列项与运行次数
+func TestSchedulerRecoverState(t *testing.T) {
+	store := NewRedisStateStore(newTestRedis(t))
+
+	// 模拟上一次运行留下的状态：最后一次触发后停机，之后错过了 5 个整分钟的周期
+	last := time.Now().Truncate(time.Minute).Add(-5*time.Minute + time.Second)
+	for _, name := range []string{"run_all", "run_once", "skip"} {
+		require.NoError(t, store.SaveJobState(&JobState{Name: name, LastScheduledAt: last, RunCount: 7}))
+	}
+	// 上一个节点已经退出，存活标记不存在
+	require.NoError(t, store.SavePendingItem("crashed-node", TaskItem{ID: "p1", Name: "pending"}))
+
+	s, counters := newCountingScheduler(t, store)
+	for name, policy := range map[string]MisfirePolicy{
+		"run_all":  MisfirePolicyRunAll,
+		"run_once": MisfirePolicyRunOnce,
+		"skip":     MisfirePolicySkip,
+	} {
+		require.NoError(t, s.AddJob("0 * * * * *", name, name, nil, "TEST", nil))
+		s.SetMisfirePolicy(name, policy)
+	}
+	require.NoError(t, s.AddJob("@every 1h", "pending", "pending", nil, "TEST", nil))
+
+	s.recoverState()
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(counters["run_all"]) == 5 &&
+			atomic.LoadInt64(counters["run_once"]) == 1 &&
+			atomic.LoadInt64(counters["pending"]) == 1
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(0), atomic.LoadInt64(counters["skip"]))
+
+	pending, err := store.LoadPendingItems()
+	require.NoError(t, err)
+	assert.Empty(t, pending, "恢复后的队列项执行时应被移除")
+
+	stat, ok := s.Stats.Get("skip")
+	require.True(t, ok)
+	assert.Equal(t, int64(7), stat.RunCount, "运行次数应从状态存储中恢复")
+
+	// 补偿后记录了新的触发时间，再次恢复不会重复补跑
+	s.recoverState()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int64(5), atomic.LoadInt64(counters["run_all"]))
+}
+
+// 测试只接管失联节点的 pending 项，存活节点的队列项不会被重复入队，且接管后保留原任期
+func TestRecoverPendingItemsOnlyFromDeadOwners(t *testing.T) {
+	store := NewRedisStateStore(newTestRedis(t))
+	enqueuedAt := time.Now().Add(-time.Minute)
+	require.NoError(t, store.Heartbeat("alive-node", time.Minute))
+	require.NoError(t, store.SavePendingItem("alive-node", TaskItem{ID: "p-alive", Name: "pending", Epoch: 3, EnqueuedAt: enqueuedAt}))
+	require.NoError(t, store.SavePendingItem("dead-node", TaskItem{ID: "p-dead", Name: "pending", Epoch: 3, EnqueuedAt: enqueuedAt}))
+
+	s, counters := newCountingScheduler(t, store)
+	require.NoError(t, s.AddJob("@every 1h", "pending", "pending", nil, "TEST", nil))
+
+	var recovered []TaskItem
+	s.TaskQueue.Stop()
+	s.TaskQueue = &recordingQueue{items: &recovered}
+	s.recoverPendingItems()
+
+	require.Len(t, recovered, 1)
+	assert.Equal(t, "p-dead", recovered[0].ID)
+	assert.Equal(t, int64(3), recovered[0].Epoch, "接管后应保留原任期")
+	assert.True(t, recovered[0].EnqueuedAt.Equal(enqueuedAt))
+	assert.Equal(t, int64(0), atomic.LoadInt64(counters["pending"]))
+
+	pending, err := store.LoadPendingItems()
+	require.NoError(t, err)
+	owners := make(map[string]string)
+	for _, p := range pending {
+		owners[p.ID] = p.Owner
+	}
+	assert.Equal(t, "alive-node", owners["p-alive"])
+	assert.Equal(t, s.workerID, owners["p-dead"], "接管后的队列项归属本节点")
+
+	// 再次巡检不会重复接管
+	s.recoverPendingItems()
+	assert.Len(t, recovered, 1)
+}
+
+// recordingQueue 只记录入队项的测试队列
+type recordingQueue struct {
+	items *[]TaskItem
+}
+
+func (q *recordingQueue) Enqueue(item TaskItem) error {
+	*q.items = append(*q.items, item)
+	return nil
+}
+func (q *recordingQueue) Stop() {}
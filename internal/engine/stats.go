@@ -16,13 +16,16 @@ const (
 	Running jobStatus = "Running"
 	Error   jobStatus = "Error"
 	Success jobStatus = "Success"
+	Paused  jobStatus = "Paused"
 )
 
 const (
 	LastResultSuccess         string = "Success"
 	LastResultError           string = "Error: %v"
 	LastResultPending         string = "Pending"
+	LastResultCancelled       string = "Cancelled"
 	LastResultDependencyCheck string = "Dependency check failed: %v"
+	LastResultDependencyWait  string = "Dependency wait timed out"
 )
 
 // JobStats 任务运行时状态
@@ -36,6 +39,11 @@ type JobStats struct {
 	RunCount    int64     `json:"run_count"`
 	Source      string    `json:"source"`
 	RawNext     time.Time `json:"raw_next"`
+	LastExecID  string    `json:"last_exec_id"` // 最近一次执行的ID
+	Tags        []string  `json:"tags"`
+
+	RawLastRun       time.Time `json:"raw_last_run"`       // 最近一次执行结束时间
+	RawLastScheduled time.Time `json:"raw_last_scheduled"` // 最近一次 cron 触发时间
 }
 
 // StatManager 任务状态管理器
@@ -66,6 +74,13 @@ func (m *StatManager) Update(name string, fn func(stat *JobStats)) {
 	}
 }
 
+// Delete 移除任务状态
+func (m *StatManager) Delete(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.stats, name)
+}
+
 // Get 修改：Get 获取状态的安全拷贝
 func (m *StatManager) Get(name string) (JobStats, bool) {
 	m.mu.RLock()
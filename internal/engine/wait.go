@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// waitRetryInterval 挂起的执行最长等待多久后重新检查，兜底唤醒丢失（如释放名额的节点宕机）的情况；
+// 正常情况下由释放名额或之前的执行结束时唤醒
+const waitRetryInterval = 30 * time.Second
+
+// deferrableQueue 支持挂起执行的持久化队列：挂起的执行仍保存在队列中，到期或被唤醒后才会再次被取出，
+// 节点宕机也不会丢失（如 Redis 队列）
+type deferrableQueue interface {
+	// Defer 挂起执行直到 until，key 标识等待的对象
+	Defer(key string, item TaskItem, until time.Time) error
+	// Wake 提前恢复所有等待 key 的执行
+	Wake(key string) error
+}
+
+// waitList 挂起等待的执行，按等待对象先进先出，本节点释放名额时唤醒。
+// 用于不支持挂起的队列（内存队列），挂起期间状态存储中的 pending 记录保留，节点宕机后由 Leader 接管
+type waitList struct {
+	mu    sync.Mutex
+	items map[string][]TaskItem
+}
+
+func newWaitList() *waitList {
+	return &waitList{items: make(map[string][]TaskItem)}
+}
+
+// park 挂起等待 key 的执行
+func (w *waitList) park(key string, item TaskItem) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.items[key] = append(w.items[key], item)
+}
+
+// remove 取出指定的执行，已被唤醒时返回 false
+func (w *waitList) remove(key, id string) (TaskItem, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i := slices.IndexFunc(w.items[key], func(item TaskItem) bool { return item.ID == id })
+	if i < 0 {
+		return TaskItem{}, false
+	}
+	item := w.items[key][i]
+	w.items[key] = slices.Delete(w.items[key], i, i+1)
+	if len(w.items[key]) == 0 {
+		delete(w.items, key)
+	}
+	return item, true
+}
+
+// drain 取出 key 下所有挂起的执行
+func (w *waitList) drain(key string) []TaskItem {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	items := w.items[key]
+	delete(w.items, key)
+	return items
+}
+
+// len 返回 key 下挂起的执行数
+func (w *waitList) len(key string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.items[key])
+}
+
+// parkWaiting 挂起等待 key 的执行，等待期间不占用 worker。
+// 持久化队列直接把执行延迟保存在队列中；否则挂起在本节点，pending 记录保留到执行真正开始
+func (s *Scheduler) parkWaiting(key string, item TaskItem) {
+	if dq, ok := s.TaskQueue.(deferrableQueue); ok {
+		err := dq.Defer(key, item, time.Now().Add(waitRetryInterval))
+		if err == nil {
+			return
+		}
+		s.logger.Error("❌ [Schedule] Defer job in queue failed, wait on this node", "name", item.Name, "exec_id", item.ID, err)
+	}
+
+	s.waiters.park(key, item)
+	time.AfterFunc(waitRetryInterval, func() {
+		if item, ok := s.waiters.remove(key, item.ID); ok {
+			s.requeueWaitingItem(item)
+		}
+	})
+}
+
+// wakeWaiting 唤醒所有等待 key 的执行，各自重新检查能否开始
+func (s *Scheduler) wakeWaiting(key string) {
+	if dq, ok := s.TaskQueue.(deferrableQueue); ok {
+		if err := dq.Wake(key); err != nil {
+			s.logger.Error("❌ [Schedule] Wake deferred jobs failed", "key", key, err)
+		}
+	}
+	for _, item := range s.waiters.drain(key) {
+		s.requeueWaitingItem(item)
+	}
+}
+
+// requeueWaitingItem 将挂起等待的执行重新入队
+func (s *Scheduler) requeueWaitingItem(item TaskItem) {
+	if s.TaskQueue == nil {
+		go s.handleQueueItem(item)
+		return
+	}
+	if err := s.enqueue(item); err != nil {
+		s.logger.Info("⚠️ [Dispatcher] Requeue job failed", "name", item.Name, "exec_id", item.ID, err)
+	}
+}
@@ -0,0 +1,768 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidWorkflow   = errors.New("invalid workflow definition")
+	ErrWorkflowNodeEmpty = errors.New("workflow has no nodes")
+)
+
+// FailureStrategy 工作流失败策略
+type FailureStrategy string
+
+const (
+	FailureStrategyFailFast FailureStrategy = "fail_fast" // 任一节点失败立即终止整个工作流
+	FailureStrategyContinue FailureStrategy = "continue"  // 跳过失败节点的下游，其余分支继续执行
+	FailureStrategyRetryAll FailureStrategy = "retry_all" // 任一节点失败后整体重跑工作流
+)
+
+// 工作流及节点的执行状态，与 sys_workflow_executions / sys_workflow_node_executions 中的取值保持一致
+const (
+	WorkflowStatusPending        = "pending"
+	WorkflowStatusRunning        = "running"
+	WorkflowStatusSuccess        = "success"
+	WorkflowStatusFailed         = "failed"
+	WorkflowStatusCancelled      = "cancelled"
+	WorkflowStatusPartialSuccess = "partial_success"
+
+	NodeStatusPending = "pending"
+	NodeStatusRunning = "running"
+	NodeStatusSuccess = "success"
+	NodeStatusFailed  = "failed"
+	NodeStatusSkipped = "skipped"
+	NodeStatusTimeout = "timeout"
+)
+
+const (
+	defaultWorkflowParallelism = 10 // 单个工作流同时运行的最大节点数
+	defaultWorkflowMaxAttempts = 3  // retry_all 策略下的默认最大执行轮数
+	workflowTaskPrefix         = "workflow:"
+)
+
+// WorkflowNode DAG 中的一个节点
+type WorkflowNode struct {
+	ID        string         `json:"id"`                   // 节点ID（DAG 内唯一）
+	Name      string         `json:"name,omitempty"`       // 节点显示名称
+	Task      string         `json:"task"`                 // 任务模板名，必须已在 TaskRegistry 中注册
+	JobID     *uint          `json:"job_id,omitempty"`     // 关联任务ID（可选）
+	Params    map[string]any `json:"params,omitempty"`     // 节点参数，覆盖全局参数
+	DependsOn []string       `json:"depends_on,omitempty"` // 上游节点ID
+	Timeout   int            `json:"timeout,omitempty"`    // 节点超时时间（秒）
+}
+
+// WorkflowEdge DAG 中的一条边 From -> To
+type WorkflowEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// WorkflowDAG 工作流 DAG 定义，对应 sys_workflows.dag 字段
+// 节点之间的依赖既可以通过 edges 描述，也可以直接在节点上用 depends_on 描述，两者会被合并
+type WorkflowDAG struct {
+	Nodes []WorkflowNode `json:"nodes"`
+	Edges []WorkflowEdge `json:"edges,omitempty"`
+
+	parents  map[string][]string // 节点 -> 上游节点
+	children map[string][]string // 节点 -> 下游节点
+	index    map[string]int      // 节点 -> Nodes 下标
+}
+
+// ParseWorkflowDAG 解析并校验 DAG JSON
+func ParseWorkflowDAG(raw []byte) (*WorkflowDAG, error) {
+	var dag WorkflowDAG
+	if err := json.Unmarshal(raw, &dag); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+	}
+	if err := dag.Validate(); err != nil {
+		return nil, err
+	}
+	return &dag, nil
+}
+
+// parseWorkflowDAGValue 将任意形式的 DAG 定义 (JSON 字符串/字节/结构体/map) 转换为 WorkflowDAG
+func parseWorkflowDAGValue(v any) (*WorkflowDAG, error) {
+	switch d := v.(type) {
+	case *WorkflowDAG:
+		if err := d.Validate(); err != nil {
+			return nil, err
+		}
+		return d, nil
+	case WorkflowDAG:
+		return parseWorkflowDAGValue(&d)
+	case string:
+		return ParseWorkflowDAG([]byte(d))
+	case []byte:
+		return ParseWorkflowDAG(d)
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+		}
+		return ParseWorkflowDAG(raw)
+	}
+}
+
+// Validate 校验节点唯一性、边的合法性以及是否存在环，并建立邻接表
+func (d *WorkflowDAG) Validate() error {
+	if len(d.Nodes) == 0 {
+		return ErrWorkflowNodeEmpty
+	}
+
+	d.index = make(map[string]int, len(d.Nodes))
+	d.parents = make(map[string][]string, len(d.Nodes))
+	d.children = make(map[string][]string, len(d.Nodes))
+
+	for i, node := range d.Nodes {
+		if node.ID == "" {
+			return fmt.Errorf("%w: node #%d has empty id", ErrInvalidWorkflow, i)
+		}
+		if node.Task == "" {
+			return fmt.Errorf("%w: node %s has empty task", ErrInvalidWorkflow, node.ID)
+		}
+		if _, dup := d.index[node.ID]; dup {
+			return fmt.Errorf("%w: duplicate node id %s", ErrInvalidWorkflow, node.ID)
+		}
+		d.index[node.ID] = i
+	}
+
+	seen := make(map[[2]string]bool)
+	addEdge := func(from, to string) error {
+		if _, ok := d.index[from]; !ok {
+			return fmt.Errorf("%w: unknown node %s", ErrInvalidWorkflow, from)
+		}
+		if _, ok := d.index[to]; !ok {
+			return fmt.Errorf("%w: unknown node %s", ErrInvalidWorkflow, to)
+		}
+		if from == to {
+			return fmt.Errorf("%w: node %s depends on itself", ErrCircularDependency, from)
+		}
+		key := [2]string{from, to}
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+		d.parents[to] = append(d.parents[to], from)
+		d.children[from] = append(d.children[from], to)
+		return nil
+	}
+
+	for _, node := range d.Nodes {
+		for _, dep := range node.DependsOn {
+			if err := addEdge(dep, node.ID); err != nil {
+				return err
+			}
+		}
+	}
+	for _, edge := range d.Edges {
+		if err := addEdge(edge.From, edge.To); err != nil {
+			return err
+		}
+	}
+
+	if _, err := d.TopologicalOrder(); err != nil {
+		return err
+	}
+	return d.validateOutputRefs(nil)
+}
+
+// validateOutputRefs 校验参数表达式只引用上游节点的输出，global 为合并到每个节点参数中的全局参数
+func (d *WorkflowDAG) validateOutputRefs(global map[string]any) error {
+	for _, node := range d.Nodes {
+		refs := make(map[string]bool)
+		collectOutputRefs(mergeParams(global, node.Params), refs)
+		if len(refs) == 0 {
+			continue
+		}
+		ancestors := d.ancestors(node.ID)
+		for ref := range refs {
+			if _, ok := d.index[ref]; !ok {
+				return fmt.Errorf("%w: node %s references unknown node %s", ErrInvalidWorkflow, node.ID, ref)
+			}
+			if !ancestors[ref] {
+				return fmt.Errorf("%w: node %s references output of %s which is not upstream", ErrInvalidWorkflow, node.ID, ref)
+			}
+		}
+	}
+	return nil
+}
+
+// TopologicalOrder 使用 Kahn 算法返回节点的拓扑序，存在环时返回 ErrCircularDependency
+func (d *WorkflowDAG) TopologicalOrder() ([]string, error) {
+	indegree := make(map[string]int, len(d.Nodes))
+	for _, node := range d.Nodes {
+		indegree[node.ID] = len(d.parents[node.ID])
+	}
+
+	ready := make([]string, 0)
+	for _, node := range d.Nodes {
+		if indegree[node.ID] == 0 {
+			ready = append(ready, node.ID)
+		}
+	}
+
+	order := make([]string, 0, len(d.Nodes))
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, child := range d.children[id] {
+			indegree[child]--
+			if indegree[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+
+	if len(order) != len(d.Nodes) {
+		return nil, ErrCircularDependency
+	}
+	return order, nil
+}
+
+// Node 根据ID获取节点
+func (d *WorkflowDAG) Node(id string) (WorkflowNode, bool) {
+	i, ok := d.index[id]
+	if !ok {
+		return WorkflowNode{}, false
+	}
+	return d.Nodes[i], true
+}
+
+// clone 复制节点与边的定义（不包含邻接表）
+func (d *WorkflowDAG) clone() *WorkflowDAG {
+	c := &WorkflowDAG{
+		Nodes: make([]WorkflowNode, len(d.Nodes)),
+		Edges: make([]WorkflowEdge, len(d.Edges)),
+	}
+	copy(c.Nodes, d.Nodes)
+	copy(c.Edges, d.Edges)
+	return c
+}
+
+// ancestors 返回指定节点的所有上游节点
+func (d *WorkflowDAG) ancestors(id string) map[string]bool {
+	visited := make(map[string]bool)
+	var walk func(string)
+	walk = func(n string) {
+		for _, parent := range d.parents[n] {
+			if !visited[parent] {
+				visited[parent] = true
+				walk(parent)
+			}
+		}
+	}
+	walk(id)
+	return visited
+}
+
+// descendants 返回指定节点的所有下游节点
+func (d *WorkflowDAG) descendants(id string) []string {
+	visited := make(map[string]bool)
+	var walk func(string)
+	walk = func(n string) {
+		for _, child := range d.children[n] {
+			if !visited[child] {
+				visited[child] = true
+				walk(child)
+			}
+		}
+	}
+	walk(id)
+
+	list := make([]string, 0, len(visited))
+	for n := range visited {
+		list = append(list, n)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// WorkflowDefinition 一个可执行的工作流
+type WorkflowDefinition struct {
+	WorkflowID      string          // 工作流ID
+	Name            string          // 工作流名称
+	DAG             *WorkflowDAG    // DAG 定义
+	GlobalParams    map[string]any  // 全局参数，所有节点共享
+	FailureStrategy FailureStrategy // 失败策略
+	MaxAttempts     int             // retry_all 策略下的最大执行轮数
+}
+
+// Validate 校验 DAG 定义，以及全局参数中的表达式只引用各节点上游的输出
+func (def *WorkflowDefinition) Validate() error {
+	if def == nil || def.DAG == nil {
+		return ErrInvalidWorkflow
+	}
+	if err := def.DAG.Validate(); err != nil {
+		return err
+	}
+	return def.DAG.validateOutputRefs(def.GlobalParams)
+}
+
+// WorkflowRun 一次工作流执行的状态快照
+type WorkflowRun struct {
+	ExecutionID   string
+	WorkflowID    string
+	WorkflowName  string
+	Status        string
+	NodeStatus    map[string]string // 节点ID -> 状态
+	FailedNodes   []string
+	ErrorMessage  string
+	TriggerSource string
+	ScheduledAt   time.Time
+	StartedAt     *time.Time
+	FinishedAt    *time.Time
+	DurationMs    *int64
+}
+
+// WorkflowNodeRun 工作流中单个节点的执行状态快照
+type WorkflowNodeRun struct {
+	WorkflowExecID string
+	NodeID         string
+	TaskName       string
+	JobID          *uint
+	Status         string
+	ScheduledAt    time.Time
+	StartedAt      *time.Time
+	FinishedAt     *time.Time
+	DurationMs     *int64
+	RetryCount     int
+	InputParams    map[string]any
+	OutputData     map[string]any
+	ErrorMessage   string
+}
+
+// WorkflowStore 工作流执行记录的持久化接口，由外部 (如 GORM) 实现
+// 两个方法都应当具备 upsert 语义：同一个执行ID / 节点会被多次保存
+type WorkflowStore interface {
+	SaveWorkflowRun(run *WorkflowRun) error
+	SaveNodeRun(node *WorkflowNodeRun) error
+}
+
+// WorkflowEngine 工作流执行引擎：解析 DAG，按拓扑序并行执行节点，并持久化每个节点的状态
+type WorkflowEngine struct {
+	registry    *TaskRegistry
+	store       WorkflowStore
+	logger      Logger
+	parallelism int
+}
+
+// 确保 WorkflowEngine 实现了 core.WorkflowExecutor 接口
+var _ core.WorkflowExecutor = (*WorkflowEngine)(nil)
+
+// NewWorkflowEngine 创建工作流执行引擎，store 为空时不做持久化
+func NewWorkflowEngine(registry *TaskRegistry, store WorkflowStore, log Logger) *WorkflowEngine {
+	return &WorkflowEngine{
+		registry:    registry,
+		store:       store,
+		logger:      log,
+		parallelism: defaultWorkflowParallelism,
+	}
+}
+
+// WorkflowTaskName 返回工作流在 TaskRegistry 中注册使用的任务模板名
+func WorkflowTaskName(workflowID string) string {
+	return workflowTaskPrefix + workflowID
+}
+
+// ExecuteWorkflow 实现 core.WorkflowExecutor，供 core.CompositeTask 调用。
+// dag 为 *WorkflowDefinition 时沿用其失败策略与最大执行轮数，否则按 DAG 定义以 fail_fast 策略执行
+func (w *WorkflowEngine) ExecuteWorkflow(ctx *core.TaskContext, workflowID string, dag any, params map[string]any) error {
+	if def, ok := dag.(*WorkflowDefinition); ok {
+		local := *def
+		local.GlobalParams = params
+		_, err := w.Execute(ctx, &local, "schedule")
+		return err
+	}
+
+	parsed, err := parseWorkflowDAGValue(dag)
+	if err != nil {
+		return err
+	}
+	_, err = w.Execute(ctx, &WorkflowDefinition{
+		WorkflowID:      workflowID,
+		Name:            workflowID,
+		DAG:             parsed,
+		GlobalParams:    params,
+		FailureStrategy: FailureStrategyFailFast,
+	}, "composite")
+	return err
+}
+
+// Execute 执行一次工作流，返回最终的执行快照
+// 当工作流未全部成功时返回 error，便于上层 (调度器/重试链) 感知失败
+func (w *WorkflowEngine) Execute(ctx context.Context, def *WorkflowDefinition, triggerSource string) (*WorkflowRun, error) {
+	if def == nil || def.DAG == nil {
+		return nil, ErrInvalidWorkflow
+	}
+	// 每次执行使用独立的 DAG 副本，避免同一工作流并发执行时共享邻接表
+	local := *def
+	local.DAG = def.DAG.clone()
+	if err := local.Validate(); err != nil {
+		return nil, err
+	}
+	def = &local
+
+	strategy := def.FailureStrategy
+	if strategy == "" {
+		strategy = FailureStrategyFailFast
+	}
+	maxAttempts := 1
+	if strategy == FailureStrategyRetryAll {
+		maxAttempts = def.MaxAttempts
+		if maxAttempts <= 0 {
+			maxAttempts = defaultWorkflowMaxAttempts
+		}
+	}
+
+	// 由调度器触发时沿用调度器分配的执行ID，便于串联任务与工作流的执行记录
+	execID := ExecIDFromContext(ctx)
+	if execID == "" {
+		execID = uuid.New().String()
+	}
+
+	now := time.Now()
+	run := &WorkflowRun{
+		ExecutionID:   execID,
+		WorkflowID:    def.WorkflowID,
+		WorkflowName:  def.Name,
+		Status:        WorkflowStatusRunning,
+		NodeStatus:    make(map[string]string, len(def.DAG.Nodes)),
+		TriggerSource: triggerSource,
+		ScheduledAt:   now,
+		StartedAt:     &now,
+	}
+	for _, node := range def.DAG.Nodes {
+		run.NodeStatus[node.ID] = NodeStatusPending
+	}
+	w.saveRun(run)
+
+	w.logger.Info("🧭 [Workflow] Starting workflow",
+		"workflow_id", def.WorkflowID,
+		"execution_id", run.ExecutionID,
+		"nodes", len(def.DAG.Nodes),
+		"strategy", string(strategy),
+	)
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		lastErr = w.runOnce(ctx, def, run, strategy, attempt-1)
+		if lastErr == nil || ctx.Err() != nil || attempt == maxAttempts {
+			break
+		}
+		w.logger.Warn("🔄 [Workflow] Workflow failed, retrying all nodes",
+			"workflow_id", def.WorkflowID,
+			"execution_id", run.ExecutionID,
+			"attempt", attempt,
+			lastErr,
+		)
+	}
+
+	finished := time.Now()
+	duration := finished.Sub(*run.StartedAt).Milliseconds()
+	run.FinishedAt = &finished
+	run.DurationMs = &duration
+	run.FailedNodes = run.FailedNodes[:0]
+	succeeded := 0
+	for _, node := range def.DAG.Nodes {
+		switch run.NodeStatus[node.ID] {
+		case NodeStatusSuccess:
+			succeeded++
+		case NodeStatusFailed, NodeStatusTimeout:
+			run.FailedNodes = append(run.FailedNodes, node.ID)
+		}
+	}
+
+	switch {
+	case lastErr == nil:
+		run.Status = WorkflowStatusSuccess
+	case ctx.Err() != nil:
+		run.Status = WorkflowStatusCancelled
+	case strategy == FailureStrategyContinue && succeeded > 0:
+		run.Status = WorkflowStatusPartialSuccess
+	default:
+		run.Status = WorkflowStatusFailed
+	}
+	if lastErr != nil {
+		run.ErrorMessage = lastErr.Error()
+	}
+	w.saveRun(run)
+
+	w.logger.Info("🏁 [Workflow] Workflow finished",
+		"workflow_id", def.WorkflowID,
+		"execution_id", run.ExecutionID,
+		"status", run.Status,
+		"duration_ms", duration,
+	)
+	return run, lastErr
+}
+
+// nodeResult 节点执行结果
+type nodeResult struct {
+	nodeID string
+	status string
+	output map[string]any
+	err    error
+}
+
+// runOnce 按拓扑序执行一轮工作流：入度为 0 的节点并行执行，上游全部成功后才会调度下游
+func (w *WorkflowEngine) runOnce(parent context.Context, def *WorkflowDefinition, run *WorkflowRun, strategy FailureStrategy, retryCount int) error {
+	dag := def.DAG
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	// NodeStatus 只在当前协程中读写，节点协程通过 results 通道回传状态
+	setStatus := func(id, status string) {
+		run.NodeStatus[id] = status
+	}
+	for _, node := range dag.Nodes {
+		setStatus(node.ID, NodeStatusPending)
+	}
+
+	indegree := make(map[string]int, len(dag.Nodes))
+	for _, node := range dag.Nodes {
+		indegree[node.ID] = len(dag.parents[node.ID])
+	}
+
+	parallelism := w.parallelism
+	if parallelism <= 0 {
+		parallelism = len(dag.Nodes)
+	}
+	sem := make(chan struct{}, parallelism)
+	results := make(chan nodeResult, len(dag.Nodes))
+	outputs := make(map[string]map[string]any, len(dag.Nodes)) // 节点ID -> 输出，同样只在当前协程中读写
+	running := 0
+	finished := 0
+	aborted := false
+	var firstErr error
+
+	launch := func(id string) {
+		node, _ := dag.Node(id)
+		setStatus(id, NodeStatusRunning)
+		running++
+		// 上游节点均已完成，其输出不会再被修改，复制一份引用交给节点协程
+		upstream := make(map[string]map[string]any, len(outputs))
+		for k, v := range outputs {
+			upstream[k] = v
+		}
+		go func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			status, output, err := w.runNode(ctx, def, run, node, upstream, retryCount)
+			results <- nodeResult{nodeID: id, status: status, output: output, err: err}
+		}()
+	}
+
+	for _, node := range dag.Nodes {
+		if indegree[node.ID] == 0 {
+			launch(node.ID)
+		}
+	}
+
+	for running > 0 {
+		res := <-results
+		running--
+		finished++
+		setStatus(res.nodeID, res.status)
+		// 以完成的节点数作为工作流的执行进度
+		core.ReportProgress(parent, finished, len(dag.Nodes), fmt.Sprintf("node %s %s", res.nodeID, res.status))
+		if res.output != nil {
+			outputs[res.nodeID] = res.output
+		}
+
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("node %s failed: %w", res.nodeID, res.err)
+			}
+			if strategy == FailureStrategyContinue {
+				// 仅跳过失败节点的下游，其它分支继续执行
+				for _, id := range dag.descendants(res.nodeID) {
+					if run.NodeStatus[id] == NodeStatusPending {
+						setStatus(id, NodeStatusSkipped)
+						w.saveSkippedNode(run, dag, id, retryCount)
+					}
+				}
+			} else if !aborted {
+				// fail_fast / retry_all：立即终止本轮，取消正在运行的节点
+				aborted = true
+				cancel()
+			}
+		}
+
+		for _, child := range dag.children[res.nodeID] {
+			indegree[child]--
+			if indegree[child] == 0 && !aborted && run.NodeStatus[child] == NodeStatusPending {
+				launch(child)
+			}
+		}
+	}
+
+	// 本轮中未被调度的节点全部标记为跳过
+	for _, node := range dag.Nodes {
+		if run.NodeStatus[node.ID] == NodeStatusPending {
+			setStatus(node.ID, NodeStatusSkipped)
+			w.saveSkippedNode(run, dag, node.ID, retryCount)
+		}
+	}
+	w.saveRun(run)
+
+	if firstErr == nil && parent.Err() != nil {
+		return parent.Err()
+	}
+	return firstErr
+}
+
+// runNode 执行单个节点并持久化其状态
+// upstream 为已完成节点的输出，用于解析参数中的 {{ nodes.<id>.output.<path> }} 表达式
+func (w *WorkflowEngine) runNode(ctx context.Context, def *WorkflowDefinition, run *WorkflowRun, node WorkflowNode, upstream map[string]map[string]any, retryCount int) (status string, output map[string]any, err error) {
+	start := time.Now()
+	record := &WorkflowNodeRun{
+		WorkflowExecID: run.ExecutionID,
+		NodeID:         node.ID,
+		TaskName:       node.Task,
+		JobID:          node.JobID,
+		Status:         NodeStatusRunning,
+		ScheduledAt:    run.ScheduledAt,
+		StartedAt:      &start,
+		RetryCount:     retryCount,
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in workflow node %s: %v\n%s", node.ID, r, debug.Stack())
+		}
+
+		finished := time.Now()
+		duration := finished.Sub(start).Milliseconds()
+		record.FinishedAt = &finished
+		record.DurationMs = &duration
+		record.Status = NodeStatusSuccess
+		if err != nil {
+			record.Status = NodeStatusFailed
+			if errors.Is(err, ErrJobTimeout) || errors.Is(err, context.DeadlineExceeded) {
+				record.Status = NodeStatusTimeout
+			}
+			record.ErrorMessage = err.Error()
+		}
+
+		w.saveNode(record)
+		status = record.Status
+
+		w.logger.Info("🧩 [Workflow] Node finished",
+			"workflow_id", def.WorkflowID,
+			"execution_id", run.ExecutionID,
+			"node", node.ID,
+			"status", record.Status,
+			"duration_ms", duration,
+		)
+	}()
+
+	creator, err := w.registry.Get(node.Task)
+	if err != nil {
+		return "", nil, err
+	}
+	task := creator()
+
+	params := mergeParams(core.DefaultParams(task), def.GlobalParams, node.Params)
+	record.InputParams = params
+	params, err = resolveParams(params, upstream)
+	if err != nil {
+		return "", nil, err
+	}
+	record.InputParams = params
+	w.saveNode(record)
+	if err := core.ValidateTaskParams(task, params); err != nil {
+		return "", nil, err
+	}
+
+	runCtx, collector := core.WithOutput(ctx)
+	jobFunc := func(c context.Context) error {
+		return core.Execute(nodeTaskContext(c, run, node), task, params)
+	}
+	if node.Timeout > 0 {
+		jobFunc = Chain{}.Then(Timeout(time.Duration(node.Timeout) * time.Second)).Apply(jobFunc)
+	}
+	err = jobFunc(runCtx)
+	output = collector.Data()
+	record.OutputData = output
+	return "", output, err
+}
+
+// nodeTaskContext 构造节点的任务上下文：沿用工作流所在执行的任务ID、日志与 Worker 信息，日志附加节点ID
+func nodeTaskContext(ctx context.Context, run *WorkflowRun, node WorkflowNode) *core.TaskContext {
+	tc := &core.TaskContext{
+		Context:     ctx,
+		TaskName:    node.Task,
+		ExecutionID: run.ExecutionID,
+		Metadata: map[string]interface{}{
+			"workflow_id": run.WorkflowID,
+			"node_id":     node.ID,
+		},
+	}
+	if parent, ok := core.FromContext(ctx); ok {
+		tc.TaskID = parent.TaskID
+		tc.WorkerID = parent.WorkerID
+		tc.Hostname = parent.Hostname
+	}
+	tc.Logger = core.LoggerFromContext(ctx).With(
+		zap.String("workflow_exec_id", run.ExecutionID),
+		zap.String("node", node.ID),
+		zap.String("node_task", node.Task),
+	)
+	return core.BindTaskContext(tc)
+}
+
+// saveSkippedNode 持久化被跳过的节点
+func (w *WorkflowEngine) saveSkippedNode(run *WorkflowRun, dag *WorkflowDAG, id string, retryCount int) {
+	node, _ := dag.Node(id)
+	w.saveNode(&WorkflowNodeRun{
+		WorkflowExecID: run.ExecutionID,
+		NodeID:         node.ID,
+		TaskName:       node.Task,
+		JobID:          node.JobID,
+		Status:         NodeStatusSkipped,
+		ScheduledAt:    run.ScheduledAt,
+		RetryCount:     retryCount,
+	})
+}
+
+func (w *WorkflowEngine) saveRun(run *WorkflowRun) {
+	if w.store == nil {
+		return
+	}
+	if err := w.store.SaveWorkflowRun(run); err != nil {
+		w.logger.Error("❌ [Workflow] Failed to save workflow execution", "execution_id", run.ExecutionID, err)
+	}
+}
+
+func (w *WorkflowEngine) saveNode(node *WorkflowNodeRun) {
+	if w.store == nil {
+		return
+	}
+	if err := w.store.SaveNodeRun(node); err != nil {
+		w.logger.Error("❌ [Workflow] Failed to save node execution", "execution_id", node.WorkflowExecID, "node", node.NodeID, err)
+	}
+}
+
+// mergeParams 按顺序合并多组参数，后者覆盖前者
+func mergeParams(layers ...map[string]any) map[string]any {
+	merged := make(map[string]any)
+	for _, layer := range layers {
+		for k, v := range layer {
+			merged[k] = v
+		}
+	}
+	return merged
+}
@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrUnresolvedReference 参数表达式引用的节点输出不存在
+var ErrUnresolvedReference = errors.New("unresolved workflow output reference")
+
+// outputRefPattern 匹配 {{ nodes.<节点ID>.output[.路径] }}，路径支持 a.b.0 与 a.b[0] 两种写法
+var outputRefPattern = regexp.MustCompile(`\{\{\s*nodes\.([A-Za-z0-9_\-]+)\.output((?:\.[A-Za-z0-9_\-]+|\[\d+\])*)\s*\}\}`)
+
+// outputRef 一个已解析的输出引用
+type outputRef struct {
+	node string
+	path []string
+}
+
+// parseOutputRef 将正则子匹配转换为 outputRef
+func parseOutputRef(match []string) outputRef {
+	path := strings.NewReplacer("[", ".", "]", "").Replace(match[2])
+	ref := outputRef{node: match[1]}
+	for _, seg := range strings.Split(path, ".") {
+		if seg != "" {
+			ref.path = append(ref.path, seg)
+		}
+	}
+	return ref
+}
+
+// collectOutputRefs 收集参数中引用到的所有节点ID
+func collectOutputRefs(v any, refs map[string]bool) {
+	switch val := v.(type) {
+	case string:
+		for _, m := range outputRefPattern.FindAllStringSubmatch(val, -1) {
+			refs[m[1]] = true
+		}
+	case map[string]any:
+		for _, item := range val {
+			collectOutputRefs(item, refs)
+		}
+	case []any:
+		for _, item := range val {
+			collectOutputRefs(item, refs)
+		}
+	}
+}
+
+// resolveParams 将参数中的输出引用替换为上游节点的实际输出
+// 整个字符串只有一个表达式时保留原始类型（如数组、数字），否则按字符串拼接
+func resolveParams(params map[string]any, outputs map[string]map[string]any) (map[string]any, error) {
+	resolved := make(map[string]any, len(params))
+	for k, v := range params {
+		r, err := resolveValue(v, outputs)
+		if err != nil {
+			return nil, fmt.Errorf("param %s: %w", k, err)
+		}
+		resolved[k] = r
+	}
+	return resolved, nil
+}
+
+func resolveValue(v any, outputs map[string]map[string]any) (any, error) {
+	switch val := v.(type) {
+	case string:
+		return resolveString(val, outputs)
+	case map[string]any:
+		return resolveParams(val, outputs)
+	case []any:
+		list := make([]any, len(val))
+		for i, item := range val {
+			r, err := resolveValue(item, outputs)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = r
+		}
+		return list, nil
+	default:
+		return v, nil
+	}
+}
+
+func resolveString(s string, outputs map[string]map[string]any) (any, error) {
+	matches := outputRefPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s, nil
+	}
+
+	// 整个字符串就是一个表达式：直接返回原始值
+	if len(matches) == 1 && strings.TrimSpace(s) == s[matches[0][0]:matches[0][1]] {
+		return lookupOutput(parseOutputRef(submatches(s, matches[0])), outputs)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(s[last:m[0]])
+		value, err := lookupOutput(parseOutputRef(submatches(s, m)), outputs)
+		if err != nil {
+			return nil, err
+		}
+		text, err := stringifyOutput(value)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(text)
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+// submatches 将下标形式的匹配结果转换为字符串切片
+func submatches(s string, loc []int) []string {
+	out := make([]string, len(loc)/2)
+	for i := range out {
+		if loc[2*i] >= 0 {
+			out[i] = s[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return out
+}
+
+// lookupOutput 按路径在节点输出中取值
+func lookupOutput(ref outputRef, outputs map[string]map[string]any) (any, error) {
+	data, ok := outputs[ref.node]
+	if !ok {
+		return nil, fmt.Errorf("%w: node %s has no output", ErrUnresolvedReference, ref.node)
+	}
+
+	var cur any = data
+	for i, seg := range ref.path {
+		switch c := cur.(type) {
+		case map[string]any:
+			v, ok := c[seg]
+			if !ok {
+				return nil, fmt.Errorf("%w: nodes.%s.output.%s", ErrUnresolvedReference, ref.node, strings.Join(ref.path[:i+1], "."))
+			}
+			cur = v
+		default:
+			list, ok := toList(cur)
+			if !ok {
+				return nil, fmt.Errorf("%w: nodes.%s.output.%s is not an object or array", ErrUnresolvedReference, ref.node, strings.Join(ref.path[:i], "."))
+			}
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(list) {
+				return nil, fmt.Errorf("%w: nodes.%s.output.%s", ErrUnresolvedReference, ref.node, strings.Join(ref.path[:i+1], "."))
+			}
+			cur = list[idx]
+		}
+	}
+	return cur, nil
+}
+
+// toList 将常见的切片类型统一转换为 []any
+func toList(v any) ([]any, bool) {
+	switch l := v.(type) {
+	case []any:
+		return l, true
+	case []map[string]any:
+		list := make([]any, len(l))
+		for i, item := range l {
+			list[i] = item
+		}
+		return list, true
+	case []string:
+		list := make([]any, len(l))
+		for i, item := range l {
+			list[i] = item
+		}
+		return list, true
+	default:
+		return nil, false
+	}
+}
+
+// stringifyOutput 将输出值转换为可以嵌入字符串的文本，非字符串值使用 JSON 编码
+func stringifyOutput(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputRefPattern(t *testing.T) {
+	cases := []struct {
+		expr string
+		node string
+		path []string
+	}{
+		{"{{nodes.fetch.output}}", "fetch", nil},
+		{"{{ nodes.fetch.output.body }}", "fetch", []string{"body"}},
+		{"{{ nodes.fetch-1.output.items[0].name }}", "fetch-1", []string{"items", "0", "name"}},
+		{"{{ nodes.fetch_2.output.items.1 }}", "fetch_2", []string{"items", "1"}},
+	}
+	for _, tc := range cases {
+		m := outputRefPattern.FindStringSubmatch(tc.expr)
+		require.NotNil(t, m, tc.expr)
+		ref := parseOutputRef(m)
+		assert.Equal(t, tc.node, ref.node, tc.expr)
+		assert.Equal(t, tc.path, ref.path, tc.expr)
+	}
+
+	// 不是节点输出的表达式原样保留
+	for _, expr := range []string{"{{ vars.name }}", "{{ nodes.fetch.input }}", "nodes.fetch.output"} {
+		assert.Nil(t, outputRefPattern.FindStringSubmatch(expr), expr)
+	}
+}
+
+func TestResolveParams(t *testing.T) {
+	outputs := map[string]map[string]any{
+		"fetch": {
+			"status": 200,
+			"body":   map[string]any{"items": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}}},
+			"tags":   []string{"x", "y"},
+			"url":    "https://example.com",
+		},
+	}
+
+	cases := []struct {
+		name string
+		in   any
+		want any
+	}{
+		{"plain string", "hello", "hello"},
+		{"non-string value", 42, 42},
+		{"full value keeps type", "{{ nodes.fetch.output.status }}", 200},
+		{"full value with spaces", "  {{ nodes.fetch.output.tags }} ", []string{"x", "y"}},
+		{"whole output", "{{ nodes.fetch.output }}", outputs["fetch"]},
+		{"nested path", "{{ nodes.fetch.output.body.items[1].name }}", "b"},
+		{"dotted index", "{{ nodes.fetch.output.tags.0 }}", "x"},
+		{"interpolated", "GET {{ nodes.fetch.output.url }} -> {{ nodes.fetch.output.status }}", "GET https://example.com -> 200"},
+		{"interpolated non-string", "tags={{ nodes.fetch.output.tags }}", `tags=["x","y"]`},
+		{"nested params", map[string]any{"q": []any{"{{ nodes.fetch.output.body.items[0].name }}"}}, map[string]any{"q": []any{"a"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := resolveParams(map[string]any{"v": tc.in}, outputs)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got["v"])
+		})
+	}
+}
+
+func TestResolveParamsUnresolved(t *testing.T) {
+	outputs := map[string]map[string]any{"fetch": {"body": map[string]any{"items": []any{"a"}}, "status": 200}}
+
+	for name, expr := range map[string]string{
+		"missing node":         "{{ nodes.other.output.body }}",
+		"missing key":          "{{ nodes.fetch.output.headers }}",
+		"index out of range":   "{{ nodes.fetch.output.body.items[3] }}",
+		"index on object":      "{{ nodes.fetch.output.body.0 }}",
+		"path into scalar":     "{{ nodes.fetch.output.status.code }}",
+		"missing interpolated": "id={{ nodes.other.output.id }}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := resolveParams(map[string]any{"v": expr}, outputs)
+			assert.ErrorIs(t, err, ErrUnresolvedReference)
+		})
+	}
+}
+
+// 测试节点参数与全局参数中的输出引用都只能指向上游节点
+func TestWorkflowDefinitionValidateRefs(t *testing.T) {
+	nodes := func() []WorkflowNode {
+		return []WorkflowNode{
+			{ID: "fetch", Task: "step"},
+			{ID: "parse", Task: "step", DependsOn: []string{"fetch"}},
+			{ID: "other", Task: "step"},
+		}
+	}
+
+	cases := []struct {
+		name   string
+		node   map[string]any // parse 节点的参数
+		global map[string]any
+		ok     bool
+	}{
+		{"node ref to upstream", map[string]any{"url": "{{ nodes.fetch.output.url }}"}, nil, true},
+		{"node ref to unknown", map[string]any{"url": "{{ nodes.missing.output.url }}"}, nil, false},
+		{"node ref to sibling", map[string]any{"url": "{{ nodes.other.output.url }}"}, nil, false},
+		{"global ref to unknown", nil, map[string]any{"url": "{{ nodes.missing.output.url }}"}, false},
+		// 全局参数合并到每个节点，fetch 自身不能引用自己的输出
+		{"global ref not upstream of every node", nil, map[string]any{"url": "{{ nodes.fetch.output.url }}"}, false},
+		{"global ref nested", nil, map[string]any{"h": map[string]any{"x": []any{"{{ nodes.parse.output }}"}}}, false},
+		{"global plain value", nil, map[string]any{"url": "https://example.com"}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dag := &WorkflowDAG{Nodes: nodes()}
+			dag.Nodes[1].Params = tc.node
+			def := &WorkflowDefinition{WorkflowID: "wf", DAG: dag, GlobalParams: tc.global}
+			if tc.ok {
+				assert.NoError(t, def.Validate())
+			} else {
+				assert.ErrorIs(t, def.Validate(), ErrInvalidWorkflow)
+			}
+		})
+	}
+}
+
+// 测试下游节点通过表达式读取上游输出
+func TestWorkflowPassesOutputs(t *testing.T) {
+	w, store, _ := newTestWorkflowEngine(t)
+	run, err := w.Execute(t.Context(), &WorkflowDefinition{
+		WorkflowID: "outputs",
+		DAG: &WorkflowDAG{Nodes: []WorkflowNode{
+			step("fetch", nil),
+			step("parse", map[string]any{"from": "got {{ nodes.fetch.output.id }}"}, "fetch"),
+		}},
+	}, "test")
+	require.NoError(t, err)
+	assert.Equal(t, "got fetch", store.node(run.ExecutionID, "parse").InputParams["from"])
+}
@@ -0,0 +1,303 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
+	"github.com/iceymoss/go-task/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryWorkflowStore 内存中的工作流执行记录，按执行ID与节点ID覆盖保存
+type memoryWorkflowStore struct {
+	mu    sync.Mutex
+	runs  map[string]WorkflowRun
+	nodes map[string]WorkflowNodeRun
+}
+
+func newMemoryWorkflowStore() *memoryWorkflowStore {
+	return &memoryWorkflowStore{runs: make(map[string]WorkflowRun), nodes: make(map[string]WorkflowNodeRun)}
+}
+
+func (m *memoryWorkflowStore) SaveWorkflowRun(run *WorkflowRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[run.ExecutionID] = *run
+	return nil
+}
+
+func (m *memoryWorkflowStore) SaveNodeRun(node *WorkflowNodeRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nodes[node.WorkflowExecID+"/"+node.NodeID] = *node
+	return nil
+}
+
+func (m *memoryWorkflowStore) node(execID, nodeID string) WorkflowNodeRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.nodes[execID+"/"+nodeID]
+}
+
+// workflowRecorder 记录节点的执行顺序、执行次数与最大并行数
+type workflowRecorder struct {
+	mu        sync.Mutex
+	order     []string
+	runs      map[string]int
+	active    int
+	maxActive int
+}
+
+// stepTask 工作流测试节点：params.id 标识节点，sleep 为执行耗时(毫秒)，前 fail_times 次执行失败
+type stepTask struct {
+	rec *workflowRecorder
+}
+
+func (t *stepTask) Run(ctx context.Context, params map[string]any) error {
+	id, _ := params["id"].(string)
+	t.rec.mu.Lock()
+	t.rec.order = append(t.rec.order, id)
+	t.rec.runs[id]++
+	runs := t.rec.runs[id]
+	t.rec.active++
+	t.rec.maxActive = max(t.rec.maxActive, t.rec.active)
+	t.rec.mu.Unlock()
+	defer func() {
+		t.rec.mu.Lock()
+		t.rec.active--
+		t.rec.mu.Unlock()
+	}()
+
+	if ms, ok := params["sleep"].(int); ok {
+		select {
+		case <-time.After(time.Duration(ms) * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if times, ok := params["fail_times"].(int); ok && runs <= times {
+		return errors.New("step failed")
+	}
+	core.SetOutput(ctx, "id", id)
+	return nil
+}
+
+func (t *stepTask) Identifier() string               { return "test:step" }
+func (t *stepTask) GetDefaultCron() string           { return "" }
+func (t *stepTask) GetDefaultParams() map[string]any { return nil }
+func (t *stepTask) GetTaskType() constants.TaskType  { return constants.TaskTypeAPI }
+
+func newTestWorkflowEngine(t *testing.T) (*WorkflowEngine, *memoryWorkflowStore, *workflowRecorder) {
+	rec := &workflowRecorder{runs: make(map[string]int)}
+	registry := NewTaskRegistry()
+	registry.Register("step", func() core.Task { return &stepTask{rec: rec} })
+	store := newMemoryWorkflowStore()
+	return NewWorkflowEngine(registry, store, NewDefaultLogger()), store, rec
+}
+
+// step 构造一个测试节点
+func step(id string, params map[string]any, dependsOn ...string) WorkflowNode {
+	p := map[string]any{"id": id}
+	for k, v := range params {
+		p[k] = v
+	}
+	return WorkflowNode{ID: id, Task: "step", Params: p, DependsOn: dependsOn}
+}
+
+func TestWorkflowDAGValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		dag  WorkflowDAG
+		err  error
+	}{
+		{"empty", WorkflowDAG{}, ErrWorkflowNodeEmpty},
+		{"duplicate node", WorkflowDAG{Nodes: []WorkflowNode{step("a", nil), step("a", nil)}}, ErrInvalidWorkflow},
+		{"unknown dependency", WorkflowDAG{Nodes: []WorkflowNode{step("a", nil, "missing")}}, ErrInvalidWorkflow},
+		{"unknown edge", WorkflowDAG{Nodes: []WorkflowNode{step("a", nil)}, Edges: []WorkflowEdge{{From: "a", To: "b"}}}, ErrInvalidWorkflow},
+		{"self dependency", WorkflowDAG{Nodes: []WorkflowNode{step("a", nil, "a")}}, ErrCircularDependency},
+		{"cycle", WorkflowDAG{
+			Nodes: []WorkflowNode{step("a", nil, "c"), step("b", nil, "a"), step("c", nil, "b")},
+		}, ErrCircularDependency},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, tc.dag.Validate(), tc.err)
+		})
+	}
+}
+
+// 测试拓扑序：depends_on 与 edges 合并，上游总排在下游之前
+func TestWorkflowTopologicalOrder(t *testing.T) {
+	dag := WorkflowDAG{
+		Nodes: []WorkflowNode{step("d", nil, "b", "c"), step("b", nil), step("c", nil, "a"), step("a", nil)},
+		Edges: []WorkflowEdge{{From: "a", To: "b"}},
+	}
+	require.NoError(t, dag.Validate())
+	order, err := dag.TopologicalOrder()
+	require.NoError(t, err)
+	require.Len(t, order, 4)
+
+	pos := make(map[string]int)
+	for i, id := range order {
+		pos[id] = i
+	}
+	assert.Less(t, pos["a"], pos["b"])
+	assert.Less(t, pos["a"], pos["c"])
+	assert.Less(t, pos["b"], pos["d"])
+	assert.Less(t, pos["c"], pos["d"])
+}
+
+// 测试入度为 0 的分支并行执行，同时运行的节点数受 parallelism 限制，汇聚节点在所有上游完成后执行
+func TestWorkflowParallelBranches(t *testing.T) {
+	w, store, rec := newTestWorkflowEngine(t)
+	w.parallelism = 2
+
+	sleep := map[string]any{"sleep": 50}
+	run, err := w.Execute(context.Background(), &WorkflowDefinition{
+		WorkflowID: "parallel",
+		DAG: &WorkflowDAG{Nodes: []WorkflowNode{
+			step("a", sleep), step("b", sleep), step("c", sleep), step("d", sleep),
+			step("join", nil, "a", "b", "c", "d"),
+		}},
+	}, "test")
+	require.NoError(t, err)
+
+	assert.Equal(t, WorkflowStatusSuccess, run.Status)
+	assert.Equal(t, 2, rec.maxActive)
+	assert.Equal(t, "join", rec.order[len(rec.order)-1])
+	assert.Equal(t, WorkflowStatusSuccess, store.runs[run.ExecutionID].Status)
+	assert.Equal(t, map[string]any{"id": "join"}, store.node(run.ExecutionID, "join").OutputData)
+}
+
+func TestWorkflowFailureStrategies(t *testing.T) {
+	fail := map[string]any{"fail_times": 1}
+
+	t.Run("fail_fast", func(t *testing.T) {
+		w, _, rec := newTestWorkflowEngine(t)
+		run, err := w.Execute(context.Background(), &WorkflowDefinition{
+			WorkflowID: "fail_fast",
+			DAG: &WorkflowDAG{Nodes: []WorkflowNode{
+				step("a", fail), step("slow", map[string]any{"sleep": 2000}), step("child", nil, "a"),
+			}},
+		}, "test")
+		require.Error(t, err)
+
+		// 失败后取消正在运行的分支，未开始的下游被跳过
+		assert.Equal(t, WorkflowStatusFailed, run.Status)
+		assert.Equal(t, NodeStatusFailed, run.NodeStatus["a"])
+		assert.Equal(t, NodeStatusFailed, run.NodeStatus["slow"])
+		assert.Equal(t, NodeStatusSkipped, run.NodeStatus["child"])
+		assert.Zero(t, rec.runs["child"])
+	})
+
+	t.Run("continue", func(t *testing.T) {
+		w, store, rec := newTestWorkflowEngine(t)
+		run, err := w.Execute(context.Background(), &WorkflowDefinition{
+			WorkflowID:      "continue",
+			FailureStrategy: FailureStrategyContinue,
+			DAG: &WorkflowDAG{Nodes: []WorkflowNode{
+				step("a", fail), step("child", nil, "a"), step("grandchild", nil, "child"),
+				step("b", map[string]any{"sleep": 20}), step("after_b", nil, "b"),
+			}},
+		}, "test")
+		require.Error(t, err)
+
+		// 只跳过失败节点的下游，其它分支继续执行
+		assert.Equal(t, WorkflowStatusPartialSuccess, run.Status)
+		assert.Equal(t, []string{"a"}, run.FailedNodes)
+		assert.Equal(t, NodeStatusSkipped, run.NodeStatus["child"])
+		assert.Equal(t, NodeStatusSkipped, run.NodeStatus["grandchild"])
+		assert.Equal(t, NodeStatusSkipped, store.node(run.ExecutionID, "grandchild").Status)
+		assert.Equal(t, NodeStatusSuccess, run.NodeStatus["after_b"])
+		assert.Equal(t, 1, rec.runs["after_b"])
+	})
+
+	t.Run("retry_all", func(t *testing.T) {
+		w, store, rec := newTestWorkflowEngine(t)
+		def := &WorkflowDefinition{
+			WorkflowID:      "retry_all",
+			FailureStrategy: FailureStrategyRetryAll,
+			MaxAttempts:     3,
+			DAG: &WorkflowDAG{Nodes: []WorkflowNode{
+				step("a", nil), step("b", map[string]any{"fail_times": 2}, "a"),
+			}},
+		}
+		run, err := w.Execute(context.Background(), def, "test")
+		require.NoError(t, err)
+
+		// 每一轮都从头执行所有节点
+		assert.Equal(t, WorkflowStatusSuccess, run.Status)
+		assert.Equal(t, 3, rec.runs["a"])
+		assert.Equal(t, 3, rec.runs["b"])
+		assert.Equal(t, 2, store.node(run.ExecutionID, "b").RetryCount)
+
+		// 达到最大执行轮数后仍失败
+		def.MaxAttempts = 2
+		def.DAG.Nodes[1] = step("b2", map[string]any{"fail_times": 5}, "a")
+		run, err = w.Execute(context.Background(), def, "test")
+		require.Error(t, err)
+		assert.Equal(t, WorkflowStatusFailed, run.Status)
+		assert.Equal(t, 2, rec.runs["b2"])
+	})
+}
+
+// 测试节点超时记录为 timeout 状态
+func TestWorkflowNodeTimeout(t *testing.T) {
+	w, store, _ := newTestWorkflowEngine(t)
+	slow := step("slow", map[string]any{"sleep": 3000})
+	slow.Timeout = 1
+
+	run, err := w.Execute(context.Background(), &WorkflowDefinition{
+		WorkflowID: "timeout",
+		DAG:        &WorkflowDAG{Nodes: []WorkflowNode{slow}},
+	}, "test")
+	require.Error(t, err)
+	assert.Equal(t, NodeStatusTimeout, run.NodeStatus["slow"])
+	assert.Equal(t, NodeStatusTimeout, store.node(run.ExecutionID, "slow").Status)
+	assert.Equal(t, []string{"slow"}, run.FailedNodes)
+}
+
+// 测试组合任务沿用工作流定义中的失败策略
+func TestExecuteWorkflowUsesDefinitionStrategy(t *testing.T) {
+	w, store, _ := newTestWorkflowEngine(t)
+	def := &WorkflowDefinition{
+		WorkflowID:      "composite",
+		FailureStrategy: FailureStrategyContinue,
+		DAG: &WorkflowDAG{Nodes: []WorkflowNode{
+			step("a", map[string]any{"fail_times": 1}), step("b", nil),
+		}},
+	}
+	task := core.NewCompositeTask(def.WorkflowID, def, nil, w)
+	assert.Equal(t, WorkflowTaskName("composite"), task.Identifier())
+
+	err := task.Run(&core.TaskContext{Context: context.Background()}, nil)
+	require.Error(t, err)
+	require.Len(t, store.runs, 1)
+	for _, run := range store.runs {
+		assert.Equal(t, WorkflowStatusPartialSuccess, run.Status)
+		assert.Equal(t, "schedule", run.TriggerSource)
+	}
+}
+
+// 测试不带 cron 重新注册工作流时移除之前的定时触发
+func TestAddWorkflowReplacesCronEntry(t *testing.T) {
+	s := NewScheduler(NewTaskRegistry())
+	t.Cleanup(s.Stop)
+	def := &WorkflowDefinition{
+		WorkflowID: "nightly",
+		DAG:        &WorkflowDAG{Nodes: []WorkflowNode{step("a", nil)}},
+	}
+
+	require.NoError(t, s.AddWorkflow("@every 1h", def, "TEST"))
+	assert.Len(t, s.cron.Entries(), 1)
+
+	require.NoError(t, s.AddWorkflow("", def, "TEST"))
+	assert.Empty(t, s.cron.Entries(), "旧的 cron 条目应被移除")
+	assert.Zero(t, s.jobDefinition[WorkflowTaskName("nightly")].entryID)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/alert"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// testSendTimeout 测试发送的超时时间
+const testSendTimeout = 15 * time.Second
+
+// AlertHandler 告警渠道处理器
+type AlertHandler struct{}
+
+// NewAlertHandler 创建告警渠道处理器
+func NewAlertHandler() *AlertHandler {
+	return &AlertHandler{}
+}
+
+// TestChannelRequest 测试未保存的渠道配置
+type TestChannelRequest struct {
+	ChannelType string `json:"channel_type" binding:"required"`
+	Config      string `json:"config" binding:"required"`
+}
+
+// TestChannel 向已保存的渠道发送一条测试消息
+func (h *AlertHandler) TestChannel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	var channel models.AlertChannel
+	dbConn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	if err := dbConn.Where("id = ? AND deleted_at IS NULL", id).First(&channel).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.sendTest(c, channel.ChannelType, channel.Config)
+}
+
+// TestChannelConfig 按请求中的渠道类型与配置发送一条测试消息，用于保存前校验
+func (h *AlertHandler) TestChannelConfig(c *gin.Context) {
+	var req TestChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.sendTest(c, req.ChannelType, req.Config)
+}
+
+func (h *AlertHandler) sendTest(c *gin.Context, channelType, config string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), testSendTimeout)
+	defer cancel()
+
+	if err := alert.SendTest(ctx, channelType, config); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Test notification sent"})
+}
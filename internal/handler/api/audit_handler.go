@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+	maxAuditExport    = 10000 // CSV 导出的最大行数
+)
+
+// AuditHandler 审计日志处理器
+type AuditHandler struct {
+}
+
+// NewAuditHandler 创建审计日志处理器
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{}
+}
+
+// GetAuditLogs 查询审计日志，支持按用户、资源、动作与时间范围筛选；format=csv 时导出为 CSV 文件
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	query := dbCnn.Model(&models.AuditLog{})
+
+	// 支持筛选
+	if user := c.Query("user"); user != "" {
+		if id, err := strconv.ParseUint(user, 10, 32); err == nil {
+			query = query.Where("user_id = ? OR username = ?", id, user)
+		} else {
+			query = query.Where("username = ?", user)
+		}
+	}
+	if resource := c.Query("resource"); resource != "" {
+		query = query.Where("resource = ?", resource)
+	}
+	if resourceID := c.Query("resource_id"); resourceID != "" {
+		query = query.Where("resource_id = ?", resourceID)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	for param, cond := range map[string]string{"start": "created_at >= ?", "end": "created_at < ?"} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s, expected RFC3339 time", param)})
+			return
+		}
+		query = query.Where(cond, t)
+	}
+
+	if c.Query("format") == "csv" {
+		var logs []models.AuditLog
+		if err := query.Order("created_at DESC").Limit(maxAuditExport).Find(&logs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		writeAuditCSV(c, logs)
+		return
+	}
+
+	limit := defaultAuditLimit
+	if l := c.Query("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 && n <= maxAuditLimit {
+			limit = n
+		}
+	}
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var logs []models.AuditLog
+	if err := query.Order("created_at DESC").Offset(max(offset, 0)).Limit(limit).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": logs, "total": total})
+}
+
+// writeAuditCSV 以附件形式输出 CSV
+func writeAuditCSV(c *gin.Context, logs []models.AuditLog) {
+	filename := fmt.Sprintf("audit-logs-%s.csv", time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{
+		"id", "created_at", "user_id", "username", "action", "resource", "resource_id", "status",
+		"error_code", "method", "path", "ip", "user_agent", "request_id", "details", "old_value", "new_value",
+	})
+	for _, log := range logs {
+		userID := ""
+		if log.UserID != nil {
+			userID = strconv.FormatUint(uint64(*log.UserID), 10)
+		}
+		_ = w.Write([]string{
+			strconv.FormatUint(uint64(log.ID), 10),
+			log.CreatedAt.Format(time.RFC3339),
+			userID,
+			log.Username,
+			log.Action,
+			log.Resource,
+			log.ResourceID,
+			log.Status,
+			log.ErrorCode,
+			log.Method,
+			log.Path,
+			log.IP,
+			log.UserAgent,
+			log.RequestID,
+			log.Details,
+			log.OldValue,
+			log.NewValue,
+		})
+	}
+	w.Flush()
+}
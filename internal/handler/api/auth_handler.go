@@ -3,8 +3,10 @@ package api
 import (
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/iceymoss/go-task/internal/audit"
 	"github.com/iceymoss/go-task/internal/conf"
 	"github.com/iceymoss/go-task/internal/service"
 	"github.com/iceymoss/go-task/pkg/auth"
@@ -24,6 +26,11 @@ func NewAuthHandler(cfg *conf.Config) *AuthHandler {
 	jwtService := auth.NewJWTService(cfg.Auth.JWTSecret, time.Duration(cfg.Auth.TokenExpireHrs)*time.Hour)
 	authService := service.NewAuthService(jwtService, time.Duration(cfg.Auth.TokenExpireHrs)*time.Hour)
 
+	// 初始化内置角色
+	if err := service.SeedRoles(); err != nil {
+		log.Printf("⚠️ Failed to seed roles: %v", err)
+	}
+
 	// 初始化默认管理员
 	if err := authService.InitDefaultUser(
 		cfg.Auth.DefaultAdmin.Username,
@@ -53,17 +60,22 @@ type LoginResponse struct {
 
 // Login 用户登录
 func (h *AuthHandler) Login(c *gin.Context) {
+	audit.Record(c, audit.ActionLogin, audit.ResourceUser, "")
+
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	audit.SetUser(c, 0, req.Username)
 
 	user, token, err := h.AuthService.Login(c, req.Username, req.Password)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
+	audit.Record(c, audit.ActionLogin, audit.ResourceUser, strconv.FormatUint(uint64(user.ID), 10))
+	audit.SetUser(c, user.ID, user.Username)
 
 	// 隐藏密码哈希
 	user.PasswordHash = ""
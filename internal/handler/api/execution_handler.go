@@ -0,0 +1,251 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/audit"
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/pkg/auth"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ExecutionHandler 任务执行记录处理器
+type ExecutionHandler struct {
+	scheduler *engine.Scheduler
+}
+
+// NewExecutionHandler 创建执行记录处理器
+func NewExecutionHandler(scheduler *engine.Scheduler) *ExecutionHandler {
+	return &ExecutionHandler{
+		scheduler: scheduler,
+	}
+}
+
+// GetExecutions 获取执行记录列表
+func (h *ExecutionHandler) GetExecutions(c *gin.Context) {
+	limit := 100
+	if l := c.Query("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 && n <= 1000 {
+			limit = n
+		}
+	}
+
+	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	query := dbCnn.Model(&models.JobExecution{})
+
+	// 支持筛选
+	if jobName := c.Query("job_name"); jobName != "" {
+		query = query.Where("job_name = ?", jobName)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if trigger := c.Query("trigger_source"); trigger != "" {
+		query = query.Where("trigger_source = ?", trigger)
+	}
+
+	// 按分组授权的用户只能看到授权分组中任务的执行记录
+	all, groupIDs, err := permittedGroupIDs(c, auth.PermExecutionView)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !all {
+		query = query.Where("job_name IN (?)", dbCnn.Model(&models.Job{}).Select("name").Where("group_id IN ?", groupIDs))
+	}
+
+	var executions []models.JobExecution
+	if err := query.Order("scheduled_at DESC").Limit(limit).Find(&executions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": executions})
+}
+
+// GetExecution 根据执行ID获取执行详情
+func (h *ExecutionHandler) GetExecution(c *gin.Context) {
+	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+
+	var execution models.JobExecution
+	if err := dbCnn.Where("execution_id = ?", c.Param("exec_id")).First(&execution).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "execution not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": execution})
+}
+
+// GetExecutionLogs 获取某次执行的日志
+func (h *ExecutionHandler) GetExecutionLogs(c *gin.Context) {
+	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+
+	var logs []models.JobLog
+	if err := dbCnn.Where("execution_id = ?", c.Param("exec_id")).Order("timestamp ASC").Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": logs})
+}
+
+const (
+	streamPollInterval  = time.Second      // 进度流、日志流轮询的间隔
+	progressWaitTimeout = 30 * time.Second // 执行迟迟没有进度记录（不存在或仍在排队）时结束进度流
+)
+
+// GetExecutionProgress 获取某次执行的实时进度
+func (h *ExecutionHandler) GetExecutionProgress(c *gin.Context) {
+	progress, err := h.scheduler.Progress(c.Request.Context(), c.Param("exec_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if progress == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "progress not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": progress})
+}
+
+// StreamExecutionProgress 以 SSE 推送某次执行的实时进度，执行结束或客户端断开时结束
+func (h *ExecutionHandler) StreamExecutionProgress(c *gin.Context) {
+	execID := c.Param("exec_id")
+	ctx := c.Request.Context()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+	started := time.Now()
+	var lastUpdate time.Time
+
+	c.Stream(func(w io.Writer) bool {
+		progress, err := h.scheduler.Progress(ctx, execID)
+		switch {
+		case err != nil:
+			c.SSEvent("error", gin.H{"error": err.Error()})
+			return false
+		case progress == nil:
+			if time.Since(started) > progressWaitTimeout {
+				c.SSEvent("error", gin.H{"error": "progress not found"})
+				return false
+			}
+		case !progress.UpdatedAt.Equal(lastUpdate):
+			lastUpdate = progress.UpdatedAt
+			c.SSEvent("progress", progress)
+			if progress.Finished() {
+				return false
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
+
+const logStreamBatch = 500 // 日志流每次读取的最大行数
+
+// StreamExecutionLogs 以 SSE 推送某次执行捕获的日志（log 事件），执行结束且日志读完后发送 end 事件并结束；
+// 断线重连时通过 after 参数从指定序号之后继续读取
+func (h *ExecutionHandler) StreamExecutionLogs(c *gin.Context) {
+	execID := c.Param("exec_id")
+	ctx := c.Request.Context()
+	after, _ := strconv.ParseInt(c.Query("after"), 10, 64)
+	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		// 先读状态再读日志：执行结束前日志已全部写入，读到结束状态后的这次读取不会遗漏
+		var execution models.JobExecution
+		if err := dbCnn.WithContext(ctx).Select("status").Where("execution_id = ?", execID).First(&execution).Error; err != nil {
+			msg := err.Error()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				msg = "execution not found"
+			}
+			c.SSEvent("error", gin.H{"error": msg})
+			return false
+		}
+
+		entries, err := h.scheduler.ExecutionLogs(ctx, execID, after, logStreamBatch)
+		if err != nil {
+			c.SSEvent("error", gin.H{"error": err.Error()})
+			return false
+		}
+		for _, e := range entries {
+			c.SSEvent("log", e)
+			after = e.Seq
+		}
+		if len(entries) == logStreamBatch {
+			return true
+		}
+		if engine.ExecutionStatus(execution.Status).Finished() {
+			c.SSEvent("end", gin.H{"status": execution.Status, "after": after})
+			return false
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
+
+// CancelExecution 取消一次排队中或执行中的任务
+func (h *ExecutionHandler) CancelExecution(c *gin.Context) {
+	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	audit.Record(c, audit.ActionCancel, audit.ResourceExecution, c.Param("exec_id"))
+
+	var execution models.JobExecution
+	if err := dbCnn.Where("execution_id = ?", c.Param("exec_id")).First(&execution).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "execution not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 只有排队中和执行中的任务可以取消
+	status := engine.ExecutionStatus(execution.Status)
+	if status != engine.ExecutionStatusPending && status != engine.ExecutionStatusRunning {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("execution already %s", execution.Status)})
+		return
+	}
+
+	if err := h.scheduler.CancelExecution(execution.ExecutionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to cancel execution: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "cancel requested", "exec_id": execution.ExecutionID})
+}
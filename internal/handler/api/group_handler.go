@@ -0,0 +1,492 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/audit"
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/internal/service"
+	"github.com/iceymoss/go-task/pkg/auth"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GroupHandler 任务分组处理器
+type GroupHandler struct {
+	scheduler *engine.Scheduler
+	jobs      *JobHandler
+}
+
+// NewGroupHandler 创建任务分组处理器
+func NewGroupHandler(scheduler *engine.Scheduler) *GroupHandler {
+	return &GroupHandler{
+		scheduler: scheduler,
+		jobs:      NewJobHandler(scheduler),
+	}
+}
+
+// CreateGroupRequest 创建分组请求
+type CreateGroupRequest struct {
+	Name           string                    `json:"name" binding:"required"`
+	DisplayName    string                    `json:"display_name" binding:"required"`
+	Description    string                    `json:"description"`
+	ParentID       *uint                     `json:"parent_id"`
+	Sort           int                       `json:"sort"`
+	Icon           string                    `json:"icon"`
+	Color          string                    `json:"color"`
+	MaxConcurrency int                       `json:"max_concurrency"` // 组内同时执行的任务数上限，0 表示不限制
+	RetryPolicy    *service.GroupRetryPolicy `json:"retry_policy"`    // 组内任务继承的默认重试策略
+}
+
+// UpdateGroupRequest 更新分组请求
+type UpdateGroupRequest struct {
+	DisplayName    *string                   `json:"display_name"`
+	Description    *string                   `json:"description"`
+	ParentID       *uint                     `json:"parent_id"` // 0 表示移动到根节点
+	Sort           *int                      `json:"sort"`
+	Icon           *string                   `json:"icon"`
+	Color          *string                   `json:"color"`
+	MaxConcurrency *int                      `json:"max_concurrency"`
+	RetryPolicy    *service.GroupRetryPolicy `json:"retry_policy"` // 传入空对象表示取消默认重试策略
+}
+
+// GroupResponse 分组响应
+type GroupResponse struct {
+	ID             uint                      `json:"id"`
+	Name           string                    `json:"name"`
+	DisplayName    string                    `json:"display_name"`
+	Description    string                    `json:"description"`
+	ParentID       *uint                     `json:"parent_id,omitempty"`
+	Level          int                       `json:"level"`
+	Path           string                    `json:"path"`
+	Sort           int                       `json:"sort"`
+	Icon           string                    `json:"icon"`
+	Color          string                    `json:"color"`
+	MaxConcurrency int                       `json:"max_concurrency"`
+	RetryPolicy    *service.GroupRetryPolicy `json:"retry_policy"`
+	Children       []*GroupResponse          `json:"children,omitempty"`
+	CreatedAt      time.Time                 `json:"created_at"`
+	UpdatedAt      time.Time                 `json:"updated_at"`
+}
+
+// GroupOperationResult 分组批量操作的结果
+type GroupOperationResult struct {
+	Succeeded  []string          `json:"succeeded"`
+	Skipped    []string          `json:"skipped,omitempty"`
+	Failed     map[string]string `json:"failed,omitempty"`     // 任务名 -> 错误
+	Executions map[string]string `json:"executions,omitempty"` // 任务名 -> 执行ID，仅 run
+}
+
+// GetGroups 获取当前用户可见的分组树，flat=true 时返回按层级排序的列表
+func (h *GroupHandler) GetGroups(c *gin.Context) {
+	groups, err := service.ListGroups()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 按分组授权的用户只能看到授权分组及其子分组
+	all, groupIDs, err := permittedGroupIDs(c, auth.PermJobView)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !all {
+		groups = slices.DeleteFunc(groups, func(group models.JobGroup) bool {
+			return !slices.Contains(groupIDs, group.ID)
+		})
+	}
+
+	if c.Query("flat") == "true" {
+		data := make([]*GroupResponse, len(groups))
+		for i := range groups {
+			data[i] = groupToResponse(&groups[i])
+		}
+		c.JSON(http.StatusOK, gin.H{"data": data, "total": len(data)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": treeToResponse(service.BuildGroupTree(groups)), "total": len(groups)})
+}
+
+// GetGroup 获取分组详情与组内（含子分组）的任务
+func (h *GroupHandler) GetGroup(c *gin.Context) {
+	group, ok := loadGroup(c)
+	if !ok {
+		return
+	}
+	jobs, err := service.GroupJobs(group.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data := make([]JobResponse, len(jobs))
+	for i := range jobs {
+		data[i] = h.jobs.jobToResponse(&jobs[i])
+	}
+	c.JSON(http.StatusOK, gin.H{"data": groupToResponse(group), "jobs": data})
+}
+
+// CreateGroup 创建分组
+func (h *GroupHandler) CreateGroup(c *gin.Context) {
+	audit.Record(c, audit.ActionCreate, audit.ResourceGroup, "")
+
+	var req CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ParentID != nil && *req.ParentID == 0 {
+		req.ParentID = nil
+	}
+
+	// 需要父分组的管理权限，根分组需要全局的管理权限
+	if !requireGroupPermission(c, auth.PermGroupManage, req.ParentID) {
+		return
+	}
+	if !validateGroupDefaults(c, req.MaxConcurrency, req.RetryPolicy) {
+		return
+	}
+
+	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var existing models.JobGroup
+	if err := dbCnn.Where("name = ?", req.Name).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "group name already exists"})
+		return
+	}
+
+	group := &models.JobGroup{
+		Name:           req.Name,
+		DisplayName:    req.DisplayName,
+		Description:    req.Description,
+		ParentID:       req.ParentID,
+		Sort:           req.Sort,
+		Icon:           req.Icon,
+		Color:          req.Color,
+		MaxConcurrency: req.MaxConcurrency,
+		RetryPolicy:    retryPolicyJSON(req.RetryPolicy),
+	}
+	if err := service.CreateGroup(group); err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	audit.Record(c, audit.ActionCreate, audit.ResourceGroup, strconv.FormatUint(uint64(group.ID), 10))
+	audit.SetValues(c, nil, group)
+
+	h.scheduler.SetGroupConcurrency(service.GroupKey(group.ID), group.MaxConcurrency)
+	c.JSON(http.StatusCreated, gin.H{"data": groupToResponse(group)})
+}
+
+// UpdateGroup 更新分组；父分组或默认重试策略变化时，组内任务重新加载到调度器
+func (h *GroupHandler) UpdateGroup(c *gin.Context) {
+	audit.Record(c, audit.ActionUpdate, audit.ResourceGroup, c.Param("id"))
+	group, ok := loadGroup(c)
+	if !ok {
+		return
+	}
+	before := *group
+
+	var req UpdateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 移动到其他分组下时需要目标分组的管理权限
+	parentID := group.ParentID
+	if req.ParentID != nil {
+		parentID = req.ParentID
+		if *parentID == 0 {
+			parentID = nil
+		}
+		if !sameGroup(group.ParentID, parentID) && !requireGroupPermission(c, auth.PermGroupManage, parentID) {
+			return
+		}
+	}
+
+	if req.DisplayName != nil {
+		group.DisplayName = *req.DisplayName
+	}
+	if req.Description != nil {
+		group.Description = *req.Description
+	}
+	if req.Sort != nil {
+		group.Sort = *req.Sort
+	}
+	if req.Icon != nil {
+		group.Icon = *req.Icon
+	}
+	if req.Color != nil {
+		group.Color = *req.Color
+	}
+	if req.MaxConcurrency != nil {
+		group.MaxConcurrency = *req.MaxConcurrency
+	}
+	if req.RetryPolicy != nil {
+		group.RetryPolicy = retryPolicyJSON(req.RetryPolicy)
+	}
+	if !validateGroupDefaults(c, group.MaxConcurrency, req.RetryPolicy) {
+		return
+	}
+
+	if err := service.UpdateGroup(group, parentID); err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	audit.SetValues(c, before, group)
+
+	h.scheduler.SetGroupConcurrency(service.GroupKey(group.ID), group.MaxConcurrency)
+	if !sameGroup(before.ParentID, group.ParentID) || before.RetryPolicy != group.RetryPolicy {
+		if err := h.reloadGroupJobs(group.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to reload group jobs: %v", err)})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": groupToResponse(group)})
+}
+
+// DeleteGroup 删除分组，分组下仍有子分组或任务时返回 409
+func (h *GroupHandler) DeleteGroup(c *gin.Context) {
+	audit.Record(c, audit.ActionDelete, audit.ResourceGroup, c.Param("id"))
+	group, ok := loadGroup(c)
+	if !ok {
+		return
+	}
+
+	if err := service.DeleteGroup(group); err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	audit.SetValues(c, group, nil)
+
+	h.scheduler.SetGroupConcurrency(service.GroupKey(group.ID), 0)
+	c.JSON(http.StatusOK, gin.H{"message": "group deleted successfully"})
+}
+
+// PauseGroup 禁用组内（含子分组）所有启用的任务
+func (h *GroupHandler) PauseGroup(c *gin.Context) {
+	audit.Record(c, audit.ActionDisable, audit.ResourceGroup, c.Param("id"))
+	h.setGroupEnable(c, false)
+}
+
+// ResumeGroup 启用组内（含子分组）所有禁用的任务
+func (h *GroupHandler) ResumeGroup(c *gin.Context) {
+	audit.Record(c, audit.ActionEnable, audit.ResourceGroup, c.Param("id"))
+	h.setGroupEnable(c, true)
+}
+
+func (h *GroupHandler) setGroupEnable(c *gin.Context, enable bool) {
+	jobs, ok := loadGroupJobs(c)
+	if !ok {
+		return
+	}
+
+	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	result := newGroupOperationResult()
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Enable == enable {
+			result.Skipped = append(result.Skipped, job.Name)
+			continue
+		}
+		job.Enable = enable
+		if err := dbCnn.Model(job).Update("enable", enable).Error; err != nil {
+			result.Failed[job.Name] = err.Error()
+			continue
+		}
+		if err := h.jobs.reloadJobToScheduler(job); err != nil {
+			result.Failed[job.Name] = err.Error()
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, job.Name)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// RunGroup 立即执行组内（含子分组）所有启用的任务，仍受任务并发策略与分组并发上限约束
+func (h *GroupHandler) RunGroup(c *gin.Context) {
+	audit.Record(c, audit.ActionRun, audit.ResourceGroup, c.Param("id"))
+	jobs, ok := loadGroupJobs(c)
+	if !ok {
+		return
+	}
+
+	result := newGroupOperationResult()
+	result.Executions = make(map[string]string)
+	for _, job := range jobs {
+		if !job.Enable {
+			result.Skipped = append(result.Skipped, job.Name)
+			continue
+		}
+		execID, err := h.scheduler.ManualRun(job.Name)
+		if err != nil {
+			result.Failed[job.Name] = err.Error()
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, job.Name)
+		result.Executions[job.Name] = execID
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": result})
+}
+
+// SetGroupAlertRule 将告警规则绑定到分组，组内（含子分组）任务继承该规则
+func (h *GroupHandler) SetGroupAlertRule(c *gin.Context) {
+	audit.Record(c, audit.ActionUpdate, audit.ResourceGroup, c.Param("id"))
+	group, ok := loadGroup(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		RuleID uint `json:"rule_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := service.BindAlertRule(req.RuleID, group.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "alert rule bound to group", "rule_id": req.RuleID, "group_id": group.ID})
+}
+
+// reloadGroupJobs 将组内启用的任务重新加载到调度器，使分组链与继承的重试策略生效
+func (h *GroupHandler) reloadGroupJobs(groupID uint) error {
+	jobs, err := service.GroupJobs(groupID)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := range jobs {
+		if !jobs[i].Enable {
+			continue
+		}
+		if err := service.AddJobToScheduler(h.scheduler, &jobs[i]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", jobs[i].Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// loadGroup 按路由参数 :id 查询分组，失败时写入响应并返回 false
+func loadGroup(c *gin.Context) (*models.JobGroup, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return nil, false
+	}
+	group, err := service.GetGroup(uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrGroupNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return group, true
+}
+
+// loadGroupJobs 查询路由参数 :id 对应分组及其子分组中的任务
+func loadGroupJobs(c *gin.Context) ([]models.Job, bool) {
+	group, ok := loadGroup(c)
+	if !ok {
+		return nil, false
+	}
+	jobs, err := service.GroupJobs(group.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return jobs, true
+}
+
+// validateGroupDefaults 校验分组的并发上限与默认重试策略
+func validateGroupDefaults(c *gin.Context, maxConcurrency int, policy *service.GroupRetryPolicy) bool {
+	if maxConcurrency < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_concurrency must not be negative"})
+		return false
+	}
+	if policy == nil {
+		return true
+	}
+	if policy.MaxRetries != nil && *policy.MaxRetries < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "retry_policy.max_retries must not be negative"})
+		return false
+	}
+	switch strings.ToLower(policy.RetryBackoff) {
+	case "", "exponential", "linear", "fixed":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid retry_policy.retry_backoff: %s", policy.RetryBackoff)})
+		return false
+	}
+	return true
+}
+
+// writeGroupError 将分组服务的错误转换为响应
+func writeGroupError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrGroupNotFound), errors.Is(err, service.ErrGroupCycle):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrGroupNotEmpty):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// retryPolicyJSON 序列化默认重试策略，空策略表示不设置
+func retryPolicyJSON(policy *service.GroupRetryPolicy) string {
+	if policy == nil {
+		return ""
+	}
+	raw, _ := json.Marshal(policy)
+	if string(raw) == "{}" {
+		return ""
+	}
+	return string(raw)
+}
+
+func newGroupOperationResult() *GroupOperationResult {
+	return &GroupOperationResult{Succeeded: []string{}, Failed: make(map[string]string)}
+}
+
+func groupToResponse(group *models.JobGroup) *GroupResponse {
+	return &GroupResponse{
+		ID:             group.ID,
+		Name:           group.Name,
+		DisplayName:    group.DisplayName,
+		Description:    group.Description,
+		ParentID:       group.ParentID,
+		Level:          group.Level,
+		Path:           group.Path,
+		Sort:           group.Sort,
+		Icon:           group.Icon,
+		Color:          group.Color,
+		MaxConcurrency: group.MaxConcurrency,
+		RetryPolicy:    service.ParseGroupRetryPolicy(group.RetryPolicy),
+		CreatedAt:      group.CreatedAt,
+		UpdatedAt:      group.UpdatedAt,
+	}
+}
+
+func treeToResponse(nodes []*service.GroupNode) []*GroupResponse {
+	data := make([]*GroupResponse, len(nodes))
+	for i, node := range nodes {
+		data[i] = groupToResponse(&node.Group)
+		data[i].Children = treeToResponse(node.Children)
+	}
+	return data
+}
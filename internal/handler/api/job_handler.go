@@ -7,10 +7,15 @@ import (
 	"gorm.io/gorm"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/iceymoss/go-task/internal/audit"
+	"github.com/iceymoss/go-task/internal/core"
 	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/internal/service"
 	"github.com/iceymoss/go-task/internal/tasks"
+	"github.com/iceymoss/go-task/pkg/auth"
 	"github.com/iceymoss/go-task/pkg/constants"
 	"github.com/iceymoss/go-task/pkg/db"
 	"github.com/iceymoss/go-task/pkg/db/models"
@@ -37,14 +42,25 @@ type CreateJobRequest struct {
 	DisplayName  string         `json:"display_name" binding:"required"`
 	Type         string         `json:"type" binding:"required"`
 	CronExpr     string         `json:"cron_expr" binding:"required"`
+	GroupID      *uint          `json:"group_id"` // 所属分组
 	Params       map[string]any `json:"params"`
 	Enable       bool           `json:"enable"`
 	Dependencies []string       `json:"dependencies"`
 	Priority     int            `json:"priority"`
 	Timeout      int            `json:"timeout"`
 	MaxRetries   int            `json:"max_retries"`
+	Concurrency  string         `json:"concurrency"` // 并发策略: allow, forbid, replace, queue
+	MaxPending   int            `json:"max_pending"` // queue 策略下最多排队等待的执行数
+	Misfire      string         `json:"misfire"`     // 错过触发的补偿策略: skip(默认), run_once, run_all
 	Description  string         `json:"description"`
 	Tags         []string       `json:"tags"`
+
+	DependencyType    string `json:"dependency_type"`    // 依赖类型: all_success(默认), any_success, all_complete
+	DependencyTimeout int    `json:"dependency_timeout"` // 等待上游的超时时间(秒)，0 表示一直等待
+
+	RetryBackoff  int      `json:"retry_backoff"`  // 首次重试前的等待时间(秒)，默认 5
+	RetryStrategy string   `json:"retry_strategy"` // 退避方式: exponential(默认), linear, fixed
+	RetryOn       []string `json:"retry_on"`       // 只重试指定类别的错误: timeout, network，为空时重试所有错误
 }
 
 // UpdateJobRequest 更新任务请求
@@ -52,14 +68,26 @@ type UpdateJobRequest struct {
 	DisplayName  *string        `json:"display_name"`
 	Type         *string        `json:"type"`
 	CronExpr     *string        `json:"cron_expr"`
+	GroupID      *uint          `json:"group_id"` // 所属分组，0 表示移出分组
 	Params       map[string]any `json:"params"`
 	Enable       *bool          `json:"enable"`
 	Dependencies []string       `json:"dependencies"`
 	Priority     *int           `json:"priority"`
 	Timeout      *int           `json:"timeout"`
 	MaxRetries   *int           `json:"max_retries"`
+	Concurrency  *string        `json:"concurrency"`
+	MaxPending   *int           `json:"max_pending"`
+	Misfire      *string        `json:"misfire"`
 	Description  *string        `json:"description"`
 	Tags         []string       `json:"tags"`
+	ChangeLog    string         `json:"change_log"` // 变更说明，记录在更新前的版本中
+
+	DependencyType    *string `json:"dependency_type"`
+	DependencyTimeout *int    `json:"dependency_timeout"`
+
+	RetryBackoff  *int     `json:"retry_backoff"`
+	RetryStrategy *string  `json:"retry_strategy"`
+	RetryOn       []string `json:"retry_on"`
 }
 
 // JobResponse 任务响应
@@ -71,17 +99,29 @@ type JobResponse struct {
 	CronExpr     string         `json:"cron_expr"`
 	Enable       bool           `json:"enable"`
 	Source       string         `json:"source"`
+	GroupID      *uint          `json:"group_id,omitempty"`
 	Params       map[string]any `json:"params"`
 	Dependencies []string       `json:"dependencies"`
 	Priority     int            `json:"priority"`
 	Timeout      int            `json:"timeout"`
 	MaxRetries   int            `json:"max_retries"`
+	Concurrency  string         `json:"concurrency"`
+	MaxPending   int            `json:"max_pending"`
+	Misfire      string         `json:"misfire"`
 	Description  string         `json:"description"`
 	Tags         []string       `json:"tags"`
 	Status       string         `json:"status"`
+	LastExecID   string         `json:"last_exec_id,omitempty"`
 	LastRunAt    *time.Time     `json:"last_run_at"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
+
+	DependencyType    string `json:"dependency_type,omitempty"`
+	DependencyTimeout int    `json:"dependency_timeout,omitempty"`
+
+	RetryBackoff  int      `json:"retry_backoff"`
+	RetryStrategy string   `json:"retry_strategy"`
+	RetryOn       []string `json:"retry_on,omitempty"`
 }
 
 // GetJobs 获取任务列表
@@ -101,6 +141,30 @@ func (h *JobHandler) GetJobs(c *gin.Context) {
 			query = query.Where("enable = ?", false)
 		}
 	}
+	if group := c.Query("group_id"); group != "" {
+		groupID, err := strconv.ParseUint(group, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group_id"})
+			return
+		}
+		// 包含子分组中的任务
+		ids, err := service.GroupSubtree([]uint{uint(groupID)})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("group_id IN ?", ids)
+	}
+
+	// 按分组授权的用户只能看到授权分组中的任务
+	all, groupIDs, err := permittedGroupIDs(c, auth.PermJobView)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !all {
+		query = query.Where("group_id IN ?", groupIDs)
+	}
 
 	if err := query.Order("created_at DESC").Find(&jobs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -135,12 +199,19 @@ func (h *JobHandler) GetJob(c *gin.Context) {
 
 // CreateJob 创建任务
 func (h *JobHandler) CreateJob(c *gin.Context) {
+	audit.Record(c, audit.ActionCreate, audit.ResourceJob, "")
+
 	var req CreateJobRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// 需要所属分组的创建权限，未分组的任务需要全局的创建权限
+	if !validateGroup(c, req.GroupID) || !requireGroupPermission(c, auth.PermJobCreate, req.GroupID) {
+		return
+	}
+
 	// 验证任务类型
 	if !isValidTaskType(req.Type) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid task type: %s", req.Type)})
@@ -153,6 +224,15 @@ func (h *JobHandler) CreateJob(c *gin.Context) {
 		return
 	}
 
+	if !validateRetry(c, req.RetryBackoff, req.RetryStrategy) {
+		return
+	}
+
+	// 按任务类型的 ParamSchema 校验参数
+	if !validateJobParams(c, req.Type, req.Params) {
+		return
+	}
+
 	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
 
 	// 检查任务名称是否已存在
@@ -162,9 +242,14 @@ func (h *JobHandler) CreateJob(c *gin.Context) {
 		return
 	}
 
+	// 上游任务必须存在且不能形成循环依赖
+	deps := service.JobDependencies{DependsOn: req.Dependencies, Type: req.DependencyType, Timeout: req.DependencyTimeout}
+	if !h.validateDependencies(c, req.Name, deps) {
+		return
+	}
+
 	// 转换参数
 	paramsJSON, _ := json.Marshal(req.Params)
-	depsJSON, _ := json.Marshal(req.Dependencies)
 	tagsJSON, _ := json.Marshal(req.Tags)
 
 	// 创建任务记录
@@ -173,31 +258,39 @@ func (h *JobHandler) CreateJob(c *gin.Context) {
 		DisplayName:  req.DisplayName,
 		Type:         req.Type,
 		CronExpr:     req.CronExpr,
+		GroupID:      req.GroupID,
 		Params:       string(paramsJSON),
 		Enable:       req.Enable,
-		Dependencies: string(depsJSON),
+		Dependencies: deps.Encode(),
 		Priority:     req.Priority,
 		Timeout:      req.Timeout,
 		MaxRetries:   req.MaxRetries,
 		Description:  req.Description,
 		Tags:         string(tagsJSON),
 		Source:       string(constants.TaskTypeWEB),
+
+		ConcurrentPolicy: string(engine.ParseConcurrencyPolicy(req.Concurrency)),
+		MaxPending:       req.MaxPending,
+		MisfirePolicy:    string(engine.ParseMisfirePolicy(req.Misfire)),
+
+		RetryBackoff:  req.RetryBackoff,
+		RetryStrategy: strings.ToLower(req.RetryStrategy),
+	}
+	if len(req.RetryOn) > 0 {
+		retryOnJSON, _ := json.Marshal(req.RetryOn)
+		job.RetryOn = string(retryOnJSON)
 	}
 
 	if err := dbCnn.Create(job).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.Record(c, audit.ActionCreate, audit.ResourceJob, strconv.FormatUint(uint64(job.ID), 10))
+	audit.SetValues(c, nil, job)
 
 	// 动态添加到调度器
 	if job.Enable {
-		if err := h.scheduler.AddJob(
-			job.CronExpr,
-			job.Name,
-			job.Name,
-			req.Params,
-			job.Source,
-		); err != nil {
+		if err := service.AddJobToScheduler(h.scheduler, job); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to add job to scheduler: %v", err)})
 			return
 		}
@@ -211,6 +304,7 @@ func (h *JobHandler) UpdateJob(c *gin.Context) {
 	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
 
 	id := c.Param("id")
+	audit.Record(c, audit.ActionUpdate, audit.ResourceJob, id)
 	var job models.Job
 	if err := dbCnn.First(&job, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -221,6 +315,8 @@ func (h *JobHandler) UpdateJob(c *gin.Context) {
 		return
 	}
 
+	before := job
+
 	var req UpdateJobRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -241,6 +337,28 @@ func (h *JobHandler) UpdateJob(c *gin.Context) {
 		}
 	}
 
+	backoff, strategy := job.RetryBackoff, job.RetryStrategy
+	if req.RetryBackoff != nil {
+		backoff = *req.RetryBackoff
+	}
+	if req.RetryStrategy != nil {
+		strategy = *req.RetryStrategy
+	}
+	if !validateRetry(c, backoff, strategy) {
+		return
+	}
+
+	// 移动到其他分组时需要目标分组的更新权限
+	if req.GroupID != nil {
+		if *req.GroupID == 0 {
+			req.GroupID = nil
+		}
+		if !validateGroup(c, req.GroupID) || !requireGroupPermission(c, auth.PermJobUpdate, req.GroupID) {
+			return
+		}
+		job.GroupID = req.GroupID
+	}
+
 	// 更新字段
 	if req.DisplayName != nil {
 		job.DisplayName = *req.DisplayName
@@ -258,9 +376,21 @@ func (h *JobHandler) UpdateJob(c *gin.Context) {
 	if req.Enable != nil {
 		job.Enable = *req.Enable
 	}
-	if req.Dependencies != nil {
-		depsJSON, _ := json.Marshal(req.Dependencies)
-		job.Dependencies = string(depsJSON)
+	if req.Dependencies != nil || req.DependencyType != nil || req.DependencyTimeout != nil {
+		deps, _ := service.ParseJobDependencies(job.Dependencies)
+		if req.Dependencies != nil {
+			deps.DependsOn = req.Dependencies
+		}
+		if req.DependencyType != nil {
+			deps.Type = *req.DependencyType
+		}
+		if req.DependencyTimeout != nil {
+			deps.Timeout = *req.DependencyTimeout
+		}
+		if !h.validateDependencies(c, job.Name, deps) {
+			return
+		}
+		job.Dependencies = deps.Encode()
 	}
 	if req.Priority != nil {
 		job.Priority = *req.Priority
@@ -271,6 +401,25 @@ func (h *JobHandler) UpdateJob(c *gin.Context) {
 	if req.MaxRetries != nil {
 		job.MaxRetries = *req.MaxRetries
 	}
+	if req.RetryBackoff != nil {
+		job.RetryBackoff = *req.RetryBackoff
+	}
+	if req.RetryStrategy != nil {
+		job.RetryStrategy = strings.ToLower(*req.RetryStrategy)
+	}
+	if req.RetryOn != nil {
+		retryOnJSON, _ := json.Marshal(req.RetryOn)
+		job.RetryOn = string(retryOnJSON)
+	}
+	if req.Concurrency != nil {
+		job.ConcurrentPolicy = string(engine.ParseConcurrencyPolicy(*req.Concurrency))
+	}
+	if req.MaxPending != nil {
+		job.MaxPending = *req.MaxPending
+	}
+	if req.Misfire != nil {
+		job.MisfirePolicy = string(engine.ParseMisfirePolicy(*req.Misfire))
+	}
 	if req.Description != nil {
 		job.Description = *req.Description
 	}
@@ -279,10 +428,29 @@ func (h *JobHandler) UpdateJob(c *gin.Context) {
 		job.Tags = string(tagsJSON)
 	}
 
-	if err := dbCnn.Save(&job).Error; err != nil {
+	// 类型或参数变化后按新的 ParamSchema 校验参数
+	if req.Type != nil || req.Params != nil {
+		var params map[string]any
+		_ = json.Unmarshal([]byte(job.Params), &params)
+		if !validateJobParams(c, job.Type, params) {
+			return
+		}
+	}
+
+	// 配置发生变化时将更新前的任务保存为新版本
+	err := dbCnn.Transaction(func(tx *gorm.DB) error {
+		if len(service.DiffSnapshots(service.SnapshotJob(&before), service.SnapshotJob(&job))) > 0 {
+			if _, err := service.SaveJobVersion(tx, &before, req.ChangeLog, currentUserID(c)); err != nil {
+				return err
+			}
+		}
+		return tx.Save(&job).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.SetValues(c, before, job)
 
 	// 重新加载到调度器
 	if err := h.reloadJobToScheduler(&job); err != nil {
@@ -298,6 +466,7 @@ func (h *JobHandler) DeleteJob(c *gin.Context) {
 	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
 
 	id := c.Param("id")
+	audit.Record(c, audit.ActionDelete, audit.ResourceJob, id)
 	var job models.Job
 	if err := dbCnn.First(&job, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -313,6 +482,26 @@ func (h *JobHandler) DeleteJob(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.SetValues(c, job, nil)
+
+	// 从调度器中移除
+	if err := h.scheduler.RemoveJob(job.Name); err != nil && !errors.Is(err, engine.ErrJobNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to remove job: %v", err)})
+		return
+	}
+
+	// 从下游任务的依赖中移除该任务，避免下游一直等待
+	downstream, err := service.RemoveDependencyOnJob(job.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to remove dependencies: %v", err)})
+		return
+	}
+	for i := range downstream {
+		if err := h.reloadJobToScheduler(&downstream[i]); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to reload job %s: %v", downstream[i].Name, err)})
+			return
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "job deleted successfully"})
 }
@@ -322,6 +511,7 @@ func (h *JobHandler) EnableJob(c *gin.Context) {
 	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
 
 	id := c.Param("id")
+	audit.Record(c, audit.ActionEnable, audit.ResourceJob, id)
 	var job models.Job
 	if err := dbCnn.First(&job, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -332,11 +522,13 @@ func (h *JobHandler) EnableJob(c *gin.Context) {
 		return
 	}
 
+	before := job
 	job.Enable = true
 	if err := dbCnn.Save(&job).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.SetValues(c, before, job)
 
 	// 添加到调度器
 	if err := h.reloadJobToScheduler(&job); err != nil {
@@ -351,6 +543,7 @@ func (h *JobHandler) EnableJob(c *gin.Context) {
 func (h *JobHandler) DisableJob(c *gin.Context) {
 	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
 	id := c.Param("id")
+	audit.Record(c, audit.ActionDisable, audit.ResourceJob, id)
 	var job models.Job
 	if err := dbCnn.First(&job, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -361,18 +554,57 @@ func (h *JobHandler) DisableJob(c *gin.Context) {
 		return
 	}
 
+	before := job
 	job.Enable = false
 	if err := dbCnn.Save(&job).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.SetValues(c, before, job)
+
+	// 暂停调度
+	if err := h.reloadJobToScheduler(&job); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to disable job: %v", err)})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": h.jobToResponse(&job)})
 }
 
-// GetJobLogs 获取任务执行日志
-func (h *JobHandler) GetJobLogs(c *gin.Context) {
+// KillJob 取消任务所有正在执行和排队中的执行
+func (h *JobHandler) KillJob(c *gin.Context) {
+	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
 	id := c.Param("id")
+	audit.Record(c, audit.ActionKill, audit.ResourceJob, id)
+	var job models.Job
+	if err := dbCnn.First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	cancelled, err := h.scheduler.KillJob(job.Name)
+	if err != nil {
+		if errors.Is(err, engine.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "job not scheduled"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to kill job: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "kill requested", "cancelled": cancelled})
+}
+
+// GetJobLogs 获取任务执行日志，路由参数 :id 为任务ID，与 JobScope 的鉴权对象一致
+func (h *JobHandler) GetJobLogs(c *gin.Context) {
+	job, ok := loadJob(c)
+	if !ok {
+		return
+	}
 	limit := 100
 	if l := c.Query("limit"); l != "" {
 		if n, err := strconv.Atoi(l); err == nil && n > 0 && n <= 1000 {
@@ -383,7 +615,7 @@ func (h *JobHandler) GetJobLogs(c *gin.Context) {
 	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
 
 	var logs []models.JobLog
-	if err := dbCnn.Where("job_name = ?", id).Order("start_time DESC").Limit(limit).Find(&logs).Error; err != nil {
+	if err := dbCnn.Where("job_name = ?", job.Name).Order("start_time DESC").Limit(limit).Find(&logs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -434,6 +666,11 @@ func (h *JobHandler) GetJobTemplates(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": templates})
 }
 
+// GetTaskTypes 获取所有任务类型的元数据，前端据 param_schema 自动生成参数表单
+func (h *JobHandler) GetTaskTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": h.scheduler.TaskTypes()})
+}
+
 // CreateFromTemplate 从模板创建任务
 func (h *JobHandler) CreateFromTemplate(c *gin.Context) {
 	type Request struct {
@@ -443,12 +680,18 @@ func (h *JobHandler) CreateFromTemplate(c *gin.Context) {
 		Enable     bool              `json:"enable"`
 	}
 
+	audit.Record(c, audit.ActionCreate, audit.ResourceJob, "")
+
 	var req Request
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if !requireGroupPermission(c, auth.PermJobCreate, nil) {
+		return
+	}
+
 	// 获取模板
 	template, err := tasks.GetJobTemplate(req.TemplateID)
 	if err != nil {
@@ -458,6 +701,11 @@ func (h *JobHandler) CreateFromTemplate(c *gin.Context) {
 
 	// 应用变量替换
 	params := tasks.ApplyTemplateVariables(template, req.Variables)
+	var paramMap map[string]any
+	_ = json.Unmarshal([]byte(params), &paramMap)
+	if !validateJobParams(c, template.Type, paramMap) {
+		return
+	}
 
 	// 创建任务
 	job := &models.Job{
@@ -477,6 +725,8 @@ func (h *JobHandler) CreateFromTemplate(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.Record(c, audit.ActionCreate, audit.ResourceJob, strconv.FormatUint(uint64(job.ID), 10))
+	audit.SetValues(c, nil, job)
 
 	c.JSON(http.StatusCreated, gin.H{"data": h.jobToResponse(job)})
 }
@@ -485,15 +735,28 @@ func (h *JobHandler) CreateFromTemplate(c *gin.Context) {
 func (h *JobHandler) GetDependencyGraph(c *gin.Context) {
 	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
 
+	query := dbCnn.Where("enable = ?", true)
+	// 按分组授权的用户只能看到授权分组中的任务及其之间的依赖
+	all, groupIDs, err := permittedGroupIDs(c, auth.PermJobView)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !all {
+		query = query.Where("group_id IN ?", groupIDs)
+	}
+
 	var jobs []models.Job
-	if err := dbCnn.Where("enable = ?", true).Find(&jobs).Error; err != nil {
+	if err := query.Find(&jobs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// 构建节点和边
 	nodes := make([]map[string]any, len(jobs))
+	visible := make(map[string]bool, len(jobs))
 	for i, job := range jobs {
+		visible[job.Name] = true
 		nodes[i] = map[string]any{
 			"id":        job.Name,
 			"label":     job.DisplayName,
@@ -505,15 +768,15 @@ func (h *JobHandler) GetDependencyGraph(c *gin.Context) {
 
 	links := make([]map[string]any, 0)
 	for _, job := range jobs {
-		if job.Dependencies != "" {
-			var deps []string
-			json.Unmarshal([]byte(job.Dependencies), &deps)
-			for _, dep := range deps {
-				links = append(links, map[string]any{
-					"source": dep,
-					"target": job.Name,
-				})
+		deps, _ := service.ParseJobDependencies(job.Dependencies)
+		for _, dep := range deps.DependsOn {
+			if !all && !visible[dep] {
+				continue
 			}
+			links = append(links, map[string]any{
+				"source": dep,
+				"target": job.Name,
+			})
 		}
 	}
 
@@ -527,23 +790,26 @@ func (h *JobHandler) GetDependencyGraph(c *gin.Context) {
 
 func (h *JobHandler) jobToResponse(job *models.Job) JobResponse {
 	var params map[string]any
-	var dependencies []string
 	var tags []string
 
 	if job.Params != "" {
 		json.Unmarshal([]byte(job.Params), &params)
 	}
-	if job.Dependencies != "" {
-		json.Unmarshal([]byte(job.Dependencies), &dependencies)
-	}
+	deps, _ := service.ParseJobDependencies(job.Dependencies)
 	if job.Tags != "" {
 		json.Unmarshal([]byte(job.Tags), &tags)
 	}
+	var retryOn []string
+	if job.RetryOn != "" {
+		json.Unmarshal([]byte(job.RetryOn), &retryOn)
+	}
 
 	// 获取任务状态
 	status := "unknown"
+	var lastExecID string
 	if stat, ok := h.scheduler.Stats.Get(job.Name); ok {
 		status = string(stat.Status)
+		lastExecID = stat.LastExecID
 	}
 
 	return JobResponse{
@@ -554,39 +820,47 @@ func (h *JobHandler) jobToResponse(job *models.Job) JobResponse {
 		CronExpr:     job.CronExpr,
 		Enable:       job.Enable,
 		Source:       job.Source,
+		GroupID:      job.GroupID,
 		Params:       params,
-		Dependencies: dependencies,
+		Dependencies: deps.DependsOn,
 		Priority:     job.Priority,
 		Timeout:      job.Timeout,
 		MaxRetries:   job.MaxRetries,
+		Concurrency:  string(engine.ParseConcurrencyPolicy(job.ConcurrentPolicy)),
+		MaxPending:   job.MaxPending,
+		Misfire:      string(engine.ParseMisfirePolicy(job.MisfirePolicy)),
 		Description:  job.Description,
 		Tags:         tags,
 		Status:       status,
+		LastExecID:   lastExecID,
 		LastRunAt:    job.LastRunAt,
 		CreatedAt:    job.CreatedAt,
 		UpdatedAt:    job.UpdatedAt,
+
+		DependencyType:    deps.Type,
+		DependencyTimeout: deps.Timeout,
+
+		RetryBackoff:  job.RetryBackoff,
+		RetryStrategy: job.RetryStrategy,
+		RetryOn:       retryOn,
 	}
 }
 
+// reloadJobToScheduler 将数据库中的任务配置同步到调度器：
+// 启用的任务整体替换原有调度（不会叠加 cron 条目）并恢复暂停，禁用的任务暂停调度
 func (h *JobHandler) reloadJobToScheduler(job *models.Job) error {
-	// 解析参数
-	var params map[string]any
-	if job.Params != "" {
-		json.Unmarshal([]byte(job.Params), &params)
+	if !job.Enable {
+		if err := h.scheduler.PauseJob(job.Name); err != nil && !errors.Is(err, engine.ErrJobNotFound) {
+			return err
+		}
+		return nil
 	}
 
-	// 如果任务启用，添加到调度器
-	if job.Enable {
-		return h.scheduler.AddJob(
-			job.CronExpr,
-			job.Name,
-			job.Name,
-			params,
-			job.Source,
-		)
+	if err := service.AddJobToScheduler(h.scheduler, job); err != nil {
+		return err
 	}
-
-	return nil
+	// 重新注册会保留暂停状态，数据库中已启用的任务需要显式恢复
+	return h.scheduler.ResumeJob(job.Name)
 }
 
 func isValidTaskType(taskType string) bool {
@@ -600,6 +874,79 @@ func isValidTaskType(taskType string) bool {
 	return validTypes[taskType]
 }
 
+// validateJobParams 按任务类型声明的 ParamSchema 校验参数，不通过时返回 400 与字段级错误
+func validateJobParams(c *gin.Context, taskType string, params map[string]any) bool {
+	task, err := tasks.GetTaskByType(taskType)
+	if err != nil {
+		// custom 等需要注册的任务类型在创建时拿不到实例，执行前再校验
+		return true
+	}
+	err = core.ValidateTaskParams(task, params)
+	if err == nil {
+		return true
+	}
+
+	var verrs core.ValidationErrors
+	if errors.As(err, &verrs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "fields": verrs.Fields()})
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	return false
+}
+
+// validateRetry 校验重试间隔与退避方式，不通过时返回 400
+func validateRetry(c *gin.Context, backoff int, strategy string) bool {
+	if backoff < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "retry_backoff must not be negative"})
+		return false
+	}
+	switch strings.ToLower(strategy) {
+	case "", "exponential", "linear", "fixed":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid retry_strategy: %s", strategy)})
+		return false
+	}
+	return true
+}
+
+// validateGroup 校验分组存在，不存在时返回 400
+func validateGroup(c *gin.Context, groupID *uint) bool {
+	if groupID == nil {
+		return true
+	}
+	if _, err := service.GetGroup(*groupID); err != nil {
+		if errors.Is(err, service.ErrGroupNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("group not found: %d", *groupID)})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// validateDependencies 校验依赖类型与超时，上游任务必须存在且不能形成循环依赖，不通过时返回 400
+func (h *JobHandler) validateDependencies(c *gin.Context, name string, deps service.JobDependencies) bool {
+	if _, err := engine.ParseDependencyType(deps.Type); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if deps.Timeout < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dependency_timeout must not be negative"})
+		return false
+	}
+	if err := service.CheckJobDependencies(h.scheduler, name, deps.DependsOn); err != nil {
+		if errors.Is(err, engine.ErrCircularDependency) || errors.Is(err, engine.ErrDependencyNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
 // parseCronExpr 解析 Cron 表达式
 func parseCronExpr(expr string) (cron.Schedule, error) {
 	// 使用 cron 的默认解析器
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/audit"
+	"github.com/iceymoss/go-task/internal/service"
+	"github.com/iceymoss/go-task/pkg/auth"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// JobVersionResponse 任务版本响应
+type JobVersionResponse struct {
+	ID        uint                `json:"id"`
+	Version   string              `json:"version"`
+	Config    service.JobSnapshot `json:"config"`
+	ChangeLog string              `json:"change_log"`
+	CreatedBy *uint               `json:"created_by,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// GetJobVersions 获取任务的历史版本，按版本从新到旧排列
+func (h *JobHandler) GetJobVersions(c *gin.Context) {
+	job, ok := loadJob(c)
+	if !ok {
+		return
+	}
+
+	versions, err := service.ListJobVersions(job.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data := make([]JobVersionResponse, 0, len(versions))
+	for i := range versions {
+		snap, _ := service.ParseJobSnapshot(&versions[i])
+		data = append(data, JobVersionResponse{
+			ID:        versions[i].ID,
+			Version:   versions[i].Version,
+			Config:    snap,
+			ChangeLog: versions[i].ChangeLog,
+			CreatedBy: versions[i].CreatedBy,
+			CreatedAt: versions[i].CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data, "total": len(data)})
+}
+
+// GetJobVersionDiff 比较版本 :v 与当前配置的字段差异，传入 against 时与该版本比较
+func (h *JobHandler) GetJobVersionDiff(c *gin.Context) {
+	job, ok := loadJob(c)
+	if !ok {
+		return
+	}
+
+	from, ok := loadJobSnapshot(c, job.ID, c.Param("v"))
+	if !ok {
+		return
+	}
+	against := c.DefaultQuery("against", "current")
+	to := service.SnapshotJob(job)
+	if against != "current" {
+		if to, ok = loadJobSnapshot(c, job.ID, against); !ok {
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"from":    c.Param("v"),
+		"to":      against,
+		"changes": service.DiffSnapshots(from, to),
+	}})
+}
+
+// RollbackJob 将任务回滚到版本 :v 的配置并重新加载到调度器，回滚前的配置保存为新版本
+func (h *JobHandler) RollbackJob(c *gin.Context) {
+	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+
+	audit.Record(c, audit.ActionRollback, audit.ResourceJob, c.Param("id"))
+	job, ok := loadJob(c)
+	if !ok {
+		return
+	}
+	v := c.Param("v")
+	snap, ok := loadJobSnapshot(c, job.ID, v)
+	if !ok {
+		return
+	}
+
+	// 回滚会改变所属分组时，需要目标分组的更新权限
+	if !sameGroup(job.GroupID, snap.GroupID) && (!validateGroup(c, snap.GroupID) || !requireGroupPermission(c, auth.PermJobUpdate, snap.GroupID)) {
+		return
+	}
+	if !isValidTaskType(snap.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid task type: %s", snap.Type)})
+		return
+	}
+	if _, err := parseCronExpr(snap.CronExpr); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid cron expression: %v", err)})
+		return
+	}
+	if !validateJobParams(c, snap.Type, snap.Params) {
+		return
+	}
+	// 版本中的上游可能已被删除，或与之后新增的依赖形成环
+	deps := service.JobDependencies{DependsOn: snap.Dependencies, Type: snap.DependencyType, Timeout: snap.DependencyTimeout}
+	if !h.validateDependencies(c, job.Name, deps) {
+		return
+	}
+
+	before := *job
+	snap.Apply(job)
+	err := dbCnn.Transaction(func(tx *gorm.DB) error {
+		if len(service.DiffSnapshots(service.SnapshotJob(&before), snap)) > 0 {
+			if _, err := service.SaveJobVersion(tx, &before, fmt.Sprintf("rollback to version %s", v), currentUserID(c)); err != nil {
+				return err
+			}
+		}
+		return tx.Save(job).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit.SetValues(c, before, *job)
+
+	// 重新加载到调度器
+	if err := h.reloadJobToScheduler(job); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to reload job: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": h.jobToResponse(job)})
+}
+
+// loadJob 按路由参数 :id 查询任务，失败时写入响应并返回 false
+func loadJob(c *gin.Context) (*models.Job, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid job id: %s", c.Param("id"))})
+		return nil, false
+	}
+	dbCnn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var job models.Job
+	if err := dbCnn.First(&job, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &job, true
+}
+
+// loadJobSnapshot 查询任务指定版本的配置快照，失败时写入响应并返回 false
+func loadJobSnapshot(c *gin.Context, jobID uint, version string) (service.JobSnapshot, bool) {
+	if _, err := strconv.Atoi(version); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid version: %s", version)})
+		return service.JobSnapshot{}, false
+	}
+	v, err := service.GetJobVersion(jobID, version)
+	if err != nil {
+		if errors.Is(err, service.ErrJobVersionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return service.JobSnapshot{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return service.JobSnapshot{}, false
+	}
+	snap, err := service.ParseJobSnapshot(v)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return service.JobSnapshot{}, false
+	}
+	return snap, true
+}
+
+// currentUserID 当前登录用户的ID，未认证时返回 nil
+func currentUserID(c *gin.Context) *uint {
+	if v, ok := c.Get("user_id"); ok {
+		if id, ok := v.(uint); ok {
+			return &id
+		}
+	}
+	return nil
+}
+
+func sameGroup(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
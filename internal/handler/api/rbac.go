@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/internal/service"
+	"github.com/iceymoss/go-task/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GlobalScope 不属于任何分组的资源（审计日志、告警渠道），只有全局授权可以访问
+func GlobalScope(*gin.Context) ([]uint, error) {
+	return nil, nil
+}
+
+// JobScope 按路由参数 :id 解析任务所属的分组链，供 auth.RequirePermission 按分组校验
+func JobScope(c *gin.Context) ([]uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+	return service.JobGroupChain(uint(id))
+}
+
+// GroupScope 按路由参数 :id 解析分组及其上级分组
+func GroupScope(c *gin.Context) ([]uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+	groupID := uint(id)
+	return service.GroupChain(&groupID)
+}
+
+// TaskScope 按路由参数 :name 解析任务所属的分组链
+func TaskScope(c *gin.Context) ([]uint, error) {
+	return service.JobGroupChainByName(c.Param("name"))
+}
+
+// ExecutionScope 按路由参数 :exec_id 解析执行记录所属任务的分组链
+func ExecutionScope(c *gin.Context) ([]uint, error) {
+	return service.ExecutionGroupChain(c.Param("exec_id"))
+}
+
+// requireGroupPermission 校验当前用户能否在分组 groupID 中执行 perm，不满足时写入响应并返回 false
+func requireGroupPermission(c *gin.Context, perm string, groupID *uint) bool {
+	groups, err := service.GroupChain(groupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if !auth.HasPermission(c, perm, groups) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied: " + perm})
+		return false
+	}
+	return true
+}
+
+// permittedGroupIDs 当前用户拥有 perm 的分组（含子分组），all 为 true 时不受分组限制
+func permittedGroupIDs(c *gin.Context, perm string) (all bool, ids []uint, err error) {
+	all, groups := auth.PermittedGroups(c, perm)
+	if all {
+		return true, nil, nil
+	}
+	ids, err = service.GroupSubtree(groups)
+	return false, ids, err
+}
+
+// PermittedJobStats 过滤出当前用户有 perm 权限的任务运行状态，
+// 未分组或没有数据库记录的任务（系统、YAML 任务）只对全局授权可见
+func PermittedJobStats(c *gin.Context, perm string, stats []engine.JobStats) ([]engine.JobStats, error) {
+	all, groupIDs, err := permittedGroupIDs(c, perm)
+	if err != nil || all {
+		return stats, err
+	}
+	names, err := service.JobNamesInGroups(groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(stats, func(stat engine.JobStats) bool {
+		return !slices.Contains(names, stat.Name)
+	}), nil
+}
@@ -1,13 +1,17 @@
 package router
 
 import (
+	"errors"
 	"io/fs"
 	"net/http"
+	"path"
 	"strings"
 
+	"github.com/iceymoss/go-task/internal/audit"
 	"github.com/iceymoss/go-task/internal/conf"
 	"github.com/iceymoss/go-task/internal/engine"
-	"github.com/iceymoss/go-task/internal/handler/api"
+	apihandler "github.com/iceymoss/go-task/internal/handler/api"
+	"github.com/iceymoss/go-task/internal/service"
 	"github.com/iceymoss/go-task/pkg/auth"
 
 	"github.com/gin-gonic/gin"
@@ -17,58 +21,128 @@ import (
 func RegisterRoute(cfg *conf.Config, scheduler *engine.Scheduler, staticFS fs.FS) *gin.Engine {
 	router := gin.Default()
 	// 创建认证处理器
-	authHandler := api.NewAuthHandler(cfg)
+	authHandler := apihandler.NewAuthHandler(cfg)
 
 	// 创建任务处理器
-	jobHandler := api.NewJobHandler(scheduler) // scheduler 稍后设置
+	jobHandler := apihandler.NewJobHandler(scheduler) // scheduler 稍后设置
+
+	// 创建任务分组处理器
+	groupHandler := apihandler.NewGroupHandler(scheduler)
+
+	// 创建执行记录处理器
+	executionHandler := apihandler.NewExecutionHandler(scheduler)
+
+	// 创建告警渠道处理器
+	alertHandler := apihandler.NewAlertHandler()
+
+	// 创建审计日志处理器，所有写操作（含登录）记录审计日志
+	auditHandler := apihandler.NewAuditHandler()
+	auditLog := audit.Middleware(service.NewGormAuditStore())
 
 	// 认证路由（无需token）
 	authGroup := router.Group("/api/auth")
+	authGroup.Use(auditLog)
 	{
 		authGroup.POST("/login", authHandler.Login)
 		authGroup.POST("/refresh", authHandler.RefreshToken)
 	}
 
-	// 需要认证的路由
+	// 需要认证的路由，每个接口按角色权限校验，涉及具体任务的接口按任务所属分组校验
 	api := router.Group("/api")
-	api.Use(auth.AuthMiddleware(authHandler.JwtService))
+	api.Use(auth.AuthMiddleware(authHandler.JwtService), auditLog, auth.PermissionMiddleware(service.NewGormPermissionStore()))
 	{
 		// 用户相关
 		api.GET("/auth/me", authHandler.GetMe)
 		api.POST("/auth/logout", authHandler.Logout)
 
 		// 任务相关（需要认证）
-		api.GET("/tasks", func(c *gin.Context) {
-			c.JSON(200, gin.H{"data": scheduler.Stats.GetAll()})
+		api.GET("/tasks", auth.RequirePermission(auth.PermJobView), func(c *gin.Context) {
+			stats, err := apihandler.PermittedJobStats(c, auth.PermJobView, scheduler.Stats.GetAll())
+			if err != nil {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(200, gin.H{"data": stats})
 		})
 
-		api.POST("/tasks/:name/run", func(c *gin.Context) {
+		api.POST("/tasks/:name/run", auth.RequirePermission(auth.PermJobRun, apihandler.TaskScope), func(c *gin.Context) {
 			name := c.Param("name")
-			if err := scheduler.ManualRun(name); err != nil {
+			audit.Record(c, audit.ActionRun, audit.ResourceJob, name)
+			execID, err := scheduler.ManualRun(name)
+			if errors.Is(err, engine.ErrConcurrencyLimited) {
+				c.JSON(409, gin.H{"error": err.Error(), "exec_id": execID})
+				return
+			}
+			if err != nil {
 				c.JSON(400, gin.H{"error": err.Error()})
 				return
 			}
-			c.JSON(200, gin.H{"message": "Triggered"})
+			c.JSON(200, gin.H{"message": "Triggered", "exec_id": execID})
 		})
 
 		// 任务管理 API
-		api.GET("/jobs", jobHandler.GetJobs)
-		api.GET("/jobs/:id", jobHandler.GetJob)
-		api.POST("/jobs", jobHandler.CreateJob)
-		api.PUT("/jobs/:id", jobHandler.UpdateJob)
-		api.DELETE("/jobs/:id", jobHandler.DeleteJob)
-		api.POST("/jobs/:id/enable", jobHandler.EnableJob)
-		api.POST("/jobs/:id/disable", jobHandler.DisableJob)
-		api.GET("/jobs/:id/logs", jobHandler.GetJobLogs)
-		api.POST("/jobs/validate-cron", jobHandler.ValidateCron)
-		api.GET("/jobs/templates", jobHandler.GetJobTemplates)
-		api.POST("/jobs/from-template", jobHandler.CreateFromTemplate)
-		api.GET("/jobs/dependency-graph", jobHandler.GetDependencyGraph)
-		api.POST("/jobs/:id/save-template", jobHandler.SaveAsTemplate)
+		api.GET("/jobs", auth.RequirePermission(auth.PermJobView), jobHandler.GetJobs)
+		api.GET("/jobs/:id", auth.RequirePermission(auth.PermJobView, apihandler.JobScope), jobHandler.GetJob)
+		api.POST("/jobs", auth.RequirePermission(auth.PermJobCreate), jobHandler.CreateJob)
+		api.PUT("/jobs/:id", auth.RequirePermission(auth.PermJobUpdate, apihandler.JobScope), jobHandler.UpdateJob)
+		api.DELETE("/jobs/:id", auth.RequirePermission(auth.PermJobDelete, apihandler.JobScope), jobHandler.DeleteJob)
+		api.POST("/jobs/:id/enable", auth.RequirePermission(auth.PermJobUpdate, apihandler.JobScope), jobHandler.EnableJob)
+		api.POST("/jobs/:id/disable", auth.RequirePermission(auth.PermJobUpdate, apihandler.JobScope), jobHandler.DisableJob)
+		api.POST("/jobs/:id/kill", auth.RequirePermission(auth.PermJobRun, apihandler.JobScope), jobHandler.KillJob)
+		api.GET("/jobs/:id/logs", auth.RequirePermission(auth.PermJobView, apihandler.JobScope), jobHandler.GetJobLogs)
+		api.GET("/jobs/:id/versions", auth.RequirePermission(auth.PermJobView, apihandler.JobScope), jobHandler.GetJobVersions)
+		api.GET("/jobs/:id/versions/:v/diff", auth.RequirePermission(auth.PermJobView, apihandler.JobScope), jobHandler.GetJobVersionDiff)
+		api.POST("/jobs/:id/rollback/:v", auth.RequirePermission(auth.PermJobUpdate, apihandler.JobScope), jobHandler.RollbackJob)
+		api.POST("/jobs/validate-cron", auth.RequirePermission(auth.PermJobView), jobHandler.ValidateCron)
+		api.GET("/jobs/templates", auth.RequirePermission(auth.PermJobView), jobHandler.GetJobTemplates)
+		api.POST("/jobs/from-template", auth.RequirePermission(auth.PermJobCreate), jobHandler.CreateFromTemplate)
+		api.GET("/jobs/dependency-graph", auth.RequirePermission(auth.PermJobView), jobHandler.GetDependencyGraph)
+		api.POST("/jobs/:id/save-template", auth.RequirePermission(auth.PermJobCreate, apihandler.JobScope), jobHandler.SaveAsTemplate)
+		api.GET("/task-types", auth.RequirePermission(auth.PermJobView), jobHandler.GetTaskTypes)
+
+		// 任务分组 API，批量操作作用于分组及其子分组中的任务
+		api.GET("/groups", auth.RequirePermission(auth.PermJobView), groupHandler.GetGroups)
+		api.GET("/groups/:id", auth.RequirePermission(auth.PermJobView, apihandler.GroupScope), groupHandler.GetGroup)
+		api.POST("/groups", auth.RequirePermission(auth.PermGroupManage), groupHandler.CreateGroup)
+		api.PUT("/groups/:id", auth.RequirePermission(auth.PermGroupManage, apihandler.GroupScope), groupHandler.UpdateGroup)
+		api.DELETE("/groups/:id", auth.RequirePermission(auth.PermGroupManage, apihandler.GroupScope), groupHandler.DeleteGroup)
+		api.POST("/groups/:id/pause", auth.RequirePermission(auth.PermJobUpdate, apihandler.GroupScope), groupHandler.PauseGroup)
+		api.POST("/groups/:id/resume", auth.RequirePermission(auth.PermJobUpdate, apihandler.GroupScope), groupHandler.ResumeGroup)
+		api.POST("/groups/:id/run", auth.RequirePermission(auth.PermJobRun, apihandler.GroupScope), groupHandler.RunGroup)
+		api.PUT("/groups/:id/alert-rule", auth.RequirePermission(auth.PermAlertManage, apihandler.GroupScope), groupHandler.SetGroupAlertRule)
+
+		// 执行记录 API
+		api.GET("/executions", auth.RequirePermission(auth.PermExecutionView), executionHandler.GetExecutions)
+		api.GET("/executions/:exec_id", auth.RequirePermission(auth.PermExecutionView, apihandler.ExecutionScope), executionHandler.GetExecution)
+		api.GET("/executions/:exec_id/logs", auth.RequirePermission(auth.PermExecutionView, apihandler.ExecutionScope), executionHandler.GetExecutionLogs)
+		api.GET("/executions/:exec_id/logs/stream", auth.RequirePermission(auth.PermExecutionView, apihandler.ExecutionScope), executionHandler.StreamExecutionLogs)
+		api.GET("/executions/:exec_id/progress", auth.RequirePermission(auth.PermExecutionView, apihandler.ExecutionScope), executionHandler.GetExecutionProgress)
+		api.GET("/executions/:exec_id/progress/stream", auth.RequirePermission(auth.PermExecutionView, apihandler.ExecutionScope), executionHandler.StreamExecutionProgress)
+		api.POST("/executions/:exec_id/cancel", auth.RequirePermission(auth.PermExecutionCancel, apihandler.ExecutionScope), executionHandler.CancelExecution)
+
+		// 任务产出文件下载（如 SQL 导出结果），文件按 <exec_id>/... 存放，按执行记录所属分组鉴权
+		if cfg.Storage.BasePath != "" {
+			exports := http.Dir(cfg.Storage.BasePath)
+			api.GET("/exports/:exec_id/*filepath", auth.RequirePermission(auth.PermExecutionView, apihandler.ExecutionScope), func(c *gin.Context) {
+				c.FileFromFS(path.Join(c.Param("exec_id"), c.Param("filepath")), exports)
+			})
+		}
+
+		// 告警渠道 API
+		api.POST("/alert-channels/test", auth.RequirePermission(auth.PermAlertManage, apihandler.GlobalScope), alertHandler.TestChannelConfig)
+		api.POST("/alert-channels/:id/test", auth.RequirePermission(auth.PermAlertManage, apihandler.GlobalScope), alertHandler.TestChannel)
+
+		// 审计日志 API
+		api.GET("/audit-logs", auth.RequirePermission(auth.PermAuditView, apihandler.GlobalScope), auditHandler.GetAuditLogs)
 
 		// 仪表盘统计数据
-		api.GET("/dashboard/stats", func(c *gin.Context) {
-			stats := scheduler.Stats.GetAll()
+		api.GET("/dashboard/stats", auth.RequirePermission(auth.PermJobView), func(c *gin.Context) {
+			// 只统计当前用户有查看权限的任务
+			stats, err := apihandler.PermittedJobStats(c, auth.PermJobView, scheduler.Stats.GetAll())
+			if err != nil {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
 
 			// 计算统计数据
 			totalTasks := len(stats)
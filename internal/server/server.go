@@ -11,6 +11,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/iceymoss/go-task/internal/alert"
 	"github.com/iceymoss/go-task/internal/conf"
 	"github.com/iceymoss/go-task/internal/engine"
 	"github.com/iceymoss/go-task/internal/router"
@@ -52,16 +53,67 @@ func NewServer(cfg *conf.Config, staticFS *embed.FS) *Server {
 	// 历史存储插件：配置 GORM 保存任务执行记录
 	historyStorage := service.NewGormHistoryStorage()
 
+	// 工作流存储插件：持久化工作流及节点的执行记录
+	workflowStore := service.NewGormWorkflowStore()
+
+	// 执行记录插件：每次分发分配 WUID 执行ID，并记录执行的完整生命周期
+	executionStore := service.NewGormExecutionStore()
+	idGenerator := service.NewWUIDGenerator()
+
+	// 取消广播插件：通过 Redis Pub/Sub 将取消请求发送到集群内所有节点
+	cancelBus := engine.NewRedisCancelBus(redisClient, engineLogger)
+
+	// 状态存储插件：持久化任务运行状态、依赖状态与未执行的队列项
+	stateStore := engine.NewRedisStateStore(redisClient)
+
+	// 进度插件：任务上报的执行进度写入 Redis，任意节点都可以查询并推送给前端
+	progressStore := engine.NewRedisProgressStore(redisClient)
+
+	// 执行日志插件：捕获任务日志与 stdout/stderr，供执行详情与日志流读取
+	logSink := newLogSink(cfg)
+
+	// 队列插件：redis 模式下 Leader 只负责分发，所有节点共同拉取任务执行
+	schedulerOpts := []engine.Option{
+		engine.WithLogger(engineLogger),               // 注入日志
+		engine.WithLeaderElector(leaderElector),       // 注入分布式选主
+		engine.WithHistoryStorage(historyStorage),     // 注入历史记录器
+		engine.WithWorkflowStore(workflowStore),       // 注入工作流执行记录存储
+		engine.WithStateStore(stateStore),             // 注入调度状态存储，重启、换主后恢复
+		engine.WithExecutionStore(executionStore),     // 注入执行记录存储
+		engine.WithIDGenerator(idGenerator),           // 注入执行ID生成器
+		engine.WithCancelBus(cancelBus),               // 注入取消广播，取消请求到达持有执行的节点
+		engine.WithProgressStore(progressStore),       // 注入执行进度存储
+		engine.WithLogSink(logSink),                   // 注入执行日志存储
+		engine.WithWorkerNum(cfg.Scheduler.WorkerNum), // 配置队列并发数
+	}
+	// 并发控制插件：redis 模式下执行可能落在任意节点，通过 Redis 登记活跃的执行使并发策略在所有节点间生效；
+	// 节点宕机时 Redis 队列会重新投递它持有的执行，登记随之释放。内存队列沿用默认的本节点登记，
+	// 避免进程退出后遗留的登记长时间阻塞任务
+	if cfg.Scheduler.Queue == "redis" {
+		schedulerOpts = append(schedulerOpts,
+			engine.WithQueue(engine.RedisQueueFactory(
+				redisClient,
+				engine.WithVisibilityTimeout(time.Duration(cfg.Scheduler.VisibilityTimeout)*time.Second),
+			)),
+			engine.WithConcurrencyStore(engine.NewRedisConcurrencyStore(redisClient)),
+		)
+	}
+
 	// 初始化注册表
 	registry := engine.NewTaskRegistry()
 
 	// 初始化调度内核，并通过 Option 注入所有外部依赖！
-	scheduler := engine.NewScheduler(registry,
-		engine.WithLogger(engineLogger),           // 注入日志
-		engine.WithLeaderElector(leaderElector),   // 注入分布式选主
-		engine.WithHistoryStorage(historyStorage), // 注入历史记录器
-		engine.WithWorkerNum(10),                  // 配置队列并发数
+	scheduler := engine.NewScheduler(registry, schedulerOpts...)
+
+	// 告警插件：订阅任务结束事件，按告警规则在 Redis 滑动窗口上求值，写入告警历史并发送到告警渠道
+	alertStore := service.NewGormAlertStore()
+	alertManager := alert.NewManager(
+		alertStore,
+		alert.NewRedisWindowStore(redisClient),
+		alert.WithLogger(engineLogger),
 	)
+	alertManager.OnAlert(alert.NewDispatcher(alertStore, alert.WithDispatcherLogger(engineLogger)).Handle)
+	alertManager.Register(scheduler.EventManager)
 
 	// 将任务装载进注册表并下订单
 	tasks.LoadAllTasks(tasks.LoadTestConfig{
@@ -71,6 +123,13 @@ func NewServer(cfg *conf.Config, staticFS *embed.FS) *Server {
 		Log:       engineLogger,
 	})
 
+	// 装载分组的并发上限与数据库中启用的任务
+	service.LoadGroupLimits(scheduler, engineLogger)
+	service.LoadJobs(scheduler, engineLogger)
+
+	// 装载数据库中启用的工作流
+	service.LoadWorkflows(scheduler, engineLogger)
+
 	return &Server{
 		engine:    router.RegisterRoute(cfg, scheduler, *staticFS),
 		scheduler: scheduler,
@@ -114,3 +173,24 @@ func (s *Server) Run(addr string) error {
 	log.Println("✅ [Server] All services stopped safely. Bye!")
 	return nil
 }
+
+// newLogSink 按配置创建执行日志存储，none 时不捕获任务输出
+func newLogSink(cfg *conf.Config) engine.LogSink {
+	switch cfg.Scheduler.LogSink {
+	case "none":
+		return nil
+	case "mongo":
+		client := db.GetMongoConn()
+		if client == nil {
+			logger.Error("❌ [Logs] Mongo unavailable, fallback to mysql log sink")
+			return service.NewGormLogSink()
+		}
+		database := cfg.Mongo.Database
+		if database == "" {
+			database = "go_task"
+		}
+		return service.NewMongoLogSink(client, database)
+	default:
+		return service.NewGormLogSink()
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/alert"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+	"github.com/iceymoss/go-task/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// GormAlertStore 基于 GORM 的告警存储实现：读取告警规则与静默，写入 sys_alert_history
+type GormAlertStore struct {
+}
+
+// 确保 GormAlertStore 实现了 alert.Store 与 alert.ChannelStore 接口
+var (
+	_ alert.Store        = (*GormAlertStore)(nil)
+	_ alert.ChannelStore = (*GormAlertStore)(nil)
+)
+
+// NewGormAlertStore 创建告警存储
+func NewGormAlertStore() *GormAlertStore {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	if err := conn.AutoMigrate(&models.AlertRule{}, &models.AlertChannel{}, &models.AlertSilence{}, &models.AlertHistory{}); err != nil {
+		logger.Error("❌ [Alert] AutoMigrate failed", zap.Error(err))
+	}
+	return &GormAlertStore{}
+}
+
+// LoadRules 加载所有启用的告警规则
+func (g *GormAlertStore) LoadRules() ([]models.AlertRule, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var rules []models.AlertRule
+	err := conn.Where("enable = ? AND deleted_at IS NULL", true).Find(&rules).Error
+	return rules, err
+}
+
+// LoadSilences 加载当前生效的静默规则
+func (g *GormAlertStore) LoadSilences(now time.Time) ([]models.AlertSilence, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var silences []models.AlertSilence
+	err := conn.Where("status = ? AND start_time <= ? AND end_time >= ?", "active", now, now).Find(&silences).Error
+	return silences, err
+}
+
+// JobInfo 按任务名查询任务ID与所属分组，系统任务与 YAML 任务的ID为 0 且不属于任何分组
+func (g *GormAlertStore) JobInfo(name string) (alert.JobInfo, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var job models.Job
+	if err := conn.Select("id, group_id").Where("name = ?", name).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return alert.JobInfo{}, nil
+		}
+		return alert.JobInfo{}, err
+	}
+	groups, err := GroupChain(job.GroupID)
+	if err != nil {
+		return alert.JobInfo{}, err
+	}
+	return alert.JobInfo{ID: job.ID, GroupID: job.GroupID, Groups: groups}, nil
+}
+
+// CreateAlert 写入一条告警历史
+func (g *GormAlertStore) CreateAlert(a *alert.Alert, status string) error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+
+	history := &models.AlertHistory{
+		AlertID:        a.AlertID,
+		ExecutionID:    a.ExecID,
+		JobName:        a.JobName,
+		AlertType:      a.Type,
+		AlertLevel:     a.Level,
+		Title:          a.Title,
+		Message:        a.Message,
+		Details:        toJSON(a.Details, "null"),
+		Status:         status,
+		Channels:       "null",
+		FailedChannels: "null",
+		State:          a.State,
+		TriggeredAt:    a.TriggeredAt,
+		CreatedAt:      time.Now(),
+	}
+	if a.RuleID != 0 {
+		ruleID := a.RuleID
+		history.RuleID = &ruleID
+	}
+	if a.JobID != 0 {
+		jobID := a.JobID
+		history.JobID = &jobID
+	}
+	return conn.Create(history).Error
+}
+
+// ResolveAlert 将告警标记为已恢复
+func (g *GormAlertStore) ResolveAlert(alertID string, resolvedAt time.Time) error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	return conn.Model(&models.AlertHistory{}).Where("alert_id = ?", alertID).Updates(map[string]any{
+		"state":       alert.StateResolved,
+		"resolved_at": resolvedAt,
+	}).Error
+}
+
+// LoadChannels 加载所有启用的告警渠道
+func (g *GormAlertStore) LoadChannels() ([]models.AlertChannel, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var channels []models.AlertChannel
+	err := conn.Where("enable = ? AND deleted_at IS NULL", true).Order("priority DESC").Find(&channels).Error
+	return channels, err
+}
+
+// UpdateDelivery 记录告警的发送状态、发送与失败的渠道
+func (g *GormAlertStore) UpdateDelivery(alertID, status string, channels, failed []string, sentAt *time.Time) error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	updates := map[string]any{"status": status}
+	if channels != nil || failed != nil {
+		updates["channels"] = toJSON(channels, "[]")
+		updates["failed_channels"] = toJSON(failed, "[]")
+	}
+	if sentAt != nil {
+		updates["sent_at"] = *sentAt
+	}
+	return conn.Model(&models.AlertHistory{}).Where("alert_id = ?", alertID).Updates(updates).Error
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"github.com/iceymoss/go-task/internal/audit"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+)
+
+// GormAuditStore 基于 GORM 的审计日志存储，写入 sys_audit_logs
+type GormAuditStore struct {
+}
+
+// 确保 GormAuditStore 实现了 audit.Store 接口
+var _ audit.Store = (*GormAuditStore)(nil)
+
+// NewGormAuditStore 创建审计日志存储
+func NewGormAuditStore() *GormAuditStore {
+	return &GormAuditStore{}
+}
+
+// CreateAuditLog 写入一条审计日志
+func (g *GormAuditStore) CreateAuditLog(log *models.AuditLog) error {
+	return db.GetMysqlConn(db.MYSQL_DB_GO_TASK).Create(log).Error
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+	"github.com/iceymoss/go-task/pkg/logger"
+	"github.com/iceymoss/go-task/pkg/wuid"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormExecutionStore 基于 GORM 的任务执行记录存储实现，写入 sys_job_executions
+type GormExecutionStore struct {
+}
+
+// 确保 GormExecutionStore 实现了 ExecutionStore 接口
+var _ engine.ExecutionStore = (*GormExecutionStore)(nil)
+
+// NewGormExecutionStore 创建执行记录存储
+func NewGormExecutionStore() *GormExecutionStore {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	if err := conn.AutoMigrate(&models.JobExecution{}); err != nil {
+		logger.Error("❌ [Execution] AutoMigrate failed", zap.Error(err))
+	}
+	return &GormExecutionStore{}
+}
+
+// CreateExecution 写入一条新的执行记录
+func (g *GormExecutionStore) CreateExecution(exec *engine.Execution) error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	return conn.Create(executionToModel(conn, exec)).Error
+}
+
+// UpdateExecution 按执行ID更新状态、时间与结果；记录不存在时补写一条
+func (g *GormExecutionStore) UpdateExecution(exec *engine.Execution) error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+
+	updates := map[string]any{
+		"status":      string(exec.Status),
+		"retry_count": exec.RetryCount,
+	}
+	if exec.WorkerID != "" {
+		updates["worker_id"] = exec.WorkerID
+	}
+	if exec.StartedAt != nil {
+		updates["started_at"] = exec.StartedAt
+	}
+	if exec.FinishedAt != nil {
+		updates["finished_at"] = exec.FinishedAt
+	}
+	if exec.DurationMs != nil {
+		updates["duration_ms"] = exec.DurationMs
+	}
+	if exec.Result != nil {
+		updates["result"] = toJSON(exec.Result, "null")
+	}
+	if exec.ErrorMessage != "" {
+		updates["error_message"] = exec.ErrorMessage
+	}
+
+	result := conn.Model(&models.JobExecution{}).Where("execution_id = ?", exec.ExecID).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+	// MySQL 在更新前后数据相同时同样返回 0 行，此时记录可能已存在，补写时按 execution_id 冲突转为更新
+	return conn.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "execution_id"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(executionToModel(conn, exec)).Error
+}
+
+// executionToModel 将引擎的执行记录转换为数据库模型
+func executionToModel(conn *gorm.DB, exec *engine.Execution) *models.JobExecution {
+	return &models.JobExecution{
+		ExecutionID:      exec.ExecID,
+		JobID:            jobIDByName(conn, exec.JobName),
+		JobName:          exec.JobName,
+		WorkerID:         exec.WorkerID,
+		Status:           string(exec.Status),
+		ScheduledAt:      exec.ScheduledAt,
+		StartedAt:        exec.StartedAt,
+		FinishedAt:       exec.FinishedAt,
+		DurationMs:       exec.DurationMs,
+		RetryCount:       exec.RetryCount,
+		MaxRetries:       exec.MaxRetries,
+		Dependencies:     "null",
+		DependencyStatus: "null",
+		Result:           toJSON(exec.Result, "null"),
+		ErrorMessage:     exec.ErrorMessage,
+		TriggerType:      "job",
+		TriggerSource:    exec.TriggerSource,
+		Metadata:         "null",
+		Tags:             "null",
+	}
+}
+
+// jobIDByName 查询任务在 sys_jobs 中的ID，系统任务与 YAML 任务没有数据库记录时返回 0
+func jobIDByName(conn *gorm.DB, name string) uint {
+	var job models.Job
+	if err := conn.Select("id").Where("name = ?", name).First(&job).Error; err != nil {
+		return 0
+	}
+	return job.ID
+}
+
+// NewWUIDGenerator 基于 WUID 的执行ID生成器，号段从 MySQL 加载，多节点之间不会重复
+func NewWUIDGenerator() engine.IDGenerator {
+	dsn := db.MysqlDSN(db.MYSQL_DB_GO_TASK)
+
+	// WUID 依赖 wuid 表分配高位号段
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	if err := conn.Exec("CREATE TABLE IF NOT EXISTS wuid (" +
+		"h INT NOT NULL AUTO_INCREMENT, " +
+		"x TINYINT NOT NULL DEFAULT '0', " +
+		"PRIMARY KEY (x), UNIQUE KEY h (h))").Error; err != nil {
+		logger.Error("❌ [Execution] Create wuid table failed", zap.Error(err))
+	}
+	wuid.Init(dsn)
+
+	return engine.IDGeneratorFunc(func() string {
+		return wuid.GenUid(dsn)
+	})
+}
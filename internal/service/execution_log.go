@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+	"github.com/iceymoss/go-task/pkg/logger"
+	"github.com/iceymoss/go-task/pkg/mongomodels"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// logWriteBatch 单条 INSERT 写入的最大行数
+const logWriteBatch = 200
+
+// GormLogSink 基于 GORM 的执行日志存储，与执行事件共用 sys_job_logs，捕获的日志 seq 从 1 开始
+type GormLogSink struct {
+}
+
+// 确保 GormLogSink 实现了 LogSink 接口
+var _ engine.LogSink = (*GormLogSink)(nil)
+
+// NewGormLogSink 创建 MySQL 执行日志存储
+func NewGormLogSink() *GormLogSink {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	if err := conn.AutoMigrate(&models.JobLog{}); err != nil {
+		logger.Error("❌ [Logs] AutoMigrate failed", zap.Error(err))
+	}
+	return &GormLogSink{}
+}
+
+func (g *GormLogSink) WriteLogs(entries []engine.LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	jobID := logJobID(conn, entries[0])
+	now := time.Now()
+
+	rows := make([]models.JobLog, len(entries))
+	for i, e := range entries {
+		rows[i] = models.JobLog{
+			ExecutionID: e.ExecID,
+			JobID:       jobID,
+			JobName:     e.JobName,
+			Seq:         e.Seq,
+			LogLevel:    e.Level,
+			Message:     e.Message,
+			Timestamp:   e.Timestamp,
+			CreatedAt:   now,
+		}
+		if len(e.Fields) > 0 {
+			fields := toJSON(e.Fields, "null")
+			rows[i].Fields = &fields
+		}
+	}
+	return conn.CreateInBatches(rows, logWriteBatch).Error
+}
+
+func (g *GormLogSink) ReadLogs(ctx context.Context, execID string, afterSeq int64, limit int) ([]engine.LogEntry, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+
+	var rows []models.JobLog
+	if err := conn.WithContext(ctx).
+		Where("execution_id = ? AND seq > ?", execID, afterSeq).
+		Order("seq ASC").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]engine.LogEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = engine.LogEntry{
+			ExecID:    row.ExecutionID,
+			JobName:   row.JobName,
+			Seq:       row.Seq,
+			Level:     row.LogLevel,
+			Message:   row.Message,
+			Timestamp: row.Timestamp,
+		}
+		if row.JobID != 0 {
+			entries[i].TaskID = strconv.FormatUint(uint64(row.JobID), 10)
+		}
+		if row.Fields != nil {
+			_ = json.Unmarshal([]byte(*row.Fields), &entries[i].Fields)
+		}
+	}
+	return entries, nil
+}
+
+// logJobID 优先使用执行携带的任务ID，YAML 配置的任务按名称查找
+func logJobID(conn *gorm.DB, e engine.LogEntry) uint {
+	if id, err := strconv.ParseUint(e.TaskID, 10, 64); err == nil {
+		return uint(id)
+	}
+	return jobIDByName(conn, e.JobName)
+}
+
+// MongoLogSink 基于 MongoDB 的执行日志存储，写入 execution_log_streams 集合
+type MongoLogSink struct {
+	collection *mongo.Collection
+}
+
+// 确保 MongoLogSink 实现了 LogSink 接口
+var _ engine.LogSink = (*MongoLogSink)(nil)
+
+// NewMongoLogSink 创建 MongoDB 执行日志存储，并确保 (execution_id, seq) 索引存在
+func NewMongoLogSink(client *mongo.Client, database string) *MongoLogSink {
+	collection := client.Database(database).Collection(mongomodels.ExecutionLogStream{}.CollectionName())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "execution_id", Value: 1}, {Key: "seq", Value: 1}},
+	}); err != nil {
+		logger.Error("❌ [Logs] Create mongo index failed", zap.Error(err))
+	}
+	return &MongoLogSink{collection: collection}
+}
+
+func (m *MongoLogSink) WriteLogs(entries []engine.LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	var jobID uint
+	if id, err := strconv.ParseUint(entries[0].TaskID, 10, 64); err == nil {
+		jobID = uint(id)
+	}
+	now := time.Now()
+
+	docs := make([]any, len(entries))
+	for i, e := range entries {
+		docs[i] = mongomodels.ExecutionLogStream{
+			ExecutionID: e.ExecID,
+			JobID:       jobID,
+			JobName:     e.JobName,
+			Seq:         e.Seq,
+			LogLevel:    e.Level,
+			Message:     e.Message,
+			Fields:      e.Fields,
+			Timestamp:   e.Timestamp,
+			CreatedAt:   now,
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := m.collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (m *MongoLogSink) ReadLogs(ctx context.Context, execID string, afterSeq int64, limit int) ([]engine.LogEntry, error) {
+	cursor, err := m.collection.Find(ctx,
+		bson.M{"execution_id": execID, "seq": bson.M{"$gt": afterSeq}},
+		options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []mongomodels.ExecutionLogStream
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	entries := make([]engine.LogEntry, len(docs))
+	for i, doc := range docs {
+		entries[i] = engine.LogEntry{
+			ExecID:    doc.ExecutionID,
+			JobName:   doc.JobName,
+			Seq:       doc.Seq,
+			Level:     doc.LogLevel,
+			Message:   doc.Message,
+			Fields:    doc.Fields,
+			Timestamp: doc.Timestamp,
+		}
+		if doc.JobID != 0 {
+			entries[i].TaskID = strconv.FormatUint(uint64(doc.JobID), 10)
+		}
+	}
+	return entries, nil
+}
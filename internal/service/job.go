@@ -0,0 +1,96 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/internal/tasks"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+)
+
+// JobTaskName 根据任务类型找到注册表中的任务模板名，自定义任务以任务名注册
+func JobTaskName(job *models.Job) string {
+	task, err := tasks.GetTaskByType(job.Type)
+	if err != nil {
+		return job.Name
+	}
+	return task.Identifier()
+}
+
+// JobOptionsFromModel 将 sys_jobs 中的超时、重试、优先级、并发策略与标签转换为调度器的执行配置
+func JobOptionsFromModel(job *models.Job) *engine.JobOptions {
+	opts := &engine.JobOptions{
+		Timeout:     time.Duration(job.Timeout) * time.Second,
+		Retry:       JobRetryPolicy(job),
+		Priority:    job.Priority,
+		Concurrency: engine.ParseConcurrencyPolicy(job.ConcurrentPolicy),
+		MaxPending:  job.MaxPending,
+		TaskID:      strconv.FormatUint(uint64(job.ID), 10),
+	}
+	if job.Tags != "" {
+		_ = json.Unmarshal([]byte(job.Tags), &opts.Tags)
+	}
+	return opts
+}
+
+// JobRetryPolicy 按 sys_jobs 中的重试次数、间隔、退避方式与可重试的错误类别构造重试策略
+func JobRetryPolicy(job *models.Job) *engine.RetryPolicy {
+	var retryOn []string
+	if job.RetryOn != "" {
+		_ = json.Unmarshal([]byte(job.RetryOn), &retryOn)
+	}
+	return engine.NewRetryPolicy(
+		job.MaxRetries,
+		job.RetryStrategy,
+		time.Duration(job.RetryBackoff)*time.Second,
+		engine.ParseErrorClasses(retryOn),
+	)
+}
+
+// AddJobToScheduler 按数据库中的任务配置注册到调度器，同名任务会被整体替换；
+// 分组中的任务受分组并发上限约束，并继承分组的默认重试策略；声明了上游的任务同时注册依赖规则；
+// 错过触发的补偿策略与 YAML 任务一样在注册后设置
+func AddJobToScheduler(scheduler *engine.Scheduler, job *models.Job) error {
+	var params map[string]any
+	if job.Params != "" {
+		_ = json.Unmarshal([]byte(job.Params), &params)
+	}
+	opts := JobOptionsFromModel(job)
+	if err := applyGroupDefaults(opts, job); err != nil {
+		return err
+	}
+	deps, err := ParseJobDependencies(job.Dependencies)
+	if err != nil {
+		return fmt.Errorf("invalid dependencies: %w", err)
+	}
+	rule, err := deps.Rule(job.Name)
+	if err != nil {
+		return err
+	}
+	if err := scheduler.AddJobWithDependency(job.CronExpr, JobTaskName(job), job.Name, params, job.Source, opts, rule); err != nil {
+		return err
+	}
+	scheduler.SetMisfirePolicy(job.Name, engine.ParseMisfirePolicy(job.MisfirePolicy))
+	return nil
+}
+
+// LoadJobs 将数据库中所有启用的任务注册到调度器
+func LoadJobs(scheduler *engine.Scheduler, log engine.Logger) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+
+	var jobs []models.Job
+	if err := conn.Where("enable = ? AND is_template = ? AND deleted_at IS NULL", true, false).Find(&jobs).Error; err != nil {
+		log.Error("❌ [Job] Load jobs failed", err)
+		return
+	}
+
+	for i := range jobs {
+		if err := AddJobToScheduler(scheduler, &jobs[i]); err != nil {
+			log.Error("❌ [Job] Add job to scheduler failed", "name", jobs[i].Name, err)
+		}
+	}
+}
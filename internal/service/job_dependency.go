@@ -0,0 +1,118 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+)
+
+// JobDependencies 任务的上游依赖声明。sys_jobs.dependencies 兼容两种格式：
+// 上游任务名数组 ["a","b"]，以及带依赖类型与等待超时的对象 {"depends_on":["a"],"type":"any_success","timeout":600}
+type JobDependencies struct {
+	DependsOn []string `json:"depends_on"`
+	Type      string   `json:"type,omitempty"`    // all_success(默认), any_success, all_complete
+	Timeout   int      `json:"timeout,omitempty"` // 等待上游的超时时间(秒)，超时后放弃本次触发，0 表示一直等待
+}
+
+// ParseJobDependencies 解析 sys_jobs.dependencies
+func ParseJobDependencies(raw string) (JobDependencies, error) {
+	var deps JobDependencies
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return deps, nil
+	}
+	if strings.HasPrefix(raw, "[") {
+		err := json.Unmarshal([]byte(raw), &deps.DependsOn)
+		return deps, err
+	}
+	err := json.Unmarshal([]byte(raw), &deps)
+	return deps, err
+}
+
+// Encode 编码为 sys_jobs.dependencies，只声明上游时保持数组格式
+func (d JobDependencies) Encode() string {
+	if d.Type == "" && d.Timeout == 0 {
+		return toJSON(d.DependsOn, "")
+	}
+	return toJSON(d, "")
+}
+
+// Rule 转换为调度器的依赖规则，未声明上游时返回 nil
+func (d JobDependencies) Rule(taskName string) (*engine.DependencyRule, error) {
+	if len(d.DependsOn) == 0 {
+		return nil, nil
+	}
+	depType, err := engine.ParseDependencyType(d.Type)
+	if err != nil {
+		return nil, err
+	}
+	return &engine.DependencyRule{
+		TaskName:       taskName,
+		DependsOn:      d.DependsOn,
+		DependencyType: depType,
+		Timeout:        time.Duration(d.Timeout) * time.Second,
+	}, nil
+}
+
+// CheckJobDependencies 校验任务 name 的上游：上游必须是已存在的任务（数据库或 YAML 配置），
+// 且加入后数据库与调度器中的依赖关系不能形成环
+func CheckJobDependencies(scheduler *engine.Scheduler, name string, dependsOn []string) error {
+	if len(dependsOn) == 0 {
+		return nil
+	}
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var jobs []models.Job
+	if err := conn.Select("name", "dependencies").Where("is_template = ? AND deleted_at IS NULL", false).Find(&jobs).Error; err != nil {
+		return err
+	}
+
+	// 以调度器中的依赖图为基础（包含 YAML 任务），数据库中的配置覆盖同名任务
+	graph := scheduler.DependencyManager.Graph()
+	names := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		names[job.Name] = true
+		deps, _ := ParseJobDependencies(job.Dependencies)
+		graph[job.Name] = deps.DependsOn
+	}
+
+	for _, dep := range dependsOn {
+		if dep == name {
+			return engine.ErrCircularDependency
+		}
+		if _, ok := scheduler.Stats.Get(dep); !ok && !names[dep] {
+			return fmt.Errorf("%w: %s", engine.ErrDependencyNotFound, dep)
+		}
+	}
+	return engine.CheckCircularDependency(graph, name, dependsOn)
+}
+
+// RemoveDependencyOnJob 从所有下游任务的依赖中移除已删除的任务 name，返回被修改的任务
+func RemoveDependencyOnJob(name string) ([]models.Job, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var candidates []models.Job
+	pattern, _ := json.Marshal(name)
+	if err := conn.Where("dependencies LIKE ? AND deleted_at IS NULL", "%"+string(pattern)+"%").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	var updated []models.Job
+	for _, job := range candidates {
+		deps, err := ParseJobDependencies(job.Dependencies)
+		if err != nil || !slices.Contains(deps.DependsOn, name) {
+			continue
+		}
+		deps.DependsOn = slices.DeleteFunc(deps.DependsOn, func(dep string) bool { return dep == name })
+		job.Dependencies = deps.Encode()
+		if err := conn.Model(&job).Update("dependencies", job.Dependencies).Error; err != nil {
+			return updated, err
+		}
+		updated = append(updated, job)
+	}
+	return updated, nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/engine"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJobDependencies(t *testing.T) {
+	// 兼容旧的上游名称数组
+	deps, err := ParseJobDependencies(`["extract","load"]`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"extract", "load"}, deps.DependsOn)
+	assert.Equal(t, `["extract","load"]`, deps.Encode())
+
+	deps, err = ParseJobDependencies(`{"depends_on":["extract"],"type":"any_success","timeout":600}`)
+	require.NoError(t, err)
+	assert.Equal(t, JobDependencies{DependsOn: []string{"extract"}, Type: "any_success", Timeout: 600}, deps)
+	assert.Equal(t, `{"depends_on":["extract"],"type":"any_success","timeout":600}`, deps.Encode())
+
+	rule, err := deps.Rule("report")
+	require.NoError(t, err)
+	assert.Equal(t, "report", rule.TaskName)
+	assert.Equal(t, engine.DependencyTypeAnySuccess, rule.DependencyType)
+	assert.Equal(t, 10*time.Minute, rule.Timeout)
+
+	// 未声明上游时不注册规则
+	deps, err = ParseJobDependencies("")
+	require.NoError(t, err)
+	rule, err = deps.Rule("report")
+	require.NoError(t, err)
+	assert.Nil(t, rule)
+
+	_, err = JobDependencies{DependsOn: []string{"extract"}, Type: "sometimes"}.Rule("report")
+	assert.Error(t, err)
+}
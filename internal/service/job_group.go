@@ -0,0 +1,284 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrGroupNotFound 分组不存在
+	ErrGroupNotFound = errors.New("group not found")
+	// ErrGroupCycle 不能将分组移动到自身或其子分组下
+	ErrGroupCycle = errors.New("group cannot be moved under itself or its descendants")
+	// ErrGroupNotEmpty 分组下仍有子分组或任务
+	ErrGroupNotEmpty = errors.New("group still has subgroups or jobs")
+)
+
+// GroupRetryPolicy 分组的默认重试策略，字段含义与 YAML 任务配置一致；
+// 组内任务继承最近一级设置了策略的分组，MaxRetries 为空时沿用任务自身的最大重试次数
+type GroupRetryPolicy struct {
+	MaxRetries   *int     `json:"max_retries,omitempty"`
+	RetryBackoff string   `json:"retry_backoff,omitempty"` // exponential(默认), linear, fixed
+	RetryDelay   int      `json:"retry_delay,omitempty"`   // 首次重试前的等待时间(秒)
+	RetryOn      []string `json:"retry_on,omitempty"`      // 只重试指定类别的错误: timeout, network
+}
+
+// GroupNode 分组树的节点
+type GroupNode struct {
+	Group    models.JobGroup
+	Children []*GroupNode
+}
+
+// ParseGroupRetryPolicy 解析分组的默认重试策略，未设置时返回 nil
+func ParseGroupRetryPolicy(raw string) *GroupRetryPolicy {
+	if raw == "" || raw == "null" {
+		return nil
+	}
+	var policy GroupRetryPolicy
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		return nil
+	}
+	return &policy
+}
+
+// GroupKey 分组在调度器中的标识，用于分组并发上限
+func GroupKey(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ListGroups 按层级与排序列出所有分组
+func ListGroups() ([]models.JobGroup, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var groups []models.JobGroup
+	err := conn.Where("deleted_at IS NULL").Order("level, sort, id").Find(&groups).Error
+	return groups, err
+}
+
+// GetGroup 查询分组
+func GetGroup(id uint) (*models.JobGroup, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var group models.JobGroup
+	if err := conn.Where("deleted_at IS NULL").First(&group, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// BuildGroupTree 将按层级排序的分组组装为树，父分组不存在的分组作为根节点
+func BuildGroupTree(groups []models.JobGroup) []*GroupNode {
+	nodes := make(map[uint]*GroupNode, len(groups))
+	for _, group := range groups {
+		nodes[group.ID] = &GroupNode{Group: group, Children: []*GroupNode{}}
+	}
+
+	roots := []*GroupNode{}
+	for _, group := range groups {
+		node := nodes[group.ID]
+		if group.ParentID != nil {
+			if parent, ok := nodes[*group.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+// CreateGroup 创建分组，按父分组计算层级与物化路径
+func CreateGroup(group *models.JobGroup) error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	return conn.Transaction(func(tx *gorm.DB) error {
+		parentPath := ""
+		group.Level = 1
+		if group.ParentID != nil {
+			parent, err := getGroupTx(tx, *group.ParentID)
+			if err != nil {
+				return err
+			}
+			parentPath, group.Level = parent.Path, parent.Level+1
+		}
+		if err := tx.Create(group).Error; err != nil {
+			return err
+		}
+		group.Path = fmt.Sprintf("%s/%d", parentPath, group.ID)
+		return tx.Model(group).Update("path", group.Path).Error
+	})
+}
+
+// UpdateGroup 保存分组，父分组变化时同步更新分组及其所有子分组的层级与物化路径
+func UpdateGroup(group *models.JobGroup, parentID *uint) error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	return conn.Transaction(func(tx *gorm.DB) error {
+		if !sameParent(group.ParentID, parentID) {
+			if err := moveGroup(tx, group, parentID); err != nil {
+				return err
+			}
+		}
+		return tx.Save(group).Error
+	})
+}
+
+func moveGroup(tx *gorm.DB, group *models.JobGroup, parentID *uint) error {
+	oldPath, oldLevel := group.Path, group.Level
+	parentPath, level := "", 1
+	if parentID != nil {
+		parent, err := getGroupTx(tx, *parentID)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(parseGroupPath(parent.Path), group.ID) {
+			return ErrGroupCycle
+		}
+		parentPath, level = parent.Path, parent.Level+1
+	}
+	group.ParentID, group.Level = parentID, level
+	group.Path = fmt.Sprintf("%s/%d", parentPath, group.ID)
+
+	var children []models.JobGroup
+	if err := tx.Where("path LIKE ?", oldPath+"/%").Find(&children).Error; err != nil {
+		return err
+	}
+	for _, child := range children {
+		err := tx.Model(&child).Updates(map[string]any{
+			"path":  group.Path + strings.TrimPrefix(child.Path, oldPath),
+			"level": child.Level + level - oldLevel,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteGroup 删除空分组，仍有子分组或任务时返回 ErrGroupNotEmpty
+func DeleteGroup(group *models.JobGroup) error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var children, jobs int64
+	if err := conn.Model(&models.JobGroup{}).Where("parent_id = ? AND deleted_at IS NULL", group.ID).Count(&children).Error; err != nil {
+		return err
+	}
+	if err := conn.Model(&models.Job{}).Where("group_id = ?", group.ID).Count(&jobs).Error; err != nil {
+		return err
+	}
+	if children > 0 || jobs > 0 {
+		return ErrGroupNotEmpty
+	}
+	return conn.Delete(group).Error
+}
+
+// GroupJobs 列出分组及其子分组中的任务（不含模板）
+func GroupJobs(groupID uint) ([]models.Job, error) {
+	ids, err := GroupSubtree([]uint{groupID})
+	if err != nil {
+		return nil, err
+	}
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var jobs []models.Job
+	err = conn.Where("group_id IN ? AND is_template = ? AND deleted_at IS NULL", ids, false).Order("id").Find(&jobs).Error
+	return jobs, err
+}
+
+// BindAlertRule 将告警规则绑定到分组，组内（含子分组）任务继承该规则；告警模块在下一次刷新规则时生效
+func BindAlertRule(ruleID, groupID uint) error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	result := conn.Model(&models.AlertRule{}).Where("id = ?", ruleID).Update("group_id", groupID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("alert rule %d not found", ruleID)
+	}
+	return nil
+}
+
+// LoadGroupLimits 将设置了并发上限的分组注册到调度器
+func LoadGroupLimits(scheduler *engine.Scheduler, log engine.Logger) {
+	groups, err := ListGroups()
+	if err != nil {
+		log.Error("❌ [Group] Load groups failed", err)
+		return
+	}
+	for _, group := range groups {
+		scheduler.SetGroupConcurrency(GroupKey(group.ID), group.MaxConcurrency)
+	}
+}
+
+// applyGroupDefaults 为分组中的任务设置所属分组与继承的重试策略
+func applyGroupDefaults(opts *engine.JobOptions, job *models.Job) error {
+	chain, err := GroupChain(job.GroupID)
+	if err != nil || len(chain) == 0 {
+		return err
+	}
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var groups []models.JobGroup
+	if err := conn.Where("id IN ? AND deleted_at IS NULL", chain).Find(&groups).Error; err != nil {
+		return err
+	}
+	// 按分组链从根到叶排序
+	sort.Slice(groups, func(i, j int) bool {
+		return slices.Index(chain, groups[i].ID) < slices.Index(chain, groups[j].ID)
+	})
+
+	opts.Groups = make([]string, len(groups))
+	for i, group := range groups {
+		opts.Groups[i] = GroupKey(group.ID)
+	}
+	if retry := groupRetryPolicy(groups, job.MaxRetries); retry != nil {
+		opts.Retry = retry
+	}
+	return nil
+}
+
+// groupRetryPolicy 按从根到叶排列的分组链，返回最近一级设置了默认重试策略的分组对应的策略
+func groupRetryPolicy(groups []models.JobGroup, maxRetries int) *engine.RetryPolicy {
+	for i := len(groups) - 1; i >= 0; i-- {
+		policy := ParseGroupRetryPolicy(groups[i].RetryPolicy)
+		if policy == nil {
+			continue
+		}
+		if policy.MaxRetries != nil {
+			maxRetries = *policy.MaxRetries
+		}
+		return engine.NewRetryPolicy(
+			maxRetries,
+			policy.RetryBackoff,
+			time.Duration(policy.RetryDelay)*time.Second,
+			engine.ParseErrorClasses(policy.RetryOn),
+		)
+	}
+	return nil
+}
+
+func getGroupTx(tx *gorm.DB, id uint) (*models.JobGroup, error) {
+	var group models.JobGroup
+	if err := tx.Where("deleted_at IS NULL").First(&group, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
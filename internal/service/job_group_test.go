@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildGroupTree(t *testing.T) {
+	reporting, daily := uint(1), uint(3)
+	tree := BuildGroupTree([]models.JobGroup{
+		{ID: 1, Name: "reporting", Level: 1},
+		{ID: 2, Name: "ops", Level: 1},
+		{ID: 3, Name: "daily", ParentID: &reporting, Level: 2},
+		{ID: 4, Name: "sales", ParentID: &daily, Level: 3},
+		{ID: 5, Name: "orphan", ParentID: new(uint), Level: 2},
+	})
+
+	// 父分组不存在的分组作为根节点
+	require.Len(t, tree, 3)
+	assert.Equal(t, "reporting", tree[0].Group.Name)
+	assert.Equal(t, "ops", tree[1].Group.Name)
+	assert.Equal(t, "orphan", tree[2].Group.Name)
+	require.Len(t, tree[0].Children, 1)
+	assert.Equal(t, "daily", tree[0].Children[0].Group.Name)
+	assert.Equal(t, "sales", tree[0].Children[0].Children[0].Group.Name)
+	assert.Empty(t, tree[1].Children)
+}
+
+func TestGroupRetryPolicy(t *testing.T) {
+	groups := []models.JobGroup{
+		{ID: 1, RetryPolicy: `{"max_retries":5,"retry_backoff":"fixed","retry_delay":10}`},
+		{ID: 3},
+	}
+
+	// 继承最近一级设置了策略的分组
+	policy := groupRetryPolicy(groups, 3)
+	require.NotNil(t, policy)
+	assert.Equal(t, 6, policy.MaxAttempts)
+	assert.Equal(t, 10*time.Second, policy.InitialDelay)
+
+	// 子分组未设置最大重试次数时沿用任务自身的配置
+	groups[1].RetryPolicy = `{"retry_on":["timeout"]}`
+	policy = groupRetryPolicy(groups, 2)
+	assert.Equal(t, 3, policy.MaxAttempts)
+	assert.Equal(t, []engine.ErrorClass{engine.ErrorClassTimeout}, policy.RetryableClasses)
+
+	assert.Nil(t, groupRetryPolicy([]models.JobGroup{{ID: 2}}, 3))
+	assert.Nil(t, ParseGroupRetryPolicy(""))
+}
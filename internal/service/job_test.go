@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobRetryPolicy(t *testing.T) {
+	job := &models.Job{
+		MaxRetries:    4,
+		RetryBackoff:  10,
+		RetryStrategy: "linear",
+		RetryOn:       `["timeout","network"]`,
+	}
+	policy := JobRetryPolicy(job)
+	assert.Equal(t, 5, policy.MaxAttempts)
+	assert.Equal(t, 10*time.Second, policy.InitialDelay)
+	assert.True(t, policy.LinearBackoff)
+	assert.Equal(t, []engine.ErrorClass{engine.ErrorClassTimeout, engine.ErrorClassNetwork}, policy.RetryableClasses)
+
+	// 快照记录并还原重试配置
+	snap := SnapshotJob(job)
+	assert.Equal(t, []string{"timeout", "network"}, snap.RetryOn)
+	restored := &models.Job{RetryBackoff: 5, RetryStrategy: "exponential"}
+	snap.Apply(restored)
+	assert.Equal(t, 10, restored.RetryBackoff)
+	assert.Equal(t, "linear", restored.RetryStrategy)
+	assert.Equal(t, job.RetryOn, restored.RetryOn)
+
+	// 未配置错误类别时重试所有错误
+	policy = JobRetryPolicy(&models.Job{MaxRetries: 2, RetryBackoff: 5, RetryStrategy: "fixed"})
+	assert.Empty(t, policy.RetryableClasses)
+	assert.Equal(t, 5*time.Second, policy.InitialDelay)
+	assert.False(t, policy.LinearBackoff)
+}
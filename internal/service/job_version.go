@@ -0,0 +1,212 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrJobVersionNotFound 任务不存在指定版本
+var ErrJobVersionNotFound = errors.New("job version not found")
+
+// JobSnapshot 任务版本中保存的配置快照，不包含启停状态与运行时字段
+type JobSnapshot struct {
+	DisplayName       string         `json:"display_name"`
+	Type              string         `json:"type"`
+	CronExpr          string         `json:"cron_expr"`
+	GroupID           *uint          `json:"group_id"`
+	Params            map[string]any `json:"params"`
+	Dependencies      []string       `json:"dependencies"`
+	DependencyType    string         `json:"dependency_type,omitempty"`
+	DependencyTimeout int            `json:"dependency_timeout,omitempty"`
+	Priority          int            `json:"priority"`
+	Timeout           int            `json:"timeout"`
+	MaxRetries        int            `json:"max_retries"`
+	RetryBackoff      int            `json:"retry_backoff,omitempty"`
+	RetryStrategy     string         `json:"retry_strategy,omitempty"`
+	RetryOn           []string       `json:"retry_on,omitempty"`
+	Concurrency       string         `json:"concurrency"`
+	MaxPending        int            `json:"max_pending"`
+	Misfire           string         `json:"misfire,omitempty"`
+	Description       string         `json:"description"`
+	Tags              []string       `json:"tags"`
+}
+
+// FieldChange 两个版本之间一个字段的变化，嵌套字段以 . 连接，如 params.url
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// SnapshotJob 提取任务当前的配置快照
+func SnapshotJob(job *models.Job) JobSnapshot {
+	snap := JobSnapshot{
+		DisplayName:   job.DisplayName,
+		Type:          job.Type,
+		CronExpr:      job.CronExpr,
+		GroupID:       job.GroupID,
+		Priority:      job.Priority,
+		Timeout:       job.Timeout,
+		MaxRetries:    job.MaxRetries,
+		RetryBackoff:  job.RetryBackoff,
+		RetryStrategy: job.RetryStrategy,
+		Concurrency:   job.ConcurrentPolicy,
+		MaxPending:    job.MaxPending,
+		Misfire:       job.MisfirePolicy,
+		Description:   job.Description,
+	}
+	if job.Params != "" {
+		_ = json.Unmarshal([]byte(job.Params), &snap.Params)
+	}
+	deps, _ := ParseJobDependencies(job.Dependencies)
+	snap.Dependencies, snap.DependencyType, snap.DependencyTimeout = deps.DependsOn, deps.Type, deps.Timeout
+	if job.Tags != "" {
+		_ = json.Unmarshal([]byte(job.Tags), &snap.Tags)
+	}
+	if job.RetryOn != "" {
+		_ = json.Unmarshal([]byte(job.RetryOn), &snap.RetryOn)
+	}
+	return snap
+}
+
+// Apply 将快照中的配置写回任务
+func (s JobSnapshot) Apply(job *models.Job) {
+	job.DisplayName = s.DisplayName
+	job.Type = s.Type
+	job.CronExpr = s.CronExpr
+	job.GroupID = s.GroupID
+	job.Params = toJSON(s.Params, "")
+	job.Dependencies = JobDependencies{DependsOn: s.Dependencies, Type: s.DependencyType, Timeout: s.DependencyTimeout}.Encode()
+	job.Priority = s.Priority
+	job.Timeout = s.Timeout
+	job.MaxRetries = s.MaxRetries
+	// 早期版本的快照没有重试间隔与退避方式，保留任务当前的配置
+	if s.RetryBackoff > 0 {
+		job.RetryBackoff = s.RetryBackoff
+	}
+	if s.RetryStrategy != "" {
+		job.RetryStrategy = s.RetryStrategy
+	}
+	job.RetryOn = toJSON(s.RetryOn, "")
+	job.ConcurrentPolicy = s.Concurrency
+	job.MaxPending = s.MaxPending
+	job.MisfirePolicy = s.Misfire
+	job.Description = s.Description
+	job.Tags = toJSON(s.Tags, "")
+}
+
+// ParseJobSnapshot 解析版本中保存的配置快照
+func ParseJobSnapshot(version *models.JobVersion) (JobSnapshot, error) {
+	var snap JobSnapshot
+	if err := json.Unmarshal([]byte(version.Config), &snap); err != nil {
+		return snap, fmt.Errorf("invalid config of version %s: %w", version.Version, err)
+	}
+	return snap, nil
+}
+
+// SaveJobVersion 在 tx 中将任务的配置快照保存为新版本，版本号按任务自增
+func SaveJobVersion(tx *gorm.DB, job *models.Job, changeLog string, createdBy *uint) (*models.JobVersion, error) {
+	var last models.JobVersion
+	next := 1
+	err := tx.Where("job_id = ?", job.ID).Order("id DESC").First(&last).Error
+	switch {
+	case err == nil:
+		n, _ := strconv.Atoi(last.Version)
+		next = n + 1
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	version := &models.JobVersion{
+		JobID:     job.ID,
+		Version:   strconv.Itoa(next),
+		Config:    toJSON(SnapshotJob(job), "{}"),
+		ChangeLog: changeLog,
+		CreatedBy: createdBy,
+	}
+	if err := tx.Create(version).Error; err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// ListJobVersions 按版本从新到旧列出任务的所有版本
+func ListJobVersions(jobID uint) ([]models.JobVersion, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var versions []models.JobVersion
+	err := conn.Where("job_id = ?", jobID).Order("id DESC").Find(&versions).Error
+	return versions, err
+}
+
+// GetJobVersion 查询任务的指定版本
+func GetJobVersion(jobID uint, version string) (*models.JobVersion, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var v models.JobVersion
+	err := conn.Where("job_id = ? AND version = ?", jobID, version).First(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// DiffSnapshots 按 JSON 字段比较两个快照，params 等对象逐个字段比较，结果按字段名排序
+func DiffSnapshots(old, new JobSnapshot) []FieldChange {
+	changes := []FieldChange{}
+	diffValues("", toValue(old), toValue(new), &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func diffValues(field string, old, new any, changes *[]FieldChange) {
+	oldMap, oldOK := old.(map[string]any)
+	newMap, newOK := new.(map[string]any)
+	// 对象新增或删除时按空对象比较，逐个列出字段
+	if old == nil && newOK {
+		oldMap, oldOK = map[string]any{}, true
+	}
+	if new == nil && oldOK {
+		newMap, newOK = map[string]any{}, true
+	}
+	if !oldOK || !newOK {
+		if !reflect.DeepEqual(old, new) {
+			*changes = append(*changes, FieldChange{Field: field, Old: old, New: new})
+		}
+		return
+	}
+
+	for key, oldValue := range oldMap {
+		diffValues(joinField(field, key), oldValue, newMap[key], changes)
+	}
+	for key, newValue := range newMap {
+		if _, ok := oldMap[key]; !ok {
+			diffValues(joinField(field, key), nil, newValue, changes)
+		}
+	}
+}
+
+func joinField(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// toValue 将值转换为 JSON 通用结构，便于比较
+func toValue(v any) any {
+	var out any
+	data, _ := json.Marshal(v)
+	_ = json.Unmarshal(data, &out)
+	return out
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobSnapshot(t *testing.T) {
+	groupID := uint(2)
+	job := &models.Job{
+		ID:               1,
+		Name:             "sync",
+		DisplayName:      "同步",
+		Type:             "http",
+		CronExpr:         "0 * * * * *",
+		GroupID:          &groupID,
+		Params:           `{"url":"http://a","method":"GET"}`,
+		Dependencies:     `["prepare"]`,
+		Timeout:          60,
+		ConcurrentPolicy: "forbid",
+		Tags:             `["daily"]`,
+	}
+	snap := SnapshotJob(job)
+	assert.Equal(t, map[string]any{"url": "http://a", "method": "GET"}, snap.Params)
+	assert.Equal(t, []string{"prepare"}, snap.Dependencies)
+
+	// 快照写回后配置不变，名称等标识字段保留
+	restored := &models.Job{ID: 1, Name: "sync", Enable: true}
+	snap.Apply(restored)
+	assert.Equal(t, "sync", restored.Name)
+	assert.True(t, restored.Enable)
+	assert.Empty(t, DiffSnapshots(snap, SnapshotJob(restored)))
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	old := JobSnapshot{
+		CronExpr:     "0 * * * * *",
+		Params:       map[string]any{"url": "http://a", "headers": map[string]any{"X-Token": "1"}},
+		Dependencies: []string{"prepare"},
+	}
+	new := JobSnapshot{
+		CronExpr:     "0 */5 * * * *",
+		Params:       map[string]any{"url": "http://a", "headers": map[string]any{"X-Token": "2"}, "method": "POST"},
+		Dependencies: []string{"prepare", "load"},
+	}
+
+	// params 逐个字段比较，结果按字段名排序
+	assert.Equal(t, []FieldChange{
+		{Field: "cron_expr", Old: "0 * * * * *", New: "0 */5 * * * *"},
+		{Field: "dependencies", Old: []any{"prepare"}, New: []any{"prepare", "load"}},
+		{Field: "params.headers.X-Token", Old: "1", New: "2"},
+		{Field: "params.method", Old: nil, New: "POST"},
+	}, DiffSnapshots(old, new))
+
+	// 参数从无到有时列出新增的字段
+	assert.Equal(t, []FieldChange{{Field: "params.url", New: "http://a"}},
+		DiffSnapshots(JobSnapshot{}, JobSnapshot{Params: map[string]any{"url": "http://a"}}))
+	assert.Empty(t, DiffSnapshots(old, old))
+}
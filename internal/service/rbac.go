@@ -0,0 +1,216 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/iceymoss/go-task/pkg/auth"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"gorm.io/gorm"
+)
+
+// GormPermissionStore 基于 user_roles 与 roles 表查询用户授权
+type GormPermissionStore struct {
+}
+
+// 确保 GormPermissionStore 实现了 auth.PermissionStore 接口
+var _ auth.PermissionStore = (*GormPermissionStore)(nil)
+
+// NewGormPermissionStore 创建授权存储
+func NewGormPermissionStore() *GormPermissionStore {
+	return &GormPermissionStore{}
+}
+
+// Grants 查询用户的所有角色授权；没有分配角色的用户按 users.role 匹配同名角色，兼容角色表之前创建的用户
+func (g *GormPermissionStore) Grants(userID uint) ([]auth.Grant, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+
+	var rows []struct {
+		Name        string
+		Permissions string
+		GroupID     *uint
+	}
+	err := conn.Table("user_roles").
+		Select("roles.name, roles.permissions, user_roles.group_id").
+		Joins("JOIN roles ON roles.id = user_roles.role_id AND roles.deleted_at IS NULL").
+		Where("user_roles.user_id = ?", userID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		var user models.User
+		if err := conn.Select("role").First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		var role models.Role
+		err := conn.Where("name = ? AND deleted_at IS NULL", user.Role).First(&role).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []auth.Grant{{Role: role.Name, Permissions: parsePermissions(role.Permissions)}}, nil
+	}
+
+	grants := make([]auth.Grant, len(rows))
+	for i, row := range rows {
+		grants[i] = auth.Grant{Role: row.Name, Permissions: parsePermissions(row.Permissions), GroupID: row.GroupID}
+	}
+	return grants, nil
+}
+
+func parsePermissions(raw string) []string {
+	var perms []string
+	_ = json.Unmarshal([]byte(raw), &perms)
+	return perms
+}
+
+// SeedRoles 写入内置角色，已存在的角色保留管理员修改后的权限
+func SeedRoles() error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	for _, builtin := range auth.BuiltinRoles {
+		perms, _ := json.Marshal(builtin.Permissions)
+		role := models.Role{
+			Name:        builtin.Name,
+			DisplayName: builtin.DisplayName,
+			Description: builtin.Description,
+			Permissions: string(perms),
+		}
+		if err := conn.Where("name = ?", builtin.Name).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssignRole 为用户分配角色，groupID 为空表示全局生效
+func AssignRole(userID uint, roleName string, groupID *uint) error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var role models.Role
+	if err := conn.Where("name = ? AND deleted_at IS NULL", roleName).First(&role).Error; err != nil {
+		return err
+	}
+	query := conn.Where("user_id = ? AND role_id = ?", userID, role.ID)
+	if groupID == nil {
+		query = query.Where("group_id IS NULL")
+	} else {
+		query = query.Where("group_id = ?", *groupID)
+	}
+	return query.FirstOrCreate(&models.UserRole{UserID: userID, RoleID: role.ID, GroupID: groupID}).Error
+}
+
+// GroupChain 返回分组及其所有上级分组的ID，groupID 为空时返回空
+func GroupChain(groupID *uint) ([]uint, error) {
+	if groupID == nil {
+		return nil, nil
+	}
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var group models.JobGroup
+	if err := conn.Select("id, path").First(&group, *groupID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return []uint{*groupID}, nil
+		}
+		return nil, err
+	}
+	chain := parseGroupPath(group.Path)
+	if len(chain) == 0 {
+		chain = []uint{group.ID}
+	}
+	return chain, nil
+}
+
+// parseGroupPath 解析物化路径 /1/3/5
+func parseGroupPath(path string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(path, "/") {
+		if id, err := strconv.ParseUint(part, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// GroupSubtree 返回分组及其所有子分组的ID
+func GroupSubtree(groupIDs []uint) ([]uint, error) {
+	if len(groupIDs) == 0 {
+		return nil, nil
+	}
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var groups []models.JobGroup
+	if err := conn.Select("id, path").Where("id IN ?", groupIDs).Find(&groups).Error; err != nil {
+		return nil, err
+	}
+
+	ids := append([]uint(nil), groupIDs...)
+	for _, group := range groups {
+		if group.Path == "" {
+			continue
+		}
+		var children []uint
+		if err := conn.Model(&models.JobGroup{}).Where("path LIKE ?", group.Path+"/%").Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, children...)
+	}
+	return ids, nil
+}
+
+// JobNamesInGroups 查询属于指定分组的任务名
+func JobNamesInGroups(groupIDs []uint) ([]string, error) {
+	if len(groupIDs) == 0 {
+		return nil, nil
+	}
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var names []string
+	err := conn.Model(&models.Job{}).Where("group_id IN ? AND deleted_at IS NULL", groupIDs).Pluck("name", &names).Error
+	return names, err
+}
+
+// JobGroupChain 按任务ID查询任务所属分组链
+func JobGroupChain(jobID uint) ([]uint, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var job models.Job
+	if err := conn.Select("id, group_id").First(&job, jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return GroupChain(job.GroupID)
+}
+
+// JobGroupChainByName 按任务名查询任务所属分组链，没有数据库记录的任务（系统、YAML 任务）不属于任何分组
+func JobGroupChainByName(name string) ([]uint, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var job models.Job
+	if err := conn.Select("id, group_id").Where("name = ?", name).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return GroupChain(job.GroupID)
+}
+
+// ExecutionGroupChain 查询执行记录所属任务的分组链
+func ExecutionGroupChain(execID string) ([]uint, error) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+	var exec models.JobExecution
+	if err := conn.Select("job_name").Where("execution_id = ?", execID).First(&exec).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return JobGroupChainByName(exec.JobName)
+}
@@ -203,5 +203,8 @@ func (s *AuthService) InitDefaultUser(username, password, email string) error {
 		return err
 	}
 
-	return dbConn.Model(&models.User{}).Create(admin).Error
+	if err := dbConn.Model(&models.User{}).Create(admin).Error; err != nil {
+		return err
+	}
+	return AssignRole(admin.ID, auth.RoleAdmin, nil)
 }
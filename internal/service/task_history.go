@@ -30,8 +30,9 @@ func NewGormHistoryStorage() *GormHistoryStorage {
 
 // SaveEvent 根据事件持久化任务历史
 func (g *GormHistoryStorage) SaveEvent(event *engine.Event) error {
-	// 只处理完成或失败的事件
-	if event.Type != engine.EventTypeAfterJob && event.Type != engine.EventTypeJobError {
+	// 只处理完成、失败、取消或跳过的事件
+	if event.Type != engine.EventTypeAfterJob && event.Type != engine.EventTypeJobError &&
+		event.Type != engine.EventTypeJobCancelled && event.Type != engine.EventTypeJobSkipped {
 		return nil
 	}
 
@@ -64,9 +65,15 @@ func (g *GormHistoryStorage) SaveEvent(event *engine.Event) error {
 		return err
 	}
 
+	// 没有执行ID的事件（如引擎外部直接发射的事件）才退化为按名称和时间拼接
+	execID := event.ExecID
+	if execID == "" {
+		execID = fmt.Sprintf("%s-%d", event.TaskName, event.TimeStamp.Unix())
+	}
+
 	logEntry := &models.JobLog{
-		ExecutionID: fmt.Sprintf("%s-%d", event.TaskName, event.TimeStamp.Unix()),
-		JobID:       uint(event.TimeStamp.Unix()),
+		ExecutionID: execID,
+		JobID:       jobIDByName(conn, event.TaskName),
 		JobName:     event.TaskName,
 		LogLevel:    string(event.Type),
 		Message:     string(msg),
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/iceymoss/go-task/internal/engine"
+	"github.com/iceymoss/go-task/pkg/constants"
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/db/models"
+
+	"gorm.io/gorm"
+)
+
+// GormWorkflowStore 基于 GORM 的工作流执行记录存储实现
+type GormWorkflowStore struct {
+}
+
+// NewGormWorkflowStore 创建工作流执行记录存储
+func NewGormWorkflowStore() *GormWorkflowStore {
+	return &GormWorkflowStore{}
+}
+
+// SaveWorkflowRun 按执行ID写入或更新 sys_workflow_executions
+func (g *GormWorkflowStore) SaveWorkflowRun(run *engine.WorkflowRun) error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+
+	record := models.WorkflowExecution{
+		ExecutionID:   run.ExecutionID,
+		WorkflowID:    run.WorkflowID,
+		WorkflowName:  run.WorkflowName,
+		Status:        run.Status,
+		NodeStatus:    toJSON(run.NodeStatus, "{}"),
+		ScheduledAt:   run.ScheduledAt,
+		StartedAt:     run.StartedAt,
+		FinishedAt:    run.FinishedAt,
+		DurationMs:    run.DurationMs,
+		TriggerType:   "workflow",
+		TriggerSource: run.TriggerSource,
+		ErrorMessage:  run.ErrorMessage,
+		FailedNodes:   toJSON(run.FailedNodes, "[]"),
+		Metadata:      "null",
+		Tags:          "null",
+	}
+
+	var existing models.WorkflowExecution
+	err := conn.Where("execution_id = ?", run.ExecutionID).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return conn.Create(&record).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	record.ID = existing.ID
+	record.CreatedAt = existing.CreatedAt
+	return conn.Save(&record).Error
+}
+
+// SaveNodeRun 按 (执行ID, 节点ID) 写入或更新 sys_workflow_node_executions
+func (g *GormWorkflowStore) SaveNodeRun(node *engine.WorkflowNodeRun) error {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+
+	record := models.WorkflowNodeExecution{
+		WorkflowExecID: node.WorkflowExecID,
+		NodeID:         node.NodeID,
+		JobID:          node.JobID,
+		JobName:        node.TaskName,
+		Status:         node.Status,
+		ScheduledAt:    node.ScheduledAt,
+		StartedAt:      node.StartedAt,
+		FinishedAt:     node.FinishedAt,
+		DurationMs:     node.DurationMs,
+		RetryCount:     node.RetryCount,
+		InputParams:    toJSON(node.InputParams, "null"),
+		OutputData:     toJSON(node.OutputData, "null"),
+		ErrorMessage:   node.ErrorMessage,
+	}
+
+	var existing models.WorkflowNodeExecution
+	err := conn.Where("workflow_exec_id = ? AND node_id = ?", node.WorkflowExecID, node.NodeID).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return conn.Create(&record).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	record.ID = existing.ID
+	record.CreatedAt = existing.CreatedAt
+	return conn.Save(&record).Error
+}
+
+// WorkflowScheduleConfig sys_workflows.schedule_config 字段结构
+type WorkflowScheduleConfig struct {
+	CronExpr    string `json:"cron_expr"`    // Cron 表达式，为空表示仅手动触发
+	MaxAttempts int    `json:"max_attempts"` // retry_all 策略下的最大执行轮数
+}
+
+// WorkflowDefinitionFromModel 将数据库中的工作流转换为引擎可执行的定义
+func WorkflowDefinitionFromModel(wf *models.Workflow) (*engine.WorkflowDefinition, *WorkflowScheduleConfig, error) {
+	dag, err := engine.ParseWorkflowDAG([]byte(wf.DAG))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var globalParams map[string]any
+	if wf.GlobalParams != "" {
+		if err := json.Unmarshal([]byte(wf.GlobalParams), &globalParams); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	schedule := &WorkflowScheduleConfig{}
+	if wf.ScheduleConfig != "" {
+		if err := json.Unmarshal([]byte(wf.ScheduleConfig), schedule); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	def := &engine.WorkflowDefinition{
+		WorkflowID:      wf.WorkflowID,
+		Name:            wf.Name,
+		DAG:             dag,
+		GlobalParams:    globalParams,
+		FailureStrategy: engine.FailureStrategy(strings.ToLower(wf.FailureStrategy)),
+		MaxAttempts:     schedule.MaxAttempts,
+	}
+	// 全局参数中的表达式同样只能引用上游节点的输出
+	if err := def.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return def, schedule, nil
+}
+
+// LoadWorkflows 将数据库中所有启用的工作流注册到调度器
+func LoadWorkflows(scheduler *engine.Scheduler, log engine.Logger) {
+	conn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+
+	var workflows []models.Workflow
+	if err := conn.Where("enable = ? AND status = ? AND deleted_at IS NULL", true, "active").Find(&workflows).Error; err != nil {
+		log.Error("❌ [Workflow] Load workflows failed", err)
+		return
+	}
+
+	for i := range workflows {
+		wf := &workflows[i]
+		def, schedule, err := WorkflowDefinitionFromModel(wf)
+		if err != nil {
+			log.Error("❌ [Workflow] Invalid workflow definition", "workflow_id", wf.WorkflowID, err)
+			continue
+		}
+		if err := scheduler.AddWorkflow(schedule.CronExpr, def, string(constants.TaskTypeWEB)); err != nil {
+			log.Error("❌ [Workflow] Add workflow to scheduler failed", "workflow_id", wf.WorkflowID, err)
+		}
+	}
+}
+
+// toJSON 序列化为 JSON 字符串，空值时返回 fallback（JSON 列不允许写入空字符串）
+func toJSON(v any, fallback string) string {
+	raw, err := json.Marshal(v)
+	if err != nil || string(raw) == "null" {
+		return fallback
+	}
+	return string(raw)
+}
@@ -144,7 +144,8 @@ func (t *AutoPushSummarizerTask) Run(ctx context.Context, params map[string]any)
 		return err
 	}
 
-	for _, article := range articles {
+	for i, article := range articles {
+		core.ReportProgress(ctx, i, len(articles), "publishing "+article.Title)
 		log.Printf("🚀 [AutoPushSummarizerTask] Processing article: %s", article.Title)
 
 		input := saveFileInput{
@@ -193,6 +194,7 @@ func (t *AutoPushSummarizerTask) Run(ctx context.Context, params map[string]any)
 			return fmt.Errorf("set last id failed: %w", err)
 		}
 	}
+	core.ReportProgress(ctx, len(articles), len(articles), "completed")
 	log.Println("✅ Completed successfully.")
 	return nil
 }
@@ -61,6 +61,9 @@ type WriterParams struct {
 	RandomDelay bool   `json:"random_delay"`
 }
 
+// writerSteps 执行进度的总步数：克隆、读取话题、生成、保存、推送、发布
+const writerSteps = 6
+
 func (t *WriterTask) Run(ctx context.Context, params map[string]any) error {
 	// 1. 解析参数
 	p := parseParams(params)
@@ -82,12 +85,14 @@ func (t *WriterTask) Run(ctx context.Context, params map[string]any) error {
 	}()
 
 	// 4. Git Clone
+	core.ReportProgress(ctx, 0, writerSteps, "cloning repository")
 	log.Printf("📥 [AI Task] Cloning %s into %s", p.RemoteURL, repoLocalPath)
 	if err := t.gitClone(ctx, p.RemoteURL, repoLocalPath, p.SSHKeyPath); err != nil {
 		return fmt.Errorf("git clone failed: %w", err)
 	}
 
 	// 数据库中获取文章话题
+	core.ReportProgress(ctx, 1, writerSteps, "loading article topic")
 	dbConn := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
 
 	// 自动迁移表结构 (为了方便，生产环境建议手动建表)
@@ -120,6 +125,7 @@ func (t *WriterTask) Run(ctx context.Context, params map[string]any) error {
 	}
 
 	// 5. 调用 AI 生成 (封装在 callAI 中)
+	core.ReportProgress(ctx, 2, writerSteps, "generating content")
 	log.Printf("🤖 [AI Task] Generating content using %s (Model: %s)...", p.BaseURL, p.Model)
 	title, content, err := t.callAI(ctx, p)
 	if err != nil {
@@ -127,12 +133,14 @@ func (t *WriterTask) Run(ctx context.Context, params map[string]any) error {
 	}
 
 	// 6. 保存文件
+	core.ReportProgress(ctx, 3, writerSteps, "saving file")
 	filename, err := t.saveFile(repoLocalPath, p.AuthorName, title, content)
 	if err != nil {
 		return fmt.Errorf("save file failed: %w", err)
 	}
 
 	// 7. Git 提交并推送
+	core.ReportProgress(ctx, 4, writerSteps, "pushing changes")
 	log.Println("🚀 [AI Task] Pushing changes...")
 	if err := t.gitPush(ctx, repoLocalPath, filename, p, p.SSHKeyPath); err != nil {
 		return fmt.Errorf("git push failed: %w", err)
@@ -141,6 +149,7 @@ func (t *WriterTask) Run(ctx context.Context, params map[string]any) error {
 	rdb.Set(ctx, LastID, article.ID+1, 0)
 
 	// 1. 登录信息
+	core.ReportProgress(ctx, 5, writerSteps, "publishing article")
 	username := "ai_bot"
 	password := "admin123"
 
@@ -172,6 +181,7 @@ func (t *WriterTask) Run(ctx context.Context, params map[string]any) error {
 		return err
 	}
 
+	core.ReportProgress(ctx, writerSteps, writerSteps, "completed")
 	log.Println("✅ [AI Task] Completed successfully.")
 	return nil
 }
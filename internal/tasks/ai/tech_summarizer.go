@@ -84,8 +84,9 @@ func (t *TechSummarizerTask) Run(ctx context.Context, params map[string]any) err
 
 	totalProcessed := 0
 
-	// 1. 遍历所有 RSS 源
-	for _, url := range p.Sources {
+	// 1. 遍历所有 RSS 源，以处理完的源数作为执行进度
+	for i, url := range p.Sources {
+		core.ReportProgress(ctx, i, len(p.Sources), "fetching "+url)
 		log.Printf("🕷️ [Crawler] Fetching: %s", url)
 		feed, err := fp.ParseURLWithContext(url, ctx)
 		if err != nil {
@@ -149,6 +150,7 @@ func (t *TechSummarizerTask) Run(ctx context.Context, params map[string]any) err
 		}
 	}
 
+	core.ReportProgress(ctx, len(p.Sources), len(p.Sources), fmt.Sprintf("new articles: %d", totalProcessed))
 	log.Printf("🎉 [Crawler] Task finished. New articles: %d", totalProcessed)
 	return nil
 }
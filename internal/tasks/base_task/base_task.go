@@ -1,6 +1,7 @@
 package base_task
 
 import (
+	"github.com/iceymoss/go-task/internal/core"
 	"github.com/iceymoss/go-task/pkg/constants"
 )
 
@@ -10,6 +11,7 @@ type BaseTask struct {
 	DefaultCron   string
 	DefaultParams map[string]any
 	TaskType      constants.TaskType
+	Meta          core.TaskMetadata // 任务元数据与参数 Schema，用于前端生成表单与参数校验
 }
 
 func (b *BaseTask) Identifier() string               { return b.Name }
@@ -18,3 +20,25 @@ func (b *BaseTask) GetDefaultParams() map[string]any { return b.DefaultParams }
 func (b *BaseTask) GetTaskType() constants.TaskType {
 	return b.TaskType
 }
+
+// Metadata 返回任务元数据，未声明名称时使用任务标识
+func (b *BaseTask) Metadata() core.TaskMetadata {
+	meta := b.Meta
+	if meta.Name == "" {
+		meta.Name = b.Name
+	}
+	return meta
+}
+
+// ValidateParams 按 ParamSchema 校验参数
+func (b *BaseTask) ValidateParams(params map[string]any) error {
+	return b.Meta.ParamSchema.Validate(params)
+}
+
+// BeforeRun 默认前置钩子（空实现）
+func (b *BaseTask) BeforeRun(ctx *core.TaskContext, params map[string]any) error { return nil }
+
+// AfterRun 默认后置钩子（空实现）
+func (b *BaseTask) AfterRun(ctx *core.TaskContext, params map[string]any, err error) error {
+	return nil
+}
@@ -1,7 +1,6 @@
 package email
 
 import (
-	"context"
 	"fmt"
 	"net/smtp"
 	"strings"
@@ -9,7 +8,6 @@ import (
 	"github.com/iceymoss/go-task/internal/core"
 	"github.com/iceymoss/go-task/internal/tasks/base_task"
 	"github.com/iceymoss/go-task/pkg/constants"
-	"github.com/iceymoss/go-task/pkg/logger"
 
 	"go.uber.org/zap"
 )
@@ -21,15 +19,34 @@ type EmailTask struct {
 	base_task.BaseTask
 }
 
-func NewEmailTask() core.Task {
+func NewEmailTask() core.Tasker {
 	return &EmailTask{
 		BaseTask: base_task.BaseTask{
 			Name:     TaskName,
 			TaskType: constants.TaskTypeAPI,
+			Meta: core.TaskMetadata{
+				DisplayName: "发送邮件",
+				Description: "通过 SMTP 发送邮件",
+				Category:    "notification",
+				Type:        "email",
+				ParamSchema: core.ParamSchema{
+					Type: "object",
+					Properties: map[string]core.ParamSchema{
+						"to":      {Title: "收件人", Description: "数组或逗号分隔的字符串", Required: true},
+						"cc":      {Title: "抄送", Description: "数组或逗号分隔的字符串"},
+						"bcc":     {Title: "密送", Description: "数组或逗号分隔的字符串"},
+						"subject": {Type: "string", Title: "主题", Required: true, MinLength: intPtr(1)},
+						"body":    {Type: "string", Title: "正文", Required: true, MinLength: intPtr(1)},
+						"is_html": {Type: "boolean", Title: "HTML 正文", Default: false},
+					},
+				},
+			},
 		},
 	}
 }
 
+func intPtr(v int) *int { return &v }
+
 // EmailParams 参数结构
 type EmailParams struct {
 	To      []string `json:"to" binding:"required"`
@@ -40,7 +57,7 @@ type EmailParams struct {
 	IsHTML  bool     `json:"is_html"`
 }
 
-func (t *EmailTask) Run(ctx context.Context, params map[string]any) error {
+func (t *EmailTask) Run(ctx *core.TaskContext, params map[string]any) error {
 	// 解析参数
 	p := parseParams(params)
 	if len(p.To) == 0 {
@@ -53,21 +70,21 @@ func (t *EmailTask) Run(ctx context.Context, params map[string]any) error {
 		return fmt.Errorf("body is required")
 	}
 
-	logger.Info("🚀 [EmailTask] Sending email",
+	ctx.Log().Info("🚀 [EmailTask] Sending email",
 		zap.Strings("to", p.To),
 		zap.String("subject", p.Subject),
 	)
 
 	// 发送邮件
 	if err := t.sendEmail(p); err != nil {
-		logger.Error("❌ [EmailTask] Failed to send email",
+		ctx.Log().Error("❌ [EmailTask] Failed to send email",
 			zap.Strings("to", p.To),
 			zap.Error(err),
 		)
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
-	logger.Info("✅ [EmailTask] Email sent successfully",
+	ctx.Log().Info("✅ [EmailTask] Email sent successfully",
 		zap.Strings("to", p.To),
 	)
 
@@ -5,8 +5,8 @@ import (
 )
 
 // Creators 暴露ai块下的所有任务工厂
-func Creators() []core.TaskCreator {
-	return []core.TaskCreator{
+func Creators() []core.TaskerCreator {
+	return []core.TaskerCreator{
 		NewEmailTask,
 	}
 }
@@ -11,7 +11,7 @@ import (
 )
 
 // GetTaskByType 根据任务类型创建任务实例
-func GetTaskByType(taskType string) (core.Task, error) {
+func GetTaskByType(taskType string) (core.Tasker, error) {
 	switch taskType {
 	case "shell":
 		return shell.NewShellTask(), nil
@@ -1,6 +1,8 @@
 package tasks
 
 import (
+	"time"
+
 	"github.com/iceymoss/go-task/internal/conf"
 	"github.com/iceymoss/go-task/internal/core"
 	"github.com/iceymoss/go-task/internal/engine"
@@ -10,8 +12,12 @@ import (
 	"github.com/iceymoss/go-task/internal/tasks/shell"
 	"github.com/iceymoss/go-task/internal/tasks/sql"
 	"github.com/iceymoss/go-task/pkg/constants"
+	"github.com/iceymoss/go-task/pkg/storage"
 )
 
+// ExportsURL 任务产出文件的下载地址前缀
+const ExportsURL = "/api/exports"
+
 type LoadTestConfig struct {
 	Registry  *engine.TaskRegistry
 	Scheduler *engine.Scheduler
@@ -21,28 +27,37 @@ type LoadTestConfig struct {
 
 // LoadAllTasks 统一装配, 负责将任务注册到菜单，并交给调度器运行
 func LoadAllTasks(load LoadTestConfig) {
-	var allCreators []core.TaskCreator
-	allCreators = append(allCreators, ai.Creators()...)
+	var allCreators []core.TaskerCreator
+	for _, creator := range ai.Creators() {
+		allCreators = append(allCreators, core.AdaptCreator(creator))
+	}
 	allCreators = append(allCreators, email.Creators()...)
 	allCreators = append(allCreators, network.Creators()...)
-	allCreators = append(allCreators, sql.Creators()...)
-	allCreators = append(allCreators, shell.Creators()...)
+	allCreators = append(allCreators, sql.Creators(sqlOptionsFromConfig(load.Cfg)...)...)
+	allCreators = append(allCreators, shell.Creators(
+		shell.WithAllowedCommands(load.Cfg.Shell.AllowedCommands...),
+		shell.WithAllowedRunAs(load.Cfg.Shell.AllowedRunAs...),
+		shell.WithDefaultRunAs(load.Cfg.Shell.DefaultRunAs),
+		shell.WithWorkspaceRoot(load.Cfg.Shell.WorkspaceRoot),
+	)...)
 
 	for _, creator := range allCreators {
 		task := creator()
 		name := task.Identifier()
 
 		// 将所有任务执行逻辑都注册到任务注册中心
-		load.Registry.Register(name, creator)
+		load.Registry.RegisterTasker(name, creator)
 
 		// 如果是系统任务，直接将对应的系统任务参数添加到调度器中
-		if task.GetTaskType() == constants.TaskTypeSYSTEM {
+		defaults, ok := task.(core.TaskDefaults)
+		if ok && defaults.GetTaskType() == constants.TaskTypeSYSTEM {
 			err := load.Scheduler.AddJob(
-				task.GetDefaultCron(),
+				defaults.GetDefaultCron(),
 				name,
 				name,
-				task.GetDefaultParams(),
-				string(task.GetTaskType()),
+				defaults.GetDefaultParams(),
+				string(defaults.GetTaskType()),
+				nil,
 			)
 			if err != nil {
 				load.Log.Error("add system job failed", "task_name", name, err)
@@ -59,7 +74,9 @@ func LoadAllTasks(load LoadTestConfig) {
 		cronExpr := job.Cron
 		if cronExpr == "" { // 如果 YAML 没配时间，读任务内置时间
 			if creator, err := load.Registry.Get(job.Name); err == nil {
-				cronExpr = creator().GetDefaultCron()
+				if defaults, ok := creator().(core.TaskDefaults); ok {
+					cronExpr = defaults.GetDefaultCron()
+				}
 				if cronExpr == "" {
 					load.Log.Error("task has no cron", "task_name", job.Name)
 					continue
@@ -67,10 +84,76 @@ func LoadAllTasks(load LoadTestConfig) {
 			}
 		}
 
-		err := load.Scheduler.AddJob(cronExpr, job.Name, job.Name, job.Params, string(constants.TaskTypeYAML))
+		rule, err := dependencyRuleFromConfig(job)
+		if err != nil {
+			load.Log.Error("invalid job dependencies", "task_name", job.Name, err)
+			continue
+		}
+		err = load.Scheduler.AddJobWithDependency(cronExpr, job.Name, job.Name, job.Params, string(constants.TaskTypeYAML), jobOptionsFromConfig(job), rule)
 		if err != nil {
 			load.Log.Error("add config job failed", "task_name", job.Name, err)
+			continue
+		}
+		load.Scheduler.SetMisfirePolicy(job.Name, engine.ParseMisfirePolicy(job.Misfire))
+	}
+
+}
+
+// sqlOptionsFromConfig 将配置中的数据源与导出存储转换为 SQL 任务的 Option
+func sqlOptionsFromConfig(cfg *conf.Config) []sql.Option {
+	sources := make(map[string]sql.DataSource, len(cfg.DataSources))
+	for name, ds := range cfg.DataSources {
+		sources[name] = sql.DataSource{
+			Driver:       ds.Driver,
+			DSN:          ds.DSN,
+			MaxOpenConns: ds.MaxOpenConns,
+			MaxIdleConns: ds.MaxIdleConns,
 		}
 	}
+	opts := []sql.Option{sql.WithDataSources(sources)}
+	if cfg.Storage.BasePath != "" {
+		opts = append(opts, sql.WithExportStorage(storage.NewLocalStorage(cfg.Storage.BasePath, ExportsURL)))
+	}
+	return opts
+}
 
+// jobOptionsFromConfig 将 YAML 中的超时、重试、优先级、并发策略与标签转换为调度器的执行配置
+func jobOptionsFromConfig(job conf.JobConfig) *engine.JobOptions {
+	opts := &engine.JobOptions{
+		Timeout:     time.Duration(job.Timeout) * time.Second,
+		Priority:    job.Priority,
+		Concurrency: engine.ParseConcurrencyPolicy(job.Concurrency),
+		MaxPending:  job.MaxPending,
+		Tags:        job.Tags,
+	}
+	if job.MaxRetries != nil || job.RetryBackoff != "" || job.RetryDelay > 0 || len(job.RetryOn) > 0 {
+		maxRetries := engine.DefaultRetryPolicy().MaxAttempts - 1
+		if job.MaxRetries != nil {
+			maxRetries = *job.MaxRetries
+		}
+		opts.Retry = engine.NewRetryPolicy(
+			maxRetries,
+			job.RetryBackoff,
+			time.Duration(job.RetryDelay)*time.Second,
+			engine.ParseErrorClasses(job.RetryOn),
+		)
+	}
+	return opts
+}
+
+// dependencyRuleFromConfig 将 YAML 中的 depends_on 转换为调度器的依赖规则，未配置上游时返回 nil
+func dependencyRuleFromConfig(job conf.JobConfig) (*engine.DependencyRule, error) {
+	if len(job.DependsOn) == 0 {
+		return nil, nil
+	}
+	depType, err := engine.ParseDependencyType(job.DependencyType)
+	if err != nil {
+		return nil, err
+	}
+	return &engine.DependencyRule{
+		TaskName:       job.Name,
+		DependsOn:      job.DependsOn,
+		DependencyType: depType,
+		Timeout:        time.Duration(job.DependencyTimeout) * time.Second,
+	}, nil
 }
@@ -2,42 +2,88 @@ package network
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/iceymoss/go-task/internal/core"
 	"github.com/iceymoss/go-task/internal/tasks/base_task"
 	"github.com/iceymoss/go-task/pkg/constants"
-	"github.com/iceymoss/go-task/pkg/logger"
 
 	"go.uber.org/zap"
 )
 
 const HttpReqTaskName = "network:http:request"
 
+const maxResponseBytes = 10 << 20 // 读取的响应体上限
+
 // HttpTask HTTP 请求任务
 type HttpTask struct {
-	client *http.Client
 	base_task.BaseTask
 }
 
-func NewHttpTask() core.Task {
+func NewHttpTask() core.Tasker {
 	return &HttpTask{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
 		BaseTask: base_task.BaseTask{
 			Name:          HttpReqTaskName,
 			DefaultParams: map[string]any{},
 			TaskType:      constants.TaskTypeAPI,
+			Meta: core.TaskMetadata{
+				DisplayName: "HTTP 请求",
+				Description: "发送 HTTP 请求，校验状态码与响应断言，并提取响应字段",
+				Category:    "ops",
+				Type:        "http",
+				ParamSchema: core.ParamSchema{
+					Type: "object",
+					Properties: map[string]core.ParamSchema{
+						"url":             {Type: "string", Title: "请求地址", Required: true, Pattern: `^https?://`},
+						"method":          {Type: "string", Title: "请求方法", Default: "GET", Enum: []any{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}},
+						"headers":         {Type: "object", Title: "请求头"},
+						"body":            {Title: "请求体", Description: "对象会序列化为 JSON"},
+						"timeout":         {Type: "integer", Title: "超时时间（秒）", Default: 30, Minimum: floatPtr(1)},
+						"expected_status": {Type: "integer", Title: "期望状态码", Default: 200, Minimum: floatPtr(100), Maximum: floatPtr(599)},
+						"assertions": {Type: "array", Title: "响应断言", Description: "全部通过才算成功", Items: &core.ParamSchema{
+							Type: "object",
+							Properties: map[string]core.ParamSchema{
+								"type":  {Type: "string", Title: "类型", Required: true, Enum: []any{AssertJSONPath, AssertRegex, AssertContains, AssertHeader, AssertLatency}},
+								"path":  {Type: "string", Title: "JSONPath 或响应头名称"},
+								"op":    {Type: "string", Title: "比较方式", Enum: []any{OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpContains, OpMatches, OpExists, OpNotExists}},
+								"value": {Title: "期望值", Description: "latency 为毫秒数"},
+							},
+						}},
+						"capture": {Type: "object", Title: "提取响应字段", Description: "输出名 -> 表达式：$.data.id、header:X-Request-Id 或 regex:id=(\\d+)，结果写入输出 captures"},
+						"auth": {Type: "object", Title: "认证", Properties: map[string]core.ParamSchema{
+							"type":          {Type: "string", Title: "认证方式", Required: true, Enum: []any{AuthBasic, AuthBearer, AuthOAuth2}},
+							"username":      {Type: "string", Title: "用户名"},
+							"password":      {Type: "string", Title: "密码"},
+							"token":         {Type: "string", Title: "Bearer Token"},
+							"token_url":     {Type: "string", Title: "Token 地址", Pattern: `^https?://`},
+							"client_id":     {Type: "string", Title: "Client ID"},
+							"client_secret": {Type: "string", Title: "Client Secret"},
+							"scopes":        {Type: "array", Title: "Scopes", Items: &core.ParamSchema{Type: "string"}},
+						}},
+						"tls": {Type: "object", Title: "TLS", Properties: map[string]core.ParamSchema{
+							"cert_file":            {Type: "string", Title: "客户端证书", Description: "mTLS，Worker 上的 PEM 文件路径"},
+							"key_file":             {Type: "string", Title: "客户端私钥"},
+							"ca_file":              {Type: "string", Title: "CA 证书"},
+							"insecure_skip_verify": {Type: "boolean", Title: "跳过证书校验", Default: false},
+						}},
+						"proxy":           {Type: "string", Title: "代理地址", Pattern: `^(https?|socks5)://`},
+						"retry_on_status": {Type: "array", Title: "可重试的状态码", Description: "配置后只有这些状态码与网络错误会按任务的重试策略重试", Items: &core.ParamSchema{Type: "integer", Minimum: floatPtr(100), Maximum: floatPtr(599)}},
+					},
+				},
+			},
 		},
 	}
 }
 
+func floatPtr(v float64) *float64 { return &v }
+
 // HttpParams 参数结构
 type HttpParams struct {
 	URL            string            `json:"url" binding:"required"`
@@ -46,27 +92,52 @@ type HttpParams struct {
 	Body           any               `json:"body"`
 	Timeout        int               `json:"timeout"`
 	ExpectedStatus int               `json:"expected_status"`
+	Assertions     []Assertion       `json:"assertions"`
+	Capture        map[string]string `json:"capture"`
+	Auth           *AuthConfig       `json:"auth"`
+	TLS            *TLSConfig        `json:"tls"`
+	Proxy          string            `json:"proxy"`
+	RetryOnStatus  []int             `json:"retry_on_status"`
+}
+
+func (t *HttpTask) ValidateParams(params map[string]any) error {
+	if err := t.BaseTask.ValidateParams(params); err != nil {
+		return err
+	}
+	p, err := parseParams(params)
+	if err != nil {
+		return err
+	}
+	return p.validate()
 }
 
-func (t *HttpTask) Run(ctx context.Context, params map[string]any) error {
+func (t *HttpTask) Run(ctx *core.TaskContext, params map[string]any) error {
 	// 解析参数
-	p := parseParams(params)
+	p, err := parseParams(params)
+	if err != nil {
+		return err
+	}
 	if p.URL == "" {
 		return fmt.Errorf("url is required")
 	}
-
-	if p.Method == "" {
-		p.Method = "GET"
+	if err := p.validate(); err != nil {
+		return err
 	}
 
-	logger.Info("🚀 [HttpTask] Starting HTTP request",
+	ctx.Log().Info("🚀 [HttpTask] Starting HTTP request",
 		zap.String("url", p.URL),
 		zap.String("method", p.Method),
 	)
 
-	// 设置超时
-	if p.Timeout > 0 {
-		t.client.Timeout = time.Duration(p.Timeout) * time.Second
+	// 每次执行按代理与 TLS 配置创建客户端
+	transport, err := newTransport(p.Proxy, p.TLS)
+	if err != nil {
+		return err
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{
+		Timeout:   time.Duration(p.Timeout) * time.Second,
+		Transport: transport,
 	}
 
 	// 准备请求体
@@ -86,10 +157,8 @@ func (t *HttpTask) Run(ctx context.Context, params map[string]any) error {
 	}
 
 	// 设置请求头
-	if p.Headers != nil {
-		for key, value := range p.Headers {
-			req.Header.Set(key, value)
-		}
+	for key, value := range p.Headers {
+		req.Header.Set(key, value)
 	}
 
 	// 如果有 body，设置 Content-Type
@@ -97,11 +166,17 @@ func (t *HttpTask) Run(ctx context.Context, params map[string]any) error {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
+	if p.Auth != nil {
+		if err := p.Auth.apply(ctx, client, req); err != nil {
+			return err
+		}
+	}
+
 	// 执行请求
 	startTime := time.Now()
-	resp, err := t.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		logger.Error("❌ [HttpTask] Request failed",
+		ctx.Log().Error("❌ [HttpTask] Request failed",
 			zap.String("url", p.URL),
 			zap.Error(err),
 		)
@@ -110,35 +185,112 @@ func (t *HttpTask) Run(ctx context.Context, params map[string]any) error {
 	defer resp.Body.Close()
 
 	// 读取响应体
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
 	if err != nil {
 		return fmt.Errorf("failed to read response: %w", err)
 	}
 
 	duration := time.Since(startTime)
 
+	// 任务输出：状态码、响应体，JSON 响应额外输出解析后的结构
+	core.SetOutput(ctx, "status_code", resp.StatusCode)
+	core.SetOutput(ctx, "body", string(respBody))
+	core.SetOutput(ctx, "duration_ms", duration.Milliseconds())
+	result := &httpResponse{header: resp.Header, body: respBody, latency: duration}
+	if json.Unmarshal(respBody, &result.json) == nil {
+		result.isJSON = true
+		core.SetOutput(ctx, "json", result.json)
+	}
+
 	// 检查状态码
 	if p.ExpectedStatus > 0 && resp.StatusCode != p.ExpectedStatus {
-		logger.Error("❌ [HttpTask] Status code mismatch",
+		ctx.Log().Error("❌ [HttpTask] Status code mismatch",
 			zap.String("url", p.URL),
 			zap.Int("expected", p.ExpectedStatus),
 			zap.Int("actual", resp.StatusCode),
 			zap.String("response", string(respBody)),
 		)
-		return fmt.Errorf("status code mismatch: expected %d, got %d", p.ExpectedStatus, resp.StatusCode)
+		return p.retryable(resp.StatusCode, fmt.Errorf("status code mismatch: expected %d, got %d", p.ExpectedStatus, resp.StatusCode))
 	}
 
-	logger.Info("✅ [HttpTask] Request completed",
+	// 提取响应字段
+	if len(p.Capture) > 0 {
+		captures := make(map[string]any, len(p.Capture))
+		for name, expr := range p.Capture {
+			if v, ok := capture(expr, result); ok {
+				captures[name] = v
+			} else {
+				ctx.Log().Warn("⚠️ [HttpTask] Capture not found", zap.String("name", name), zap.String("expr", expr))
+			}
+		}
+		core.SetOutput(ctx, "captures", captures)
+	}
+
+	// 响应断言
+	if len(p.Assertions) > 0 {
+		results := make([]AssertionResult, len(p.Assertions))
+		var failures []string
+		for i, a := range p.Assertions {
+			results[i] = a.check(result)
+			if !results[i].Passed {
+				failures = append(failures, results[i].Message)
+			}
+		}
+		core.SetOutput(ctx, "assertions", results)
+		if len(failures) > 0 {
+			ctx.Log().Error("❌ [HttpTask] Assertions failed",
+				zap.String("url", p.URL),
+				zap.Strings("failures", failures),
+			)
+			return p.retryable(0, fmt.Errorf("assertion failed: %s", strings.Join(failures, "; ")))
+		}
+	}
+
+	ctx.Log().Info("✅ [HttpTask] Request completed",
 		zap.String("url", p.URL),
 		zap.Int("status", resp.StatusCode),
 		zap.Duration("duration", duration),
 		zap.String("response", string(respBody)),
 	)
+	return nil
+}
 
+// retryable 配置了 retry_on_status 时，只有列出的状态码可以重试，其余失败标记为不可重试
+func (p HttpParams) retryable(status int, err error) error {
+	if len(p.RetryOnStatus) == 0 || slices.Contains(p.RetryOnStatus, status) {
+		return err
+	}
+	return core.Permanent(err)
+}
+
+// validate 校验断言、提取表达式与认证配置，错误按字段返回
+func (p HttpParams) validate() error {
+	var errs core.ValidationErrors
+	for i, a := range p.Assertions {
+		if err := a.validate(); err != nil {
+			errs = append(errs, &core.ValidationError{Field: fmt.Sprintf("assertions[%d]", i), Message: err.Error()})
+		}
+	}
+	for name, expr := range p.Capture {
+		if err := validateCapture(expr); err != nil {
+			errs = append(errs, &core.ValidationError{Field: "capture." + name, Message: err.Error()})
+		}
+	}
+	if p.Auth != nil {
+		if err := p.Auth.validate(); err != nil {
+			errs = append(errs, &core.ValidationError{Field: "auth", Message: err.Error()})
+		}
+	}
+	if p.TLS != nil && (p.TLS.CertFile == "") != (p.TLS.KeyFile == "") {
+		errs = append(errs, &core.ValidationError{Field: "tls", Message: "cert_file and key_file must be set together"})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
-func parseParams(params map[string]any) HttpParams {
+func parseParams(params map[string]any) (HttpParams, error) {
 	p := HttpParams{
 		Method:         "GET",
 		Timeout:        30,
@@ -148,7 +300,7 @@ func parseParams(params map[string]any) HttpParams {
 	if v, ok := params["url"].(string); ok {
 		p.URL = v
 	}
-	if v, ok := params["method"].(string); ok {
+	if v, ok := params["method"].(string); ok && v != "" {
 		p.Method = v
 	}
 	if v, ok := params["headers"].(map[string]any); ok {
@@ -168,6 +320,41 @@ func parseParams(params map[string]any) HttpParams {
 	if v, ok := params["expected_status"].(float64); ok {
 		p.ExpectedStatus = int(v)
 	}
+	if v, ok := params["proxy"].(string); ok {
+		p.Proxy = v
+	}
+
+	// 结构化参数经 JSON 转换为对应的结构体
+	for key, dst := range map[string]any{
+		"assertions":      &p.Assertions,
+		"capture":         &p.Capture,
+		"auth":            &p.Auth,
+		"tls":             &p.TLS,
+		"retry_on_status": &p.RetryOnStatus,
+	} {
+		if err := decodeParam(params[key], dst); err != nil {
+			return p, core.ValidationErrors{{Field: key, Message: err.Error()}}
+		}
+	}
+
+	return p, nil
+}
 
-	return p
+// decodeParam 将 map/slice 形式的参数转换为结构体，参数不存在时不做处理
+func decodeParam(value any, dst any) error {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return fmt.Errorf("invalid value for %s", typeErr.Field)
+		}
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,318 @@
+package network
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AssertJSONPath = "jsonpath" // 按 JSONPath 取响应 JSON 中的值
+	AssertRegex    = "regex"    // 响应体匹配正则
+	AssertContains = "contains" // 响应体包含字符串
+	AssertHeader   = "header"   // 响应头
+	AssertLatency  = "latency"  // 响应耗时（毫秒）
+
+	OpEq        = "eq"
+	OpNe        = "ne"
+	OpGt        = "gt"
+	OpGte       = "gte"
+	OpLt        = "lt"
+	OpLte       = "lte"
+	OpContains  = "contains"
+	OpMatches   = "matches"
+	OpExists    = "exists"
+	OpNotExists = "not_exists"
+)
+
+// Assertion 对响应的一条断言
+type Assertion struct {
+	Type  string `json:"type"`  // jsonpath、regex、contains、header、latency
+	Path  string `json:"path"`  // jsonpath 的路径或 header 的名称
+	Op    string `json:"op"`    // 比较方式，jsonpath/header 默认 eq（未设置 value 时为 exists），latency 默认 lte
+	Value any    `json:"value"` // 期望值；regex/contains 为模式或子串，latency 为毫秒数
+}
+
+// AssertionResult 断言结果，写入任务输出
+type AssertionResult struct {
+	Type    string `json:"type"`
+	Path    string `json:"path,omitempty"`
+	Op      string `json:"op,omitempty"`
+	Passed  bool   `json:"passed"`
+	Actual  any    `json:"actual,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// httpResponse 断言与提取使用的响应内容
+type httpResponse struct {
+	header  http.Header
+	body    []byte
+	json    any
+	isJSON  bool
+	latency time.Duration
+}
+
+// validate 在保存任务时检查断言配置
+func (a Assertion) validate() error {
+	switch a.Type {
+	case AssertJSONPath:
+		if _, err := parseJSONPath(a.Path); err != nil {
+			return err
+		}
+	case AssertHeader:
+		if a.Path == "" {
+			return fmt.Errorf("path is required")
+		}
+	case AssertRegex:
+		pattern, _ := a.Value.(string)
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	case AssertContains:
+		if _, ok := a.Value.(string); !ok {
+			return fmt.Errorf("value must be a string")
+		}
+	case AssertLatency:
+		if _, ok := toFloat(a.Value); !ok {
+			return fmt.Errorf("value must be a number of milliseconds")
+		}
+	default:
+		return fmt.Errorf("unsupported assertion type %q", a.Type)
+	}
+	switch a.op() {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpContains, OpMatches, OpExists, OpNotExists:
+	default:
+		return fmt.Errorf("unsupported op %q", a.Op)
+	}
+	if a.op() == OpMatches {
+		pattern, _ := a.Value.(string)
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+	return nil
+}
+
+// op 返回比较方式，未设置时按断言类型取默认值
+func (a Assertion) op() string {
+	if a.Op != "" {
+		return a.Op
+	}
+	switch a.Type {
+	case AssertLatency:
+		return OpLte
+	case AssertRegex:
+		return OpMatches
+	case AssertContains:
+		return OpContains
+	}
+	if a.Value == nil {
+		return OpExists
+	}
+	return OpEq
+}
+
+// check 执行断言
+func (a Assertion) check(resp *httpResponse) AssertionResult {
+	res := AssertionResult{Type: a.Type, Path: a.Path, Op: a.op()}
+
+	var actual any
+	found := true
+	switch a.Type {
+	case AssertJSONPath:
+		if !resp.isJSON {
+			res.Message = "response is not json"
+			return res
+		}
+		path, _ := parseJSONPath(a.Path)
+		actual, found = path.lookup(resp.json)
+	case AssertHeader:
+		values := resp.header.Values(a.Path)
+		found = len(values) > 0
+		actual = strings.Join(values, ", ")
+	case AssertRegex, AssertContains:
+		actual = string(resp.body)
+	case AssertLatency:
+		actual = resp.latency.Milliseconds()
+	}
+	if found && a.Type != AssertRegex && a.Type != AssertContains {
+		res.Actual = actual
+	}
+
+	ok, err := compare(res.Op, actual, found, a.Value)
+	if err != nil {
+		res.Message = err.Error()
+		return res
+	}
+	res.Passed = ok
+	if !ok {
+		res.Message = a.describeFailure(res.Op, actual, found)
+	}
+	return res
+}
+
+func (a Assertion) describeFailure(op string, actual any, found bool) string {
+	target := a.Type
+	if a.Path != "" {
+		target += " " + a.Path
+	}
+	switch {
+	case op == OpExists:
+		return target + " does not exist"
+	case op == OpNotExists:
+		return target + " exists"
+	case !found:
+		return target + " does not exist"
+	case a.Type == AssertRegex || a.Type == AssertContains:
+		return fmt.Sprintf("body does not %s %v", op, a.Value)
+	default:
+		return fmt.Sprintf("%s: expected %s %v, got %v", target, op, a.Value, actual)
+	}
+}
+
+// compare 按比较方式比较实际值与期望值；数字按数值比较，JSON 中的 1 与参数中的 1 相等
+func compare(op string, actual any, found bool, expected any) (bool, error) {
+	switch op {
+	case OpExists:
+		return found, nil
+	case OpNotExists:
+		return !found, nil
+	}
+	if !found {
+		return false, nil
+	}
+
+	switch op {
+	case OpEq:
+		return equal(actual, expected), nil
+	case OpNe:
+		return !equal(actual, expected), nil
+	case OpGt, OpGte, OpLt, OpLte:
+		a, ok1 := toFloat(actual)
+		e, ok2 := toFloat(expected)
+		if !ok1 || !ok2 {
+			return false, fmt.Errorf("%s requires numbers, got %v and %v", op, actual, expected)
+		}
+		switch op {
+		case OpGt:
+			return a > e, nil
+		case OpGte:
+			return a >= e, nil
+		case OpLt:
+			return a < e, nil
+		default:
+			return a <= e, nil
+		}
+	case OpContains:
+		if list, ok := actual.([]any); ok {
+			for _, item := range list {
+				if equal(item, expected) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return strings.Contains(stringify(actual), stringify(expected)), nil
+	case OpMatches:
+		re, err := regexp.Compile(stringify(expected))
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(stringify(actual)), nil
+	}
+	return false, fmt.Errorf("unsupported op %q", op)
+}
+
+func equal(actual, expected any) bool {
+	if a, ok := toFloat(actual); ok {
+		if e, ok := toFloat(expected); ok {
+			return a == e
+		}
+	}
+	if reflect.DeepEqual(actual, expected) {
+		return true
+	}
+	// 响应头等字符串与参数中的数字、布尔值比较
+	if _, ok := actual.(string); ok {
+		return stringify(actual) == stringify(expected)
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func stringify(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// capture 从响应中提取字段：$ 开头为 JSONPath，header: 前缀为响应头，regex: 前缀取第一个分组（没有分组时取整个匹配）
+func capture(expr string, resp *httpResponse) (any, bool) {
+	switch {
+	case strings.HasPrefix(expr, "header:"):
+		v := resp.header.Get(strings.TrimPrefix(expr, "header:"))
+		return v, v != ""
+	case strings.HasPrefix(expr, "regex:"):
+		re, err := regexp.Compile(strings.TrimPrefix(expr, "regex:"))
+		if err != nil {
+			return nil, false
+		}
+		m := re.FindSubmatch(resp.body)
+		if m == nil {
+			return nil, false
+		}
+		if len(m) > 1 {
+			return string(m[1]), true
+		}
+		return string(m[0]), true
+	default:
+		if !resp.isJSON {
+			return nil, false
+		}
+		path, err := parseJSONPath(expr)
+		if err != nil {
+			return nil, false
+		}
+		return path.lookup(resp.json)
+	}
+}
+
+// validateCapture 在保存任务时检查提取表达式
+func validateCapture(expr string) error {
+	switch {
+	case strings.HasPrefix(expr, "header:"):
+		if strings.TrimPrefix(expr, "header:") == "" {
+			return fmt.Errorf("header name is required")
+		}
+	case strings.HasPrefix(expr, "regex:"):
+		if _, err := regexp.Compile(strings.TrimPrefix(expr, "regex:")); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	default:
+		if _, err := parseJSONPath(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AuthBasic  = "basic"  // HTTP Basic 认证
+	AuthBearer = "bearer" // Bearer Token
+	AuthOAuth2 = "oauth2" // OAuth2 client credentials，自动获取并缓存 access token
+
+	tokenExpiryMargin = 30 * time.Second // access token 提前过期的余量
+)
+
+// AuthConfig 请求认证
+type AuthConfig struct {
+	Type         string   `json:"type"` // basic、bearer、oauth2
+	Username     string   `json:"username"`
+	Password     string   `json:"password"`
+	Token        string   `json:"token"`
+	TokenURL     string   `json:"token_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes"`
+}
+
+// TLSConfig 客户端证书（mTLS）、自定义 CA 与跳过证书校验，证书为 Worker 上的文件路径
+type TLSConfig struct {
+	CertFile           string `json:"cert_file"`
+	KeyFile            string `json:"key_file"`
+	CAFile             string `json:"ca_file"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+func (a *AuthConfig) validate() error {
+	switch a.Type {
+	case AuthBasic:
+		if a.Username == "" {
+			return fmt.Errorf("username is required")
+		}
+	case AuthBearer:
+		if a.Token == "" {
+			return fmt.Errorf("token is required")
+		}
+	case AuthOAuth2:
+		if a.TokenURL == "" || a.ClientID == "" {
+			return fmt.Errorf("token_url and client_id are required")
+		}
+	default:
+		return fmt.Errorf("unsupported auth type %q", a.Type)
+	}
+	return nil
+}
+
+// apply 为请求设置认证信息
+func (a *AuthConfig) apply(ctx context.Context, client *http.Client, req *http.Request) error {
+	switch a.Type {
+	case AuthBasic:
+		req.SetBasicAuth(a.Username, a.Password)
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	case AuthOAuth2:
+		token, err := defaultTokenCache.token(ctx, client, a)
+		if err != nil {
+			return fmt.Errorf("oauth2: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// tokenCache 按 token_url、客户端凭证与 scope 缓存 access token，定时执行的监控任务不必每次重新获取
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+}
+
+type cachedToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+var defaultTokenCache = &tokenCache{tokens: make(map[string]cachedToken)}
+
+func (c *tokenCache) token(ctx context.Context, client *http.Client, a *AuthConfig) (string, error) {
+	key := strings.Join([]string{a.TokenURL, a.ClientID, a.ClientSecret, strings.Join(a.Scopes, " ")}, "|")
+	c.mu.Lock()
+	cached, ok := c.tokens[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(a.ClientID), url.QueryEscape(a.ClientSecret))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("read token response failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, body)
+	}
+
+	var tr struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tr); err != nil || tr.AccessToken == "" {
+		return "", fmt.Errorf("invalid token response: %s", body)
+	}
+	if tr.ExpiresIn > 0 {
+		c.mu.Lock()
+		c.tokens[key] = cachedToken{
+			value:     tr.AccessToken,
+			expiresAt: time.Now().Add(time.Duration(tr.ExpiresIn)*time.Second - tokenExpiryMargin),
+		}
+		c.mu.Unlock()
+	}
+	return tr.AccessToken, nil
+}
+
+// newTransport 按代理与 TLS 配置创建 Transport
+func newTransport(proxy string, tlsCfg *TLSConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if tlsCfg == nil {
+		return transport, nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: tlsCfg.InsecureSkipVerify}
+	if tlsCfg.CertFile != "" || tlsCfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(tlsCfg.CertFile, tlsCfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate failed: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if tlsCfg.CAFile != "" {
+		pem, err := os.ReadFile(tlsCfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file failed: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", tlsCfg.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	transport.TLSClientConfig = config
+	return transport, nil
+}
@@ -0,0 +1,275 @@
+package network
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runHttp 校验参数并执行 HTTP 任务，返回任务输出与错误
+func runHttp(t *testing.T, params map[string]any) (map[string]any, error) {
+	t.Helper()
+	ctx, output := core.WithOutput(context.Background())
+	task := NewHttpTask()
+	if err := task.ValidateParams(params); err != nil {
+		return nil, err
+	}
+	err := task.Run(&core.TaskContext{Context: ctx}, params)
+	return output.Data(), err
+}
+
+func jsonServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req-42")
+		fmt.Fprint(w, `{"status":"ok","data":{"id":7,"items":[{"name":"a"},{"name":"b"}]},"version":"v1.2.3"}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// 测试 JSONPath、正则、包含、响应头与耗时断言，以及响应字段提取
+func TestHttpAssertionsAndCapture(t *testing.T) {
+	srv := jsonServer(t)
+	out, err := runHttp(t, map[string]any{
+		"url": srv.URL,
+		"assertions": []any{
+			map[string]any{"type": "jsonpath", "path": "$.status", "value": "ok"},
+			map[string]any{"type": "jsonpath", "path": "$.data.id", "op": "gte", "value": 5},
+			map[string]any{"type": "jsonpath", "path": "$.data.items[-1].name", "value": "b"},
+			map[string]any{"type": "jsonpath", "path": "data.missing", "op": "not_exists"},
+			map[string]any{"type": "regex", "value": `v\d+\.\d+`},
+			map[string]any{"type": "contains", "value": `"items"`},
+			map[string]any{"type": "header", "path": "X-Request-Id", "value": "req-42"},
+			map[string]any{"type": "latency", "value": 5000},
+		},
+		"capture": map[string]any{
+			"id":         "$.data.id",
+			"first":      "$.data.items[0].name",
+			"request_id": "header:X-Request-Id",
+			"version":    `regex:"version":"([^"]+)"`,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": float64(7), "first": "a", "request_id": "req-42", "version": "v1.2.3"}, out["captures"])
+	results := out["assertions"].([]AssertionResult)
+	require.Len(t, results, 8)
+	for _, r := range results {
+		assert.True(t, r.Passed, r.Message)
+	}
+
+	// 断言失败时任务失败，并输出每条断言的结果
+	out, err = runHttp(t, map[string]any{
+		"url": srv.URL,
+		"assertions": []any{
+			map[string]any{"type": "jsonpath", "path": "$.status", "value": "degraded"},
+			map[string]any{"type": "latency", "value": 0, "op": "lt"},
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "jsonpath $.status: expected eq degraded, got ok")
+	results = out["assertions"].([]AssertionResult)
+	assert.False(t, results[0].Passed)
+	assert.Equal(t, "ok", results[0].Actual)
+	assert.False(t, core.IsPermanent(err), "未配置 retry_on_status 时沿用任务的重试策略")
+}
+
+// 测试断言与提取表达式在保存时校验
+func TestHttpValidate(t *testing.T) {
+	_, err := runHttp(t, map[string]any{
+		"url": "http://example.com",
+		"assertions": []any{
+			map[string]any{"type": "jsonpath", "path": "$.a[x]"},
+			map[string]any{"type": "regex", "value": "("},
+		},
+		"capture": map[string]any{"bad": "regex:("},
+		"auth":    map[string]any{"type": "bearer"},
+		"tls":     map[string]any{"cert_file": "/tmp/cert.pem"},
+	})
+	var verrs core.ValidationErrors
+	require.ErrorAs(t, err, &verrs)
+	fields := verrs.Fields()
+	assert.Contains(t, fields, "assertions[0]")
+	assert.Contains(t, fields, "assertions[1]")
+	assert.Contains(t, fields, "capture.bad")
+	assert.Equal(t, "token is required", fields["auth"])
+	assert.Contains(t, fields, "tls")
+}
+
+// 测试 Basic、Bearer 与 OAuth2 client credentials 认证，access token 在有效期内复用
+func TestHttpAuth(t *testing.T) {
+	var tokenRequests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			atomic.AddInt32(&tokenRequests, 1)
+			id, secret, _ := r.BasicAuth()
+			require.NoError(t, r.ParseForm())
+			if id != "client" || secret != "s3cret" || r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("scope") != "read write" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"oauth-token","token_type":"bearer","expires_in":3600}`)
+		default:
+			fmt.Fprint(w, r.Header.Get("Authorization"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	out, err := runHttp(t, map[string]any{"url": srv.URL, "auth": map[string]any{"type": "basic", "username": "u", "password": "p"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Basic dTpw", out["body"])
+
+	out, err = runHttp(t, map[string]any{"url": srv.URL, "auth": map[string]any{"type": "bearer", "token": "abc"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer abc", out["body"])
+
+	oauth := map[string]any{
+		"type":          "oauth2",
+		"token_url":     srv.URL + "/token",
+		"client_id":     "client",
+		"client_secret": "s3cret",
+		"scopes":        []any{"read", "write"},
+	}
+	for i := 0; i < 2; i++ {
+		out, err = runHttp(t, map[string]any{"url": srv.URL, "auth": oauth})
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer oauth-token", out["body"])
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokenRequests))
+
+	oauth["client_secret"] = "wrong"
+	_, err = runHttp(t, map[string]any{"url": srv.URL, "auth": oauth})
+	assert.ErrorContains(t, err, "token endpoint returned 401")
+}
+
+// 测试 retry_on_status：只有列出的状态码可以重试
+func TestHttpRetryOnStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(map[string]int{"/busy": 503, "/missing": 404}[r.URL.Path])
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := runHttp(t, map[string]any{"url": srv.URL + "/busy", "retry_on_status": []any{502, 503}})
+	require.Error(t, err)
+	assert.False(t, core.IsPermanent(err))
+
+	_, err = runHttp(t, map[string]any{"url": srv.URL + "/missing", "retry_on_status": []any{502, 503}})
+	require.Error(t, err)
+	assert.True(t, core.IsPermanent(err))
+}
+
+// 测试通过代理发送请求
+func TestHttpProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "proxied %s", r.URL.String())
+	}))
+	t.Cleanup(proxy.Close)
+
+	out, err := runHttp(t, map[string]any{"url": "http://upstream.invalid/health", "proxy": proxy.URL})
+	require.NoError(t, err)
+	assert.Equal(t, "proxied http://upstream.invalid/health", out["body"])
+}
+
+// 测试跳过证书校验、自定义 CA 与 mTLS 客户端证书
+func TestHttpTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, clientCert := writeClientCert(t, dir)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCert)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600))
+
+	// 服务端证书不受信任
+	_, err := runHttp(t, map[string]any{"url": srv.URL, "tls": map[string]any{"cert_file": certFile, "key_file": keyFile}})
+	require.Error(t, err)
+
+	out, err := runHttp(t, map[string]any{"url": srv.URL, "tls": map[string]any{"cert_file": certFile, "key_file": keyFile, "ca_file": caFile}})
+	require.NoError(t, err)
+	assert.Equal(t, "go-task-client", out["body"])
+
+	out, err = runHttp(t, map[string]any{"url": srv.URL, "tls": map[string]any{"cert_file": certFile, "key_file": keyFile, "insecure_skip_verify": true}})
+	require.NoError(t, err)
+	assert.Equal(t, "go-task-client", out["body"])
+
+	// 没有客户端证书时服务端拒绝握手
+	_, err = runHttp(t, map[string]any{"url": srv.URL, "tls": map[string]any{"ca_file": caFile}})
+	require.Error(t, err)
+}
+
+// writeClientCert 生成自签名的客户端证书并写入文件
+func writeClientCert(t *testing.T, dir string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "go-task-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "client.pem")
+	keyFile = filepath.Join(dir, "client-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile, cert
+}
+
+// 测试 JSONPath 解析
+func TestParseJSONPath(t *testing.T) {
+	doc := map[string]any{"a b": map[string]any{"list": []any{1.0, 2.0}}}
+	path, err := parseJSONPath(`$['a b'].list[1]`)
+	require.NoError(t, err)
+	v, ok := path.lookup(doc)
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, v)
+
+	path, err = parseJSONPath("$")
+	require.NoError(t, err)
+	v, _ = path.lookup(doc)
+	assert.Equal(t, doc, v)
+
+	for _, bad := range []string{"$..a", "$.a[", "$.a[b]"} {
+		_, err := parseJSONPath(bad)
+		assert.Error(t, err, bad)
+	}
+}
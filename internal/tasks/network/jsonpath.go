@@ -0,0 +1,92 @@
+package network
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPath 解析后的 JSONPath，支持 $.a.b、$.list[0].name、$['key with space'] 与负数下标
+type jsonPath []pathSegment
+
+type pathSegment struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// parseJSONPath 解析 JSONPath，开头的 $ 可以省略
+func parseJSONPath(expr string) (jsonPath, error) {
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+	var path jsonPath
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid json path %q: empty key", expr)
+			}
+			path = append(path, pathSegment{key: s[:end]})
+			s = s[end:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid json path %q: missing ]", expr)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				path = append(path, pathSegment{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid json path %q: bad index %q", expr, inner)
+			}
+			path = append(path, pathSegment{index: idx, isIdx: true})
+		default:
+			// 允许省略开头的点，如 data.items[0]
+			if len(path) == 0 {
+				s = "." + s
+				continue
+			}
+			return nil, fmt.Errorf("invalid json path %q", expr)
+		}
+	}
+	return path, nil
+}
+
+// lookup 在解析后的 JSON 中取值，路径不存在时返回 false
+func (p jsonPath) lookup(doc any) (any, bool) {
+	cur := doc
+	for _, seg := range p {
+		if seg.isIdx {
+			list, ok := cur.([]any)
+			if !ok {
+				return nil, false
+			}
+			idx := seg.index
+			if idx < 0 {
+				idx += len(list)
+			}
+			if idx < 0 || idx >= len(list) {
+				return nil, false
+			}
+			cur = list[idx]
+			continue
+		}
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[seg.key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
@@ -1,7 +1,6 @@
 package network
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -19,7 +18,7 @@ type PingTask struct {
 	base_task.BaseTask
 }
 
-func NewPingTask() core.Task {
+func NewPingTask() core.Tasker {
 	return &PingTask{
 		BaseTask: base_task.BaseTask{
 			Name:        NetworkHttpTaskName,
@@ -29,11 +28,23 @@ func NewPingTask() core.Task {
 				"timeout": 5,
 			},
 			TaskType: constants.TaskTypeSYSTEM,
+			Meta: core.TaskMetadata{
+				DisplayName: "网络探测",
+				Description: "定期对目标地址发送 HEAD 请求，状态码 >= 400 视为失败",
+				Category:    "ops",
+				ParamSchema: core.ParamSchema{
+					Type: "object",
+					Properties: map[string]core.ParamSchema{
+						"url":     {Type: "string", Title: "探测地址", Required: true, Pattern: `^https?://`},
+						"timeout": {Type: "integer", Title: "超时时间（秒）", Default: 5, Minimum: floatPtr(1)},
+					},
+				},
+			},
 		},
 	}
 }
 
-func (t *PingTask) Run(ctx context.Context, params map[string]any) error {
+func (t *PingTask) Run(ctx *core.TaskContext, params map[string]any) error {
 	// 1. 即使是自动任务，也可以读取 Params，因为我们注册时传进去了
 	url, _ := params["url"].(string)
 
@@ -0,0 +1,21 @@
+//go:build linux
+
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setNetworkIsolation 在新的网络命名空间中运行命令，命名空间中只有未启用的 lo，无法访问网络；
+// 调度器不是 root 时同时创建用户命名空间，并将当前用户映射为自身
+func setNetworkIsolation(cmd *exec.Cmd) error {
+	cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNET
+	if os.Geteuid() != 0 {
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER
+		cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Geteuid(), HostID: os.Geteuid(), Size: 1}}
+		cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getegid(), HostID: os.Getegid(), Size: 1}}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package shell
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// setNetworkIsolation 网络隔离依赖 Linux 命名空间
+func setNetworkIsolation(cmd *exec.Cmd) error {
+	return fmt.Errorf("isolate_network is only supported on linux")
+}
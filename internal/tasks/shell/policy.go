@@ -0,0 +1,199 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/iceymoss/go-task/internal/core"
+)
+
+// Policy Worker 节点对 Shell 任务的限制，由配置文件统一设置，任务参数无法绕过
+type Policy struct {
+	AllowedCommands []string // 允许执行的程序，为空时不限制；含路径的按完整路径匹配，否则按 PATH 中的程序名匹配
+	AllowedRunAs    []string // 允许的 run_as，为空时不限制；非空时未指定 run_as 的任务同样被拒绝
+	DefaultRunAs    string   // 任务未指定 run_as 时使用的用户
+	WorkspaceRoot   string   // 执行工作区的根目录，默认系统临时目录
+}
+
+// Option 配置 Shell 任务的限制
+type Option func(*Policy)
+
+// WithAllowedCommands 设置允许执行的程序
+func WithAllowedCommands(commands ...string) Option {
+	return func(p *Policy) {
+		p.AllowedCommands = commands
+	}
+}
+
+// WithAllowedRunAs 设置允许的 run_as
+func WithAllowedRunAs(users ...string) Option {
+	return func(p *Policy) {
+		p.AllowedRunAs = users
+	}
+}
+
+// WithDefaultRunAs 设置任务未指定 run_as 时使用的用户
+func WithDefaultRunAs(runAs string) Option {
+	return func(p *Policy) {
+		p.DefaultRunAs = runAs
+	}
+}
+
+// WithWorkspaceRoot 设置执行工作区的根目录
+func WithWorkspaceRoot(root string) Option {
+	return func(p *Policy) {
+		p.WorkspaceRoot = root
+	}
+}
+
+// runAs 任务参数未指定时使用默认用户
+func (p Policy) runAs(params ShellParams) string {
+	if params.RunAs != "" {
+		return params.RunAs
+	}
+	return p.DefaultRunAs
+}
+
+// check 校验参数是否符合 Worker 的限制
+func (p Policy) check(params ShellParams) error {
+	var errs core.ValidationErrors
+	if len(p.AllowedRunAs) > 0 && !slices.Contains(p.AllowedRunAs, p.runAs(params)) {
+		errs = append(errs, &core.ValidationError{
+			Field:   "run_as",
+			Message: "must be one of " + strings.Join(p.AllowedRunAs, ", "),
+		})
+	}
+	if len(p.AllowedCommands) > 0 {
+		name, err := params.executable()
+		if err == nil {
+			err = p.checkCommand(name)
+		}
+		if err != nil {
+			field := "command"
+			if params.Script != "" {
+				field = "shell"
+			}
+			errs = append(errs, &core.ValidationError{Field: field, Message: err.Error()})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkCommand 校验程序是否在允许列表中；不含路径的程序在 PATH 中查找，含路径的只能按完整路径放行，
+// 避免在工作目录中放置同名程序绕过限制
+func (p Policy) checkCommand(name string) error {
+	if strings.ContainsRune(name, filepath.Separator) {
+		abs, err := filepath.Abs(name)
+		if err == nil && slices.Contains(p.AllowedCommands, filepath.Clean(abs)) {
+			return nil
+		}
+		return fmt.Errorf("%s is not in the allowed commands", name)
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return fmt.Errorf("%s not found in PATH", name)
+	}
+	if slices.Contains(p.AllowedCommands, name) || slices.Contains(p.AllowedCommands, path) {
+		return nil
+	}
+	return fmt.Errorf("%s is not in the allowed commands", name)
+}
+
+// newWorkspace 为一次执行创建独立的工作区，属于 run_as 用户
+func (p Policy) newWorkspace(execID string, owner *runAsUser) (string, error) {
+	root := p.WorkspaceRoot
+	if root == "" {
+		root = filepath.Join(os.TempDir(), "go-task-workspaces")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", fmt.Errorf("create workspace root failed: %w", err)
+	}
+	dir, err := os.MkdirTemp(root, "exec-"+execID+"-")
+	if err != nil {
+		return "", fmt.Errorf("create workspace failed: %w", err)
+	}
+	if owner != nil {
+		if err := os.Chown(dir, int(owner.UID), int(owner.GID)); err != nil {
+			_ = os.RemoveAll(dir)
+			return "", fmt.Errorf("chown workspace failed: %w", err)
+		}
+	}
+	return dir, nil
+}
+
+// runAsUser run_as 解析出的用户
+type runAsUser struct {
+	Name   string
+	Home   string
+	UID    uint32
+	GID    uint32
+	Groups []uint32
+}
+
+// lookupRunAs 解析 run_as：用户名、uid，或 uid:gid
+func lookupRunAs(spec string) (*runAsUser, error) {
+	name, group, hasGroup := strings.Cut(spec, ":")
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		u, err = user.LookupId(name)
+	}
+	var r runAsUser
+	if err == nil {
+		uid, uidErr := strconv.ParseUint(u.Uid, 10, 32)
+		gid, gidErr := strconv.ParseUint(u.Gid, 10, 32)
+		if uidErr != nil || gidErr != nil {
+			return nil, fmt.Errorf("run_as %s: unsupported user id %s", spec, u.Uid)
+		}
+		r = runAsUser{Name: u.Username, Home: u.HomeDir, UID: uint32(uid), GID: uint32(gid)}
+		if ids, err := u.GroupIds(); err == nil {
+			for _, id := range ids {
+				if g, err := strconv.ParseUint(id, 10, 32); err == nil {
+					r.Groups = append(r.Groups, uint32(g))
+				}
+			}
+		}
+	} else {
+		// 容器中常见只有 uid 没有对应的 passwd 记录
+		uid, uidErr := strconv.ParseUint(name, 10, 32)
+		if uidErr != nil {
+			return nil, fmt.Errorf("run_as %s: unknown user", spec)
+		}
+		r = runAsUser{Name: name, UID: uint32(uid), GID: uint32(uid)}
+	}
+
+	if hasGroup {
+		g, err := user.LookupGroup(group)
+		if err != nil {
+			g, err = user.LookupGroupId(group)
+		}
+		if err == nil {
+			group = g.Gid
+		}
+		gid, err := strconv.ParseUint(group, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("run_as %s: unknown group %s", spec, group)
+		}
+		r.GID = uint32(gid)
+		r.Groups = nil
+	}
+	return &r, nil
+}
+
+// env 切换用户后对应的 HOME、USER 等环境变量
+func (u *runAsUser) env() []string {
+	env := []string{"USER=" + u.Name, "LOGNAME=" + u.Name}
+	if u.Home != "" {
+		env = append(env, "HOME="+u.Home)
+	}
+	return env
+}
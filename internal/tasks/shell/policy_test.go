@@ -0,0 +1,140 @@
+//go:build !windows
+
+package shell
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/iceymoss/go-task/internal/core"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runWithPolicy 按 Worker 限制校验参数并执行 Shell 任务
+func runWithPolicy(t *testing.T, opts []Option, params map[string]any) (map[string]any, error) {
+	t.Helper()
+	ctx, output := core.WithOutput(context.Background())
+	task := Creators(opts...)[0]()
+	if err := task.ValidateParams(params); err != nil {
+		return nil, err
+	}
+	err := task.Run(&core.TaskContext{Context: ctx, ExecutionID: "exec-1"}, params)
+	return output.Data(), err
+}
+
+// 测试允许执行的程序列表
+func TestPolicyAllowedCommands(t *testing.T) {
+	echo, err := exec.LookPath("echo")
+	require.NoError(t, err)
+	opts := []Option{WithAllowedCommands("echo")}
+
+	out, err := runWithPolicy(t, opts, map[string]any{"command": "echo ok", "shell": ShellNone})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", out["output"])
+
+	// sh 模式校验的是 shell 本身
+	_, err = runWithPolicy(t, opts, map[string]any{"command": "echo ok"})
+	var verrs core.ValidationErrors
+	require.ErrorAs(t, err, &verrs)
+	assert.Contains(t, verrs.Fields()["command"], "sh is not in the allowed commands")
+
+	// 含路径的程序只能按完整路径放行
+	_, err = runWithPolicy(t, opts, map[string]any{"command": echo + " ok", "shell": ShellNone})
+	assert.ErrorContains(t, err, "not in the allowed commands")
+	_, err = runWithPolicy(t, []Option{WithAllowedCommands(echo)}, map[string]any{"command": echo + " ok", "shell": ShellNone})
+	assert.NoError(t, err)
+
+	_, err = runWithPolicy(t, opts, map[string]any{"script": "echo ok", "shell": ShellBash})
+	require.ErrorAs(t, err, &verrs)
+	assert.Contains(t, verrs.Fields(), "shell")
+}
+
+// 测试 run_as 允许列表，未指定 run_as 时使用默认用户参与校验
+func TestPolicyAllowedRunAs(t *testing.T) {
+	opts := []Option{WithAllowedRunAs("nobody")}
+	_, err := runWithPolicy(t, opts, map[string]any{"command": "true"})
+	assert.ErrorContains(t, err, "run_as: must be one of nobody")
+	_, err = runWithPolicy(t, opts, map[string]any{"command": "true", "run_as": "root"})
+	assert.ErrorContains(t, err, "run_as: must be one of nobody")
+
+	p := Policy{DefaultRunAs: "nobody", AllowedRunAs: []string{"nobody"}}
+	assert.NoError(t, p.check(ShellParams{Command: "true", Shell: ShellSh}))
+}
+
+// 测试每次执行的独立工作区在结束后删除
+func TestPolicyWorkspace(t *testing.T) {
+	root := t.TempDir()
+	out, err := runWithPolicy(t, []Option{WithWorkspaceRoot(root)}, map[string]any{
+		"script":    "pwd\necho $GO_TASK_WORKSPACE\ntouch result.txt\nls\n",
+		"workspace": true,
+	})
+	require.NoError(t, err)
+	lines := strings.Split(out["output"].(string), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, lines[0], lines[1])
+	assert.True(t, strings.HasPrefix(filepath.Base(lines[0]), "exec-exec-1-"))
+	assert.Equal(t, "result.txt", lines[2])
+	_, statErr := os.Stat(lines[0])
+	assert.True(t, os.IsNotExist(statErr), "工作区应被删除")
+
+	_, err = runWithPolicy(t, nil, map[string]any{"command": "true", "workspace": true, "working_dir": root})
+	assert.ErrorContains(t, err, "mutually exclusive")
+}
+
+// 测试 run_as 的解析
+func TestLookupRunAs(t *testing.T) {
+	u, err := lookupRunAs("0")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), u.UID)
+
+	u, err = lookupRunAs("12345:23456")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(12345), u.UID)
+	assert.Equal(t, uint32(23456), u.GID)
+
+	_, err = lookupRunAs("no-such-user-xyz")
+	assert.ErrorContains(t, err, "unknown user")
+}
+
+// 测试以其他用户身份运行，工作区与脚本归属该用户；需要 root
+func TestShellRunAs(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("run_as 需要以 root 运行")
+	}
+	// t.TempDir 的上级目录只有 root 可以进入，工作区根目录放在系统临时目录下
+	root, err := os.MkdirTemp("", "go-task-runas-")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(root) })
+	require.NoError(t, os.Chmod(root, 0o755))
+
+	out, err := runWithPolicy(t, []Option{WithWorkspaceRoot(root)}, map[string]any{
+		"script":    "id -u\nid -g\ntouch owned.txt && echo writable\n",
+		"run_as":    "65534:65534",
+		"workspace": true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "65534\n65534\nwritable", out["output"])
+}
+
+// 测试网络隔离：新的网络命名空间中只有 lo
+func TestShellIsolateNetwork(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("网络隔离仅在 Linux 上生效")
+	}
+	out, err := runWithPolicy(t, nil, map[string]any{
+		"command":         "tail -n +3 /proc/net/dev | cut -d: -f1 | tr -d ' '",
+		"isolate_network": true,
+	})
+	if err != nil && strings.Contains(err.Error(), "operation not permitted") {
+		t.Skip("当前环境不允许创建网络命名空间")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, "lo", out["output"])
+}
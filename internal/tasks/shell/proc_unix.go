@@ -0,0 +1,26 @@
+//go:build !windows
+
+package shell
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup 让命令在独立的进程组中运行，取消或超时时结束整个进程组，避免子进程残留
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// setCredential 以指定用户身份运行命令，需要调度器以 root 运行
+func setCredential(cmd *exec.Cmd, u *runAsUser) error {
+	cmd.SysProcAttr.Credential = &syscall.Credential{
+		Uid:    u.UID,
+		Gid:    u.GID,
+		Groups: u.Groups,
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package shell
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// setProcessGroup Windows 下没有进程组，取消时只结束命令进程本身
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// setCredential Windows 不支持 run_as
+func setCredential(cmd *exec.Cmd, u *runAsUser) error {
+	return fmt.Errorf("run_as is not supported on windows")
+}
@@ -4,9 +4,13 @@ import (
 	"github.com/iceymoss/go-task/internal/core"
 )
 
-// Creators 暴露ai块下的所有任务工厂
-func Creators() []core.TaskCreator {
-	return []core.TaskCreator{
-		NewShellTask,
+// Creators 暴露 shell 块下的所有任务工厂，opts 设置 Worker 对 Shell 任务的限制
+func Creators(opts ...Option) []core.TaskerCreator {
+	var policy Policy
+	for _, opt := range opts {
+		opt(&policy)
+	}
+	return []core.TaskerCreator{
+		func() core.Tasker { return newShellTask(policy) },
 	}
 }
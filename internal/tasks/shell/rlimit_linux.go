@@ -0,0 +1,27 @@
+//go:build linux
+
+package shell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// withResourceLimits 通过 sh 的 ulimit 设置资源限制后 exec 原命令，限制在命令启动前生效且只作用于该命令
+func withResourceLimits(args []string, limits ResourceLimits) []string {
+	if limits.IsZero() {
+		return args
+	}
+	var ulimits []string
+	if limits.CPUSeconds > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -t %d", limits.CPUSeconds))
+	}
+	if limits.MemoryMB > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -v %d", limits.MemoryMB*1024))
+	}
+	if limits.NoFile > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -n %d", limits.NoFile))
+	}
+	script := strings.Join(ulimits, " && ") + ` && exec "$@"`
+	return append([]string{"sh", "-c", script, "go-task"}, args...)
+}
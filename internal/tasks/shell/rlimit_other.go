@@ -0,0 +1,8 @@
+//go:build !linux
+
+package shell
+
+// withResourceLimits 资源限制仅在 Linux 上生效，其他平台原样执行
+func withResourceLimits(args []string, limits ResourceLimits) []string {
+	return args
+}
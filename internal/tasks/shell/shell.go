@@ -1,117 +1,517 @@
 package shell
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/iceymoss/go-task/internal/core"
 	"github.com/iceymoss/go-task/internal/tasks/base_task"
 	"github.com/iceymoss/go-task/pkg/constants"
-	"github.com/iceymoss/go-task/pkg/logger"
 
 	"go.uber.org/zap"
 )
 
 const ShellTaskName = "shell:shell"
 
+const (
+	ShellSh   = "sh"   // 通过 sh 执行，支持管道、引号、重定向
+	ShellBash = "bash" // 通过 bash 执行
+	ShellNone = "none" // 不经过 shell，按空白与引号拆分后直接执行
+
+	ExitStatusSuccess = "success" // 视为成功
+	ExitStatusWarning = "warning" // 视为成功，在输出中标记为告警
+	ExitStatusFailed  = "failed"  // 视为失败
+
+	defaultTimeout        = 300     // 默认超时时间（秒）
+	defaultMaxOutputBytes = 1 << 20 // 默认保留的输出上限（字节）
+	killWaitDelay         = 5 * time.Second
+)
+
 // ShellTask Shell 命令任务
 type ShellTask struct {
 	base_task.BaseTask
+	policy Policy
 }
 
-func NewShellTask() core.Task {
+// NewShellTask 创建不受限制的 Shell 任务，Worker 的限制通过 Creators 的 Option 设置
+func NewShellTask() core.Tasker {
+	return newShellTask(Policy{})
+}
+
+func newShellTask(policy Policy) *ShellTask {
 	return &ShellTask{
+		policy: policy,
 		BaseTask: base_task.BaseTask{
 			Name:     ShellTaskName,
 			TaskType: constants.TaskTypeAPI,
+			Meta: core.TaskMetadata{
+				DisplayName: "Shell 命令",
+				Description: "在 Worker 节点上执行 Shell 命令或脚本",
+				Category:    "ops",
+				Type:        "shell",
+				ParamSchema: core.ParamSchema{
+					Type: "object",
+					Properties: map[string]core.ParamSchema{
+						"command":          {Type: "string", Title: "命令", Description: "与 script 二选一"},
+						"script":           {Type: "string", Title: "脚本", Description: "多行脚本，写入临时文件后由 shell 执行"},
+						"shell":            {Type: "string", Title: "Shell", Default: ShellSh, Enum: []any{ShellSh, ShellBash, ShellNone}},
+						"working_dir":      {Type: "string", Title: "工作目录"},
+						"env":              {Type: "array", Title: "环境变量", Description: "KEY=VALUE 格式，追加在 Worker 环境变量之后", Items: &core.ParamSchema{Type: "string"}},
+						"timeout":          {Type: "integer", Title: "超时时间（秒）", Default: defaultTimeout, Minimum: floatPtr(1)},
+						"exit_codes":       {Type: "object", Title: "退出码映射", Description: "退出码 -> success/warning/failed，如 {\"2\": \"warning\"}，未配置的非 0 退出码视为失败"},
+						"max_output_bytes": {Type: "integer", Title: "输出上限（字节）", Default: defaultMaxOutputBytes, Minimum: floatPtr(1)},
+						"rlimit_cpu":       {Type: "integer", Title: "CPU 时间上限（秒）", Description: "仅 Linux 生效", Minimum: floatPtr(1)},
+						"rlimit_memory_mb": {Type: "integer", Title: "内存上限（MB）", Description: "仅 Linux 生效，限制虚拟内存", Minimum: floatPtr(1)},
+						"rlimit_nofile":    {Type: "integer", Title: "文件描述符上限", Description: "仅 Linux 生效", Minimum: floatPtr(1)},
+						"run_as":           {Type: "string", Title: "运行用户", Description: "用户名、uid 或 uid:gid，需要调度器以 root 运行"},
+						"workspace":        {Type: "boolean", Title: "独立工作区", Description: "在每次执行独立的临时目录中运行，结束后删除；与 working_dir 二选一", Default: false},
+						"isolate_network":  {Type: "boolean", Title: "禁止网络访问", Description: "仅 Linux 生效，在独立的网络命名空间中运行", Default: false},
+					},
+				},
+			},
 		},
 	}
 }
 
+func floatPtr(v float64) *float64 { return &v }
+
 // ShellParams 参数结构
 type ShellParams struct {
-	Command    string   `json:"command" binding:"required"` // 要执行的命令
-	WorkingDir string   `json:"working_dir"`                // 工作目录
-	Env        []string `json:"env"`                        // 环境变量
-	Timeout    int      `json:"timeout"`                    // 超时时间（秒）
+	Command        string         `json:"command"`          // 要执行的命令
+	Script         string         `json:"script"`           // 多行脚本，与 Command 二选一
+	Shell          string         `json:"shell"`            // sh、bash 或 none
+	WorkingDir     string         `json:"working_dir"`      // 工作目录
+	Env            []string       `json:"env"`              // 追加的环境变量
+	Timeout        int            `json:"timeout"`          // 超时时间（秒）
+	ExitCodes      map[int]string `json:"exit_codes"`       // 退出码 -> 状态
+	MaxOutputBytes int            `json:"max_output_bytes"` // 保留的输出上限（字节）
+	Limits         ResourceLimits `json:"-"`                // 资源限制
+	RunAs          string         `json:"run_as"`           // 运行用户
+	Workspace      bool           `json:"workspace"`        // 是否在独立工作区中运行
+	IsolateNetwork bool           `json:"isolate_network"`  // 是否禁止网络访问
 }
 
-func (t *ShellTask) Run(ctx context.Context, params map[string]any) error {
+// ResourceLimits 子进程的资源限制，0 表示不限制
+type ResourceLimits struct {
+	CPUSeconds int // CPU 时间（秒）
+	MemoryMB   int // 虚拟内存（MB）
+	NoFile     int // 打开的文件描述符数量
+}
+
+// IsZero 是否未设置任何限制
+func (l ResourceLimits) IsZero() bool {
+	return l.CPUSeconds == 0 && l.MemoryMB == 0 && l.NoFile == 0
+}
+
+func (t *ShellTask) ValidateParams(params map[string]any) error {
+	if err := t.BaseTask.ValidateParams(params); err != nil {
+		return err
+	}
+	p, err := parseParams(params)
+	if err != nil {
+		return err
+	}
+	if err := p.validate(); err != nil {
+		return err
+	}
+	return t.policy.check(p)
+}
+
+func (t *ShellTask) Run(ctx *core.TaskContext, params map[string]any) error {
 	// 解析参数
-	p := parseParams(params)
-	if p.Command == "" {
-		return fmt.Errorf("command is required")
+	p, err := parseParams(params)
+	if err != nil {
+		return err
+	}
+	if err := p.validate(); err != nil {
+		return err
+	}
+	if err := t.policy.check(p); err != nil {
+		return err
 	}
 
-	logger.Info("🚀 [ShellTask] Starting command",
+	var runAs *runAsUser
+	if spec := t.policy.runAs(p); spec != "" {
+		if runAs, err = lookupRunAs(spec); err != nil {
+			return err
+		}
+	}
+
+	workDir := p.WorkingDir
+	if p.Workspace {
+		if workDir, err = t.policy.newWorkspace(ctx.ExecutionID, runAs); err != nil {
+			return err
+		}
+		defer func() {
+			if err := os.RemoveAll(workDir); err != nil {
+				ctx.Log().Warn("⚠️ [ShellTask] Remove workspace failed", zap.String("workspace", workDir), zap.Error(err))
+			}
+		}()
+	}
+
+	ctx.Log().Info("🚀 [ShellTask] Starting command",
+		zap.String("shell", p.Shell),
 		zap.String("command", p.Command),
-		zap.String("working_dir", p.WorkingDir),
+		zap.Bool("script", p.Script != ""),
+		zap.String("working_dir", workDir),
+		zap.String("run_as", t.policy.runAs(p)),
+		zap.Bool("isolate_network", p.IsolateNetwork),
 	)
 
-	// 准备命令
-	// 支持多平台：Linux/Mac 使用 sh，Windows 使用 cmd
-	var cmd *exec.Cmd
-	if strings.Contains(strings.ToLower(p.Command), "||") || strings.Contains(strings.ToLower(p.Command), "&&") {
-		// 如果包含管道或逻辑运算符，使用 sh -c
-		cmd = exec.CommandContext(ctx, "sh", "-c", p.Command)
-	} else {
-		// 简单命令，直接执行
-		parts := strings.Fields(p.Command)
-		if len(parts) > 0 {
-			cmd = exec.CommandContext(ctx, parts[0], parts[1:]...)
-		} else {
-			return fmt.Errorf("invalid command")
+	args, cleanup, err := p.commandArgs(runAs)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	args = withResourceLimits(args, p.Limits)
+
+	// 超时后连同子进程所在的进程组一起结束
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(p.Timeout)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, args[0], args[1:]...)
+	cmd.Dir = workDir
+	cmd.Env = os.Environ()
+	if runAs != nil {
+		cmd.Env = append(cmd.Env, runAs.env()...)
+	}
+	if p.Workspace {
+		cmd.Env = append(cmd.Env, "GO_TASK_WORKSPACE="+workDir)
+	}
+	cmd.Env = append(cmd.Env, p.Env...)
+	setProcessGroup(cmd)
+	cmd.WaitDelay = killWaitDelay
+	if runAs != nil {
+		if err := setCredential(cmd, runAs); err != nil {
+			return err
+		}
+	}
+	if p.IsolateNetwork {
+		if err := setNetworkIsolation(cmd); err != nil {
+			return err
 		}
 	}
 
-	// 设置工作目录
-	if p.WorkingDir != "" {
-		cmd.Dir = p.WorkingDir
+	// 执行命令，输出在收集的同时逐行写入执行日志
+	buf := &cappedBuffer{limit: p.MaxOutputBytes}
+	cmd.Stdout = io.MultiWriter(buf, ctx.StdoutWriter())
+	cmd.Stderr = io.MultiWriter(buf, ctx.StderrWriter())
+	err = cmd.Run()
+	output := strings.TrimRight(buf.String(), "\n")
+
+	core.SetOutput(ctx, "output", output)
+	if buf.Truncated() {
+		core.SetOutput(ctx, "output_truncated", true)
 	}
 
-	// 设置环境变量
-	if len(p.Env) > 0 {
-		cmd.Env = append(cmd.Env, p.Env...)
+	if runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		ctx.Log().Error("⏰ [ShellTask] Command timed out",
+			zap.String("command", p.Command),
+			zap.Int("timeout", p.Timeout),
+		)
+		return fmt.Errorf("command timed out after %ds: %w", p.Timeout, context.DeadlineExceeded)
 	}
 
-	// 执行命令
-	output, err := cmd.CombinedOutput()
+	exitCode := 0
 	if err != nil {
-		logger.Error("❌ [ShellTask] Command failed",
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() < 0 {
+			// 命令无法启动，或被信号终止
+			ctx.Log().Error("❌ [ShellTask] Command failed",
+				zap.String("command", p.Command),
+				zap.Error(err),
+			)
+			return fmt.Errorf("command failed: %w, output: %s", err, output)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+	core.SetOutput(ctx, "exit_code", exitCode)
+
+	status := p.exitStatus(exitCode)
+	core.SetOutput(ctx, "status", status)
+	switch status {
+	case ExitStatusFailed:
+		ctx.Log().Error("❌ [ShellTask] Command failed",
 			zap.String("command", p.Command),
-			zap.Error(err),
-			zap.String("output", string(output)),
+			zap.Int("exit_code", exitCode),
+		)
+		return fmt.Errorf("command failed: exit code %d, output: %s", exitCode, output)
+	case ExitStatusWarning:
+		ctx.Log().Warn("⚠️ [ShellTask] Command completed with warning",
+			zap.String("command", p.Command),
+			zap.Int("exit_code", exitCode),
+		)
+	default:
+		ctx.Log().Info("✅ [ShellTask] Command completed successfully",
+			zap.String("command", p.Command),
+			zap.Int("exit_code", exitCode),
+			zap.Int("output_bytes", buf.Len()),
 		)
-		return fmt.Errorf("command failed: %w, output: %s", err, string(output))
 	}
+	return nil
+}
 
-	logger.Info("✅ [ShellTask] Command completed successfully",
-		zap.String("command", p.Command),
-		zap.String("output", string(output)),
-	)
+// exitStatus 按退出码映射得到执行状态，0 默认成功，其余默认失败
+func (p ShellParams) exitStatus(code int) string {
+	if status, ok := p.ExitCodes[code]; ok {
+		return status
+	}
+	if code == 0 {
+		return ExitStatusSuccess
+	}
+	return ExitStatusFailed
+}
 
+// validate 校验参数之间的约束，错误按字段返回，便于前端在表单中就地展示
+func (p ShellParams) validate() error {
+	var errs core.ValidationErrors
+	fail := func(field, message string) {
+		errs = append(errs, &core.ValidationError{Field: field, Message: message})
+	}
+	switch {
+	case p.Command == "" && p.Script == "":
+		fail("command", "command or script is required")
+	case p.Command != "" && p.Script != "":
+		fail("script", "command and script are mutually exclusive")
+	case p.Script != "" && p.Shell == ShellNone:
+		fail("shell", "script requires shell sh or bash")
+	}
+	if p.Workspace && p.WorkingDir != "" {
+		fail("working_dir", "working_dir and workspace are mutually exclusive")
+	}
+	switch p.Shell {
+	case ShellSh, ShellBash, ShellNone:
+	default:
+		fail("shell", "unsupported shell "+p.Shell)
+	}
+	for code, status := range p.ExitCodes {
+		switch status {
+		case ExitStatusSuccess, ExitStatusWarning, ExitStatusFailed:
+		default:
+			fail(fmt.Sprintf("exit_codes.%d", code), "must be one of success, warning, failed")
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
-func parseParams(params map[string]any) ShellParams {
+// executable 要执行的程序：脚本与 sh/bash 模式为 shell 本身，none 模式为命令的第一个参数
+func (p ShellParams) executable() (string, error) {
+	if p.Script != "" || p.Shell != ShellNone {
+		return p.Shell, nil
+	}
+	args, err := splitArgs(p.Command)
+	if err != nil {
+		return "", err
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("invalid command")
+	}
+	return args[0], nil
+}
+
+// commandArgs 生成要执行的命令行；脚本写入临时文件并交给 run_as 用户，由 cleanup 删除
+func (p ShellParams) commandArgs(owner *runAsUser) (args []string, cleanup func(), err error) {
+	cleanup = func() {}
+	if p.Script != "" {
+		f, err := os.CreateTemp("", "go-task-*.sh")
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("create script file failed: %w", err)
+		}
+		cleanup = func() { _ = os.Remove(f.Name()) }
+		_, err = f.WriteString(p.Script)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil && owner != nil {
+			err = os.Chown(f.Name(), int(owner.UID), int(owner.GID))
+		}
+		if err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("write script file failed: %w", err)
+		}
+		return []string{p.Shell, f.Name()}, cleanup, nil
+	}
+
+	if p.Shell == ShellNone {
+		args, err := splitArgs(p.Command)
+		if err != nil {
+			return nil, cleanup, err
+		}
+		if len(args) == 0 {
+			return nil, cleanup, fmt.Errorf("invalid command")
+		}
+		return args, cleanup, nil
+	}
+	return []string{p.Shell, "-c", p.Command}, cleanup, nil
+}
+
+// splitArgs 按空白拆分命令，支持单引号、双引号与反斜杠转义
+func splitArgs(command string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("invalid command: unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// cappedBuffer 并发安全的输出缓冲，stdout 与 stderr 由不同协程写入；超过上限的部分丢弃
+type cappedBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if remain := b.limit - b.buf.Len(); remain < len(p) {
+		b.truncated = true
+		if remain > 0 {
+			b.buf.Write(p[:remain])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *cappedBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Len()
+}
+
+func (b *cappedBuffer) Truncated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.truncated
+}
+
+func parseParams(params map[string]any) (ShellParams, error) {
 	p := ShellParams{
-		Timeout: 300, // 默认5分钟
+		Shell:          ShellSh,
+		Timeout:        defaultTimeout, // 默认5分钟
+		MaxOutputBytes: defaultMaxOutputBytes,
 	}
 
 	if v, ok := params["command"].(string); ok {
 		p.Command = v
 	}
+	if v, ok := params["script"].(string); ok {
+		p.Script = v
+	}
+	if v, ok := params["shell"].(string); ok && v != "" {
+		p.Shell = v
+	}
 	if v, ok := params["working_dir"].(string); ok {
 		p.WorkingDir = v
 	}
-	if v, ok := params["env"].([]string); ok {
+	switch v := params["env"].(type) {
+	case []string:
 		p.Env = v
+	case []any:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				p.Env = append(p.Env, str)
+			}
+		}
 	}
-	if v, ok := params["timeout"].(float64); ok {
-		p.Timeout = int(v)
+	if v, ok := intParam(params["timeout"]); ok && v > 0 {
+		p.Timeout = v
+	}
+	if v, ok := intParam(params["max_output_bytes"]); ok && v > 0 {
+		p.MaxOutputBytes = v
+	}
+	if v, ok := params["exit_codes"].(map[string]any); ok {
+		p.ExitCodes = make(map[int]string, len(v))
+		for key, val := range v {
+			code, err := strconv.Atoi(key)
+			if err != nil {
+				return p, core.ValidationErrors{{Field: "exit_codes." + key, Message: "must be an integer exit code"}}
+			}
+			status, _ := val.(string)
+			p.ExitCodes[code] = status
+		}
+	}
+	p.Limits.CPUSeconds, _ = intParam(params["rlimit_cpu"])
+	p.Limits.MemoryMB, _ = intParam(params["rlimit_memory_mb"])
+	p.Limits.NoFile, _ = intParam(params["rlimit_nofile"])
+	if v, ok := params["run_as"].(string); ok {
+		p.RunAs = v
+	}
+	if v, ok := params["workspace"].(bool); ok {
+		p.Workspace = v
+	}
+	if v, ok := params["isolate_network"].(bool); ok {
+		p.IsolateNetwork = v
 	}
 
-	return p
+	return p, nil
+}
+
+// intParam 数字参数可能来自 JSON（float64）或 YAML（int）
+func intParam(v any) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	}
+	return 0, false
 }
@@ -0,0 +1,143 @@
+//go:build !windows
+
+package shell
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iceymoss/go-task/internal/core"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runShell 校验参数并执行 Shell 任务，返回任务输出与错误
+func runShell(t *testing.T, params map[string]any) (map[string]any, error) {
+	t.Helper()
+	ctx, output := core.WithOutput(context.Background())
+	task := NewShellTask()
+	if err := task.ValidateParams(params); err != nil {
+		return nil, err
+	}
+	err := task.Run(&core.TaskContext{Context: ctx}, params)
+	return output.Data(), err
+}
+
+// 测试默认通过 sh 执行，支持管道、引号与重定向，并继承 Worker 的环境变量
+func TestShellSemantics(t *testing.T) {
+	t.Setenv("GO_TASK_INHERITED", "from-worker")
+	out, err := runShell(t, map[string]any{
+		"command": `echo "a  b" | tr a-z A-Z; echo err 1>&2; echo $GO_TASK_INHERITED $EXTRA`,
+		"env":     []any{"EXTRA=added"},
+	})
+	require.NoError(t, err)
+	// stdout 与 stderr 由不同管道读取，两者之间的先后顺序不确定
+	assert.ElementsMatch(t, []string{"A  B", "err", "from-worker added"}, strings.Split(out["output"].(string), "\n"))
+	assert.Equal(t, 0, out["exit_code"])
+	assert.Equal(t, ExitStatusSuccess, out["status"])
+}
+
+// 测试 shell=none 时直接执行，引号内的空格不拆分
+func TestShellNone(t *testing.T) {
+	out, err := runShell(t, map[string]any{"command": `echo 'a | b' "c  d"`, "shell": ShellNone})
+	require.NoError(t, err)
+	assert.Equal(t, "a | b c  d", out["output"])
+
+	args, err := splitArgs(`cmd  a\ b 'c"d' "e'f" ""`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cmd", "a b", `c"d`, "e'f", ""}, args)
+	_, err = splitArgs(`echo "unterminated`)
+	assert.Error(t, err)
+}
+
+// 测试多行脚本写入临时文件执行，结束后删除
+func TestShellScript(t *testing.T) {
+	dir := t.TempDir()
+	out, err := runShell(t, map[string]any{
+		"script":      "set -e\nx=1\nif [ $x -eq 1 ]; then\n  echo one\nfi\npwd\necho $0\n",
+		"shell":       ShellBash,
+		"working_dir": dir,
+	})
+	require.NoError(t, err)
+	lines := strings.Split(out["output"].(string), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "one", lines[0])
+	real, _ := filepath.EvalSymlinks(dir)
+	assert.Equal(t, real, lines[1])
+	_, statErr := os.Stat(lines[2])
+	assert.True(t, os.IsNotExist(statErr), "脚本临时文件应被删除")
+}
+
+// 测试退出码映射：配置为 warning 的退出码视为成功，未配置的非 0 退出码失败
+func TestShellExitCodes(t *testing.T) {
+	params := map[string]any{
+		"command":    "exit 2",
+		"exit_codes": map[string]any{"2": "warning", "3": "success"},
+	}
+	out, err := runShell(t, params)
+	require.NoError(t, err)
+	assert.Equal(t, 2, out["exit_code"])
+	assert.Equal(t, ExitStatusWarning, out["status"])
+
+	params["command"] = "exit 3"
+	out, err = runShell(t, params)
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatusSuccess, out["status"])
+
+	params["command"] = "echo bad; exit 1"
+	out, err = runShell(t, params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit code 1")
+	assert.Equal(t, ExitStatusFailed, out["status"])
+	assert.Equal(t, "bad", out["output"])
+
+	// 非法的映射在保存时即被拒绝
+	_, err = runShell(t, map[string]any{"command": "true", "exit_codes": map[string]any{"2": "ok"}})
+	var verrs core.ValidationErrors
+	require.ErrorAs(t, err, &verrs)
+	assert.Contains(t, verrs.Fields(), "exit_codes.2")
+}
+
+// 测试参数约束
+func TestShellValidate(t *testing.T) {
+	_, err := runShell(t, map[string]any{})
+	assert.ErrorContains(t, err, "command or script is required")
+	_, err = runShell(t, map[string]any{"command": "true", "script": "true"})
+	assert.ErrorContains(t, err, "mutually exclusive")
+	_, err = runShell(t, map[string]any{"script": "true", "shell": ShellNone})
+	assert.ErrorContains(t, err, "script requires shell")
+}
+
+// 测试输出超过上限时截断
+func TestShellOutputCap(t *testing.T) {
+	out, err := runShell(t, map[string]any{"command": "printf '%0100d' 0", "max_output_bytes": 10})
+	require.NoError(t, err)
+	assert.Equal(t, "0000000000", out["output"])
+	assert.Equal(t, true, out["output_truncated"])
+}
+
+// 测试超时后结束整个进程组，后台子进程不会让任务挂起
+func TestShellTimeoutKillsProcessGroup(t *testing.T) {
+	start := time.Now()
+	_, err := runShell(t, map[string]any{"command": "sleep 30 & sleep 30", "timeout": 1})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+// 测试 Linux 下的资源限制
+func TestShellResourceLimits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("资源限制仅在 Linux 上生效")
+	}
+	out, err := runShell(t, map[string]any{"command": "ulimit -n; ulimit -t", "rlimit_nofile": 64, "rlimit_cpu": 10})
+	require.NoError(t, err)
+	assert.Equal(t, "64\n10", out["output"])
+}
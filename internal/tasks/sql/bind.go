@@ -0,0 +1,89 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// bindQuery 将参数绑定到语句上，返回驱动可以直接执行的语句与参数：
+//   - args 为数组时按位置绑定，语句中使用 ?
+//   - args 为对象时按名称绑定，语句中使用 @name，同一名称可以出现多次
+//
+// PostgreSQL 的占位符会转换为 $1、$2...；字符串、引用标识符中的 ? 与 @ 以及 MySQL 的 @@ 系统变量不做处理
+func bindQuery(driver, query string, args any) (string, []any, error) {
+	var positional []any
+	var named map[string]any
+	switch v := args.(type) {
+	case nil:
+	case []any:
+		positional = v
+	case map[string]any:
+		named = v
+	default:
+		return "", nil, fmt.Errorf("args must be an array or an object")
+	}
+
+	var (
+		out   strings.Builder
+		bound []any
+		quote byte
+		used  int
+	)
+	placeholder := func() string {
+		if driver == DriverPostgres {
+			return "$" + strconv.Itoa(len(bound))
+		}
+		return "?"
+	}
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if quote != 0 {
+			out.WriteByte(c)
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			out.WriteByte(c)
+		case c == '?' && named == nil:
+			if used >= len(positional) {
+				return "", nil, fmt.Errorf("not enough args: placeholder %d has no value", used+1)
+			}
+			bound = append(bound, positional[used])
+			used++
+			out.WriteString(placeholder())
+		case c == '@' && named != nil && i+1 < len(query) && isIdentStart(query[i+1]) && (i == 0 || query[i-1] != '@'):
+			j := i + 1
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			name := query[i+1 : j]
+			value, ok := named[name]
+			if !ok {
+				return "", nil, fmt.Errorf("missing value for @%s", name)
+			}
+			bound = append(bound, value)
+			out.WriteString(placeholder())
+			i = j - 1
+		default:
+			out.WriteByte(c)
+		}
+	}
+	if used < len(positional) {
+		return "", nil, fmt.Errorf("too many args: %d placeholders, %d args", used, len(positional))
+	}
+	return out.String(), bound, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
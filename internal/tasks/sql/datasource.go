@@ -0,0 +1,159 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/iceymoss/go-task/pkg/db"
+	"github.com/iceymoss/go-task/pkg/storage"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+
+	// DefaultDataSource 未配置同名数据源时使用调度器自身的 MySQL
+	DefaultDataSource = "mysql"
+)
+
+// DataSource 配置文件中声明的数据源
+type DataSource struct {
+	Driver       string // mysql、postgres、sqlite
+	DSN          string
+	MaxOpenConns int
+	MaxIdleConns int
+}
+
+// Option 配置 SQL 任务可用的数据源与导出存储
+type Option func(*dataSources)
+
+// WithDataSources 设置命名数据源
+func WithDataSources(sources map[string]DataSource) Option {
+	return func(d *dataSources) {
+		for name, source := range sources {
+			d.configs[name] = source
+		}
+	}
+}
+
+// WithExportStorage 设置查询结果导出的文件存储，未设置时不支持导出
+func WithExportStorage(store storage.FileStorage) Option {
+	return func(d *dataSources) {
+		d.storage = store
+	}
+}
+
+// dataSources 所有 SQL 任务实例共享的数据源，连接在首次使用时建立
+type dataSources struct {
+	configs map[string]DataSource
+	storage storage.FileStorage
+
+	mu    sync.Mutex
+	conns map[string]*sql.DB
+	// open 打开数据库连接，测试时替换
+	open func(driverName, dsn string) (*sql.DB, error)
+}
+
+func newDataSources(opts ...Option) *dataSources {
+	d := &dataSources{
+		configs: make(map[string]DataSource),
+		conns:   make(map[string]*sql.DB),
+		open:    sql.Open,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// driver 返回数据源的类型，数据源不存在时返回错误
+func (d *dataSources) driver(name string) (string, error) {
+	if name == "" {
+		name = DefaultDataSource
+	}
+	if source, ok := d.configs[name]; ok {
+		switch source.Driver {
+		case DriverMySQL, DriverPostgres, DriverSQLite:
+			return source.Driver, nil
+		}
+		return "", fmt.Errorf("datasource %s: unsupported driver %q", name, source.Driver)
+	}
+	if name == DefaultDataSource {
+		return DriverMySQL, nil
+	}
+	return "", fmt.Errorf("unknown datasource: %s", name)
+}
+
+// get 返回数据源的连接与类型
+func (d *dataSources) get(name string) (*sql.DB, string, error) {
+	if name == "" {
+		name = DefaultDataSource
+	}
+	driver, err := d.driver(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if conn, ok := d.conns[name]; ok {
+		return conn, driver, nil
+	}
+
+	source, ok := d.configs[name]
+	if !ok {
+		// 调度器自身的 MySQL 复用 GORM 的连接池
+		gormDB := db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
+		if gormDB == nil {
+			return nil, "", fmt.Errorf("database connection not found")
+		}
+		conn, err := gormDB.DB()
+		if err != nil {
+			return nil, "", err
+		}
+		d.conns[name] = conn
+		return conn, driver, nil
+	}
+
+	driverName, err := sqlDriverName(source.Driver)
+	if err != nil {
+		return nil, "", err
+	}
+	conn, err := d.open(driverName, source.DSN)
+	if err != nil {
+		return nil, "", fmt.Errorf("open datasource %s failed: %w", name, err)
+	}
+	if source.MaxOpenConns > 0 {
+		conn.SetMaxOpenConns(source.MaxOpenConns)
+	}
+	if source.MaxIdleConns > 0 {
+		conn.SetMaxIdleConns(source.MaxIdleConns)
+	}
+	d.conns[name] = conn
+	return conn, driver, nil
+}
+
+// sqlDriverName 数据源类型对应的 database/sql 驱动名；SQLite 驱动需要由构建方引入（mattn/go-sqlite3 或 modernc.org/sqlite）
+func sqlDriverName(driver string) (string, error) {
+	switch driver {
+	case DriverMySQL:
+		return "mysql", nil
+	case DriverPostgres:
+		return "pgx", nil
+	case DriverSQLite:
+		drivers := sql.Drivers()
+		for _, name := range []string{"sqlite3", "sqlite"} {
+			if slices.Contains(drivers, name) {
+				return name, nil
+			}
+		}
+		return "", fmt.Errorf("sqlite driver is not linked into this build")
+	}
+	return "", fmt.Errorf("unsupported driver %q", driver)
+}
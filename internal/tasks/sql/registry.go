@@ -4,9 +4,10 @@ import (
 	"github.com/iceymoss/go-task/internal/core"
 )
 
-// Creators 暴露ai块下的所有任务工厂
-func Creators() []core.TaskCreator {
-	return []core.TaskCreator{
-		NewSqlTask,
+// Creators 暴露 sql 块下的所有任务工厂，opts 设置可用的数据源与导出存储，所有任务实例共享连接池
+func Creators(opts ...Option) []core.TaskerCreator {
+	sources := newDataSources(opts...)
+	return []core.TaskerCreator{
+		func() core.Tasker { return newSqlTask(sources) },
 	}
 }
@@ -1,101 +1,527 @@
 package sql
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/iceymoss/go-task/internal/core"
 	"github.com/iceymoss/go-task/internal/tasks/base_task"
 	"github.com/iceymoss/go-task/pkg/constants"
-	"github.com/iceymoss/go-task/pkg/db"
-	"github.com/iceymoss/go-task/pkg/logger"
 
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 )
 
 const TaskName = "sql:sql"
 
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+
+	defaultMaxRows = 1000
+	maxExportRows  = 100000 // 导出文件的最大行数
+)
+
 // SqlTask SQL 查询任务
 type SqlTask struct {
 	base_task.BaseTask
+	sources *dataSources
 }
 
-func NewSqlTask() core.Task {
+// NewSqlTask 创建只能访问调度器自身 MySQL 的 SQL 任务，数据源与导出存储通过 Creators 的 Option 设置
+func NewSqlTask() core.Tasker {
+	return newSqlTask(newDataSources())
+}
+
+func newSqlTask(sources *dataSources) *SqlTask {
 	return &SqlTask{
+		sources: sources,
 		BaseTask: base_task.BaseTask{
 			Name:     TaskName,
 			TaskType: constants.TaskTypeAPI,
+			Meta: core.TaskMetadata{
+				DisplayName: "SQL 语句",
+				Description: "在配置的数据源上执行参数化 SQL，查询结果可作为任务输出或导出为文件",
+				Category:    "data",
+				Type:        "sql",
+				ParamSchema: core.ParamSchema{
+					Type: "object",
+					Properties: map[string]core.ParamSchema{
+						"query":       {Type: "string", Title: "SQL", Description: "与 statements 二选一"},
+						"args":        {Title: "参数", Description: "数组按位置绑定 ?，对象按名称绑定 @name"},
+						"statements":  {Type: "array", Title: "事务语句", Description: "[{query, args}]，在同一事务中依次执行，任一失败整体回滚", Items: &core.ParamSchema{Type: "object"}},
+						"database":    {Type: "string", Title: "数据源", Default: DefaultDataSource},
+						"max_rows":    {Type: "integer", Title: "最多返回行数", Default: defaultMaxRows, Minimum: floatPtr(1)},
+						"export":      {Type: "object", Title: "导出结果", Description: "{format: csv|json, filename}，导出最后一个查询的结果集"},
+						"assert_rows": {Type: "object", Title: "行数断言", Description: "{op: eq|ne|gt|gte|lt|lte, value}，不满足时任务失败，如 {\"op\": \"eq\", \"value\": 0}"},
+					},
+				},
+			},
 		},
 	}
 }
 
+func floatPtr(v float64) *float64 { return &v }
+
 // SqlParams 参数结构
 type SqlParams struct {
-	Query    string `json:"query" binding:"required"`
-	Database string `json:"database"` // mysql 或其他数据库类型
+	Query      string        `json:"query"`       // 单条语句，与 Statements 二选一
+	Args       any           `json:"args"`        // 单条语句的绑定参数
+	Statements []Statement   `json:"statements"`  // 在同一事务中执行的多条语句
+	Database   string        `json:"database"`    // 数据源名称
+	MaxRows    int           `json:"max_rows"`    // 查询语句最多返回的行数，作为任务输出供下游节点使用
+	Export     *ExportConfig `json:"export"`      // 结果导出
+	AssertRows *RowAssertion `json:"assert_rows"` // 行数断言
 }
 
-func (t *SqlTask) Run(ctx context.Context, params map[string]any) error {
+// Statement 事务中的一条语句
+type Statement struct {
+	Query string `json:"query"`
+	Args  any    `json:"args"`
+}
+
+// ExportConfig 将最后一个查询的结果集导出到文件存储
+type ExportConfig struct {
+	Format   string `json:"format"`   // csv 或 json
+	Filename string `json:"filename"` // 文件名，默认 <执行ID>.<format>
+}
+
+// RowAssertion 对最后一个查询的结果行数做断言
+type RowAssertion struct {
+	Op    string `json:"op"`
+	Value int    `json:"value"`
+}
+
+// check 判断行数是否满足断言
+func (a *RowAssertion) check(count int) bool {
+	switch a.Op {
+	case "eq":
+		return count == a.Value
+	case "ne":
+		return count != a.Value
+	case "gt":
+		return count > a.Value
+	case "gte":
+		return count >= a.Value
+	case "lt":
+		return count < a.Value
+	case "lte":
+		return count <= a.Value
+	}
+	return false
+}
+
+func (p SqlParams) validate() error {
+	var errs core.ValidationErrors
+	fail := func(field, message string) {
+		errs = append(errs, &core.ValidationError{Field: field, Message: message})
+	}
+	switch {
+	case p.Query == "" && len(p.Statements) == 0:
+		fail("query", "query or statements is required")
+	case p.Query != "" && len(p.Statements) > 0:
+		fail("statements", "query and statements are mutually exclusive")
+	}
+	for i, stmt := range p.Statements {
+		if strings.TrimSpace(stmt.Query) == "" {
+			fail(fmt.Sprintf("statements[%d].query", i), "query is required")
+		}
+	}
+	if p.Export != nil {
+		switch p.Export.Format {
+		case ExportCSV, ExportJSON:
+		default:
+			fail("export.format", "must be one of csv, json")
+		}
+	}
+	if p.AssertRows != nil {
+		switch p.AssertRows.Op {
+		case "eq", "ne", "gt", "gte", "lt", "lte":
+		default:
+			fail("assert_rows.op", "must be one of eq, ne, gt, gte, lt, lte")
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// statements 要执行的语句，单条 query 视为只有一条语句
+func (p SqlParams) statements() []Statement {
+	if len(p.Statements) > 0 {
+		return p.Statements
+	}
+	return []Statement{{Query: p.Query, Args: p.Args}}
+}
+
+func (t *SqlTask) ValidateParams(params map[string]any) error {
+	if err := t.BaseTask.ValidateParams(params); err != nil {
+		return err
+	}
+	p, err := parseParams(params)
+	if err != nil {
+		return err
+	}
+	if err := p.validate(); err != nil {
+		return err
+	}
+	return t.check(p)
+}
+
+// check 校验数据源、绑定参数与导出存储，避免提交后才在执行时失败
+func (t *SqlTask) check(p SqlParams) error {
+	driver, err := t.sources.driver(p.Database)
+	if err != nil {
+		return core.ValidationErrors{{Field: "database", Message: err.Error()}}
+	}
+	for i, stmt := range p.statements() {
+		if _, _, err := bindQuery(driver, stmt.Query, stmt.Args); err != nil {
+			field := "args"
+			if len(p.Statements) > 0 {
+				field = fmt.Sprintf("statements[%d].args", i)
+			}
+			return core.ValidationErrors{{Field: field, Message: err.Error()}}
+		}
+	}
+	if p.Export != nil && t.sources.storage == nil {
+		return core.ValidationErrors{{Field: "export", Message: "export storage is not configured"}}
+	}
+	return nil
+}
+
+// queryer *sql.DB 与 *sql.Tx 的公共方法
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// resultSet 查询语句的结果集
+type resultSet struct {
+	Columns []string
+	Rows    []map[string]any
+	Total   int  // 已读取的行数
+	More    bool // 是否还有未读取的行
+}
+
+func (t *SqlTask) Run(ctx *core.TaskContext, params map[string]any) error {
 	// 解析参数
-	p := parseParams(params)
-	if p.Query == "" {
-		return fmt.Errorf("query is required")
+	p, err := parseParams(params)
+	if err != nil {
+		return err
+	}
+	if err := p.validate(); err != nil {
+		return err
+	}
+	if err := t.check(p); err != nil {
+		return err
+	}
+
+	conn, driver, err := t.sources.get(p.Database)
+	if err != nil {
+		return err
 	}
 
-	logger.Info("🚀 [SqlTask] Executing SQL query",
+	ctx.Log().Info("🚀 [SqlTask] Executing SQL",
 		zap.String("database", p.Database),
-		zap.String("query", p.Query),
+		zap.Int("statements", len(p.statements())),
 	)
 
-	// 获取数据库连接
-	var dbConn *gorm.DB
-	switch p.Database {
-	case "mysql", "":
-		dbConn = db.GetMysqlConn(db.MYSQL_DB_GO_TASK)
-	case "mongo":
-		// MongoDB 支持（如果需要）
-		return fmt.Errorf("mongodb not supported yet")
-	default:
-		return fmt.Errorf("unsupported database: %s", p.Database)
+	var (
+		last    *resultSet
+		results []map[string]any
+		total   int64
+	)
+	exec := func(q queryer) error {
+		for i, stmt := range p.statements() {
+			query, args, err := bindQuery(driver, stmt.Query, stmt.Args)
+			if err != nil {
+				return err
+			}
+			ctx.Log().Info("▶️ [SqlTask] Executing statement", zap.Int("index", i), zap.String("query", query))
+
+			if !isQueryStatement(query) {
+				result, err := q.ExecContext(ctx, query, args...)
+				if err != nil {
+					return fmt.Errorf("statement %d failed: %w", i, err)
+				}
+				affected, _ := result.RowsAffected()
+				total += affected
+				results = append(results, map[string]any{"rows_affected": affected})
+				continue
+			}
+
+			rs, err := t.query(ctx, q, query, args, p)
+			if err != nil {
+				return fmt.Errorf("statement %d failed: %w", i, err)
+			}
+			last = rs
+			results = append(results, map[string]any{"row_count": min(len(rs.Rows), p.MaxRows)})
+		}
+		return nil
 	}
 
-	if dbConn == nil {
-		return fmt.Errorf("database connection not found")
+	if len(p.Statements) > 0 {
+		err = inTx(ctx, conn, exec)
+	} else {
+		err = exec(conn)
+	}
+	if err != nil {
+		ctx.Log().Error("❌ [SqlTask] Query failed", zap.String("database", p.Database), zap.Error(err))
+		return fmt.Errorf("query failed: %w", err)
+	}
+
+	if len(p.Statements) > 0 {
+		core.SetOutput(ctx, "results", results)
+	}
+	if last == nil {
+		ctx.Log().Info("✅ [SqlTask] Query completed", zap.Int64("rows_affected", total))
+		core.SetOutput(ctx, "rows_affected", total)
+		if p.AssertRows != nil || p.Export != nil {
+			return fmt.Errorf("export and assert_rows require a query statement")
+		}
+		return nil
+	}
+
+	rows := last.Rows[:min(len(last.Rows), p.MaxRows)]
+	truncated := last.Total > p.MaxRows || last.More
+	ctx.Log().Info("✅ [SqlTask] Query completed",
+		zap.Int("row_count", len(rows)),
+		zap.Bool("truncated", truncated),
+	)
+	core.SetOutput(ctx, "columns", last.Columns)
+	core.SetOutput(ctx, "rows", rows)
+	core.SetOutput(ctx, "row_count", len(rows))
+	core.SetOutput(ctx, "truncated", truncated)
+	if len(p.Statements) > 0 {
+		core.SetOutput(ctx, "rows_affected", total)
+	}
+
+	if p.Export != nil {
+		export, err := t.export(ctx, p.Export, last)
+		if err != nil {
+			return err
+		}
+		core.SetOutput(ctx, "export", export)
 	}
 
-	// 执行 SQL
-	result := dbConn.Exec(p.Query)
-	if result.Error != nil {
-		logger.Error("❌ [SqlTask] Query failed",
-			zap.String("query", p.Query),
-			zap.Error(result.Error),
+	if p.AssertRows != nil && !p.AssertRows.check(last.Total) {
+		ctx.Log().Warn("⚠️ [SqlTask] Row count assertion failed",
+			zap.Int("row_count", last.Total),
+			zap.String("op", p.AssertRows.Op),
+			zap.Int("value", p.AssertRows.Value),
 		)
-		return fmt.Errorf("query failed: %w", result.Error)
+		return core.Permanent(fmt.Errorf("row count assertion failed: got %d rows, want %s %d",
+			last.Total, p.AssertRows.Op, p.AssertRows.Value))
+	}
+	return nil
+}
+
+// inTx 在事务中执行，出错时回滚
+func inTx(ctx context.Context, conn *sql.DB, fn func(queryer) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			core.LoggerFromContext(ctx).Error("❌ [SqlTask] Rollback failed", zap.Error(rbErr))
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+// query 执行查询语句并读取结果集：只返回结果时读取 max_rows 行，导出时最多读取 maxExportRows 行，行数断言需要读完整个结果集计数
+func (t *SqlTask) query(ctx context.Context, q queryer, query string, args []any, p SqlParams) (*resultSet, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	keep := p.MaxRows
+	if p.Export != nil {
+		keep = max(keep, maxExportRows)
+	}
+	countAll := p.AssertRows != nil
+
+	rs := &resultSet{Columns: columns, Rows: make([]map[string]any, 0)}
+	for rows.Next() {
+		if rs.Total >= keep {
+			if !countAll {
+				rs.More = true
+				break
+			}
+			rs.Total++
+			continue
+		}
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("scan rows failed: %w", err)
+		}
+		row := make(map[string]any, len(columns))
+		for i, col := range columns {
+			// 驱动返回的 []byte 转为字符串，便于序列化和下游引用
+			if b, ok := values[i].([]byte); ok {
+				row[col] = string(b)
+			} else {
+				row[col] = values[i]
+			}
+		}
+		rs.Rows = append(rs.Rows, row)
+		rs.Total++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// export 将结果集写入文件存储，返回访问地址等信息作为任务输出
+func (t *SqlTask) export(ctx *core.TaskContext, cfg *ExportConfig, rs *resultSet) (map[string]any, error) {
+	var buf bytes.Buffer
+	switch cfg.Format {
+	case ExportCSV:
+		w := csv.NewWriter(&buf)
+		if err := w.Write(rs.Columns); err != nil {
+			return nil, err
+		}
+		record := make([]string, len(rs.Columns))
+		for _, row := range rs.Rows {
+			for i, col := range rs.Columns {
+				record[i] = formatCell(row[col])
+			}
+			if err := w.Write(record); err != nil {
+				return nil, err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, fmt.Errorf("write csv failed: %w", err)
+		}
+	case ExportJSON:
+		if err := json.NewEncoder(&buf).Encode(rs.Rows); err != nil {
+			return nil, fmt.Errorf("write json failed: %w", err)
+		}
 	}
 
-	// 获取影响行数
-	rowsAffected := result.RowsAffected
+	filename := cfg.Filename
+	if filename == "" {
+		filename = ctx.ExecutionID + "." + cfg.Format
+	}
+	// 产出文件按执行ID归档，下载时按执行记录所属分组鉴权
+	url, err := t.sources.storage.UploadFile(ctx, &buf, filename, ctx.ExecutionID+"/sql")
+	if err != nil {
+		return nil, fmt.Errorf("upload export failed: %w", err)
+	}
 
-	logger.Info("✅ [SqlTask] Query completed",
-		zap.String("query", p.Query),
-		zap.Int64("rows_affected", rowsAffected),
+	truncated := rs.Total > len(rs.Rows) || rs.More
+	ctx.Log().Info("📦 [SqlTask] Result exported",
+		zap.String("url", url),
+		zap.Int("rows", len(rs.Rows)),
+		zap.Bool("truncated", truncated),
 	)
+	return map[string]any{
+		"url":       url,
+		"format":    cfg.Format,
+		"rows":      len(rs.Rows),
+		"truncated": truncated,
+	}, nil
+}
 
-	return nil
+// formatCell CSV 单元格的文本表示
+func formatCell(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return val.Format(time.RFC3339)
+	case string:
+		return val
+	}
+	return fmt.Sprint(v)
+}
+
+// isQueryStatement 判断是否为返回结果集的语句
+func isQueryStatement(query string) bool {
+	fields := strings.Fields(strings.ToLower(query))
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "select", "show", "describe", "desc", "explain", "with", "pragma":
+		return true
+	}
+	return false
 }
 
-func parseParams(params map[string]any) SqlParams {
+func parseParams(params map[string]any) (SqlParams, error) {
 	p := SqlParams{
-		Database: "mysql", // 默认 MySQL
+		Database: DefaultDataSource,
+		MaxRows:  defaultMaxRows,
 	}
 
 	if v, ok := params["query"].(string); ok {
 		p.Query = v
 	}
-	if v, ok := params["database"].(string); ok {
+	p.Args = params["args"]
+	if v, ok := params["database"].(string); ok && v != "" {
 		p.Database = v
 	}
+	if v, ok := intParam(params["max_rows"]); ok && v > 0 {
+		p.MaxRows = v
+	}
+	if err := decodeParam(params["statements"], &p.Statements); err != nil {
+		return p, core.ValidationErrors{{Field: "statements", Message: err.Error()}}
+	}
+	if err := decodeParam(params["export"], &p.Export); err != nil {
+		return p, core.ValidationErrors{{Field: "export", Message: err.Error()}}
+	}
+	if err := decodeParam(params["assert_rows"], &p.AssertRows); err != nil {
+		return p, core.ValidationErrors{{Field: "assert_rows", Message: err.Error()}}
+	}
+
+	return p, nil
+}
+
+// intParam 数字参数可能来自 JSON（float64）或 YAML（int）
+func intParam(v any) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	}
+	return 0, false
+}
 
-	return p
+// decodeParam 通过 JSON 将对象/数组参数转换为结构体
+func decodeParam(value any, dst any) error {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
 }
@@ -0,0 +1,213 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/iceymoss/go-task/internal/core"
+	"github.com/iceymoss/go-task/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockTask 创建使用 sqlmock 数据源 report 的 SQL 任务
+func newMockTask(t *testing.T, driver string, opts ...Option) (*SqlTask, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	opts = append(opts, WithDataSources(map[string]DataSource{"report": {Driver: driver}}))
+	sources := newDataSources(opts...)
+	sources.open = func(string, string) (*sql.DB, error) { return conn, nil }
+	return newSqlTask(sources), mock
+}
+
+func runTask(task *SqlTask, params map[string]any) (map[string]any, error) {
+	ctx, output := core.WithOutput(context.Background())
+	if err := task.ValidateParams(params); err != nil {
+		return nil, err
+	}
+	err := task.Run(&core.TaskContext{Context: ctx, ExecutionID: "exec-1"}, params)
+	return output.Data(), err
+}
+
+func TestBindQuery(t *testing.T) {
+	// 按位置绑定，PostgreSQL 转换为 $n
+	query, args, err := bindQuery(DriverPostgres, "SELECT * FROM t WHERE a = ? AND b = '?' AND c = ?", []any{1, "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM t WHERE a = $1 AND b = '?' AND c = $2", query)
+	assert.Equal(t, []any{1, "x"}, args)
+
+	// 按名称绑定，同名参数可重复出现，@@ 系统变量与字符串中的 @ 不处理
+	query, args, err = bindQuery(DriverMySQL, "SELECT @@version, '@skip' WHERE id = @id OR parent = @id AND name = @name", map[string]any{"id": 7, "name": "n"})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT @@version, '@skip' WHERE id = ? OR parent = ? AND name = ?", query)
+	assert.Equal(t, []any{7, 7, "n"}, args)
+
+	// 参数数量不匹配
+	_, _, err = bindQuery(DriverMySQL, "SELECT ?", nil)
+	assert.Error(t, err)
+	_, _, err = bindQuery(DriverMySQL, "SELECT ?", []any{1, 2})
+	assert.Error(t, err)
+	_, _, err = bindQuery(DriverMySQL, "SELECT @missing", map[string]any{})
+	assert.Error(t, err)
+}
+
+func TestSqlQueryWithArgs(t *testing.T) {
+	task, mock := newMockTask(t, DriverPostgres)
+	mock.ExpectQuery("SELECT id, name FROM users WHERE status = $1").
+		WithArgs("active").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, []byte("a")).AddRow(2, []byte("b")).AddRow(3, []byte("c")))
+
+	out, err := runTask(task, map[string]any{
+		"database": "report",
+		"query":    "SELECT id, name FROM users WHERE status = @status",
+		"args":     map[string]any{"status": "active"},
+		"max_rows": float64(2),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name"}, out["columns"])
+	assert.Equal(t, 2, out["row_count"])
+	assert.Equal(t, true, out["truncated"])
+	assert.Equal(t, "a", out["rows"].([]map[string]any)[0]["name"])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSqlTransaction(t *testing.T) {
+	// 所有语句成功后提交，最后一个查询的结果集作为输出
+	task, mock := newMockTask(t, DriverMySQL)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE accounts SET balance = balance - ? WHERE id = ?").WithArgs(float64(10), float64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE accounts SET balance = balance + ? WHERE id = ?").WithArgs(float64(10), float64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT SUM(balance) AS total FROM accounts").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(100))
+	mock.ExpectCommit()
+
+	out, err := runTask(task, map[string]any{
+		"database": "report",
+		"statements": []any{
+			map[string]any{"query": "UPDATE accounts SET balance = balance - ? WHERE id = ?", "args": []any{float64(10), float64(1)}},
+			map[string]any{"query": "UPDATE accounts SET balance = balance + ? WHERE id = ?", "args": []any{float64(10), float64(2)}},
+			map[string]any{"query": "SELECT SUM(balance) AS total FROM accounts"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), out["rows_affected"])
+	assert.Len(t, out["results"], 3)
+	assert.Equal(t, 1, out["row_count"])
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// 任一语句失败时回滚
+	task, mock = newMockTask(t, DriverMySQL)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM a").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM b").WillReturnError(errors.New("lock wait timeout"))
+	mock.ExpectRollback()
+
+	_, err = runTask(task, map[string]any{
+		"database": "report",
+		"statements": []any{
+			map[string]any{"query": "DELETE FROM a"},
+			map[string]any{"query": "DELETE FROM b"},
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "lock wait timeout")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSqlAssertRows(t *testing.T) {
+	// 断言读取完整结果集计数，不受 max_rows 限制
+	task, mock := newMockTask(t, DriverMySQL)
+	mock.ExpectQuery("SELECT id FROM orders WHERE amount < 0").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+
+	out, err := runTask(task, map[string]any{
+		"database":    "report",
+		"query":       "SELECT id FROM orders WHERE amount < 0",
+		"max_rows":    float64(1),
+		"assert_rows": map[string]any{"op": "eq", "value": float64(0)},
+	})
+	require.Error(t, err)
+	assert.True(t, core.IsPermanent(err))
+	assert.Contains(t, err.Error(), "got 3 rows, want eq 0")
+	assert.Equal(t, 1, out["row_count"])
+
+	task, mock = newMockTask(t, DriverMySQL)
+	mock.ExpectQuery("SELECT id FROM orders WHERE amount < 0").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = runTask(task, map[string]any{
+		"database":    "report",
+		"query":       "SELECT id FROM orders WHERE amount < 0",
+		"assert_rows": map[string]any{"op": "eq", "value": float64(0)},
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSqlExport(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewLocalStorage(dir, "/api/exports")
+
+	for _, format := range []string{ExportCSV, ExportJSON} {
+		task, mock := newMockTask(t, DriverMySQL, WithExportStorage(store))
+		mock.ExpectQuery("SELECT id, name FROM users").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a,b").AddRow(2, nil))
+
+		out, err := runTask(task, map[string]any{
+			"database": "report",
+			"query":    "SELECT id, name FROM users",
+			"export":   map[string]any{"format": format, "filename": "users." + format},
+		})
+		require.NoError(t, err)
+
+		export := out["export"].(map[string]any)
+		assert.Equal(t, 2, export["rows"])
+		assert.Equal(t, false, export["truncated"])
+		url := export["url"].(string)
+		require.True(t, strings.HasPrefix(url, "/api/exports/exec-1/sql/"), url)
+
+		data, err := os.ReadFile(filepath.Join(dir, strings.TrimPrefix(url, "/api/exports/")))
+		require.NoError(t, err)
+		if format == ExportCSV {
+			assert.Equal(t, "id,name\n1,\"a,b\"\n2,\n", string(data))
+		} else {
+			assert.JSONEq(t, `[{"id":1,"name":"a,b"},{"id":2,"name":null}]`, string(data))
+		}
+	}
+}
+
+func TestSqlValidateParams(t *testing.T) {
+	task, _ := newMockTask(t, DriverMySQL)
+
+	cases := []struct {
+		name   string
+		params map[string]any
+		field  string
+	}{
+		{"缺少语句", map[string]any{}, "query"},
+		{"query 与 statements 互斥", map[string]any{"query": "SELECT 1", "statements": []any{map[string]any{"query": "SELECT 2"}}}, "statements"},
+		{"未知数据源", map[string]any{"query": "SELECT 1", "database": "missing"}, "database"},
+		{"参数不足", map[string]any{"query": "SELECT ?", "database": "report"}, "args"},
+		{"不支持的导出格式", map[string]any{"query": "SELECT 1", "export": map[string]any{"format": "xml"}}, "export.format"},
+		{"未配置导出存储", map[string]any{"query": "SELECT 1", "export": map[string]any{"format": "csv"}}, "export"},
+		{"不支持的断言", map[string]any{"query": "SELECT 1", "assert_rows": map[string]any{"op": "between"}}, "assert_rows.op"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := task.ValidateParams(tc.params)
+			var verrs core.ValidationErrors
+			require.ErrorAs(t, err, &verrs)
+			assert.Equal(t, tc.field, verrs[0].Field)
+		})
+	}
+
+	// 未链接 SQLite 驱动时执行报错
+	if _, err := sqlDriverName(DriverSQLite); err != nil {
+		assert.Contains(t, err.Error(), "sqlite driver")
+	}
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 权限字符串，格式为 资源:操作，角色中可以使用 * 或 资源:* 通配
+const (
+	PermAll = "*"
+
+	PermJobView   = "job:view"
+	PermJobCreate = "job:create"
+	PermJobUpdate = "job:update"
+	PermJobDelete = "job:delete"
+	PermJobRun    = "job:run"
+
+	PermExecutionView   = "execution:view"
+	PermExecutionCancel = "execution:cancel"
+
+	PermGroupManage = "group:manage"
+
+	PermAlertManage = "alert:manage"
+
+	PermAuditView = "audit:view"
+)
+
+// 内置角色
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleViewer   = "viewer"
+)
+
+// BuiltinRole 启动时写入的内置角色
+type BuiltinRole struct {
+	Name        string
+	DisplayName string
+	Description string
+	Permissions []string
+}
+
+// BuiltinRoles 内置的 admin/operator/viewer 角色
+var BuiltinRoles = []BuiltinRole{
+	{
+		Name:        RoleAdmin,
+		DisplayName: "管理员",
+		Description: "拥有所有权限",
+		Permissions: []string{PermAll},
+	},
+	{
+		Name:        RoleOperator,
+		DisplayName: "运维",
+		Description: "查看任务与执行记录，运行、终止任务并取消执行",
+		Permissions: []string{PermJobView, PermJobRun, PermExecutionView, PermExecutionCancel},
+	},
+	{
+		Name:        RoleViewer,
+		DisplayName: "只读",
+		Description: "查看任务与执行记录",
+		Permissions: []string{PermJobView, PermExecutionView},
+	},
+}
+
+// Grant 用户通过一个角色获得的权限，GroupID 为空表示全局生效，否则只对该分组及其子分组中的任务生效
+type Grant struct {
+	Role        string
+	Permissions []string
+	GroupID     *uint
+}
+
+// PermissionStore 查询用户的授权
+type PermissionStore interface {
+	Grants(userID uint) ([]Grant, error)
+}
+
+// ScopeFunc 解析请求所操作资源所属的分组，返回分组及其所有上级分组的ID，不属于任何分组时返回空
+type ScopeFunc func(c *gin.Context) ([]uint, error)
+
+const grantsKey = "grants"
+
+// PermissionMiddleware 加载当前用户的授权，需要在 AuthMiddleware 之后使用
+func PermissionMiddleware(store PermissionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			c.Abort()
+			return
+		}
+		grants, err := store.Grants(userID.(uint))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		c.Set(grantsKey, grants)
+		c.Next()
+	}
+}
+
+// RequirePermission 要求当前用户拥有权限 perm；传入 scope 时按资源所属分组校验，否则拥有任意分组的该权限即可通过，由处理器进一步过滤
+func RequirePermission(perm string, scope ...ScopeFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		grants := grantsFromContext(c)
+		allowed := false
+		if len(scope) == 0 {
+			allowed = slices.ContainsFunc(grants, func(g Grant) bool { return matchPermission(g.Permissions, perm) })
+		} else {
+			groups, err := scope[0](c)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				c.Abort()
+				return
+			}
+			allowed = allowedIn(grants, perm, groups)
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "permission denied: " + perm})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// HasPermission 当前用户是否可以对 groups（分组及其上级分组）中的资源执行 perm，groups 为空表示不属于任何分组的资源
+func HasPermission(c *gin.Context, perm string, groups []uint) bool {
+	return allowedIn(grantsFromContext(c), perm, groups)
+}
+
+// PermittedGroups 当前用户拥有 perm 的范围：all 为 true 时不受分组限制，否则只能访问 groups 及其子分组中的资源
+func PermittedGroups(c *gin.Context, perm string) (all bool, groups []uint) {
+	for _, g := range grantsFromContext(c) {
+		if !matchPermission(g.Permissions, perm) {
+			continue
+		}
+		if g.GroupID == nil {
+			return true, nil
+		}
+		groups = append(groups, *g.GroupID)
+	}
+	return false, groups
+}
+
+func grantsFromContext(c *gin.Context) []Grant {
+	if v, ok := c.Get(grantsKey); ok {
+		grants, _ := v.([]Grant)
+		return grants
+	}
+	return nil
+}
+
+// allowedIn 全局授权，或在资源所属分组链上任一分组的授权
+func allowedIn(grants []Grant, perm string, groups []uint) bool {
+	for _, g := range grants {
+		if !matchPermission(g.Permissions, perm) {
+			continue
+		}
+		if g.GroupID == nil || slices.Contains(groups, *g.GroupID) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPermission 判断权限列表是否包含 perm，支持 * 与 资源:* 通配
+func matchPermission(perms []string, perm string) bool {
+	resource, _, _ := strings.Cut(perm, ":")
+	for _, p := range perms {
+		if p == perm || p == PermAll || p == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore map[uint][]Grant
+
+func (f fakeStore) Grants(userID uint) ([]Grant, error) {
+	if userID == 0 {
+		return nil, errors.New("db down")
+	}
+	return f[userID], nil
+}
+
+func uintPtr(v uint) *uint { return &v }
+
+// newRBACRouter 模拟 AuthMiddleware 写入 user_id，路由 /jobs/:group 所属分组链为 [1, group]
+func newRBACRouter(store PermissionStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(len(c.GetHeader("X-User"))))
+		c.Next()
+	}, PermissionMiddleware(store))
+
+	scope := func(c *gin.Context) ([]uint, error) {
+		switch c.Param("group") {
+		case "none":
+			return nil, nil
+		case "3":
+			return []uint{1, 3}, nil
+		}
+		return []uint{1, 2}, nil
+	}
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/jobs", RequirePermission(PermJobView), ok)
+	r.POST("/jobs", RequirePermission(PermJobCreate), ok)
+	r.POST("/jobs/:group/run", RequirePermission(PermJobRun, scope), ok)
+	return r
+}
+
+func TestRequirePermission(t *testing.T) {
+	store := fakeStore{
+		1: {{Role: RoleAdmin, Permissions: []string{PermAll}}},                                         // 用户 "a"
+		2: {{Role: RoleOperator, Permissions: []string{PermJobView, PermJobRun}, GroupID: uintPtr(3)}}, // 用户 "ab"
+		3: {{Role: RoleViewer, Permissions: []string{"job:*"}, GroupID: uintPtr(1)}},                   // 用户 "abc"，上级分组授权覆盖子分组
+		4: {{Role: RoleViewer, Permissions: []string{PermJobView}}},                                    // 用户 "abcd"
+	}
+	r := newRBACRouter(store)
+
+	cases := []struct {
+		name   string
+		user   string
+		method string
+		path   string
+		code   int
+	}{
+		{"管理员可以执行所有操作", "a", http.MethodPost, "/jobs", http.StatusOK},
+		{"管理员可以运行未分组任务", "a", http.MethodPost, "/jobs/none/run", http.StatusOK},
+		{"运维可以运行授权分组中的任务", "ab", http.MethodPost, "/jobs/3/run", http.StatusOK},
+		{"运维不能运行其他分组的任务", "ab", http.MethodPost, "/jobs/2/run", http.StatusForbidden},
+		{"分组授权不能运行未分组任务", "ab", http.MethodPost, "/jobs/none/run", http.StatusForbidden},
+		{"分组授权可以访问列表", "ab", http.MethodGet, "/jobs", http.StatusOK},
+		{"运维不能创建任务", "ab", http.MethodPost, "/jobs", http.StatusForbidden},
+		{"通配权限覆盖子分组", "abc", http.MethodPost, "/jobs/2/run", http.StatusOK},
+		{"只读用户不能运行任务", "abcd", http.MethodPost, "/jobs/3/run", http.StatusForbidden},
+		{"只读用户可以查看", "abcd", http.MethodGet, "/jobs", http.StatusOK},
+		{"没有角色的用户", "abcde", http.MethodGet, "/jobs", http.StatusForbidden},
+		{"查询授权失败", "", http.MethodGet, "/jobs", http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("X-User", tc.user)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.code, w.Code)
+		})
+	}
+}
+
+func TestPermittedGroups(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	c.Set(grantsKey, []Grant{
+		{Permissions: []string{PermJobView}, GroupID: uintPtr(3)},
+		{Permissions: []string{PermJobRun}, GroupID: uintPtr(4)},
+		{Permissions: []string{PermJobView}, GroupID: uintPtr(5)},
+	})
+	all, groups := PermittedGroups(c, PermJobView)
+	assert.False(t, all)
+	assert.Equal(t, []uint{3, 5}, groups)
+	assert.True(t, HasPermission(c, PermJobRun, []uint{1, 4}))
+	assert.False(t, HasPermission(c, PermJobRun, []uint{1, 3}))
+
+	c.Set(grantsKey, []Grant{{Permissions: []string{"job:*"}}})
+	all, _ = PermittedGroups(c, PermJobView)
+	assert.True(t, all)
+	assert.False(t, HasPermission(c, PermExecutionView, nil))
+}
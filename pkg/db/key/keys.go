@@ -33,6 +33,11 @@ func KeyLeaderVersion() string {
 	return PrefixScheduler + "leader:version"
 }
 
+// KeyLeaderEpochs 各任期 Leader 的接管时间（hash: 任期 -> 毫秒时间戳）
+func KeyLeaderEpochs() string {
+	return PrefixScheduler + "leader:epochs"
+}
+
 // Worker注册
 func KeyWorkerInfo(workerID string) string {
 	return PrefixWorker + workerID + ":info"
@@ -51,6 +56,19 @@ func KeyClusterInfo() string {
 	return PrefixScheduler + "cluster:info"
 }
 
+// 调度器持久化状态（用于重启、换主后恢复）
+func KeySchedulerJobState() string {
+	return PrefixScheduler + "state:jobs"
+}
+
+func KeySchedulerDependencyState() string {
+	return PrefixScheduler + "state:dependencies"
+}
+
+func KeySchedulerPendingItems() string {
+	return PrefixScheduler + "state:pending"
+}
+
 // ============================================
 // 2. 任务调度 Keys
 // ============================================
@@ -61,6 +79,26 @@ func KeyTaskQueue(priority string) string {
 	return PrefixTask + "queue:" + priority
 }
 
+// 已被 Worker 取出、尚未确认完成的任务（score 为可见性超时的截止时间）
+func KeyTaskQueueProcessing() string {
+	return PrefixTask + "queue:processing"
+}
+
+// 挂起在队列中的任务（score 为恢复时间），到期或被唤醒后放回原来的桶
+func KeyTaskQueueDelayed() string {
+	return PrefixTask + "queue:delayed"
+}
+
+// 等待同一对象（分组名额、之前的执行结束）的挂起任务（Hash，field 为执行ID），唤醒时据此恢复
+func KeyTaskQueueWaiting(key string) string {
+	return PrefixTask + "queue:waiting:" + key
+}
+
+// 取消执行的广播频道（Pub/Sub），持有该执行的节点收到后取消
+func KeyTaskCancelChannel() string {
+	return PrefixTask + "cancel"
+}
+
 // 任务执行锁
 func KeyTaskLock(jobID uint, executionID string) string {
 	return PrefixTask + fmt.Sprintf("lock:%d:%s", jobID, executionID)
@@ -89,9 +127,9 @@ func KeyTaskRateLimit(jobID uint) string {
 	return PrefixTask + fmt.Sprintf("ratelimit:%d", jobID)
 }
 
-// 并发控制
-func KeyTaskConcurrent(jobID uint) string {
-	return PrefixTask + fmt.Sprintf("concurrent:%d", jobID)
+// 并发控制：任务活跃（排队中与执行中）的执行，按任务名区分，系统任务与 YAML 任务没有数据库ID
+func KeyTaskConcurrent(jobName string) string {
+	return PrefixTask + "concurrent:" + jobName
 }
 
 func KeyTaskConcurrentMax(jobID uint) string {
@@ -116,14 +154,19 @@ func KeyAlertQueueGlobal() string {
 	return PrefixAlert + "queue:global"
 }
 
-// 告警聚合
-func KeyAlertAggregate(jobID uint, window string) string {
-	return PrefixAlert + fmt.Sprintf("aggregate:%d:%s", jobID, window)
+// 告警聚合：规则在某个任务上的滑动窗口，series 区分命中次数(hit)与执行次数(total)
+func KeyAlertAggregate(ruleID uint, jobName string, series string) string {
+	return PrefixAlert + fmt.Sprintf("aggregate:%d:%s:%s", ruleID, jobName, series)
 }
 
 // 告警限流
-func KeyAlertRateLimit(jobID uint) string {
-	return PrefixAlert + fmt.Sprintf("ratelimit:%d", jobID)
+func KeyAlertRateLimit(ruleID uint, jobName string) string {
+	return PrefixAlert + fmt.Sprintf("ratelimit:%d:%s", ruleID, jobName)
+}
+
+// 正在告警（firing）的告警ID，恢复时清除
+func KeyAlertFiring(ruleID uint, jobName string) string {
+	return PrefixAlert + fmt.Sprintf("firing:%d:%s", ruleID, jobName)
 }
 
 // ============================================
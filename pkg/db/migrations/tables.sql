@@ -29,14 +29,23 @@ CREATE TABLE IF NOT EXISTS `user_roles` (
   `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
   `user_id` BIGINT UNSIGNED NOT NULL,
   `role_id` BIGINT UNSIGNED NOT NULL,
+  `group_id` BIGINT UNSIGNED COMMENT '授权范围的任务分组(为空表示全局)',
   `created_at` DATETIME(3) DEFAULT NULL,
   
   PRIMARY KEY (`id`),
-  UNIQUE KEY `uk_user_role` (`user_id`, `role_id`),
+  UNIQUE KEY `uk_user_role` (`user_id`, `role_id`, `group_id`),
   KEY `idx_user_id` (`user_id`),
-  KEY `idx_role_id` (`role_id`)
+  KEY `idx_role_id` (`role_id`),
+  KEY `idx_group_id` (`group_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户角色关联表';
 
+-- 1.3 更新 user_roles 表（按分组授权）
+ALTER TABLE `user_roles`
+  ADD COLUMN IF NOT EXISTS `group_id` BIGINT UNSIGNED COMMENT '授权范围的任务分组(为空表示全局)' AFTER `role_id`,
+  DROP INDEX IF EXISTS `uk_user_role`,
+  ADD UNIQUE INDEX `uk_user_role` (`user_id`, `role_id`, `group_id`),
+  ADD INDEX IF NOT EXISTS `idx_group_id` (`group_id`);
+
 -- ============================================
 -- 2. 任务管理模块
 -- ============================================
@@ -53,6 +62,8 @@ CREATE TABLE IF NOT EXISTS `sys_job_groups` (
   `sort` INT DEFAULT 0 COMMENT '排序',
   `icon` VARCHAR(50) COMMENT '图标',
   `color` VARCHAR(20) COMMENT '颜色',
+  `max_concurrency` INT DEFAULT 0 COMMENT '组内(含子分组)同时执行的任务数上限，0 表示不限制',
+  `retry_policy` TEXT COMMENT '组内任务默认重试策略(JSON)',
   `created_at` DATETIME(3) DEFAULT NULL,
   `updated_at` DATETIME(3) DEFAULT NULL,
   `deleted_at` DATETIME(3) DEFAULT NULL,
@@ -64,6 +75,11 @@ CREATE TABLE IF NOT EXISTS `sys_job_groups` (
   KEY `idx_deleted_at` (`deleted_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='任务分组表';
 
+-- 2.1.1 更新 sys_job_groups 表（组内任务继承的默认配置）
+ALTER TABLE `sys_job_groups`
+  ADD COLUMN IF NOT EXISTS `max_concurrency` INT DEFAULT 0 COMMENT '组内(含子分组)同时执行的任务数上限，0 表示不限制' AFTER `color`,
+  ADD COLUMN IF NOT EXISTS `retry_policy` TEXT COMMENT '组内任务默认重试策略(JSON)' AFTER `max_concurrency`;
+
 -- 2.2 任务版本表
 CREATE TABLE IF NOT EXISTS `sys_job_versions` (
   `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
@@ -118,18 +134,23 @@ CREATE TABLE IF NOT EXISTS `sys_task_templates` (
 -- 2.4 更新 sys_jobs 表（添加新字段）
 ALTER TABLE `sys_jobs` 
   ADD COLUMN IF NOT EXISTS `category` VARCHAR(50) DEFAULT 'default' COMMENT '任务分类: ops, data, ai, notification, workflow' AFTER `type`,
+  ADD COLUMN IF NOT EXISTS `group_id` BIGINT UNSIGNED COMMENT '所属分组' AFTER `category`,
   ADD COLUMN IF NOT EXISTS `trigger_type` VARCHAR(20) DEFAULT 'cron' COMMENT '触发类型: cron, fixed_delay, fixed_rate, once, manual, webhook' AFTER `cron_expr`,
   ADD COLUMN IF NOT EXISTS `fixed_delay` INT COMMENT '固定延迟(秒)' AFTER `trigger_type`,
   ADD COLUMN IF NOT EXISTS `fixed_rate` INT COMMENT '固定频率(秒)' AFTER `fixed_delay`,
   ADD COLUMN IF NOT EXISTS `dependency_strategy` VARCHAR(20) DEFAULT 'strict' COMMENT '依赖策略: strict, ignore, wait' AFTER `dependencies`,
   ADD COLUMN IF NOT EXISTS `retry_backoff` INT DEFAULT 5 COMMENT '重试间隔(秒)' AFTER `max_retries`,
-  ADD COLUMN IF NOT EXISTS `retry_strategy` VARCHAR(20) DEFAULT 'exponential' COMMENT '重试策略: fixed, exponential, random' AFTER `retry_backoff`,
-  ADD COLUMN IF NOT EXISTS `concurrent_policy` VARCHAR(20) DEFAULT 'allow' COMMENT '并发策略: allow, skip, delay, deny' AFTER `retry_strategy`,
+  ADD COLUMN IF NOT EXISTS `retry_strategy` VARCHAR(20) DEFAULT 'exponential' COMMENT '重试策略: exponential, linear, fixed' AFTER `retry_backoff`,
+  ADD COLUMN IF NOT EXISTS `retry_on` TEXT COMMENT '只重试指定类别的错误(JSON 数组): timeout, network' AFTER `retry_strategy`,
+  ADD COLUMN IF NOT EXISTS `concurrent_policy` VARCHAR(20) DEFAULT 'allow' COMMENT '并发策略: allow, forbid, replace, queue' AFTER `retry_on`,
+  ADD COLUMN IF NOT EXISTS `max_pending` INT DEFAULT 1 COMMENT 'queue 策略下最多排队等待的执行数' AFTER `concurrent_policy`,
+  ADD COLUMN IF NOT EXISTS `misfire_policy` VARCHAR(20) DEFAULT 'skip' COMMENT '错过触发的补偿策略: skip, run_once, run_all' AFTER `max_pending`,
   ADD COLUMN IF NOT EXISTS `param_template_id` BIGINT UNSIGNED COMMENT '参数模板ID' AFTER `template_id`,
   ADD COLUMN IF NOT EXISTS `version` VARCHAR(20) DEFAULT '1.0.0' COMMENT '版本号' AFTER `description`,
   ADD COLUMN IF NOT EXISTS `created_by` BIGINT UNSIGNED COMMENT '创建人' AFTER `version`,
   ADD COLUMN IF NOT EXISTS `updated_by` BIGINT UNSIGNED COMMENT '更新人' AFTER `created_by`,
-  ADD INDEX IF NOT EXISTS `idx_trigger_type` (`trigger_type`);
+  ADD INDEX IF NOT EXISTS `idx_trigger_type` (`trigger_type`),
+  ADD INDEX IF NOT EXISTS `idx_group` (`group_id`);
 
 -- ============================================
 -- 3. 执行记录模块
@@ -146,6 +167,7 @@ ALTER TABLE `sys_job_executions`
 -- 3.2 执行日志表（更新）
 ALTER TABLE `sys_job_logs`
   ADD COLUMN IF NOT EXISTS `job_id` BIGINT UNSIGNED COMMENT '任务ID' AFTER `execution_id`,
+  ADD COLUMN IF NOT EXISTS `seq` BIGINT NOT NULL DEFAULT 0 COMMENT '执行内的日志序号，执行事件记录为 0' AFTER `job_name`,
   ADD INDEX IF NOT EXISTS `idx_job_id` (`job_id`);
 
 -- ============================================
@@ -211,6 +233,7 @@ CREATE TABLE IF NOT EXISTS `sys_alert_history` (
   `rule_id` BIGINT UNSIGNED COMMENT '规则ID',
   `job_id` BIGINT UNSIGNED COMMENT '任务ID',
   `execution_id` VARCHAR(64) COMMENT '执行ID',
+  `job_name` VARCHAR(100) COMMENT '任务名称',
   
   `alert_type` VARCHAR(20) NOT NULL COMMENT '告警类型',
   `alert_level` VARCHAR(20) DEFAULT 'warning' COMMENT '告警级别',
@@ -221,9 +244,11 @@ CREATE TABLE IF NOT EXISTS `sys_alert_history` (
   `status` VARCHAR(20) DEFAULT 'pending' COMMENT '状态: pending, sending, sent, failed, cancelled',
   `channels` JSON COMMENT '发送的渠道',
   `failed_channels` JSON COMMENT '发送失败的渠道',
+  `state` VARCHAR(20) DEFAULT 'firing' COMMENT '告警状态: firing, resolved',
   
   `triggered_at` DATETIME(3) NOT NULL COMMENT '触发时间',
   `sent_at` DATETIME(3) COMMENT '发送时间',
+  `resolved_at` DATETIME(3) COMMENT '恢复时间',
   `created_at` DATETIME(3) DEFAULT NULL,
   
   PRIMARY KEY (`id`),
@@ -232,6 +257,7 @@ CREATE TABLE IF NOT EXISTS `sys_alert_history` (
   KEY `idx_job` (`job_id`),
   KEY `idx_execution` (`execution_id`),
   KEY `idx_status` (`status`),
+  KEY `idx_state` (`state`),
   KEY `idx_triggered_at` (`triggered_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='告警历史表';
 
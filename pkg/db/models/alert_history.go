@@ -11,6 +11,7 @@ type AlertHistory struct {
 	RuleID      *uint  `gorm:"index:idx_rule" json:"rule_id,omitempty"`                   // 规则ID
 	JobID       *uint  `gorm:"index:idx_job" json:"job_id,omitempty"`                     // 任务ID
 	ExecutionID string `gorm:"index:idx_execution;size:64" json:"execution_id,omitempty"` // 执行ID
+	JobName     string `gorm:"size:100" json:"job_name,omitempty"`                        // 任务名称
 
 	// 告警信息
 	AlertType  string `gorm:"size:20;not null" json:"alert_type"`           // 告警类型
@@ -23,10 +24,12 @@ type AlertHistory struct {
 	Status         string `gorm:"index:idx_status;size:20;default:'pending'" json:"status"` // pending, sending, sent, failed, cancelled
 	Channels       string `gorm:"type:json" json:"channels,omitempty"`                      // 发送的渠道
 	FailedChannels string `gorm:"type:json" json:"failed_channels,omitempty"`               // 发送失败的渠道
+	State          string `gorm:"index:idx_state;size:20;default:'firing'" json:"state"`    // 告警状态: firing, resolved
 
 	// 时间信息
 	TriggeredAt time.Time  `gorm:"index:idx_triggered;not null" json:"triggered_at"` // 触发时间
 	SentAt      *time.Time `json:"sent_at,omitempty"`                                // 发送时间
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`                            // 恢复时间
 	CreatedAt   time.Time  `json:"created_at"`
 }
 
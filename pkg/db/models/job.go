@@ -13,6 +13,7 @@ type Job struct {
 	CronExpr    string `gorm:"not null;size:100"`             // Cron 表达式
 	Enable      bool   `gorm:"default:true"`                  // 是否启用
 	Source      string `gorm:"default:'web';size:20"`         // 来源: system, yaml, web
+	GroupID     *uint  `gorm:"index:idx_group"`               // 所属分组
 
 	// 任务参数（JSON 格式存储）
 	Params string `gorm:"type:text"` // JSON 字符串存储参数
@@ -25,6 +26,18 @@ type Job struct {
 	Timeout    int `gorm:"default:3600"` // 超时时间(秒)
 	MaxRetries int `gorm:"default:3"`    // 最大重试次数
 
+	// 重试策略
+	RetryBackoff  int    `gorm:"default:5"`                     // 首次重试前的等待时间(秒)
+	RetryStrategy string `gorm:"default:'exponential';size:20"` // 退避方式: exponential, linear, fixed
+	RetryOn       string `gorm:"type:text"`                     // 只重试指定类别的错误（JSON 数组）: timeout, network
+
+	// 并发控制
+	ConcurrentPolicy string `gorm:"default:'allow';size:20"` // 并发策略: allow, forbid, replace, queue
+	MaxPending       int    `gorm:"default:1"`               // queue 策略下最多排队等待的执行数
+
+	// 错过触发的补偿
+	MisfirePolicy string `gorm:"default:'skip';size:20"` // 补偿策略: skip, run_once, run_all
+
 	// 模板相关
 	IsTemplate bool  `gorm:"default:false"` // 是否为模板
 	TemplateID *uint // 模板ID
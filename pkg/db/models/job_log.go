@@ -10,6 +10,7 @@ type JobLog struct {
 	ExecutionID string `gorm:"index:idx_execution;size:64;not null" json:"execution_id"` // 执行ID
 	JobID       uint   `gorm:"index:idx_job;not null" json:"job_id"`                     // 任务ID
 	JobName     string `gorm:"size:100;not null" json:"job_name"`                        // 任务名称
+	Seq         int64  `gorm:"not null;default:0" json:"seq"`                            // 执行内的日志序号，执行事件记录为 0
 
 	// 日志信息
 	LogLevel string  `gorm:"index:idx_level;size:20;not null" json:"log_level"` // debug, info, warning, error
@@ -9,6 +9,7 @@ type UserRole struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	UserID    uint      `gorm:"index:idx_user_id;not null" json:"user_id"`
 	RoleID    uint      `gorm:"index:idx_role_id;not null" json:"role_id"`
+	GroupID   *uint     `gorm:"index:idx_group_id" json:"group_id,omitempty"` // 授权范围的任务分组，为空表示全局
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -30,6 +30,15 @@ func init() {
 	}
 }
 
+// MysqlDSN 根据配置拼接指定库的连接串
+func MysqlDSN(db string) string {
+	userName := conf.ServiceConf.DB.User
+	userPwd := conf.ServiceConf.DB.Password
+	host := conf.ServiceConf.DB.Host
+	port := strconv.Itoa(conf.ServiceConf.DB.Port)
+	return userName + ":" + userPwd + "@tcp(" + host + ":" + port + ")/" + db + "?charset=utf8mb4&parseTime=True&loc=Local"
+}
+
 var mysqlConn = make(map[string]*gorm.DB)
 var mysqlMutex sync.RWMutex
 
@@ -39,10 +48,6 @@ func GetMysqlConn(db string) *gorm.DB {
 	mysqlMutex.RUnlock()
 	if !ok {
 		mysqlMutex.Lock()
-		userName := conf.ServiceConf.DB.User
-		userPwd := conf.ServiceConf.DB.Password
-		host := conf.ServiceConf.DB.Host
-		port := strconv.Itoa(conf.ServiceConf.DB.Port)
 		envLogLevel := conf.ServiceConf.DB.LogLevel
 
 		var gormlevel gormLogger.LogLevel
@@ -64,8 +69,14 @@ func GetMysqlConn(db string) *gorm.DB {
 		default:
 			gormlevel = gormLogger.Info
 		}
-		dsn := userName + ":" + userPwd + "@tcp(" + host + ":" + port + ")/" + db + "?charset=utf8mb4&parseTime=True&loc=Local"
-		fmt.Printf("dsn:%s\n", dsn, gormlevel)
+		dsn := MysqlDSN(db)
+		logger.Info("connecting mysql",
+			zap.String("host", conf.ServiceConf.DB.Host),
+			zap.Int("port", conf.ServiceConf.DB.Port),
+			zap.String("db", db),
+			zap.String("log_level", envLogLevel),
+			zap.Int("gorm_level", int(gormlevel)),
+		)
 		dbConn, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
 			//Logger: &CustomMySqlLogger{
 			//	Logger: logger,
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// dingTalkTemplate 钉钉 markdown 消息的默认模板
+const dingTalkTemplate = "### {{.Title}}\n\n{{.Text}}"
+
+// DingTalkConfig 钉钉群机器人配置
+type DingTalkConfig struct {
+	URL       string   `json:"url"`        // https://oapi.dingtalk.com/robot/send?access_token=xxx
+	Secret    string   `json:"secret"`     // 加签密钥，SEC 开头
+	AtMobiles []string `json:"at_mobiles"` // 需要 @ 的手机号
+	AtAll     bool     `json:"at_all"`
+	Template  string   `json:"template"`
+}
+
+// DingTalk 钉钉群机器人
+type DingTalk struct {
+	cfg DingTalkConfig
+}
+
+// NewDingTalk 创建钉钉发送器
+func NewDingTalk(cfg DingTalkConfig) (*DingTalk, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("dingtalk url is required")
+	}
+	return &DingTalk{cfg: cfg}, nil
+}
+
+func (d *DingTalk) Type() string { return ChannelDingTalk }
+
+func (d *DingTalk) Notify(ctx context.Context, msg *Message) error {
+	text, err := render(d.cfg.Template, dingTalkTemplate, msg)
+	if err != nil {
+		return err
+	}
+
+	target := d.cfg.URL
+	if d.cfg.Secret != "" {
+		u, err := url.Parse(target)
+		if err != nil {
+			return fmt.Errorf("invalid dingtalk url: %w", err)
+		}
+		ts := time.Now().UnixMilli()
+		q := u.Query()
+		q.Set("timestamp", formatInt(ts))
+		q.Set("sign", DingTalkSignature(d.cfg.Secret, ts))
+		u.RawQuery = q.Encode()
+		target = u.String()
+	}
+
+	payload := map[string]any{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"title": msg.Title, "text": text},
+		"at":       map[string]any{"atMobiles": d.cfg.AtMobiles, "isAtAll": d.cfg.AtAll},
+	}
+	body, err := postJSON(ctx, target, payload, nil)
+	if err != nil {
+		return err
+	}
+	return checkErrCode(body)
+}
+
+// checkErrCode 钉钉与企业微信的响应格式：{"errcode":0,"errmsg":"ok"}
+func checkErrCode(body []byte) error {
+	var resp struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("errcode %d: %s", resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
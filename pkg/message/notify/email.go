@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// emailTemplate 邮件正文的默认模板
+const emailTemplate = "{{.Text}}"
+
+// EmailConfig 邮件渠道配置
+type EmailConfig struct {
+	Host     string   `json:"host"`
+	Port     string   `json:"port"`
+	Username string   `json:"username"` // 为空时不做 SMTP 认证
+	Password string   `json:"password"`
+	From     string   `json:"from"` // 默认与 Username 相同
+	To       []string `json:"to"`
+	Template string   `json:"template"`
+}
+
+// Email 通过 SMTP 发送纯文本邮件
+type Email struct {
+	cfg EmailConfig
+}
+
+// NewEmail 创建邮件发送器
+func NewEmail(cfg EmailConfig) (*Email, error) {
+	if cfg.Host == "" || len(cfg.To) == 0 {
+		return nil, errors.New("email host and to are required")
+	}
+	if cfg.Port == "" {
+		cfg.Port = "25"
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	if cfg.From == "" {
+		return nil, errors.New("email from is required")
+	}
+	return &Email{cfg: cfg}, nil
+}
+
+func (e *Email) Type() string { return ChannelEmail }
+
+// Notify 发送邮件；net/smtp 不支持 ctx，仅在发送前检查是否已取消
+func (e *Email) Notify(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	text, err := render(e.cfg.Template, emailTemplate, msg)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Title))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)
+	}
+	addr := net.JoinHostPort(e.cfg.Host, e.cfg.Port)
+	return smtp.SendMail(addr, auth, e.cfg.From, e.cfg.To, []byte(b.String()))
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// feishuTemplate 飞书文本消息的默认模板
+const feishuTemplate = "{{.Title}}\n{{.Text}}"
+
+// FeishuConfig 飞书群机器人配置
+type FeishuConfig struct {
+	URL      string `json:"url"`    // https://open.feishu.cn/open-apis/bot/v2/hook/xxx
+	Secret   string `json:"secret"` // 签名校验密钥
+	Template string `json:"template"`
+}
+
+// Feishu 飞书群机器人
+type Feishu struct {
+	cfg FeishuConfig
+}
+
+// NewFeishu 创建飞书发送器
+func NewFeishu(cfg FeishuConfig) (*Feishu, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("feishu url is required")
+	}
+	return &Feishu{cfg: cfg}, nil
+}
+
+func (f *Feishu) Type() string { return ChannelFeishu }
+
+func (f *Feishu) Notify(ctx context.Context, msg *Message) error {
+	text, err := render(f.cfg.Template, feishuTemplate, msg)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{
+		"msg_type": "text",
+		"content":  map[string]string{"text": text},
+	}
+	if f.cfg.Secret != "" {
+		ts := time.Now().Unix()
+		payload["timestamp"] = formatInt(ts)
+		payload["sign"] = FeishuSignature(f.cfg.Secret, ts)
+	}
+
+	body, err := postJSON(ctx, f.cfg.URL, payload, nil)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if resp.Code != 0 {
+		return fmt.Errorf("code %d: %s", resp.Code, resp.Msg)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+// 渠道类型，对应 AlertChannel.ChannelType
+const (
+	ChannelEmail    = "email"
+	ChannelSMS      = "sms"
+	ChannelDingTalk = "dingtalk"
+	ChannelWeChat   = "wechat" // 企业微信群机器人
+	ChannelFeishu   = "feishu"
+	ChannelSlack    = "slack"
+	ChannelWebhook  = "webhook"
+	ChannelTelegram = "telegram"
+)
+
+// ErrUnsupportedChannel 不支持的渠道类型
+var ErrUnsupportedChannel = errors.New("unsupported notify channel")
+
+// defaultTimeout 单次发送的 HTTP 超时时间
+const defaultTimeout = 10 * time.Second
+
+// Message 待发送的通知
+type Message struct {
+	Title  string         `json:"title"`
+	Text   string         `json:"text"`             // 正文
+	Level  string         `json:"level,omitempty"`  // info, warning, error, critical
+	Fields map[string]any `json:"fields,omitempty"` // 附加字段，可在模板中通过 {{.Fields.xxx}} 引用
+}
+
+// Notifier 通知渠道的发送器
+type Notifier interface {
+	// Type 渠道类型
+	Type() string
+	// Notify 发送一条通知
+	Notify(ctx context.Context, msg *Message) error
+}
+
+// New 根据渠道类型与 JSON 配置创建发送器
+func New(channelType string, config []byte) (Notifier, error) {
+	if len(config) == 0 {
+		config = []byte("{}")
+	}
+	decode := func(v any) error {
+		if err := json.Unmarshal(config, v); err != nil {
+			return fmt.Errorf("invalid %s config: %w", channelType, err)
+		}
+		return nil
+	}
+
+	switch channelType {
+	case ChannelWebhook:
+		var cfg WebhookConfig
+		if err := decode(&cfg); err != nil {
+			return nil, err
+		}
+		return NewWebhook(cfg)
+	case ChannelDingTalk:
+		var cfg DingTalkConfig
+		if err := decode(&cfg); err != nil {
+			return nil, err
+		}
+		return NewDingTalk(cfg)
+	case ChannelFeishu:
+		var cfg FeishuConfig
+		if err := decode(&cfg); err != nil {
+			return nil, err
+		}
+		return NewFeishu(cfg)
+	case ChannelWeChat:
+		var cfg WeComConfig
+		if err := decode(&cfg); err != nil {
+			return nil, err
+		}
+		return NewWeCom(cfg)
+	case ChannelSlack:
+		var cfg SlackConfig
+		if err := decode(&cfg); err != nil {
+			return nil, err
+		}
+		return NewSlack(cfg)
+	case ChannelTelegram:
+		var cfg TelegramConfig
+		if err := decode(&cfg); err != nil {
+			return nil, err
+		}
+		return NewTelegram(cfg)
+	case ChannelEmail:
+		var cfg EmailConfig
+		if err := decode(&cfg); err != nil {
+			return nil, err
+		}
+		return NewEmail(cfg)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChannel, channelType)
+	}
+}
+
+// RetryPolicy 发送失败时的重试策略
+type RetryPolicy struct {
+	Attempts int           // 最多发送次数
+	Delay    time.Duration // 首次重试前的等待时间，之后每次翻倍
+}
+
+// DefaultRetryPolicy 默认最多发送 3 次，间隔 1s、2s
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, Delay: time.Second}
+
+// Deliver 发送通知，失败时按重试策略重试，ctx 结束时停止重试
+func Deliver(ctx context.Context, n Notifier, msg *Message, policy RetryPolicy) error {
+	attempts := max(policy.Attempts, 1)
+	delay := policy.Delay
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = n.Notify(ctx, msg); err == nil {
+			return nil
+		}
+		if i == attempts-1 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return fmt.Errorf("%s notify failed after %d attempts: %w", n.Type(), attempts, err)
+}
+
+// render 使用渠道配置的模板渲染正文，未配置时使用渠道的默认模板
+func render(custom, fallback string, msg *Message) (string, error) {
+	text := custom
+	if text == "" {
+		text = fallback
+	}
+	tmpl, err := template.New("notify").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, msg); err != nil {
+		return "", fmt.Errorf("render template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// httpClient 发送 HTTP 通知的客户端
+var httpClient = &http.Client{Timeout: defaultTimeout}
+
+// postJSON 以 JSON 格式发送请求，非 2xx 响应视为失败，返回响应体
+func postJSON(ctx context.Context, url string, payload any, headers map[string]string) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return postBody(ctx, url, body, headers)
+}
+
+// postBody 发送已序列化的 JSON 请求体
+func postBody(ctx context.Context, url string, body []byte, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return respBody, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+	return respBody, nil
+}
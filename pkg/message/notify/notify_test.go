@@ -0,0 +1,275 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMsg = &Message{
+	Title:  "backup failed",
+	Text:   "exit status 1",
+	Level:  "error",
+	Fields: map[string]any{"job_name": "backup"},
+}
+
+// captured 记录 httptest 服务收到的请求
+type captured struct {
+	path  string
+	query map[string]string
+	head  http.Header
+	body  []byte
+}
+
+// newServer 启动测试服务，按 reply 返回响应体
+func newServer(t *testing.T, reply string) (*httptest.Server, *captured) {
+	c := &captured{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.path = r.URL.Path
+		c.query = map[string]string{}
+		for k := range r.URL.Query() {
+			c.query[k] = r.URL.Query().Get(k)
+		}
+		c.head = r.Header.Clone()
+		c.body, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, c
+}
+
+func decodeBody(t *testing.T, c *captured) map[string]any {
+	var v map[string]any
+	require.NoError(t, json.Unmarshal(c.body, &v))
+	return v
+}
+
+// 测试通用 Webhook：JSON 请求体、附加请求头与 HMAC 签名
+func TestWebhookSigned(t *testing.T) {
+	srv, c := newServer(t, "ok")
+	n, err := New(ChannelWebhook, []byte(`{"url":"`+srv.URL+`","secret":"s3cret","headers":{"X-Env":"test"}}`))
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), testMsg))
+
+	body := decodeBody(t, c)
+	assert.Equal(t, "backup failed", body["title"])
+	assert.Equal(t, "backup", body["fields"].(map[string]any)["job_name"])
+	assert.Equal(t, "test", c.head.Get("X-Env"))
+
+	ts, err := strconv.ParseInt(c.head.Get(TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookSignature("s3cret", ts, c.body), c.head.Get(SignatureHeader))
+}
+
+// 测试钉钉：加签参数追加到 URL，使用 markdown 模板
+func TestDingTalk(t *testing.T) {
+	srv, c := newServer(t, `{"errcode":0,"errmsg":"ok"}`)
+	n, err := New(ChannelDingTalk, []byte(`{"url":"`+srv.URL+`/robot/send?access_token=abc","secret":"SECxyz"}`))
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), testMsg))
+
+	assert.Equal(t, "abc", c.query["access_token"])
+	ts, err := strconv.ParseInt(c.query["timestamp"], 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, DingTalkSignature("SECxyz", ts), c.query["sign"])
+
+	body := decodeBody(t, c)
+	assert.Equal(t, "markdown", body["msgtype"])
+	assert.Equal(t, "### backup failed\n\nexit status 1", body["markdown"].(map[string]any)["text"])
+}
+
+// 测试钉钉返回错误码时发送失败
+func TestDingTalkErrCode(t *testing.T) {
+	srv, _ := newServer(t, `{"errcode":310000,"errmsg":"sign not match"}`)
+	n, err := NewDingTalk(DingTalkConfig{URL: srv.URL})
+	require.NoError(t, err)
+	err = n.Notify(context.Background(), testMsg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sign not match")
+}
+
+// 测试飞书：签名放在请求体中
+func TestFeishu(t *testing.T) {
+	srv, c := newServer(t, `{"code":0,"msg":"success"}`)
+	n, err := New(ChannelFeishu, []byte(`{"url":"`+srv.URL+`","secret":"fs"}`))
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), testMsg))
+
+	body := decodeBody(t, c)
+	ts, err := strconv.ParseInt(body["timestamp"].(string), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, FeishuSignature("fs", ts), body["sign"])
+	assert.Equal(t, "text", body["msg_type"])
+	assert.Equal(t, "backup failed\nexit status 1", body["content"].(map[string]any)["text"])
+}
+
+// 测试企业微信与自定义模板
+func TestWeComCustomTemplate(t *testing.T) {
+	srv, c := newServer(t, `{"errcode":0,"errmsg":"ok"}`)
+	n, err := New(ChannelWeChat, []byte(`{"url":"`+srv.URL+`","template":"[{{.Level}}] {{.Fields.job_name}}: {{.Text}}"}`))
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), testMsg))
+
+	body := decodeBody(t, c)
+	assert.Equal(t, "[error] backup: exit status 1", body["markdown"].(map[string]any)["content"])
+}
+
+// 测试 Slack
+func TestSlack(t *testing.T) {
+	srv, c := newServer(t, "ok")
+	n, err := New(ChannelSlack, []byte(`{"url":"`+srv.URL+`","channel":"#ops"}`))
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), testMsg))
+
+	body := decodeBody(t, c)
+	assert.Equal(t, "*backup failed*\nexit status 1", body["text"])
+	assert.Equal(t, "#ops", body["channel"])
+}
+
+// 测试 Telegram：请求路径包含机器人 token
+func TestTelegram(t *testing.T) {
+	srv, c := newServer(t, `{"ok":true}`)
+	n, err := New(ChannelTelegram, []byte(`{"bot_token":"123:abc","chat_id":"-100","api_base":"`+srv.URL+`"}`))
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), testMsg))
+
+	assert.Equal(t, "/bot123:abc/sendMessage", c.path)
+	body := decodeBody(t, c)
+	assert.Equal(t, "-100", body["chat_id"])
+	assert.Equal(t, "backup failed\n\nexit status 1", body["text"])
+}
+
+// 测试邮件：使用最小化的本地 SMTP 服务接收
+func TestEmail(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	received := make(chan string, 1)
+	go serveSMTP(ln, received)
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	n, err := NewEmail(EmailConfig{Host: host, Port: port, From: "task@example.com", To: []string{"ops@example.com"}})
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), testMsg))
+
+	select {
+	case data := <-received:
+		assert.Contains(t, data, "To: ops@example.com")
+		assert.Contains(t, data, "Subject: backup failed")
+		assert.Contains(t, data, "exit status 1")
+	case <-time.After(time.Second):
+		t.Fatal("邮件未送达")
+	}
+}
+
+// serveSMTP 只处理一次会话，收到的 DATA 写入 received
+func serveSMTP(ln net.Listener, received chan<- string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			received <- data.String()
+			reply("250 ok")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// 测试不支持的渠道与缺少必填配置
+func TestNewInvalid(t *testing.T) {
+	_, err := New(ChannelSMS, nil)
+	assert.ErrorIs(t, err, ErrUnsupportedChannel)
+	_, err = New(ChannelSlack, []byte(`{}`))
+	assert.Error(t, err)
+	_, err = New(ChannelWebhook, []byte(`not json`))
+	assert.Error(t, err)
+}
+
+// flaky 前 n 次发送失败
+type flaky struct {
+	fails int32
+	calls atomic.Int32
+}
+
+func (f *flaky) Type() string { return "flaky" }
+func (f *flaky) Notify(context.Context, *Message) error {
+	if f.calls.Add(1) <= f.fails {
+		return errors.New("temporary")
+	}
+	return nil
+}
+
+// 测试失败重试与重试耗尽
+func TestDeliverRetry(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, Delay: time.Millisecond}
+
+	n := &flaky{fails: 2}
+	require.NoError(t, Deliver(context.Background(), n, testMsg, policy))
+	assert.Equal(t, int32(3), n.calls.Load())
+
+	n = &flaky{fails: 5}
+	err := Deliver(context.Background(), n, testMsg, policy)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+	assert.Equal(t, int32(3), n.calls.Load())
+
+	// HTTP 5xx 同样会重试
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	s, err := NewSlack(SlackConfig{URL: srv.URL})
+	require.NoError(t, err)
+	require.NoError(t, Deliver(context.Background(), s, testMsg, policy))
+	assert.Equal(t, int32(2), hits.Load())
+}
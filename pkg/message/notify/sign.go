@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+)
+
+// SignatureHeader 通用 Webhook 的签名请求头，值为 "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))
+const (
+	SignatureHeader = "X-Go-Task-Signature"
+	TimestampHeader = "X-Go-Task-Timestamp"
+)
+
+// WebhookSignature 计算通用 Webhook 签名，接收方用相同的密钥和时间戳校验请求体
+func WebhookSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DingTalkSignature 钉钉机器人加签：base64(HMAC-SHA256(secret, timestamp + "\n" + secret))，timestamp 为毫秒
+func DingTalkSignature(secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// FeishuSignature 飞书机器人签名校验：以 timestamp + "\n" + secret 为密钥对空串做 HMAC-SHA256，timestamp 为秒
+func FeishuSignature(secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(strconv.FormatInt(timestamp, 10)+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// formatInt 时间戳转字符串
+func formatInt(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+// slackTemplate Slack 消息的默认模板（mrkdwn）
+const slackTemplate = "*{{.Title}}*\n{{.Text}}"
+
+// SlackConfig Slack Incoming Webhook 配置
+type SlackConfig struct {
+	URL      string `json:"url"` // https://hooks.slack.com/services/xxx
+	Channel  string `json:"channel"`
+	Username string `json:"username"`
+	Template string `json:"template"`
+}
+
+// Slack Slack Incoming Webhook
+type Slack struct {
+	cfg SlackConfig
+}
+
+// NewSlack 创建 Slack 发送器
+func NewSlack(cfg SlackConfig) (*Slack, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("slack url is required")
+	}
+	return &Slack{cfg: cfg}, nil
+}
+
+func (s *Slack) Type() string { return ChannelSlack }
+
+func (s *Slack) Notify(ctx context.Context, msg *Message) error {
+	text, err := render(s.cfg.Template, slackTemplate, msg)
+	if err != nil {
+		return err
+	}
+	payload := map[string]string{"text": text}
+	if s.cfg.Channel != "" {
+		payload["channel"] = s.cfg.Channel
+	}
+	if s.cfg.Username != "" {
+		payload["username"] = s.cfg.Username
+	}
+	_, err = postJSON(ctx, s.cfg.URL, payload, nil)
+	return err
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// telegramAPI Telegram Bot API 地址
+const telegramAPI = "https://api.telegram.org"
+
+// telegramTemplate Telegram 消息的默认模板
+const telegramTemplate = "{{.Title}}\n\n{{.Text}}"
+
+// TelegramConfig Telegram 机器人配置
+type TelegramConfig struct {
+	BotToken string `json:"bot_token"`
+	ChatID   string `json:"chat_id"`
+	APIBase  string `json:"api_base"` // 默认 https://api.telegram.org，可指向自建代理
+	Template string `json:"template"`
+}
+
+// Telegram Telegram 机器人
+type Telegram struct {
+	cfg TelegramConfig
+}
+
+// NewTelegram 创建 Telegram 发送器
+func NewTelegram(cfg TelegramConfig) (*Telegram, error) {
+	if cfg.BotToken == "" || cfg.ChatID == "" {
+		return nil, errors.New("telegram bot_token and chat_id are required")
+	}
+	if cfg.APIBase == "" {
+		cfg.APIBase = telegramAPI
+	}
+	cfg.APIBase = strings.TrimRight(cfg.APIBase, "/")
+	return &Telegram{cfg: cfg}, nil
+}
+
+func (t *Telegram) Type() string { return ChannelTelegram }
+
+func (t *Telegram) Notify(ctx context.Context, msg *Message) error {
+	text, err := render(t.cfg.Template, telegramTemplate, msg)
+	if err != nil {
+		return err
+	}
+	target := fmt.Sprintf("%s/bot%s/sendMessage", t.cfg.APIBase, t.cfg.BotToken)
+	payload := map[string]string{"chat_id": t.cfg.ChatID, "text": text}
+
+	body, err := postJSON(ctx, target, payload, nil)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if !resp.OK {
+		return fmt.Errorf("telegram: %s", resp.Description)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// WebhookConfig 通用 Webhook 渠道配置
+type WebhookConfig struct {
+	URL      string            `json:"url"`
+	Secret   string            `json:"secret"`   // 非空时对请求体签名
+	Headers  map[string]string `json:"headers"`  // 附加请求头
+	Template string            `json:"template"` // 非空时渲染后作为 text 字段
+}
+
+// Webhook 以 JSON 格式 POST 消息到指定地址
+type Webhook struct {
+	cfg WebhookConfig
+}
+
+// NewWebhook 创建通用 Webhook 发送器
+func NewWebhook(cfg WebhookConfig) (*Webhook, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("webhook url is required")
+	}
+	return &Webhook{cfg: cfg}, nil
+}
+
+func (w *Webhook) Type() string { return ChannelWebhook }
+
+func (w *Webhook) Notify(ctx context.Context, msg *Message) error {
+	payload := *msg
+	if w.cfg.Template != "" {
+		text, err := render(w.cfg.Template, "", msg)
+		if err != nil {
+			return err
+		}
+		payload.Text = text
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	headers := make(map[string]string, len(w.cfg.Headers)+2)
+	for k, v := range w.cfg.Headers {
+		headers[k] = v
+	}
+	if w.cfg.Secret != "" {
+		ts := time.Now().Unix()
+		headers[TimestampHeader] = formatInt(ts)
+		headers[SignatureHeader] = WebhookSignature(w.cfg.Secret, ts, body)
+	}
+
+	_, err = postBody(ctx, w.cfg.URL, body, headers)
+	return err
+}
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+// weComTemplate 企业微信 markdown 消息的默认模板
+const weComTemplate = "**{{.Title}}**\n{{.Text}}"
+
+// WeComConfig 企业微信群机器人配置
+type WeComConfig struct {
+	URL      string `json:"url"` // https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=xxx
+	Template string `json:"template"`
+}
+
+// WeCom 企业微信群机器人
+type WeCom struct {
+	cfg WeComConfig
+}
+
+// NewWeCom 创建企业微信发送器
+func NewWeCom(cfg WeComConfig) (*WeCom, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("wechat url is required")
+	}
+	return &WeCom{cfg: cfg}, nil
+}
+
+func (w *WeCom) Type() string { return ChannelWeChat }
+
+func (w *WeCom) Notify(ctx context.Context, msg *Message) error {
+	text, err := render(w.cfg.Template, weComTemplate, msg)
+	if err != nil {
+		return err
+	}
+	payload := map[string]any{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"content": text},
+	}
+	body, err := postJSON(ctx, w.cfg.URL, payload, nil)
+	if err != nil {
+		return err
+	}
+	return checkErrCode(body)
+}
@@ -10,6 +10,7 @@ type ExecutionLogStream struct {
 	ExecutionID string             `bson:"execution_id"`      // 执行ID
 	JobID       uint               `bson:"job_id"`            // 任务ID
 	JobName     string             `bson:"job_name"`          // 任务名称
+	Seq         int64              `bson:"seq"`               // 执行内的日志序号
 
 	// 日志信息
 	LogLevel    string             `bson:"log_level"`         // 日志级别